@@ -6,6 +6,7 @@ package command
 
 import (
 	"flag"
+	"fmt"
 	"strings"
 
 	"github.com/git-time-metric/gtm/project"
@@ -28,33 +29,61 @@ func (c CleanCmd) Help() string {
 	helpText := `
 Usage: gtm clean [options]
 
-  Deletes pending time data for the current git repository.
+  Deletes pending time data for the current git repository, time saved in git notes by commit is not deleted.
 
 Options:
 
   -yes                       Delete time data without asking for confirmation.
+  -force                     Same as -yes
+  -dry-run                   List the files that would be deleted without deleting them
   -terminal-only             Only delete terminal time data
   -app-only                  Only delete apps time data
   -days=0                    Delete starting from n days in the past
+  -q                         Only print errors, not allowed with -dry-run
 `
 	return strings.TrimSpace(helpText)
 }
 
 // Run executes clean command with args
 func (c CleanCmd) Run(args []string) int {
-	var yes, terminalOnly, appOnly bool
+	var yes, force, dryRun, terminalOnly, appOnly, quiet bool
 	var days int
 	cmdFlags := flag.NewFlagSet("clean", flag.ContinueOnError)
 	cmdFlags.BoolVar(&yes, "yes", false, "")
+	cmdFlags.BoolVar(&force, "force", false, "")
+	cmdFlags.BoolVar(&dryRun, "dry-run", false, "")
 	cmdFlags.BoolVar(&terminalOnly, "terminal-only", false, "")
 	cmdFlags.BoolVar(&appOnly, "app-only", false, "")
 	cmdFlags.IntVar(&days, "days", 0, "")
+	cmdFlags.BoolVar(&quiet, "q", false, "")
 	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
-		return 1
+		return ExitUsage
 	}
 
-	confirm := yes
+	if quiet && dryRun {
+		c.UI.Error("\n-q option not allowed with -dry-run\n")
+		return ExitUsage
+	}
+
+	if quiet {
+		c.UI = quietUi{c.UI}
+	}
+
+	if dryRun {
+		files, err := project.CleanFiles(util.AfterNow(days), terminalOnly, appOnly)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		for _, f := range files {
+			c.UI.Output(f)
+		}
+		c.UI.Output(fmt.Sprintf("Would delete %d files", len(files)))
+		return 0
+	}
+
+	confirm := yes || force
 	if !confirm {
 		response, err := c.UI.Ask("Delete pending time data (y/n)?")
 		if err != nil {
@@ -66,7 +95,7 @@ func (c CleanCmd) Run(args []string) int {
 	if confirm {
 		if err := project.Clean(util.AfterNow(days), terminalOnly, appOnly); err != nil {
 			c.UI.Error(err.Error())
-			return 1
+			return exitCode(err)
 		}
 	}
 	return 0
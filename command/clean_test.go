@@ -84,6 +84,39 @@ func TestAppOnly(t *testing.T) {
 	}
 }
 
+func TestCleanDryRun(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	ui := new(cli.MockUi)
+	args := []string{"-dry-run"}
+	if rc := (CleanCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm clean(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	for _, want := range []string{"1458496803.event", "Would delete 1 files"} {
+		if !strings.Contains(ui.OutputWriter.String(), want) {
+			t.Errorf("gtm clean(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+		}
+	}
+	if !repo.FileExists("1458496803.event", project.GTMDir) {
+		t.Errorf("gtm clean(%+v), want event to not be deleted, but was deleted", args)
+	}
+
+	args = []string{"-force"}
+	if rc := (CleanCmd{UI: new(cli.MockUi)}).Run(args); rc != 0 {
+		t.Errorf("gtm clean(%+v), want 0 got %d", args, rc)
+	}
+	if repo.FileExists("1458496803.event", project.GTMDir) {
+		t.Errorf("gtm clean(%+v), want event to be deleted, but was found", args)
+	}
+}
+
 func TestCleanInvalidOption(t *testing.T) {
 	ui := new(cli.MockUi)
 	c := CleanCmd{UI: ui}
@@ -91,8 +124,8 @@ func TestCleanInvalidOption(t *testing.T) {
 	args := []string{"-invalid"}
 	rc := c.Run(args)
 
-	if rc != 1 {
-		t.Errorf("gtm clean(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter)
+	if rc != ExitUsage {
+		t.Errorf("gtm clean(%+v), want 3 got %d, %s", args, rc, ui.ErrorWriter)
 	}
 	if !strings.Contains(ui.OutputWriter.String(), "Usage:") {
 		t.Errorf("gtm clean(%+v), want 'Usage:'  got %d, %s", args, rc, ui.OutputWriter.String())
@@ -6,9 +6,12 @@ package command
 
 import (
 	"flag"
+	"fmt"
 	"strings"
 
 	"github.com/git-time-metric/gtm/metric"
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/util"
 	"github.com/mitchellh/cli"
 )
 
@@ -32,6 +35,14 @@ Usage: gtm commit [options]
 Options:
 
   -yes                       Save time data without asking for confirmation.
+
+  -ref=""                    Git notes ref to save time data to, i.e. -ref=refs/notes/gtm-billable
+                             Defaults to notes_ref in .gtm/config.json or refs/notes/gtm-data
+
+  -proration=""              Split the time of a minute with events for more than one file [proportional|last]
+                             Defaults to proration in .gtm/config.json or proportional, see gtm status -help
+
+  -q                         Only print errors
 `
 	return strings.TrimSpace(helpText)
 }
@@ -39,12 +50,34 @@ Options:
 // Run executes commit commands with args
 func (c CommitCmd) Run(args []string) int {
 
-	var yes bool
+	var yes, quiet bool
+	var ref, proration string
 	cmdFlags := flag.NewFlagSet("commit", flag.ContinueOnError)
 	cmdFlags.BoolVar(&yes, "yes", false, "")
+	cmdFlags.StringVar(&ref, "ref", "", "")
+	cmdFlags.StringVar(&proration, "proration", "", "")
+	cmdFlags.BoolVar(&quiet, "q", false, "")
 	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
-		return 1
+		return ExitUsage
+	}
+
+	if quiet {
+		c.UI = quietUi{c.UI}
+	}
+
+	if proration != "" && !util.StringInSlice(project.Prorations, proration) {
+		c.UI.Error(fmt.Sprintf("commit --proration=%s not valid\n", proration))
+		return ExitUsage
+	}
+
+	notesRef := ""
+	if ref != "" {
+		var err error
+		if notesRef, err = project.NotesRef(ref); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
 	}
 
 	confirm := yes
@@ -57,9 +90,24 @@ func (c CommitCmd) Run(args []string) int {
 	}
 
 	if confirm {
-		if _, err := metric.Process(false); err != nil {
+		config, err := projectConfig(c.UI)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		// -ref and -proration take precedence over the project's config file
+		if notesRef != "" {
+			config.NotesRef = notesRef
+		}
+		if proration != "" {
+			config.Proration = proration
+		}
+		// the events are left pending if gtm is interrupted before the time is saved
+		ctx, stop := interruptContext()
+		defer stop()
+		if _, err := metric.ProcessContext(ctx, false, config); err != nil {
 			c.UI.Error(err.Error())
-			return 1
+			return exitCode(err)
 		}
 	}
 	return 0
@@ -39,8 +39,8 @@ func TestCommitInvalidOption(t *testing.T) {
 	args := []string{"-invalid"}
 	rc := c.Run(args)
 
-	if rc != 1 {
-		t.Errorf("gtm commit(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter)
+	if rc != ExitUsage {
+		t.Errorf("gtm commit(%+v), want 3 got %d, %s", args, rc, ui.ErrorWriter)
 	}
 	if !strings.Contains(ui.OutputWriter.String(), "Usage:") {
 		t.Errorf("gtm commit(%+v), want 'Usage:'  got %d, %s", args, rc, ui.OutputWriter.String())
@@ -0,0 +1,183 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/git-time-metric/gtm/note"
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/report"
+	"github.com/git-time-metric/gtm/scm"
+	"github.com/mitchellh/cli"
+)
+
+// CompactCmd contains methods for the compact command
+type CompactCmd struct {
+	UI cli.Ui
+}
+
+// NewCompact returns a new CompactCmd struct
+func NewCompact() (cli.Command, error) {
+	return CompactCmd{}, nil
+}
+
+// Help returns help for the compact command
+func (c CompactCmd) Help() string {
+	helpText := `
+Usage: gtm compact -before=yyyy-mm-dd [options]
+
+  Shrink the time data saved in git notes for commits before a date.
+
+  Each file's hourly timeline is replaced by a single entry, the time per file
+  and the commit totals are kept. Reports that show time by hour, day, week or
+  month will show all of a file's time on the first hour it was worked on.
+
+Options:
+
+  -before=yyyy-mm-dd         Compact time data for commits before this date (required)
+
+  -dry-run                   Show how much space would be reclaimed without changing any notes
+
+  -yes                       Compact time data without asking for confirmation
+
+  -ref=""                    Git notes ref to compact, i.e. -ref=refs/notes/gtm-billable
+                             Defaults to notes_ref in .gtm/config.json or refs/notes/gtm-data
+
+  -q                         Only print errors, not allowed with -dry-run
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run executes the compact command with args
+func (c CompactCmd) Run(args []string) int {
+	var yes, dryRun, quiet bool
+	var before, ref string
+	cmdFlags := flag.NewFlagSet("compact", flag.ContinueOnError)
+	cmdFlags.BoolVar(&yes, "yes", false, "")
+	cmdFlags.BoolVar(&dryRun, "dry-run", false, "")
+	cmdFlags.StringVar(&before, "before", "", "")
+	cmdFlags.StringVar(&ref, "ref", "", "")
+	cmdFlags.BoolVar(&quiet, "q", false, "")
+	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if quiet && dryRun {
+		c.UI.Error("\n-q option not allowed with -dry-run\n")
+		return ExitUsage
+	}
+
+	if quiet {
+		c.UI = quietUi{c.UI}
+	}
+
+	if before == "" {
+		c.UI.Error("\n-before option is required\n")
+		return ExitUsage
+	}
+	beforeDate, err := time.ParseInLocation("2006-01-02", before, time.Local)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("\nInvalid -before date %s, must be yyyy-mm-dd\n", before))
+		return ExitUsage
+	}
+
+	rootPath, gtmPath, err := project.Paths()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+
+	nameSpace := loadConfig(c.UI, gtmPath).NoteNameSpace()
+	if ref != "" {
+		notesRef, err := project.NotesRef(ref)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		nameSpace = strings.TrimPrefix(notesRef, "refs/notes/")
+	}
+
+	// no limits returns all commits reachable from head
+	commitIDs, err := scm.CommitIDs(scm.CommitLimiter{}, rootPath)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+
+	compacted := map[string]string{}
+	reclaimed := 0
+	for _, id := range commitIDs {
+		n, err := scm.ReadNote(id, nameSpace, false, rootPath)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		if n.Note == "" || !n.When.Before(beforeDate) {
+			continue
+		}
+		commitNote, err := note.UnMarshal(n.Note)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Unable to compact %s, %s", id[:7], err))
+			return ExitError
+		}
+		noteTxt := note.Marshal(commitNote.Compact())
+		if len(noteTxt) >= len(n.Note) {
+			continue
+		}
+		compacted[id] = noteTxt
+		reclaimed += len(n.Note) - len(noteTxt)
+	}
+
+	if len(compacted) == 0 {
+		c.UI.Output(fmt.Sprintf("No notes to compact before %s", before))
+		return 0
+	}
+
+	summary := fmt.Sprintf("%d notes before %s, %d bytes", len(compacted), before, reclaimed)
+	if dryRun {
+		c.UI.Output(fmt.Sprintf("Would compact %s would be reclaimed", summary))
+		return 0
+	}
+
+	confirm := yes
+	if !confirm {
+		response, err := c.UI.Ask(fmt.Sprintf("Compact %s will be reclaimed, this can not be undone (y/n)?", summary))
+		if err != nil {
+			return 0
+		}
+		confirm = strings.TrimSpace(strings.ToLower(response)) == "y"
+	}
+	if !confirm {
+		return 0
+	}
+
+	for _, id := range commitIDs {
+		noteTxt, ok := compacted[id]
+		if !ok {
+			continue
+		}
+		if err := scm.CreateNoteForCommit(id, noteTxt, nameSpace, rootPath); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+	}
+	// cached notes for reports are out of date
+	if err := report.ClearCache(gtmPath); err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+	c.UI.Output(fmt.Sprintf("Compacted %s reclaimed", summary))
+	return 0
+}
+
+// Synopsis returns help for the compact command
+func (c CompactCmd) Synopsis() string {
+	return "Shrink time data saved for old commits"
+}
@@ -0,0 +1,90 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-time-metric/gtm/note"
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/util"
+	"github.com/mitchellh/cli"
+)
+
+func TestCompact(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// events in two different hours
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("event", "event.go"))
+	commitID := repo.Commit(repo.Stage(filepath.Join("event", "event.go"))).String()
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	readNote := func() note.CommitNote {
+		notes, err := note.ReadCommitNotes(repo.Workdir(), commitID)
+		if err != nil {
+			t.Fatalf("note.ReadCommitNotes, want error nil got %s", err)
+		}
+		return notes[0]
+	}
+	original := readNote()
+	if len(original.Files) != 1 || len(original.Files[0].Timeline) != 2 {
+		t.Fatalf("want one file with two hours got %+v", original)
+	}
+
+	tests := []struct {
+		args     []string
+		want     string
+		timeline int
+	}{
+		{[]string{"-before", "2013-01-01", "-yes"}, "No notes to compact", 2},
+		{[]string{"-before", "2014-01-01", "-dry-run"}, "Would compact 1 notes", 2},
+		{[]string{"-before", "2014-01-01", "-yes"}, "Compacted 1 notes", 1},
+		{[]string{"-before", "2014-01-01", "-yes"}, "No notes to compact", 1},
+	}
+
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		if rc := (CompactCmd{UI: ui}).Run(tc.args); rc != 0 {
+			t.Errorf("gtm compact(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+		}
+		if !strings.Contains(ui.OutputWriter.String(), tc.want) {
+			t.Errorf("gtm compact(%+v), want %s got %s", tc.args, tc.want, ui.OutputWriter.String())
+		}
+		n := readNote()
+		if len(n.Files) != 1 || len(n.Files[0].Timeline) != tc.timeline || n.Total() != original.Total() {
+			t.Errorf("gtm compact(%+v), want %d hours and total %d got %+v", tc.args, tc.timeline, original.Total(), n)
+		}
+	}
+
+	for _, args := range [][]string{{}, {"-before", "01/01/2014"}} {
+		if rc := (CompactCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm compact(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestCompactInvalidOption(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := CompactCmd{UI: ui}
+
+	args := []string{"-invalid"}
+	rc := c.Run(args)
+
+	if rc != ExitUsage {
+		t.Errorf("gtm compact(%+v), want 3 got %d, %s", args, rc, ui.ErrorWriter)
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "Usage:") {
+		t.Errorf("gtm compact(%+v), want 'Usage:'  got %s", args, ui.OutputWriter.String())
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/git-time-metric/gtm/metric"
+	"github.com/git-time-metric/gtm/note"
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/scm"
+	"github.com/mitchellh/cli"
+)
+
+// DoctorCmd contains methods for the doctor command
+type DoctorCmd struct {
+	UI cli.Ui
+}
+
+// NewDoctor returns a new DoctorCmd struct
+func NewDoctor() (cli.Command, error) {
+	return DoctorCmd{}, nil
+}
+
+// Help returns help for the doctor command
+func (c DoctorCmd) Help() string {
+	helpText := `
+Usage: gtm doctor
+
+  Check that gtm is set up correctly for the current project.
+
+  Checks that git is installed, or GTM_GIT is set to the git binary, the project is initialized, its config is valid, the git hooks run gtm,
+  the git notes with time data can be read, the data directory is writable and the terminal, app and
+  event source plug-ins are set up. Each check is listed as ok, warn or FAIL with a hint to fix it.
+  Exits with a non-zero status if a check fails, warnings are for optional plug-ins.
+
+  The repository, including the git notes and commits, is read and written with libgit2, GTM_GIT
+  and the -git option only set the git binary that's checked and run by gtm doctor.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Statuses of a doctor check
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "FAIL"
+)
+
+// doctorCheck is the result of one of the doctor's checks, the hint explains how to fix a warning or failure
+type doctorCheck struct {
+	Name   string
+	Status string
+	Detail string
+	Hint   string
+}
+
+// Run executes the doctor command with args
+func (c DoctorCmd) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	checks := []doctorCheck{checkGit()}
+
+	rootPath, gtmPath, err := project.Paths()
+	if err != nil {
+		checks = append(checks, doctorCheck{Name: "project", Status: checkFail, Detail: err.Error(),
+			Hint: "run gtm init in the git repository's working tree"})
+		c.output(checks)
+		return exitCode(err)
+	}
+	checks = append(checks, doctorCheck{Name: "project", Status: checkOK, Detail: "initialized in " + rootPath})
+
+	cfg, err := project.LoadConfig(gtmPath)
+	if err != nil {
+		checks = append(checks, doctorCheck{Name: "config", Status: checkFail, Detail: err.Error(),
+			Hint: fmt.Sprintf("fix or remove %s, the default config is used until then", filepath.Join(gtmPath, project.ConfigFile))})
+	} else {
+		checks = append(checks, doctorCheck{Name: "config", Status: checkOK, Detail: "valid"})
+	}
+
+	checks = append(checks,
+		checkHooks(rootPath),
+		checkNotes(rootPath, cfg),
+		checkDataDir(rootPath),
+		checkTerminal(gtmPath),
+		checkApps(gtmPath))
+	checks = append(checks, checkEventSources(cfg)...)
+
+	c.output(checks)
+
+	for _, ch := range checks {
+		if ch.Status == checkFail {
+			return ExitError
+		}
+	}
+	return 0
+}
+
+// output prints the checklist
+func (c DoctorCmd) output(checks []doctorCheck) {
+	lines := []string{}
+	failed := 0
+	for _, ch := range checks {
+		lines = append(lines, fmt.Sprintf("[%-4s] %-14s %s", ch.Status, ch.Name, ch.Detail))
+		if ch.Hint != "" && ch.Status != checkOK {
+			lines = append(lines, fmt.Sprintf("%22s%s", "", ch.Hint))
+		}
+		if ch.Status == checkFail {
+			failed++
+		}
+	}
+	c.UI.Output(strings.Join(lines, "\n"))
+	if failed > 0 {
+		c.UI.Error(fmt.Sprintf("\n%d of %d checks failed\n", failed, len(checks)))
+	}
+}
+
+// checkGit checks that the git command is installed, gtm reads the repository with libgit2
+// but the git hooks and git notes commands run git
+func checkGit() doctorCheck {
+	ch := doctorCheck{Name: "git", Hint: fmt.Sprintf("install git and add it to the PATH or set %s to the git binary", scm.GitEnv)}
+	cmd, err := scm.GitCommand("--version")
+	if err != nil {
+		ch.Status, ch.Detail = checkFail, err.Error()
+		return ch
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		ch.Status, ch.Detail = checkFail, fmt.Sprintf("unable to run %s, %s", cmd.Path, err)
+		return ch
+	}
+	ch.Status, ch.Detail = checkOK, strings.TrimSpace(string(out))
+	return ch
+}
+
+// checkHooks checks that the git hooks run gtm's commands
+func checkHooks(rootPath string) doctorCheck {
+	ch := doctorCheck{Name: "hooks", Hint: "run gtm hooks -install to add gtm's commands to the git hooks"}
+	gitRepoPath, err := scm.GitRepoPath(rootPath)
+	if err != nil {
+		ch.Status, ch.Detail = checkFail, err.Error()
+		return ch
+	}
+	hooksDir, err := scm.HooksDir(gitRepoPath)
+	if err != nil {
+		ch.Status, ch.Detail = checkFail, err.Error()
+		return ch
+	}
+	problems, err := scm.VerifyHooks(project.GitHooks, hooksDir)
+	if err != nil {
+		ch.Status, ch.Detail = checkFail, err.Error()
+		return ch
+	}
+	if len(problems) > 0 {
+		names := []string{}
+		for name := range problems {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		details := []string{}
+		for _, name := range names {
+			details = append(details, fmt.Sprintf("%s: %s", name, problems[name]))
+		}
+		ch.Status, ch.Detail = checkFail, strings.Join(details, ", ")
+		return ch
+	}
+	ch.Status, ch.Detail = checkOK, "installed in "+hooksDir
+	return ch
+}
+
+// checkNotes checks that the time data in the git notes of the head commit can be read
+func checkNotes(rootPath string, cfg project.Config) doctorCheck {
+	ref := "refs/notes/" + cfg.NoteNameSpace()
+	ch := doctorCheck{Name: "notes", Hint: "run gtm verify -notes -fix to remove entries that can not be read"}
+	head, err := scm.HeadCommit(rootPath)
+	if err != nil {
+		ch.Status, ch.Detail = checkFail, err.Error()
+		return ch
+	}
+	if head.ID == "" {
+		ch.Status, ch.Detail = checkOK, fmt.Sprintf("%s, there are no commits yet", ref)
+		return ch
+	}
+	if _, err := note.ReadCommitNotesFromRef(rootPath, ref, head.ID); err != nil {
+		ch.Status, ch.Detail = checkFail, fmt.Sprintf("unable to read %s for the head commit, %s", ref, err)
+		return ch
+	}
+	ch.Status, ch.Detail = checkOK, ref+" is readable"
+	return ch
+}
+
+// checkDataDir checks that event files can be written to the project's data directory
+func checkDataDir(rootPath string) doctorCheck {
+	ch := doctorCheck{Name: "data dir", Hint: fmt.Sprintf("check the permissions of the directory and the %s environment variable", project.DataDirEnv)}
+	dataPath, err := project.DataPath(rootPath)
+	if err != nil {
+		ch.Status, ch.Detail = checkFail, err.Error()
+		return ch
+	}
+	f, err := ioutil.TempFile(dataPath, ".doctor")
+	if err != nil {
+		ch.Status, ch.Detail = checkFail, fmt.Sprintf("%s is not writable, %s", dataPath, err)
+		return ch
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+	ch.Status, ch.Detail = checkOK, dataPath+" is writable"
+	return ch
+}
+
+// checkTerminal checks if time in the terminal is tracked, the terminal plug-in is optional
+func checkTerminal(gtmPath string) doctorCheck {
+	ch := doctorCheck{Name: "terminal", Hint: "run gtm init -terminal and install the terminal plug-in to track time in the terminal"}
+	if _, err := os.Stat(filepath.Join(gtmPath, "terminal.app")); err != nil {
+		ch.Status, ch.Detail = checkWarn, "time in the terminal is not tracked"
+		return ch
+	}
+	ch.Status, ch.Detail = checkOK, "time in the terminal is tracked"
+	return ch
+}
+
+// checkApps lists the apps with recorded time, app plug-ins are optional
+func checkApps(gtmPath string) doctorCheck {
+	ch := doctorCheck{Name: "apps", Hint: "install an app plug-in to track time in apps, it runs gtm record -app"}
+	files, err := ioutil.ReadDir(gtmPath)
+	if err != nil {
+		ch.Status, ch.Detail = checkFail, err.Error()
+		return ch
+	}
+	apps := []string{}
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), ".app") && f.Name() != "terminal.app" {
+			apps = append(apps, strings.TrimSuffix(f.Name(), ".app"))
+		}
+	}
+	if len(apps) == 0 {
+		ch.Status, ch.Detail = checkWarn, "no time has been recorded for apps"
+		return ch
+	}
+	ch.Status, ch.Detail = checkOK, "time recorded for "+strings.Join(apps, ", ")
+	return ch
+}
+
+// checkEventSources checks that the commands of the project's event sources can be found
+func checkEventSources(cfg project.Config) []doctorCheck {
+	checks := []doctorCheck{}
+	for _, cmd := range cfg.EventSources {
+		ch := doctorCheck{Name: "event source", Hint: "install the command or remove it from event_sources in .gtm/config.json"}
+		if err := (metric.CommandEventSource{Command: cmd}).Check(); err != nil {
+			ch.Status, ch.Detail = checkFail, fmt.Sprintf("%s, %s", cmd, err)
+		} else {
+			ch.Status, ch.Detail = checkOK, cmd
+		}
+		checks = append(checks, ch)
+	}
+	return checks
+}
+
+// Synopsis returns help for the doctor command
+func (c DoctorCmd) Synopsis() string {
+	return "Check that gtm is set up correctly"
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/util"
+	"github.com/mitchellh/cli"
+)
+
+func TestDoctor(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	ui := new(cli.MockUi)
+	if rc := (DoctorCmd{UI: ui}).Run([]string{}); rc != ExitNotInitialized {
+		t.Errorf("gtm doctor() before init, want %d got %d, %s", ExitNotInitialized, rc, ui.OutputWriter.String())
+	}
+	if want := "[FAIL] project"; !strings.Contains(ui.OutputWriter.String(), want) {
+		t.Errorf("gtm doctor() before init, want %s got %s", want, ui.OutputWriter.String())
+	}
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	ui = new(cli.MockUi)
+	if rc := (DoctorCmd{UI: ui}).Run([]string{}); rc != 0 {
+		t.Errorf("gtm doctor(), want 0 got %d, %s, %s", rc, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+	for _, want := range []string{"[ok  ] git", "[ok  ] project", "[ok  ] hooks", "[ok  ] notes", "[ok  ] data dir", "[ok  ] terminal", "[warn] apps"} {
+		if !strings.Contains(ui.OutputWriter.String(), want) {
+			t.Errorf("gtm doctor(), want %s got %s", want, ui.OutputWriter.String())
+		}
+	}
+
+	// a missing hook and an event source command that's not installed fail
+	gitRepoPath, err := filepath.EvalSymlinks(repo.Path())
+	util.CheckFatal(t, err)
+	util.CheckFatal(t, os.Remove(filepath.Join(gitRepoPath, "hooks", "post-commit")))
+	util.CheckFatal(t, ioutil.WriteFile(filepath.Join(repo.Workdir(), project.GTMDir, project.ConfigFile),
+		[]byte(`{"event_sources": ["gtm-doctor-missing-command read"]}`), 0644))
+
+	ui = new(cli.MockUi)
+	if rc := (DoctorCmd{UI: ui}).Run([]string{}); rc != ExitError {
+		t.Errorf("gtm doctor(), want %d got %d, %s", ExitError, rc, ui.OutputWriter.String())
+	}
+	for _, want := range []string{"[FAIL] hooks", "gtm hooks -install", "[FAIL] event source", "gtm-doctor-missing-command"} {
+		if !strings.Contains(ui.OutputWriter.String(), want) {
+			t.Errorf("gtm doctor(), want %s got %s", want, ui.OutputWriter.String())
+		}
+	}
+}
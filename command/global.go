@@ -0,0 +1,248 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/scm"
+	"github.com/git-time-metric/gtm/util"
+	"github.com/mitchellh/cli"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Exit statuses returned by the commands, scripts can rely on them to tell a project without
+// gtm data apart from invalid options or an error
+const (
+	// ExitSuccess is returned when the command succeeds
+	ExitSuccess = 0
+	// ExitError is returned for an unexpected error, i.e. git notes can not be read,
+	// and when a check fails, i.e. gtm verify or gtm hooks -verify find problems
+	ExitError = 1
+	// ExitNotInitialized is returned when there's no gtm data because the project is not initialized
+	// or is not a git repository
+	ExitNotInitialized = 2
+	// ExitUsage is returned for invalid options or arguments
+	ExitUsage = 3
+)
+
+// exitCode returns the exit status for an error, ExitNotInitialized if the project is not initialized,
+// ExitUsage for an invalid -tags regular expression and ExitError otherwise
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, project.ErrNotInitialized):
+		return ExitNotInitialized
+	case errors.Is(err, project.ErrInvalidTagRegex):
+		return ExitUsage
+	default:
+		return ExitError
+	}
+}
+
+const (
+	// StatusFlagsEnv is the environment variable with default flags for the status command, i.e. -color -terminal-off
+	StatusFlagsEnv = "GTM_STATUS_FLAGS"
+	// ReportFlagsEnv is the environment variable with default flags for the report command
+	ReportFlagsEnv = "GTM_REPORT_FLAGS"
+)
+
+// parseEnvFlags parses the space separated flags in the environment variable into the flag set, it must be
+// called before parsing the command line so a flag on the command line replaces the default in the environment.
+// Nothing is parsed if the environment variable is not set.
+func parseEnvFlags(cmdFlags *flag.FlagSet, env string) error {
+	defaults := strings.Fields(os.Getenv(env))
+	if len(defaults) == 0 {
+		return nil
+	}
+	if err := cmdFlags.Parse(defaults); err != nil {
+		return fmt.Errorf("\nInvalid %s, %s\n", env, err)
+	}
+	if cmdFlags.NArg() > 0 {
+		return fmt.Errorf("\nInvalid %s, %s is not a flag\n", env, cmdFlags.Arg(0))
+	}
+	return nil
+}
+
+// quietUi discards output so only errors and warnings are written, it's the UI for the -q option
+type quietUi struct {
+	cli.Ui
+}
+
+// Output discards the message
+func (u quietUi) Output(string) {}
+
+// Info discards the message
+func (u quietUi) Info(string) {}
+
+// interruptContext returns a context that's canceled when gtm is interrupted or terminated,
+// stop must be called to restore the default handling of the signals
+func interruptContext() (ctx context.Context, stop context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// defaultWidth is the width of output that's not written to a terminal
+const defaultWidth = 120
+
+// terminalWidth returns the number of columns of the terminal stdout is written to or defaultWidth
+// if stdout is not a terminal
+func terminalWidth() int {
+	if w, _, err := terminal.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		return w
+	}
+	return defaultWidth
+}
+
+// colorOptions returns the report Color and NoColor options for the -color and -no-color flags.
+// -no-color takes precedence over -color, and -color over a non-empty NO_COLOR environment variable,
+// see https://no-color.org. When none are set color depends on whether a terminal is detected.
+func colorOptions(color, noColor bool) (bool, bool) {
+	switch {
+	case noColor:
+		return false, true
+	case color:
+		return true, false
+	default:
+		return false, os.Getenv("NO_COLOR") != ""
+	}
+}
+
+// projectConfig returns the config of the project at projPath, or of the project in the current directory,
+// for the metric functions. Commands apply their options that take precedence over the config to it.
+// If the config can not be read or is invalid a warning is written to ui, unless it's nil, and the defaults are used.
+func projectConfig(ui cli.Ui, projPath ...string) (project.Config, error) {
+	_, gtmPath, err := project.Paths(projPath...)
+	if err != nil {
+		return project.Config{}, err
+	}
+	return loadConfig(ui, gtmPath), nil
+}
+
+// loadConfig returns the config in the gtmPath directory, if it can not be read or is invalid
+// a warning is written to ui, unless it's nil, and the defaults are returned
+func loadConfig(ui cli.Ui, gtmPath string) project.Config {
+	cfg, err := project.LoadConfig(gtmPath)
+	if err != nil && ui != nil {
+		ui.Warn(fmt.Sprintf("Warning: %s, using defaults", err))
+	}
+	return cfg
+}
+
+// readProjects returns the project paths listed one per line in file, or stdin if file is -, it's the -projects option.
+// Blank lines are skipped. Paths that are not gtm initialized git repositories are returned as invalid instead of
+// an error so the rest of the projects can still be processed.
+func readProjects(file string) (projects []string, invalid []error, err error) {
+	in := os.Stdin
+	if file != "-" {
+		if in, err = os.Open(file); err != nil {
+			return nil, nil, err
+		}
+		defer in.Close()
+	}
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		p := strings.TrimSpace(scanner.Text())
+		if p == "" {
+			continue
+		}
+		workDir, _, err := project.Paths(p)
+		if err != nil {
+			invalid = append(invalid, fmt.Errorf("%s: %s", p, err))
+			continue
+		}
+		if !util.StringInSlice(projects, workDir) {
+			projects = append(projects, workDir)
+		}
+	}
+	return projects, invalid, scanner.Err()
+}
+
+// GlobalOptions handles the options for all commands that come before the command and returns the remaining arguments.
+//
+// -C path changes the working directory, i.e. gtm -C ~/src/project status. Like git, each -C path is relative
+// to the previous one and an empty path is ignored.
+//
+// -data-dir path keeps event and metric files in the directory instead of the project's gtm directory,
+// it's the same as setting GTM_DATA_DIR.
+//
+// -git path runs the git binary instead of git in the PATH, it's the same as setting GTM_GIT.
+// If GTM_GIT is set it must be an executable file. The repository, including git notes and commits,
+// is read and written with libgit2 which does not use the git binary, only gtm doctor runs it.
+func GlobalOptions(args []string) ([]string, error) {
+	for len(args) > 0 {
+		var name, val string
+		switch {
+		case args[0] == "-C" || args[0] == "-data-dir":
+			if len(args) < 2 {
+				return args, fmt.Errorf("%s requires a directory", args[0])
+			}
+			name, val, args = args[0], args[1], args[2:]
+		case args[0] == "-git":
+			if len(args) < 2 {
+				return args, fmt.Errorf("%s requires a file", args[0])
+			}
+			name, val, args = args[0], args[1], args[2:]
+		case strings.HasPrefix(args[0], "-C="), strings.HasPrefix(args[0], "-data-dir="), strings.HasPrefix(args[0], "-git="):
+			s := strings.SplitN(args[0], "=", 2)
+			name, val, args = s[0], s[1], args[1:]
+		default:
+			return args, checkGitEnv()
+		}
+
+		switch name {
+		case "-C":
+			if val == "" {
+				continue
+			}
+			if err := os.Chdir(val); err != nil {
+				return args, fmt.Errorf("Unable to change to directory %s, %s", val, err)
+			}
+		case "-data-dir":
+			// a later -C does not change the data directory
+			dir, err := filepath.Abs(val)
+			if err != nil {
+				return args, err
+			}
+			if fileInfo, err := os.Stat(dir); err != nil || !fileInfo.IsDir() {
+				return args, fmt.Errorf("-data-dir %s is not a directory", val)
+			}
+			if err := os.Setenv(project.DataDirEnv, dir); err != nil {
+				return args, err
+			}
+		case "-git":
+			// a path is relative to the working directory before a later -C, a name is looked up in the PATH
+			exe := val
+			if strings.ContainsRune(val, filepath.Separator) || strings.ContainsRune(val, '/') {
+				var err error
+				if exe, err = filepath.Abs(val); err != nil {
+					return args, err
+				}
+			}
+			if err := os.Setenv(scm.GitEnv, exe); err != nil {
+				return args, err
+			}
+		}
+	}
+	return args, checkGitEnv()
+}
+
+// checkGitEnv returns an error if GTM_GIT is set and it's not an executable file
+func checkGitEnv() error {
+	if os.Getenv(scm.GitEnv) == "" {
+		return nil
+	}
+	_, err := scm.GitExe()
+	return err
+}
@@ -0,0 +1,196 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/scm"
+	"github.com/git-time-metric/gtm/util"
+	"github.com/mitchellh/cli"
+)
+
+func TestGlobalOptionsChdir(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.SaveFile("event.go", "event", "")
+
+	wd, err := filepath.EvalSymlinks(repo.Workdir())
+	util.CheckFatal(t, err)
+	os.Chdir(os.TempDir())
+
+	args, err := GlobalOptions([]string{"-C", wd, "-C=event", "-C", "", "status", "-C", "x"})
+	if err != nil {
+		t.Fatalf("GlobalOptions(), want error nil got %s", err)
+	}
+	if want := []string{"status", "-C", "x"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("GlobalOptions(), want args %+v got %+v", want, args)
+	}
+	got, err := os.Getwd()
+	util.CheckFatal(t, err)
+	if want := filepath.Join(wd, "event"); got != want {
+		t.Errorf("GlobalOptions(), want working directory %s got %s", want, got)
+	}
+
+	// the project is found from the new working directory
+	os.Chdir(os.TempDir())
+	args, err = GlobalOptions([]string{"-C", wd, "init"})
+	util.CheckFatal(t, err)
+	ui := new(cli.MockUi)
+	if rc := (InitCmd{UI: ui}).Run(args[1:]); rc != 0 {
+		t.Errorf("gtm -C %s init, want 0 got %d, %s", wd, rc, ui.ErrorWriter.String())
+	}
+
+	for _, args := range [][]string{{"-C"}, {"-C", filepath.Join(wd, "doesnotexist"), "status"}} {
+		if _, err := GlobalOptions(args); err == nil {
+			t.Errorf("GlobalOptions(%+v), want error got nil", args)
+		}
+	}
+}
+
+func TestGlobalOptionsDataDir(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+	repo.SaveFile("event.go", "event", "")
+
+	dataDir, err := ioutil.TempDir("", "gtm-data")
+	util.CheckFatal(t, err)
+	defer os.RemoveAll(dataDir)
+	defer os.Unsetenv(project.DataDirEnv)
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	args, err := GlobalOptions([]string{"-data-dir", dataDir, "record"})
+	if err != nil {
+		t.Fatalf("GlobalOptions(), want error nil got %s", err)
+	}
+	if os.Getenv(project.DataDirEnv) != dataDir {
+		t.Errorf("GlobalOptions(), want %s=%s got %s", project.DataDirEnv, dataDir, os.Getenv(project.DataDirEnv))
+	}
+
+	ui := new(cli.MockUi)
+	args = append(args[1:], filepath.Join(repo.Workdir(), "event", "event.go"))
+	if rc := (RecordCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm record(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+
+	// the event is written to the data directory instead of the gtm directory
+	events, err := filepath.Glob(filepath.Join(dataDir, "*", "*.event"))
+	util.CheckFatal(t, err)
+	if len(events) != 1 {
+		t.Errorf("gtm record(%+v), want 1 event in %s got %d", args, dataDir, len(events))
+	}
+	events, err = filepath.Glob(filepath.Join(repo.Workdir(), project.GTMDir, "*.event"))
+	util.CheckFatal(t, err)
+	if len(events) != 0 {
+		t.Errorf("gtm record(%+v), want no events in %s got %d", args, project.GTMDir, len(events))
+	}
+
+	ui = new(cli.MockUi)
+	if rc := (StatusCmd{UI: ui}).Run([]string{"-total-only", "-format", "json"}); rc != 0 {
+		t.Errorf("gtm status(), want 0 got %d, %s", rc, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), `"total": 60`) {
+		t.Errorf("gtm status(), want total 60 got %s", ui.OutputWriter.String())
+	}
+
+	os.Setenv(project.DataDirEnv, filepath.Join(dataDir, "doesnotexist"))
+	ui = new(cli.MockUi)
+	if rc := (StatusCmd{UI: ui}).Run([]string{}); rc != 1 {
+		t.Errorf("gtm status() %s does not exist, want 1 got %d", project.DataDirEnv, rc)
+	}
+
+	if _, err := GlobalOptions([]string{"-data-dir", filepath.Join(dataDir, "doesnotexist"), "status"}); err == nil {
+		t.Errorf("GlobalOptions(-data-dir doesnotexist), want error got nil")
+	}
+}
+
+func TestGlobalOptionsGit(t *testing.T) {
+	gitPath, err := exec.LookPath("git")
+	util.CheckFatal(t, err)
+	defer os.Unsetenv(scm.GitEnv)
+
+	args, err := GlobalOptions([]string{"-git", gitPath, "doctor"})
+	if err != nil {
+		t.Fatalf("GlobalOptions(), want error nil got %s", err)
+	}
+	if want := []string{"doctor"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("GlobalOptions(), want args %+v got %+v", want, args)
+	}
+	if os.Getenv(scm.GitEnv) != gitPath {
+		t.Errorf("GlobalOptions(), want %s=%s got %s", scm.GitEnv, gitPath, os.Getenv(scm.GitEnv))
+	}
+
+	f, err := ioutil.TempFile("", "gtm-git")
+	util.CheckFatal(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	if _, err := GlobalOptions([]string{"-git=" + f.Name(), "status"}); err == nil {
+		t.Errorf("GlobalOptions(-git %s), want error got nil", f.Name())
+	}
+
+	// GTM_GIT is checked without the -git option
+	if _, err := GlobalOptions([]string{"status"}); err == nil {
+		t.Errorf("GlobalOptions() %s=%s, want error got nil", scm.GitEnv, f.Name())
+	}
+	if ch := checkGit(); ch.Status != checkFail {
+		t.Errorf("checkGit() %s=%s, want %s got %+v", scm.GitEnv, f.Name(), checkFail, ch)
+	}
+
+	os.Unsetenv(scm.GitEnv)
+	if _, err := GlobalOptions([]string{"status"}); err != nil {
+		t.Errorf("GlobalOptions(), want error nil got %s", err)
+	}
+	if ch := checkGit(); ch.Status != checkOK {
+		t.Errorf("checkGit(), want %s got %+v", checkOK, ch)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+
+	notInitialized, err := ioutil.TempDir("", "gtm")
+	util.CheckFatal(t, err)
+	defer os.RemoveAll(notInitialized)
+
+	tests := []struct {
+		dir    string
+		cmd    cli.Command
+		args   []string
+		wantRc int
+	}{
+		{repo.Workdir(), StatusCmd{UI: cli.NewMockUi()}, []string{}, ExitSuccess},
+		{repo.Workdir(), ReportCmd{UI: cli.NewMockUi()}, []string{"-testing=true"}, ExitSuccess},
+		{notInitialized, StatusCmd{UI: cli.NewMockUi()}, []string{}, ExitNotInitialized},
+		{notInitialized, ReportCmd{UI: cli.NewMockUi()}, []string{"-testing=true"}, ExitNotInitialized},
+		{notInitialized, CommitCmd{UI: cli.NewMockUi()}, []string{"-yes"}, ExitNotInitialized},
+		{repo.Workdir(), StatusCmd{UI: cli.NewMockUi()}, []string{"-invalid"}, ExitUsage},
+		{repo.Workdir(), StatusCmd{UI: cli.NewMockUi()}, []string{"-format", "yaml"}, ExitUsage},
+		{repo.Workdir(), StatusCmd{UI: cli.NewMockUi()}, []string{"-tags", "tag(", "-tag-regex"}, ExitUsage},
+		{repo.Workdir(), ReportCmd{UI: cli.NewMockUi()}, []string{"-n", "x", "-testing=true"}, ExitUsage},
+		{repo.Workdir(), ReportCmd{UI: cli.NewMockUi()}, []string{"-format", "yaml", "-testing=true"}, ExitUsage},
+	}
+
+	for _, tc := range tests {
+		os.Chdir(tc.dir)
+		if tc.dir == repo.Workdir() {
+			(InitCmd{UI: cli.NewMockUi()}).Run([]string{})
+		}
+		if rc := tc.cmd.Run(tc.args); rc != tc.wantRc {
+			t.Errorf("gtm %T(%+v) in %s, want %d got %d", tc.cmd, tc.args, tc.dir, tc.wantRc, rc)
+		}
+	}
+}
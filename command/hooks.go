@@ -0,0 +1,137 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/scm"
+	"github.com/mitchellh/cli"
+)
+
+// HooksCmd contains methods for the hooks command
+type HooksCmd struct {
+	UI cli.Ui
+}
+
+// NewHooks returns a new HooksCmd struct
+func NewHooks() (cli.Command, error) {
+	return HooksCmd{}, nil
+}
+
+// Help returns help for the hooks command
+func (c HooksCmd) Help() string {
+	helpText := `
+Usage: gtm hooks -install|-verify [options]
+
+  Install or verify the git hooks gtm uses to save time in git notes when you commit.
+
+  Installing adds gtm's command to existing hooks so the hook's other commands continue to run.
+  Verifying exits with a non-zero status if a hook is missing, does not run gtm or is not executable.
+
+Options:
+
+  -install=false             Add gtm's commands to the git hooks
+
+  -verify=false              Check that the git hooks run gtm's commands
+
+  -path=""                   Hooks directory, i.e. -path=.githooks, a relative path is relative to the project
+                             Defaults to core.hooksPath if it's set or the hooks directory in the git directory
+
+  -q                         Only print errors
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run executes the hooks command with args
+func (c HooksCmd) Run(args []string) int {
+	var install, verify, quiet bool
+	var hooksPath string
+	cmdFlags := flag.NewFlagSet("hooks", flag.ContinueOnError)
+	cmdFlags.BoolVar(&install, "install", false, "")
+	cmdFlags.BoolVar(&verify, "verify", false, "")
+	cmdFlags.StringVar(&hooksPath, "path", "", "")
+	cmdFlags.BoolVar(&quiet, "q", false, "")
+	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if quiet {
+		c.UI = quietUi{c.UI}
+	}
+
+	if install == verify {
+		c.UI.Error("\nEither the -install or -verify option is required\n")
+		return ExitUsage
+	}
+
+	rootPath, _, err := project.Paths()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+
+	hooksDir := hooksPath
+	switch {
+	case hooksDir == "":
+		gitRepoPath, err := scm.GitRepoPath(rootPath)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		if hooksDir, err = scm.HooksDir(gitRepoPath); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+	case !filepath.IsAbs(hooksDir):
+		hooksDir = filepath.Join(rootPath, hooksDir)
+	}
+
+	hookNames := []string{}
+	for name := range project.GitHooks {
+		hookNames = append(hookNames, name)
+	}
+	sort.Strings(hookNames)
+
+	if install {
+		if err := scm.InstallHooks(project.GitHooks, hooksDir); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		for _, name := range hookNames {
+			c.UI.Output(fmt.Sprintf("%s: installed in %s", name, hooksDir))
+		}
+		return 0
+	}
+
+	problems, err := scm.VerifyHooks(project.GitHooks, hooksDir)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+	for _, name := range hookNames {
+		if p, ok := problems[name]; ok {
+			c.UI.Output(fmt.Sprintf("%s: %s", name, p))
+			continue
+		}
+		c.UI.Output(fmt.Sprintf("%s: ok", name))
+	}
+	if len(problems) > 0 {
+		c.UI.Error(fmt.Sprintf("\nFound %d problems with the git hooks in %s, run gtm hooks -install to fix them\n", len(problems), hooksDir))
+		return ExitError
+	}
+	return 0
+}
+
+// Synopsis returns help for the hooks command
+func (c HooksCmd) Synopsis() string {
+	return "Install or verify gtm's git hooks"
+}
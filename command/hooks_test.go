@@ -0,0 +1,76 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-time-metric/gtm/scm"
+	"github.com/git-time-metric/gtm/util"
+	"github.com/mitchellh/cli"
+)
+
+func TestHooks(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	run := func(args []string, wantRC int, want string) {
+		ui := new(cli.MockUi)
+		if rc := (HooksCmd{UI: ui}).Run(args); rc != wantRC {
+			t.Errorf("gtm hooks(%+v), want %d got %d, %s", args, wantRC, rc, ui.ErrorWriter.String())
+		}
+		if !strings.Contains(ui.OutputWriter.String(), want) {
+			t.Errorf("gtm hooks(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+		}
+	}
+
+	// init installs the hooks in the git directory
+	run([]string{"-verify"}, 0, "post-commit: ok")
+
+	hookFile := filepath.Join(repo.Path(), "hooks", "post-commit")
+	if err := ioutil.WriteFile(hookFile, []byte("#!/bin/sh\necho done\n"), 0755); err != nil {
+		t.Fatalf("want error nil got %s", err)
+	}
+	run([]string{"-verify"}, 1, "post-commit: modified, does not run gtm commit --yes")
+
+	// existing hook commands are kept
+	run([]string{"-install"}, 0, "post-commit: installed")
+	b, err := ioutil.ReadFile(hookFile)
+	if err != nil {
+		t.Fatalf("want error nil got %s", err)
+	}
+	if !strings.Contains(string(b), "echo done") || !strings.Contains(string(b), "gtm commit --yes") {
+		t.Errorf("gtm hooks -install, want existing and gtm commands got %s", string(b))
+	}
+	run([]string{"-verify"}, 0, "post-commit: ok")
+
+	// a custom hooks directory
+	run([]string{"-verify", "-path", ".githooks"}, 1, "post-commit: missing")
+	run([]string{"-install", "-path", ".githooks"}, 0, "post-commit: installed in "+filepath.Join(repo.Workdir(), ".githooks"))
+	run([]string{"-verify", "-path", ".githooks"}, 0, "post-commit: ok")
+
+	// core.hooksPath is the default hooks directory
+	if err := scm.ConfigSet(map[string]string{"core.hooksPath": ".githooks"}, repo.Workdir()); err != nil {
+		t.Fatalf("want error nil got %s", err)
+	}
+	run([]string{"-verify"}, 0, "post-commit: ok")
+	if err := os.Chmod(filepath.Join(repo.Workdir(), ".githooks", "post-commit"), 0644); err != nil {
+		t.Fatalf("want error nil got %s", err)
+	}
+	run([]string{"-verify"}, 1, "post-commit: not executable")
+
+	for _, args := range [][]string{{}, {"-install", "-verify"}} {
+		if rc := (HooksCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm hooks(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/git-time-metric/gtm/note"
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/report"
+	"github.com/git-time-metric/gtm/scm"
+	"github.com/git-time-metric/gtm/util"
+	"github.com/mitchellh/cli"
+)
+
+// ImportCmd contains methods for the import command
+type ImportCmd struct {
+	UI cli.Ui
+}
+
+// NewImport returns a new ImportCmd struct
+func NewImport() (cli.Command, error) {
+	return ImportCmd{}, nil
+}
+
+// Help returns help for the import command
+func (c ImportCmd) Help() string {
+	helpText := `
+Usage: gtm import -file=log.json [options]
+
+  Import time logged outside of gtm into the git notes of the current project.
+
+  The file is a JSON array of records with the commit or date the time is for,
+  the file and the number of seconds, i.e.
+
+    [
+      {"commit": "1a2b3c4", "file": "cmd/main.go", "seconds": 3600},
+      {"date": "2016-03-20T18:00:00Z", "file": "README.md", "seconds": 600, "status": "r"}
+    ]
+
+  A record with a date is imported into the first commit on or after the date,
+  like time that is pending when you commit. The status is m (modified), r (read)
+  or d (deleted) and defaults to m.
+
+Options:
+
+  -file=""                   File to import, - reads from stdin (required)
+
+  -format=json               Format of the file to import [json] (default json)
+
+  -replace=false             Replace the time saved for the commits instead of adding to it
+
+  -dry-run                   Show the time that would be imported without changing any notes
+
+  -yes                       Replace time without asking for confirmation
+
+  -ref=""                    Git notes ref to import to, i.e. -ref=refs/notes/gtm-billable
+                             Defaults to notes_ref in .gtm/config.json or refs/notes/gtm-data
+
+  -q                         Only print errors, not allowed with -dry-run
+`
+	return strings.TrimSpace(helpText)
+}
+
+// importRecord is time for a file in the commit, or the first commit on or after the date
+type importRecord struct {
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+	File    string `json:"file"`
+	Seconds int    `json:"seconds"`
+	Status  string `json:"status"`
+}
+
+// Run executes the import command with args
+func (c ImportCmd) Run(args []string) int {
+	var replace, dryRun, yes, quiet bool
+	var file, format, ref string
+	cmdFlags := flag.NewFlagSet("import", flag.ContinueOnError)
+	cmdFlags.StringVar(&file, "file", "", "")
+	cmdFlags.StringVar(&format, "format", "json", "")
+	cmdFlags.BoolVar(&replace, "replace", false, "")
+	cmdFlags.BoolVar(&dryRun, "dry-run", false, "")
+	cmdFlags.BoolVar(&yes, "yes", false, "")
+	cmdFlags.StringVar(&ref, "ref", "", "")
+	cmdFlags.BoolVar(&quiet, "q", false, "")
+	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if quiet && dryRun {
+		c.UI.Error("\n-q option not allowed with -dry-run\n")
+		return ExitUsage
+	}
+
+	if quiet {
+		c.UI = quietUi{c.UI}
+	}
+
+	if !util.StringInSlice([]string{"json"}, format) {
+		c.UI.Error(fmt.Sprintf("import --format=%s not valid\n", format))
+		return ExitUsage
+	}
+
+	if file == "" {
+		c.UI.Error("\n-file option is required\n")
+		return ExitUsage
+	}
+
+	rootPath, gtmPath, err := project.Paths()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+
+	if ref == "" {
+		ref = loadConfig(c.UI, gtmPath).NoteNameSpace()
+	}
+	if ref, err = project.NotesRef(ref); err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+
+	var raw []byte
+	if file == "-" {
+		raw, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		raw, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+
+	records := []importRecord{}
+	if err := json.Unmarshal(raw, &records); err != nil {
+		c.UI.Error(fmt.Sprintf("Unable to read %s, %s", file, err))
+		return ExitError
+	}
+
+	imported, err := c.importNotes(records, strings.TrimPrefix(ref, "refs/notes/"), rootPath)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+	if len(imported) == 0 {
+		c.UI.Output(fmt.Sprintf("No records to import in %s", file))
+		return 0
+	}
+
+	// commits are ordered oldest first
+	commitIDs := []string{}
+	for id := range imported {
+		commitIDs = append(commitIDs, id)
+	}
+	sort.Slice(commitIDs, func(i, j int) bool {
+		return imported[commitIDs[i]].when.Before(imported[commitIDs[j]].when)
+	})
+
+	summary := fmt.Sprintf("%d records into %d commits", len(records), len(commitIDs))
+	if dryRun {
+		for _, id := range commitIDs {
+			n := imported[id]
+			c.UI.Output(fmt.Sprintf("%s %s: %s for %d files", id[:7], n.subject, util.DurationStr(n.note.Total()), len(n.note.Files)))
+		}
+		c.UI.Output(fmt.Sprintf("Would import %s", summary))
+		return 0
+	}
+
+	if replace && !yes {
+		response, err := c.UI.Ask(fmt.Sprintf("Replace the time saved for %d commits, this can not be undone (y/n)?", len(commitIDs)))
+		if err != nil {
+			return 0
+		}
+		if strings.TrimSpace(strings.ToLower(response)) != "y" {
+			return 0
+		}
+	}
+
+	for _, id := range commitIDs {
+		n := imported[id].note
+		if !replace {
+			existing, err := note.ReadCommitNotesFromRef(rootPath, ref, id)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Unable to import into %s, %s", id[:7], err))
+				return ExitError
+			}
+			n = existing[0].Merge(n)
+		}
+		if err := note.WriteCommitNoteToRef(rootPath, ref, id, n); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+	}
+
+	// cached notes for reports are out of date
+	if err := report.ClearCache(gtmPath); err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+	c.UI.Output(fmt.Sprintf("Imported %s", summary))
+	return 0
+}
+
+// importedNote is the time imported for a commit
+type importedNote struct {
+	subject string
+	when    time.Time
+	note    note.CommitNote
+}
+
+// importNotes validates the records and returns the imported time by commit id, records are for commits
+// reachable from HEAD in the git repository at rootPath. Commits are read with their notes in the nameSpace.
+func (c ImportCmd) importNotes(records []importRecord, nameSpace, rootPath string) (map[string]importedNote, error) {
+	// no limits returns all commits reachable from head, newest first
+	commitIDs, err := scm.CommitIDs(scm.CommitLimiter{}, rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := map[string]scm.CommitNote{}
+	readCommit := func(id string) (scm.CommitNote, error) {
+		if n, ok := commits[id]; ok {
+			return n, nil
+		}
+		n, err := scm.ReadNote(id, nameSpace, false, rootPath)
+		if err != nil {
+			return scm.CommitNote{}, err
+		}
+		commits[id] = n
+		return n, nil
+	}
+
+	imported := map[string]importedNote{}
+	for i, r := range records {
+		errorf := func(format string, a ...interface{}) error {
+			return fmt.Errorf("Record %d, %s", i+1, fmt.Sprintf(format, a...))
+		}
+
+		if r.File == "" {
+			return nil, errorf("file is required")
+		}
+		if r.Seconds <= 0 {
+			return nil, errorf("seconds must be greater than zero")
+		}
+		if r.Status == "" {
+			r.Status = "m"
+		}
+		if !util.StringInSlice([]string{"m", "r", "d"}, r.Status) {
+			return nil, errorf("status %s not valid, must be m, r or d", r.Status)
+		}
+		if (r.Commit == "") == (r.Date == "") {
+			return nil, errorf("either a commit or a date is required")
+		}
+
+		var commitID string
+		var when time.Time
+		if r.Commit != "" {
+			if len(r.Commit) < 4 {
+				return nil, errorf("commit %s not found", r.Commit)
+			}
+			for _, id := range commitIDs {
+				if !strings.HasPrefix(id, strings.ToLower(r.Commit)) {
+					continue
+				}
+				if commitID != "" {
+					return nil, errorf("commit %s is ambiguous, use more characters of the SHA1", r.Commit)
+				}
+				commitID = id
+			}
+			if commitID == "" {
+				return nil, errorf("commit %s not found", r.Commit)
+			}
+			n, err := readCommit(commitID)
+			if err != nil {
+				return nil, errorf("%s", err)
+			}
+			when = n.When
+		} else {
+			if when, err = parseImportDate(r.Date); err != nil {
+				return nil, errorf("%s", err)
+			}
+			// the first commit on or after the date, commits are newest first
+			for _, id := range commitIDs {
+				n, err := readCommit(id)
+				if err != nil {
+					return nil, errorf("%s", err)
+				}
+				if n.When.Before(when) {
+					break
+				}
+				commitID = id
+			}
+			if commitID == "" {
+				return nil, errorf("no commit on or after %s", r.Date)
+			}
+		}
+
+		n, err := readCommit(commitID)
+		if err != nil {
+			return nil, errorf("%s", err)
+		}
+		in := imported[commitID]
+		in.subject = n.Summary
+		in.when = n.When
+		// timelines are kept by the hour
+		ep := when.Unix() / 3600 * 3600
+		in.note = in.note.Merge(note.CommitNote{Files: []note.FileDetail{
+			{SourceFile: filepath.ToSlash(r.File), TimeSpent: r.Seconds, Timeline: map[int64]int{ep: r.Seconds}, Status: r.Status}}})
+		imported[commitID] = in
+	}
+	return imported, nil
+}
+
+// parseImportDate parses a RFC3339 timestamp or a yyyy-mm-dd date in the local time zone
+func parseImportDate(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Unable to parse date %s, must be a RFC3339 timestamp or yyyy-mm-dd", s)
+	}
+	return t, nil
+}
+
+// Synopsis returns help for the import command
+func (c ImportCmd) Synopsis() string {
+	return "Import time logged outside of gtm"
+}
@@ -0,0 +1,103 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-time-metric/gtm/note"
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/util"
+	"github.com/mitchellh/cli"
+)
+
+func TestImport(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	commitID := repo.Commit(repo.Stage(filepath.Join("event", "event.go"))).String()
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	readNote := func() note.CommitNote {
+		notes, err := note.ReadCommitNotes(repo.Workdir(), commitID)
+		if err != nil {
+			t.Fatalf("note.ReadCommitNotes, want error nil got %s", err)
+		}
+		return notes[0]
+	}
+	original := readNote().Total()
+
+	saveImport := func(s string) string {
+		f := filepath.Join(repo.Workdir(), "import.json")
+		repo.SaveFile("import.json", "", s)
+		return f
+	}
+
+	records := fmt.Sprintf(`[
+		{"commit": "%s", "file": "event/event.go", "seconds": 100},
+		{"date": "2000-01-01", "file": "docs/design.md", "seconds": 200, "status": "r"}
+	]`, commitID[:7])
+
+	tests := []struct {
+		args  []string
+		want  string
+		total int
+		files int
+	}{
+		{[]string{"-dry-run"}, "Would import 2 records into 1 commits", original, 1},
+		{[]string{}, "Imported 2 records into 1 commits", original + 300, 2},
+		{[]string{"-replace", "-yes"}, "Imported 2 records into 1 commits", 300, 2},
+	}
+
+	for _, tc := range tests {
+		args := append([]string{"-file", saveImport(records)}, tc.args...)
+		ui := new(cli.MockUi)
+		if rc := (ImportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Errorf("gtm import(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		if !strings.Contains(ui.OutputWriter.String(), tc.want) {
+			t.Errorf("gtm import(%+v), want %s got %s", args, tc.want, ui.OutputWriter.String())
+		}
+		n := readNote()
+		if n.Total() != tc.total || len(n.Files) != tc.files {
+			t.Errorf("gtm import(%+v), want total %d for %d files got %+v", args, tc.total, tc.files, n)
+		}
+	}
+
+	invalid := []string{
+		`[{"commit": "0000000", "file": "event/event.go", "seconds": 100}]`,
+		fmt.Sprintf(`[{"commit": "%s", "file": "event/event.go", "seconds": 100}]`, commitID[:3]),
+		`[{"date": "2999-01-01", "file": "event/event.go", "seconds": 100}]`,
+		`[{"date": "01/01/2000", "file": "event/event.go", "seconds": 100}]`,
+		fmt.Sprintf(`[{"commit": "%s", "file": "event/event.go", "seconds": 0}]`, commitID),
+		fmt.Sprintf(`[{"commit": "%s", "file": "event/event.go", "seconds": 100, "status": "x"}]`, commitID),
+		`[{"file": "event/event.go", "seconds": 100}]`,
+		`{"not": "a list"}`,
+	}
+	for _, s := range invalid {
+		ui := new(cli.MockUi)
+		if rc := (ImportCmd{UI: ui}).Run([]string{"-file", saveImport(s)}); rc != 1 {
+			t.Errorf("gtm import(%s), want 1 got %d", s, rc)
+		}
+	}
+	if n := readNote(); n.Total() != 300 {
+		t.Errorf("gtm import with invalid records, want total 300 got %d", n.Total())
+	}
+
+	for _, args := range [][]string{{}, {"-file", saveImport(records), "-format", "csv"}} {
+		if rc := (ImportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm import(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
@@ -37,27 +37,51 @@ Options:
 
   -tags=tag1,tag2            Add tags to projects, multiple calls appends tags.
 
-  -clear-tags                Clear all tags.
+  -clear-tags                Clear all tags, a new project is not given the default tags.
+
+  -submodules                Initialize the checked out submodules and save time in a submodule to the
+                             submodule's notes instead of this project's.
+
+  -q                         Only print errors
+
+  New projects initialized without -tags are tagged with the default_tags in ~/.git-time-metric/config.json,
+  i.e. {"default_tags": ["work"]}. The tags are listed when the project is initialized, use -tags to tag
+  a new project with other tags instead. Projects that are already initialized are not changed.
 `
 	return strings.TrimSpace(helpText)
 }
 
 // Run executes init command with args
 func (c InitCmd) Run(args []string) int {
-	var terminal, clearTags bool
+	var terminal, clearTags, submodules, quiet bool
 	var tags string
 	cmdFlags := flag.NewFlagSet("init", flag.ContinueOnError)
 	cmdFlags.BoolVar(&terminal, "terminal", true, "")
 	cmdFlags.BoolVar(&clearTags, "clear-tags", false, "")
 	cmdFlags.StringVar(&tags, "tags", "", "")
+	cmdFlags.BoolVar(&submodules, "submodules", false, "")
+	cmdFlags.BoolVar(&quiet, "q", false, "")
 	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
-		return 1
+		return ExitUsage
 	}
-	m, err := project.Initialize(terminal, util.Map(strings.Split(tags, ","), strings.TrimSpace), clearTags)
+
+	if quiet {
+		c.UI = quietUi{c.UI}
+	}
+	tagList := util.Map(strings.Split(tags, ","), strings.TrimSpace)
+	m, err := project.Initialize(terminal, tagList, clearTags)
 	if err != nil {
 		c.UI.Error(err.Error())
-		return 1
+		return exitCode(err)
+	}
+	if submodules {
+		sm, err := project.InitializeSubmodules(terminal, tagList, clearTags)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		m += sm
 	}
 	c.UI.Output(m + "\n")
 	return 0
@@ -5,10 +5,15 @@
 package command
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/git-time-metric/gtm/metric"
+	"github.com/git-time-metric/gtm/project"
 	"github.com/git-time-metric/gtm/util"
 	"github.com/mitchellh/cli"
 )
@@ -131,10 +136,92 @@ func TestInitInvalidOption(t *testing.T) {
 	args := []string{"-invalid"}
 	rc := c.Run(args)
 
-	if rc != 1 {
-		t.Errorf("gtm init(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter)
+	if rc != ExitUsage {
+		t.Errorf("gtm init(%+v), want 3 got %d, %s", args, rc, ui.ErrorWriter)
 	}
 	if !strings.Contains(ui.OutputWriter.String(), "Usage:") {
 		t.Errorf("gtm init(%+v), want 'Usage:'  got %d, %s", args, rc, ui.OutputWriter.String())
 	}
 }
+
+func TestInitSubmodules(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	repo.AddSubmodule("http://example.org/lib", "lib")
+	repo.SaveFile("lib.go", "lib", "")
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// the submodule is not initialized, time is tracked in the project
+	ui := new(cli.MockUi)
+	if rc := (RecordCmd{UI: ui}).Run([]string{filepath.Join(repo.Workdir(), "lib", "lib.go")}); rc != 0 {
+		t.Fatalf("gtm record, want 0 got %d, %s", rc, ui.ErrorWriter.String())
+	}
+	if want, got := []string{filepath.Join("lib", "lib.go")}, eventSources(t, project.GTMDir); !reflect.DeepEqual(want, got) {
+		t.Fatalf("gtm record in submodule, want project events %v, got %v", want, got)
+	}
+
+	ui = new(cli.MockUi)
+	args := []string{"-submodules"}
+	rc := (InitCmd{UI: ui}).Run(args)
+	if rc != 0 {
+		t.Fatalf("gtm init(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	subPath := filepath.Join(repo.Workdir(), "lib")
+	if !strings.Contains(ui.OutputWriter.String(), "initialized for "+subPath) {
+		t.Errorf("gtm init(%+v), want submodule %s initialized, got %s", args, subPath, ui.OutputWriter.String())
+	}
+	if !project.ReadConfig(project.GTMDir).Submodules {
+		t.Errorf("gtm init(%+v), want config submodules true", args)
+	}
+
+	// events recorded before the submodule was initialized are moved to the submodule
+	if _, err := metric.Process(true, project.ReadConfig(project.GTMDir)); err != nil {
+		t.Fatalf("metric.Process(true), want error nil, got %s", err)
+	}
+	if got := eventSources(t, project.GTMDir); len(got) != 0 {
+		t.Errorf("gtm init(%+v), want no project events, got %v", args, got)
+	}
+	if want, got := []string{"lib.go"}, eventSources(t, filepath.Join(subPath, project.GTMDir)); !reflect.DeepEqual(want, got) {
+		t.Errorf("gtm init(%+v), want submodule events %v, got %v", args, want, got)
+	}
+
+	// the submodule is initialized, time is tracked in the submodule
+	if rc := (RecordCmd{UI: new(cli.MockUi)}).Run([]string{filepath.Join(repo.Workdir(), "lib", "lib.go")}); rc != 0 {
+		t.Fatalf("gtm record, want 0 got %d", rc)
+	}
+	if got := eventSources(t, project.GTMDir); len(got) != 0 {
+		t.Errorf("gtm record in initialized submodule, want no project events, got %v", got)
+	}
+}
+
+// eventSources returns the source file of each event file in the directory
+func eventSources(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*.event"))
+	util.CheckFatal(t, err)
+	sources := []string{}
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		util.CheckFatal(t, err)
+		sources = append(sources, string(b))
+	}
+	return sources
+}
+
+func TestInitQuiet(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	ui := cli.NewMockUi()
+	args := []string{"-q"}
+	if rc := (InitCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm init(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if ui.OutputWriter.String() != "" {
+		t.Errorf("gtm init(%+v), want no output got %s", args, ui.OutputWriter.String())
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/git-time-metric/gtm/metric"
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/report"
+	"github.com/git-time-metric/gtm/scm"
+	"github.com/git-time-metric/gtm/util"
+	"github.com/mitchellh/cli"
+)
+
+// MetricsCmd contains methods for the metrics command
+type MetricsCmd struct {
+	UI cli.Ui
+}
+
+// NewMetrics returns a new MetricsCmd struct
+func NewMetrics() (cli.Command, error) {
+	return MetricsCmd{}, nil
+}
+
+// Help returns help for the metrics command
+func (c MetricsCmd) Help() string {
+	helpText := `
+Usage: gtm metrics [options]
+
+  Serve pending and committed time in the Prometheus text exposition format.
+
+  Projects are read on each scrape of http://<listen>/metrics, all metrics are labeled
+  by project name, path and comma separated tags.
+
+    gtm_pending_seconds     Time spent in the project that is not committed yet
+    gtm_committed_seconds   Time saved in the git notes of the commits reachable from HEAD
+    gtm_commits             Number of commits reachable from HEAD with time saved in git notes
+
+Options:
+
+  -listen=127.0.0.1:9099     Address to serve metrics on, i.e. -listen=:9099 to serve on all interfaces
+
+  -once=false                Write the metrics to stdout and exit instead of serving them,
+                             i.e. for the node_exporter textfile collector
+
+  -terminal-off=false        Exclude time spent in terminal (Terminal plug-in is required)
+
+  -app-off=false             Exclude time spent in apps
+
+  -tags=""                   Project tags to serve metrics for, i.e --tags tag1,tag2
+
+  -tag-match=any             Serve metrics for projects with any or all of the tags [any|all] (default any)
+
+  -tag-ignore-case=false     Match -tags regardless of case, i.e. client-a matches Client-A
+
+  -tag-regex=false           Treat each of the -tags as a regular expression that must match the whole tag, i.e. -tags='client-.*'
+
+  -all=false                 Serve metrics for all projects
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run executes the metrics command with args
+func (c MetricsCmd) Run(args []string) int {
+	var once, terminalOff, appOff, all, tagIgnoreCase, tagRegex bool
+	var listen, tags, tagMatch string
+	cmdFlags := flag.NewFlagSet("metrics", flag.ContinueOnError)
+	cmdFlags.StringVar(&listen, "listen", "127.0.0.1:9099", "")
+	cmdFlags.BoolVar(&once, "once", false, "")
+	cmdFlags.BoolVar(&terminalOff, "terminal-off", false, "")
+	cmdFlags.BoolVar(&appOff, "app-off", false, "")
+	cmdFlags.StringVar(&tags, "tags", "", "")
+	cmdFlags.StringVar(&tagMatch, "tag-match", "any", "")
+	cmdFlags.BoolVar(&tagIgnoreCase, "tag-ignore-case", false, "")
+	cmdFlags.BoolVar(&tagRegex, "tag-regex", false, "")
+	cmdFlags.BoolVar(&all, "all", false, "")
+	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if !util.StringInSlice([]string{"any", "all"}, tagMatch) {
+		c.UI.Error(fmt.Sprintf("metrics --tag-match=%s not valid\n", tagMatch))
+		return ExitUsage
+	}
+
+	tagList := []string{}
+	if tags != "" {
+		tagList = util.Map(strings.Split(tags, ","), strings.TrimSpace)
+	}
+
+	options := report.OutputOptions{TerminalOff: terminalOff, AppOff: appOff}
+	gather := func() (string, error) {
+		return c.gather(tagList, all, project.TagMatch{All: tagMatch == "all", IgnoreCase: tagIgnoreCase, Regex: tagRegex}, options)
+	}
+
+	if once {
+		out, err := gather()
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		c.UI.Output(strings.TrimSuffix(out, "\n"))
+		return 0
+	}
+
+	// projects are processed by one scrape at a time
+	var mu sync.Mutex
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		out, err := gather()
+		mu.Unlock()
+		if err != nil {
+			util.Debug.Print(err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		fmt.Fprint(w, out)
+	})
+
+	c.UI.Output(fmt.Sprintf("Serving metrics on %s/metrics", listen))
+	if err := http.ListenAndServe(listen, mux); err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+	return 0
+}
+
+// gather returns the metrics for the projects with the tags, all projects or the current project
+func (c MetricsCmd) gather(tags []string, all bool, match project.TagMatch, options report.OutputOptions) (string, error) {
+	// the index is loaded on each scrape so newly initialized projects are included
+	index, err := project.NewIndex()
+	if err != nil {
+		return "", err
+	}
+	projPaths, err := index.GetMatching(tags, all, match)
+	if err != nil {
+		return "", err
+	}
+
+	projects := []report.PrometheusProject{}
+	for _, p := range projPaths {
+		config, err := projectConfig(c.UI, p)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s", p, err)
+		}
+		pending, err := metric.Process(true, config, p)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s", p, err)
+		}
+		projTags, err := project.LoadTags(filepath.Join(p, project.GTMDir))
+		if err != nil {
+			return "", fmt.Errorf("%s: %s", p, err)
+		}
+		// no limits returns all commits reachable from head
+		commits, err := scm.CommitIDs(scm.CommitLimiter{}, p)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s", p, err)
+		}
+		projects = append(projects, report.PrometheusProject{
+			ProjectCommits: report.ProjectCommits{Path: p, Commits: commits},
+			Tags:           projTags,
+			Pending:        pending,
+		})
+	}
+	return report.Prometheus(projects, options)
+}
+
+// Synopsis returns help for the metrics command
+func (c MetricsCmd) Synopsis() string {
+	return "Serve time as Prometheus metrics"
+}
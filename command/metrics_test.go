@@ -0,0 +1,55 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/util"
+	"github.com/mitchellh/cli"
+)
+
+func TestMetrics(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{"-tags", "work"})
+
+	// one committed event and one pending event
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	ui := new(cli.MockUi)
+	args := []string{"-once"}
+	if rc := (MetricsCmd{UI: ui}).Run(args); rc != 0 {
+		t.Fatalf("gtm metrics(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+
+	labels := fmt.Sprintf(`project="%s",path="%s",tags="work"`, filepath.Base(repo.Workdir()), repo.Workdir())
+	for _, want := range []string{
+		"# TYPE gtm_pending_seconds gauge",
+		"gtm_pending_seconds{" + labels + "} 60",
+		"gtm_committed_seconds{" + labels + "} 60",
+		"gtm_commits{" + labels + "} 1",
+	} {
+		if !strings.Contains(ui.OutputWriter.String(), want) {
+			t.Errorf("gtm metrics(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+		}
+	}
+
+	args = []string{"-once", "-tag-match", "some"}
+	if rc := (MetricsCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm metrics(%+v), want 3 got %d", args, rc)
+	}
+}
@@ -6,6 +6,7 @@ package command
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -62,26 +63,57 @@ Options:
   -long-duration=false       Return total time recorded in long duration format.
 
   -app=false                 Record an app event.
+
+  -lock=false                Record that the screen is locked for all initialized projects,
+                             time is not counted until it's unlocked.
+
+  -unlock=false              Record that the screen is unlocked for all initialized projects.
 `
 	return strings.TrimSpace(helpText)
 }
 
 // Run executes record command with args
 func (c RecordCmd) Run(args []string) int {
-	var status, terminal, longDuration, app bool
+	var status, terminal, longDuration, app, lock, unlock bool
 	cmdFlags := flag.NewFlagSet("record", flag.ContinueOnError)
 	cmdFlags.BoolVar(&status, "status", false, "")
 	cmdFlags.BoolVar(&terminal, "terminal", false, "")
 	cmdFlags.BoolVar(&longDuration, "long-duration", false, "")
 	cmdFlags.BoolVar(&app, "app", false, "")
+	cmdFlags.BoolVar(&lock, "lock", false, "")
+	cmdFlags.BoolVar(&unlock, "unlock", false, "")
 	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
-		return 1
+		return ExitUsage
+	}
+
+	if lock && unlock {
+		c.UI.Error("\n-lock option not allowed with -unlock\n")
+		return ExitUsage
+	}
+
+	if lock || unlock {
+		// the screen is locked for all projects, not only the project in the working directory
+		index, err := project.NewIndex()
+		if err != nil {
+			return ExitError
+		}
+		projPaths, err := index.Get([]string{}, true, false)
+		if err != nil {
+			return ExitError
+		}
+		rc := 0
+		for _, p := range projPaths {
+			if err := event.RecordLock(lock, p); err != nil && !errors.Is(err, project.ErrNotInitialized) {
+				rc = ExitError
+			}
+		}
+		return rc
 	}
 
 	if !terminal && len(cmdFlags.Args()) == 0 {
 		c.UI.Error("Unable to record, file not provided")
-		return 1
+		return ExitUsage
 	}
 
 	var fileToRecord string
@@ -100,8 +132,8 @@ func (c RecordCmd) Run(args []string) int {
 		return 0
 	}
 
-	if err := event.Record(fileToRecord); err != nil && !(err == project.ErrNotInitialized || err == project.ErrFileNotFound) {
-		return 1
+	if err := event.Record(fileToRecord); err != nil && !(errors.Is(err, project.ErrNotInitialized) || errors.Is(err, project.ErrFileNotFound)) {
+		return ExitError
 	} else if err == nil && status {
 		var (
 			err        error
@@ -113,7 +145,7 @@ func (c RecordCmd) Run(args []string) int {
 		wd, err = os.Getwd()
 		if err != nil {
 			c.UI.Error(err.Error())
-			return 1
+			return exitCode(err)
 		}
 		defer func() {
 			if err := os.Chdir(wd); err != nil {
@@ -124,17 +156,22 @@ func (c RecordCmd) Run(args []string) int {
 		err = os.Chdir(filepath.Dir(fileToRecord))
 		if err != nil {
 			c.UI.Error(err.Error())
-			return 1
+			return exitCode(err)
 		}
 
-		if commitNote, err = metric.Process(true); err != nil {
+		config, err := projectConfig(c.UI)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		if commitNote, err = metric.Process(true, config); err != nil {
 			c.UI.Error(err.Error())
-			return 1
+			return exitCode(err)
 		}
 		out, err = report.Status(commitNote, report.OutputOptions{TotalOnly: true, LongDuration: longDuration})
 		if err != nil {
 			c.UI.Error(err.Error())
-			return 1
+			return exitCode(err)
 		}
 		c.output(out)
 	}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/git-time-metric/gtm/event"
 	"github.com/git-time-metric/gtm/util"
 	"github.com/mitchellh/cli"
 )
@@ -274,6 +275,57 @@ func TestRecordApp(t *testing.T) {
 	}
 }
 
+func TestRecordLock(t *testing.T) {
+	// the lock is recorded for all initialized projects
+	other := util.NewTestRepo(t, false)
+	defer other.Remove()
+	other.Seed()
+	os.Chdir(other.Workdir())
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	workdir := repo.Workdir()
+	os.Chdir(workdir)
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	ui := new(cli.MockUi)
+	c := RecordCmd{UI: ui}
+
+	args := []string{"-lock"}
+	rc := c.Run(args)
+
+	if rc != 0 {
+		t.Errorf("gtm record(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter)
+	}
+
+	for _, dir := range []string{workdir, other.Workdir()} {
+		files, err := ioutil.ReadDir(filepath.Join(dir, ".gtm"))
+		if err != nil {
+			t.Fatalf("gtm record(%+v), want error nil got  %s", args, err)
+		}
+		sources := []string{}
+		for _, f := range files {
+			if filepath.Ext(f.Name()) == ".event" {
+				b, err := ioutil.ReadFile(filepath.Join(dir, ".gtm", f.Name()))
+				util.CheckFatal(t, err)
+				sources = append(sources, string(b))
+			}
+		}
+		if len(sources) != 1 || sources[0] != event.LockSource {
+			t.Errorf("gtm record(%+v), want event for %s in %s got %+v", args, event.LockSource, dir, sources)
+		}
+	}
+
+	args = []string{"-lock", "-unlock"}
+	rc = c.Run(args)
+	if rc != ExitUsage {
+		t.Errorf("gtm record(%+v), want %d got %d", args, ExitUsage, rc)
+	}
+}
+
 func TestRecordInvalidOption(t *testing.T) {
 	ui := new(cli.MockUi)
 	c := RecordCmd{UI: ui}
@@ -281,8 +333,8 @@ func TestRecordInvalidOption(t *testing.T) {
 	args := []string{"-invalid"}
 	rc := c.Run(args)
 
-	if rc != 1 {
-		t.Errorf("gtm record(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter)
+	if rc != ExitUsage {
+		t.Errorf("gtm record(%+v), want 3 got %d, %s", args, rc, ui.ErrorWriter)
 	}
 	if !strings.Contains(ui.OutputWriter.String(), "Usage:") {
 		t.Errorf("gtm record(%+v), want 'Usage:'  got %d, %s", args, rc, ui.OutputWriter.String())
@@ -6,14 +6,20 @@ package command
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/git-time-metric/gtm/metric"
 	"github.com/git-time-metric/gtm/project"
 	"github.com/git-time-metric/gtm/report"
 	"github.com/git-time-metric/gtm/scm"
@@ -24,7 +30,8 @@ import (
 
 // ReportCmd contains methods for report command
 type ReportCmd struct {
-	UI cli.Ui
+	UI      cli.Ui
+	Version string
 }
 
 // NewReport create new ReportCmd struct
@@ -43,20 +50,99 @@ Options:
 
   Report Formats:
 
-  -format=commits            Specify report format [summary|project|commits|files|timeline-hours|timeline-commits] (default commits)
+  -format=commits            Specify report format [summary|project|commits|files|timeline-hours|timeline-commits|csv|markdown|html|xml|jira] (default commits)
+                             jira is CSV of the time for each JIRA issue key in the commit messages for a worklog import, commits
+                             without one are unassigned. Set issue_key_regex in .gtm/config.json to match other issue keys
+  -lifetime=false            Show the total time of all commits with time, use -all for the total of all projects
+  -files=false               List every file with time in all commits with its total time, most time first, files that are
+                             not in the head commit are marked deleted. Use -top to limit the files
+  -existing-only=false       Leave out the deleted files for -files
+  -template=""               Render the report with a Go text/template file instead of a format, i.e. -template=timesheet.tmpl
+                             The template is executed with the commits, their files and totals, see the report package's
+                             TemplateData and the example in examples/timesheet.tmpl. Use {{ dur .Seconds }} to format durations
+  -sort=date                 Sort by [date|time|name] for the commits, summary, files, csv, markdown and xml formats (default date)
+                             time is most time first, name sorts commits by subject and files by path, ties are sorted by commit SHA1 or path
+  -cumulative=false          Add a running total column in commit time order for the commits, summary and -period reports
+  -round=0                   Round durations to a multiple of this interval, i.e. -round=15m, after time is totaled for each commit
+                             for -format=summary and -lifetime, each project for -format=project, each author, weekday or language
+                             for -by and each period for -period. Totals are the sum of the rounded times so they won't reconcile
+                             exactly with unrounded reports, the unrounded total is shown after the report
+  -round-mode=up             Round durations [up|nearest|down] (default up)
+  -top=0                     Only show the files with the most time for -format=files and -files, the rest are summed on an others line,
+                             0 shows all files
+  -tz=""                     Time zone for commit times and for totaling time by day, week or month, i.e. -tz=America/New_York or -tz=UTC
+                             Defaults to the local time zone
+  -title=""                  Title of the -format=html report (default Git Time Metric Report)
   -full-message=false        Include full commit message
+  -show-message=false        Add each commit's subject to -format=csv
+  -message-width=0           Truncate commit subjects to this many characters for the commits, summary, csv, markdown and html
+                             formats, 0 is no limit
+  -relative=false            Show commit dates relative to now, i.e. 3 days ago, -relative=both shows the date followed by the
+                             relative date, for the commits, markdown and html formats
   -terminal-off=false        Exclude time spent in terminal (Terminal plug-in is required)
   -app-off=false             Exclude time spent in apps
+  -terminal-weight=1.0       Multiply time spent in terminal by the weight, i.e. -terminal-weight=0.5 counts terminal time as half,
+                             weighted time is rounded to the nearest second for each hour of a file's timeline
+  -app-weight=1.0            Multiply time spent in apps by the weight
   -force-color=false         Always output color even if no terminal is detected, i.e 'gtm report -color | less -R'
+  -no-color=false            Never output color, it takes precedence over -force-color and the NO_COLOR environment variable
+  -ref=""                    Git notes ref to report time from, i.e. -ref=refs/notes/gtm-billable
+                             Defaults to notes_ref in .gtm/config.json or refs/notes/gtm-data
+  -duration-format=short     Display durations as [short|long|decimal], decimal is hours, i.e. 1.50h (default short)
+  -duration-places=2         Number of decimal places for -duration-format=decimal
+  -out=""                    Write the report to a file instead of stdout, colors are removed unless -force-color is set
+  -gzip=false                Compress the report with gzip, .gz is appended to the -out file name unless it already ends with .gz.
+                             Without -out the compressed report is written to stdout, i.e. 'gtm report -format=csv -gzip > time.csv.gz'
+  -anonymize=false           Replace commit authors, and the authors in Co-authored-by trailers, with pseudonyms numbered from
+                             the oldest commit, i.e. author-1 <author-1@anonymous.invalid>, for sharing reports. Authors are
+                             merged with -mailmap first. Other text in commit messages is not changed
+  -anonymize-map=""          Write the authors and their pseudonyms to a file in mailmap format to de-anonymize the report,
+                             requires -anonymize
+  -include-pending=false     Include each project's uncommitted time as a pending entry, like gtm status, it's left out
+                             when commits are limited by -author, -message or a date range that ends before today
+  -no-cache=false            Read time from git notes instead of the cache in .gtm/cache, a note that changed since
+                             it was cached is always read from git notes
   -testing=false             This is used for automated testing to force default test path
 
+  Grouping:
+
+  -by=""                     Group time by [author|weekday|language] instead of the report format, weekday totals time by day of
+                             the week starting with -week-start in the -tz time zone, language totals time by the programming
+                             language of each file's extension with the number of files, other is time for unknown extensions,
+                             the terminal and apps. Set languages in .gtm/config.json to map more extensions, i.e. {".tmpl": "Go"}
+  -mailmap=""                Mailmap file used to merge author names and emails when grouping by author
+  -co-authors=primary        Attribute each commit's time to [primary|equal] authors when grouping by author, primary is the
+                             commit author and equal splits the time equally between the commit author and the authors in the
+                             commit message's Co-authored-by trailers, i.e. Co-authored-by: Name <name@example.com>
+  -period=""                 Total time by [day|week|month] instead of the report format
+  -compare=false             Compare the time for this day, week or month with the one before it, use with -period,
+                             the change is N/A when there's no time in the prior period
+  -week-start=monday         First day of the week when totaling time by week or weekday, any day of the week or its three letter
+                             abbreviation, i.e. [mon|sun|sat], weeks start at midnight in the -tz time zone (default monday, ISO 8601)
+  -heatmap=false             Show time spent by day of the week and hour of the day instead of the report format
+  -split-newcode=false       Show the time split between files added by each commit, new code, and files that existed before
+                             it, maintenance, with the ratio of the two instead of the report format. Pending time for files
+                             that are not in the head commit is new code, terminal and app time is shown separately
+
   Commit Limiting:
 
   -n int=1                   Limit output, 0 is no limits, defaults to 1 when no limiting flags otherwise defaults to 0
   -from-date=yyyy-mm-dd      Show commits starting from this date
   -to-date=yyyy-mm-dd        Show commits thru the end of this date
-  -author=""                 Show commits which contain author substring
+  -author=""                 Show commits which contain author substring, or by the author's email if it contains an @
+                             i.e. -author=me@example.com, -author=me is the git user.email for each project
   -message=""                Show commits which contain message substring
+  -exclude-commits=""        Do not show these comma separated full or abbreviated commit SHA-1s, i.e. -exclude-commits=1a2b3c4,5d6e7f8
+  -no-merges=false           Do not show merge commits, commits with more than one parent
+  -path=""                   Only show time for files matching these comma separated glob patterns, i.e. -path='cmd/**'
+                             Commits without time for matching files are not shown, terminal and app time is not shown
+  -follow-renames=false      Show time for files that were moved or renamed, i.e. with git mv, under the file's current path.
+                             Git's rename detection compares the files added and deleted by each commit from the head commit
+                             back to the oldest reported commit, this is slow for long histories and commits with many files
+  -hours=""                  Only show time [working|outside] of the working_hours and holidays in each project's .gtm/config.json,
+                             i.e. -hours=outside to report out-of-hours time separately. Working hours are in the -tz time zone,
+                             all time is working time for a project without working hours. Hours that straddle the start or
+                             end of working hours are prorated
   -today=false               Show commits for today
   -yesterday=false           Show commits for yesterday
   -this-week=false           Show commits for this week
@@ -69,24 +155,76 @@ Options:
   Multi-Project Reporting:
 
   -tags=""                   Project tags to report on, i.e --tags tag1,tag2
+  -tag-ignore-case=false     Match -tags regardless of case, i.e. client-a matches Client-A
+  -tag-regex=false           Treat each of the -tags as a regular expression that must match the whole tag, i.e. -tags='client-.*'
   -all=false                 Show commits for all projects
+  -projects=""               Report on the project paths listed one per line in a file, or stdin with -projects=-, instead of
+                             the current project, paths that are not gtm projects are reported and skipped
+
+  Repository:
+
+  -repo=""                   Report on the git repository at this path instead of the current project, the
+                             repository can be bare, i.e. a server side clone. Time data must be fetched into the
+                             repository's notes ref. Only report supports bare repositories, the other commands
+                             need a working tree.
+
+Colors:
+
+  Color is output when a terminal is detected. Setting the NO_COLOR environment variable to any value turns it off,
+  -force-color turns it on even if NO_COLOR is set and -no-color turns it off even if -force-color is set.
+
+Default Flags:
+
+  Set default flags separated by spaces in the GTM_REPORT_FLAGS environment variable, i.e.
+  GTM_REPORT_FLAGS="-format=summary -terminal-off". Flags on the command line take precedence over the defaults,
+  i.e. -format=files or -terminal-off=false.
 `
 	return strings.TrimSpace(helpText)
 }
 
 // Run executes report command with args
 func (c ReportCmd) Run(args []string) int {
-	var limit int
-	var color, terminalOff, appOff, fullMessage, testing bool
+	var limit, durationPlaces, top, messageWidth int
+	var round time.Duration
+	var terminalWeight, appWeight float64
+	var color, noColor, terminalOff, appOff, fullMessage, showMessage, testing, heatmap, cumulative, noMerges, noCache, includePending, lifetime, compare, tagIgnoreCase, tagRegex, followRenames, splitNewCode, gzipOut, allFiles, existingOnly, anonymize bool
 	var today, yesterday, thisWeek, lastWeek, thisMonth, lastMonth, thisYear, lastYear, all bool
-	var fromDate, toDate, message, author, tags, format string
+	var sortBy, fromDate, toDate, message, author, tags, format, by, mailmap, period, weekStart, outFile, ref, durationFormat, repoPath, projectsFile, title, tz, excludeCommits, paths, templateFile, roundMode, hours, coAuthors, anonymizeMap string
 	cmdFlags := flag.NewFlagSet("report", flag.ContinueOnError)
 	cmdFlags.BoolVar(&color, "force-color", false, "")
+	cmdFlags.BoolVar(&noColor, "no-color", false, "")
 	cmdFlags.BoolVar(&terminalOff, "terminal-off", false, "")
 	cmdFlags.BoolVar(&appOff, "app-off", false, "")
+	cmdFlags.Float64Var(&terminalWeight, "terminal-weight", 1, "")
+	cmdFlags.Float64Var(&appWeight, "app-weight", 1, "")
 	cmdFlags.StringVar(&format, "format", "commits", "")
+	cmdFlags.StringVar(&templateFile, "template", "", "")
+	cmdFlags.BoolVar(&lifetime, "lifetime", false, "")
+	cmdFlags.BoolVar(&allFiles, "files", false, "")
+	cmdFlags.BoolVar(&existingOnly, "existing-only", false, "")
+	cmdFlags.StringVar(&outFile, "out", "", "")
+	cmdFlags.BoolVar(&gzipOut, "gzip", false, "")
+	cmdFlags.BoolVar(&anonymize, "anonymize", false, "")
+	cmdFlags.StringVar(&anonymizeMap, "anonymize-map", "", "")
+	cmdFlags.BoolVar(&noCache, "no-cache", false, "")
+	cmdFlags.BoolVar(&includePending, "include-pending", false, "")
+	cmdFlags.StringVar(&ref, "ref", "", "")
+	cmdFlags.StringVar(&repoPath, "repo", "", "")
+	cmdFlags.StringVar(&title, "title", "", "")
+	cmdFlags.StringVar(&tz, "tz", "", "")
+	cmdFlags.StringVar(&durationFormat, "duration-format", "short", "")
+	cmdFlags.IntVar(&durationPlaces, "duration-places", 2, "")
 	cmdFlags.IntVar(&limit, "n", 0, "")
+	cmdFlags.IntVar(&top, "top", 0, "")
+	cmdFlags.BoolVar(&cumulative, "cumulative", false, "")
+	cmdFlags.DurationVar(&round, "round", 0, "")
+	cmdFlags.StringVar(&roundMode, "round-mode", report.RoundUp, "")
+	cmdFlags.StringVar(&sortBy, "sort", "date", "")
 	cmdFlags.BoolVar(&fullMessage, "full-message", false, "")
+	cmdFlags.BoolVar(&showMessage, "show-message", false, "")
+	cmdFlags.IntVar(&messageWidth, "message-width", 0, "")
+	var relative relativeFlag
+	cmdFlags.Var(&relative, "relative", "")
 	cmdFlags.StringVar(&fromDate, "from-date", "", "")
 	cmdFlags.StringVar(&toDate, "to-date", "", "")
 	cmdFlags.BoolVar(&today, "today", false, "")
@@ -99,27 +237,298 @@ func (c ReportCmd) Run(args []string) int {
 	cmdFlags.BoolVar(&lastYear, "last-year", false, "")
 	cmdFlags.StringVar(&author, "author", "", "")
 	cmdFlags.StringVar(&message, "message", "", "")
+	cmdFlags.StringVar(&excludeCommits, "exclude-commits", "", "")
+	cmdFlags.BoolVar(&noMerges, "no-merges", false, "")
+	cmdFlags.StringVar(&paths, "path", "", "")
+	cmdFlags.BoolVar(&followRenames, "follow-renames", false, "")
+	cmdFlags.StringVar(&hours, "hours", "", "")
 	cmdFlags.StringVar(&tags, "tags", "", "")
+	cmdFlags.BoolVar(&tagIgnoreCase, "tag-ignore-case", false, "")
+	cmdFlags.BoolVar(&tagRegex, "tag-regex", false, "")
 	cmdFlags.BoolVar(&all, "all", false, "")
+	cmdFlags.StringVar(&projectsFile, "projects", "", "")
 	cmdFlags.BoolVar(&testing, "testing", false, "")
+	cmdFlags.StringVar(&by, "by", "", "")
+	cmdFlags.StringVar(&mailmap, "mailmap", "", "")
+	cmdFlags.StringVar(&coAuthors, "co-authors", report.CoAuthorsPrimary, "")
+	cmdFlags.StringVar(&period, "period", "", "")
+	cmdFlags.BoolVar(&compare, "compare", false, "")
+	cmdFlags.StringVar(&weekStart, "week-start", "monday", "")
+	cmdFlags.BoolVar(&heatmap, "heatmap", false, "")
+	cmdFlags.BoolVar(&splitNewCode, "split-newcode", false, "")
 	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
+	if err := parseEnvFlags(cmdFlags, ReportFlagsEnv); err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
 	if err := cmdFlags.Parse(args); err != nil {
-		return 1
+		return ExitUsage
 	}
+	color, noColor = colorOptions(color, noColor)
 
-	if !util.StringInSlice([]string{"summary", "commits", "timeline-hours", "files", "timeline-commits", "project"}, format) {
+	if !util.StringInSlice([]string{"summary", "commits", "timeline-hours", "files", "timeline-commits", "project", "csv", "markdown", "html", "xml", "jira"}, format) {
 		c.UI.Error(fmt.Sprintf("report --format=%s not valid\n", format))
-		return 1
+		return ExitUsage
+	}
+
+	if !util.StringInSlice([]string{"short", "long", "decimal"}, durationFormat) {
+		c.UI.Error(fmt.Sprintf("report --duration-format=%s not valid\n", durationFormat))
+		return ExitUsage
+	}
+
+	if repoPath != "" && (tags != "" || all) {
+		c.UI.Error("\n-repo option not allowed with -tags or -all\n")
+		return ExitUsage
+	}
+
+	if projectsFile != "" && (repoPath != "" || tags != "" || all || (!testing && len(cmdFlags.Args()) > 0)) {
+		c.UI.Error("\n-projects option not allowed with -repo, -tags, -all or commit ids\n")
+		return ExitUsage
+	}
+
+	var loc *time.Location
+	if tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			c.UI.Error(fmt.Sprintf("\nInvalid time zone %s, use a IANA time zone name, i.e. America/New_York or UTC\n", tz))
+			return ExitUsage
+		}
+	}
+
+	templateText := ""
+	if templateFile != "" {
+		formatSet := false
+		cmdFlags.Visit(func(f *flag.Flag) { formatSet = formatSet || f.Name == "format" })
+		if formatSet || heatmap || by != "" || period != "" {
+			c.UI.Error("\n-template option not allowed with -format, -heatmap, -by or -period\n")
+			return ExitUsage
+		}
+		b, err := ioutil.ReadFile(templateFile)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		templateText = string(b)
+		// check the template before reading commits
+		if _, err := report.ParseTemplate(filepath.Base(templateFile), templateText, report.OutputOptions{}); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+	}
+
+	if splitNewCode {
+		conflict := false
+		cmdFlags.Visit(func(f *flag.Flag) {
+			conflict = conflict || util.StringInSlice([]string{"format", "template", "sort", "cumulative", "top", "lifetime"}, f.Name)
+		})
+		if conflict || heatmap || by != "" || period != "" {
+			c.UI.Error("\n-split-newcode option not allowed with -format, -template, -sort, -cumulative, -top, -lifetime, -heatmap, -by or -period\n")
+			return ExitUsage
+		}
+	}
+
+	if lifetime {
+		conflict := false
+		cmdFlags.Visit(func(f *flag.Flag) {
+			conflict = conflict || util.StringInSlice([]string{"format", "template", "n", "sort", "cumulative", "top"}, f.Name)
+		})
+		if conflict || heatmap || by != "" || period != "" {
+			c.UI.Error("\n-lifetime option not allowed with -format, -template, -n, -sort, -cumulative, -top, -heatmap, -by or -period\n")
+			return ExitUsage
+		}
+	}
+
+	if allFiles {
+		conflict := false
+		cmdFlags.Visit(func(f *flag.Flag) {
+			conflict = conflict || util.StringInSlice([]string{"format", "template", "n", "cumulative", "lifetime"}, f.Name)
+		})
+		if conflict || heatmap || splitNewCode || by != "" || period != "" {
+			c.UI.Error("\n-files option not allowed with -format, -template, -n, -cumulative, -lifetime, -heatmap, -split-newcode, -by or -period\n")
+			return ExitUsage
+		}
+	}
+
+	if anonymizeMap != "" && !anonymize {
+		c.UI.Error("\n-anonymize-map option requires -anonymize\n")
+		return ExitUsage
+	}
+
+	if existingOnly && !allFiles {
+		c.UI.Error("\n-existing-only option is only allowed with -files\n")
+		return ExitUsage
+	}
+
+	if compare {
+		conflict := false
+		cmdFlags.Visit(func(f *flag.Flag) {
+			conflict = conflict || util.StringInSlice([]string{"format", "template", "n", "cumulative"}, f.Name)
+		})
+		if period == "" || conflict || heatmap || by != "" {
+			c.UI.Error("\n-compare option requires -period and is not allowed with -format, -template, -n, -cumulative, -heatmap or -by\n")
+			return ExitUsage
+		}
+	}
+
+	if relative != "" && (templateFile != "" || heatmap || splitNewCode || by != "" || period != "" || lifetime || allFiles ||
+		!util.StringInSlice([]string{"commits", "markdown", "html"}, format)) {
+		c.UI.Error("\n-relative option is only allowed with the commits, markdown and html formats\n")
+		return ExitUsage
+	}
+
+	if showMessage && (templateFile != "" || heatmap || splitNewCode || by != "" || period != "" || lifetime || format != "csv") {
+		c.UI.Error("\n-show-message option is only allowed with -format=csv\n")
+		return ExitUsage
+	}
+
+	if messageWidth < 0 {
+		c.UI.Error("\n-message-width must not be negative\n")
+		return ExitUsage
+	}
+
+	if messageWidth > 0 && (templateFile != "" || heatmap || splitNewCode || by != "" || period != "" || lifetime || allFiles ||
+		!util.StringInSlice([]string{"commits", "summary", "csv", "markdown", "html"}, format)) {
+		c.UI.Error("\n-message-width option is only allowed with the commits, summary, csv, markdown and html formats\n")
+		return ExitUsage
+	}
+
+	if title != "" && format != "html" {
+		c.UI.Error("\n-title option is only allowed with -format=html\n")
+		return ExitUsage
+	}
+
+	if terminalWeight < 0 || appWeight < 0 {
+		c.UI.Error("\n-terminal-weight and -app-weight must not be negative\n")
+		return ExitUsage
+	}
+
+	if !util.StringInSlice([]string{"date", "time", "name"}, sortBy) {
+		c.UI.Error(fmt.Sprintf("report --sort=%s not valid\n", sortBy))
+		return ExitUsage
+	}
+
+	if sortBy != "date" && (heatmap || by != "" || period != "" ||
+		!util.StringInSlice([]string{"commits", "summary", "files", "csv", "markdown", "xml"}, format)) {
+		c.UI.Error("\n-sort option is only allowed with -template and the commits, summary, files, csv, markdown and xml formats\n")
+		return ExitUsage
+	}
+
+	if top < 0 {
+		c.UI.Error("\n-top must not be negative\n")
+		return ExitUsage
+	}
+
+	if top > 0 && format != "files" && !allFiles {
+		c.UI.Error("\n-top option is only allowed with -format=files and -files\n")
+		return ExitUsage
+	}
+
+	if cumulative && (heatmap || by != "" || (period == "" && !util.StringInSlice([]string{"commits", "summary"}, format))) {
+		c.UI.Error("\n-cumulative option is only allowed with -format=commits, -format=summary or -period\n")
+		return ExitUsage
+	}
+
+	if round < 0 || (round > 0 && round < time.Second) {
+		c.UI.Error("\n-round must be 0 or at least 1s\n")
+		return ExitUsage
+	}
+
+	if hours != "" && !util.StringInSlice(report.HoursOptions, hours) {
+		c.UI.Error(fmt.Sprintf("report --hours=%s not valid\n", hours))
+		return ExitUsage
+	}
+
+	if !util.StringInSlice(report.RoundModes, roundMode) {
+		c.UI.Error(fmt.Sprintf("report --round-mode=%s not valid\n", roundMode))
+		return ExitUsage
+	}
+
+	if round > 0 && (templateFile != "" || heatmap || splitNewCode ||
+		(!lifetime && by == "" && period == "" && !util.StringInSlice([]string{"summary", "project"}, format))) {
+		c.UI.Error("\n-round option is only allowed with -format=summary, -format=project, -lifetime, -by or -period\n")
+		return ExitUsage
+	}
+
+	if durationPlaces < 0 {
+		c.UI.Error("\n-duration-places must not be negative\n")
+		return ExitUsage
+	}
+
+	if !util.StringInSlice([]string{"", "author", "weekday", "language"}, by) {
+		c.UI.Error(fmt.Sprintf("report --by=%s not valid\n", by))
+		return ExitUsage
+	}
+
+	if !util.StringInSlice(report.CoAuthorPolicies, coAuthors) {
+		c.UI.Error(fmt.Sprintf("report --co-authors=%s not valid\n", coAuthors))
+		return ExitUsage
+	}
+
+	if coAuthors != report.CoAuthorsPrimary && by != "author" {
+		c.UI.Error("\n-co-authors option is only allowed with -by=author\n")
+		return ExitUsage
+	}
+
+	if !util.StringInSlice([]string{"", "day", "week", "month"}, period) {
+		c.UI.Error(fmt.Sprintf("report --period=%s not valid\n", period))
+		return ExitUsage
+	}
+
+	firstDay, weekStartErr := report.ParseWeekday(weekStart)
+	if weekStartErr != nil {
+		c.UI.Error(fmt.Sprintf("report --week-start=%s not valid\n", weekStart))
+		return ExitUsage
+	}
+
+	pathList := []string{}
+	if paths != "" {
+		pathList = util.Map(strings.Split(paths, ","), strings.TrimSpace)
+	}
+	for _, p := range pathList {
+		if _, err := path.Match(p, ""); err != nil {
+			c.UI.Error(fmt.Sprintf("\nInvalid pattern %s\n", p))
+			return ExitUsage
+		}
+	}
+
+	if ref != "" {
+		var err error
+		if ref, err = project.NotesRef(ref); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
 	}
 
 	var (
 		commits []string
 		out     string
 		err     error
+		authors report.Mailmap
 	)
 
+	if mailmap != "" {
+		if authors, err = report.LoadMailmap(mailmap); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+	}
+
 	const invalidSHA1 = "\nNot a valid commit SHA-1 %s\n"
 
+	excludeList := []string{}
+	if excludeCommits != "" {
+		excludeList = util.Map(strings.Split(excludeCommits, ","), strings.TrimSpace)
+	}
+	abbrevSHA1Regex := regexp.MustCompile(`\A([0-9a-f]{4,40})\z`)
+	for _, e := range excludeList {
+		if !abbrevSHA1Regex.MatchString(e) {
+			c.UI.Error(fmt.Sprintf("%s %s", invalidSHA1, e))
+			return ExitUsage
+		}
+	}
+	// commits passed as arguments or from stdin are only checked for excluded commits
+	excluded := scm.CommitLimiter{Exclude: excludeList}
+
 	// if running from within a MINGW console isatty detection does not work
 	// https://github.com/mintty/mintty/issues/482
 	isMinGW := strings.HasPrefix(os.Getenv("MSYSTEM"), "MINGW")
@@ -128,25 +537,41 @@ func (c ReportCmd) Run(args []string) int {
 
 	projCommits := []report.ProjectCommits{}
 
+	// commit ids are read from stdin when it's not a terminal unless it's the -projects list
+	commitsFromStdin := projectsFile == "" && !testing && !isMinGW && !isatty.IsTerminal(os.Stdin.Fd())
+
+	if gzipOut && outFile == "" && !testing && !isMinGW && isatty.IsTerminal(os.Stdout.Fd()) {
+		c.UI.Error("\n-gzip option requires -out or stdout redirected to a file or pipe\n")
+		return ExitUsage
+	}
+
+	if includePending && (repoPath != "" || commitsFromStdin || (!testing && len(cmdFlags.Args()) > 0)) {
+		c.UI.Error("\n-include-pending option not allowed with -repo or commit ids\n")
+		return ExitUsage
+	}
+
+	// reading notes for many projects can take a while, stop reading when interrupted
+	ctx, stop := interruptContext()
+	defer stop()
+
+	var invalid []error
 	switch {
-	case !testing && !isMinGW && !isatty.IsTerminal(os.Stdin.Fd()):
+	case commitsFromStdin:
 		scanner := bufio.NewScanner(os.Stdin)
 		for scanner.Scan() {
 			if !sha1Regex.MatchString(scanner.Text()) {
 				c.UI.Error(fmt.Sprintf("%s %s", invalidSHA1, scanner.Text()))
-				return 1
+				return ExitUsage
+			}
+			if excluded.IsExcluded(scanner.Text()) {
+				continue
 			}
 			commits = append(commits, scanner.Text())
 		}
-		curProjPath, err := scm.GitRepoPath()
-		if err != nil {
-			c.UI.Error(err.Error())
-			return 1
-		}
-		curProjPath, err = scm.Workdir(curProjPath)
+		curProjPath, err := projectPath(repoPath)
 		if err != nil {
 			c.UI.Error(err.Error())
-			return 1
+			return exitCode(err)
 		}
 
 		projCommits = append(projCommits, report.ProjectCommits{Path: curProjPath, Commits: commits})
@@ -155,46 +580,59 @@ func (c ReportCmd) Run(args []string) int {
 		for _, a := range cmdFlags.Args() {
 			if !sha1Regex.MatchString(a) {
 				c.UI.Error(fmt.Sprintf("%s %s", invalidSHA1, a))
-				return 1
+				return ExitUsage
+			}
+			if excluded.IsExcluded(a) {
+				continue
 			}
 			commits = append(commits, a)
 		}
-		curProjPath, err := scm.GitRepoPath()
-		if err != nil {
-			c.UI.Error(err.Error())
-			return 1
-		}
-		curProjPath, err = scm.Workdir(curProjPath)
+		curProjPath, err := projectPath(repoPath)
 		if err != nil {
 			c.UI.Error(err.Error())
-			return 1
-		}
-		if err != nil {
-			c.UI.Error(err.Error())
-			return 1
+			return exitCode(err)
 		}
 
 		projCommits = append(projCommits, report.ProjectCommits{Path: curProjPath, Commits: commits})
 
 	default:
-		index, err := project.NewIndex()
-		if err != nil {
-			c.UI.Error(err.Error())
-			return 1
-		}
+		var projects []string
+		if projectsFile != "" {
+			var err error
+			if projects, invalid, err = readProjects(projectsFile); err != nil {
+				c.UI.Error(err.Error())
+				return exitCode(err)
+			}
+			for _, err := range invalid {
+				c.UI.Error(err.Error())
+			}
+		} else if repoPath != "" {
+			p, err := projectPath(repoPath)
+			if err != nil {
+				c.UI.Error(err.Error())
+				return exitCode(err)
+			}
+			projects = []string{p}
+		} else {
+			index, err := project.NewIndex()
+			if err != nil {
+				c.UI.Error(err.Error())
+				return exitCode(err)
+			}
 
-		tagList := []string{}
-		if tags != "" {
-			tagList = util.Map(strings.Split(tags, ","), strings.TrimSpace)
-		}
-		projects, err := index.Get(tagList, all)
-		if err != nil {
-			c.UI.Error(err.Error())
-			return 1
+			tagList := []string{}
+			if tags != "" {
+				tagList = util.Map(strings.Split(tags, ","), strings.TrimSpace)
+			}
+			projects, err = index.GetMatching(tagList, all, project.TagMatch{IgnoreCase: tagIgnoreCase, Regex: tagRegex})
+			if err != nil {
+				c.UI.Error(err.Error())
+				return exitCode(err)
+			}
 		}
 
 		// hack, if project format we want all commits for the project
-		if format == "project" && limit == 0 {
+		if (format == "project" || lifetime || compare || allFiles) && limit == 0 {
 			// set max to absurdly high value for number of possible commits
 			limit = 2147483647
 		}
@@ -206,58 +644,264 @@ func (c ReportCmd) Run(args []string) int {
 
 		if err != nil {
 			c.UI.Error(err.Error())
-			return 1
+			return exitCode(err)
 		}
 
 		limit = limiter.Max
+		limiter.NoMerges = noMerges
+		limiter.Exclude = excludeList
 
 		for _, p := range projects {
+			if author == "me" {
+				// each project can have its own git user
+				if limiter.Author, err = authorEmail(p); err != nil {
+					c.UI.Error(err.Error())
+					return exitCode(err)
+				}
+			}
 			commits, err = scm.CommitIDs(limiter, p)
 			if err != nil {
 				c.UI.Error(err.Error())
-				return 1
+				return exitCode(err)
 			}
-			projCommits = append(projCommits, report.ProjectCommits{Path: p, Commits: commits})
+			pc := report.ProjectCommits{Path: p, Commits: commits}
+			if includePending && pendingInRange(limiter) {
+				if pc.Pending, err = pendingTime(ctx, p); err != nil {
+					c.UI.Error(err.Error())
+					return exitCode(err)
+				}
+			}
+			projCommits = append(projCommits, pc)
 		}
 	}
 
+	// an invalid config is reported once for each project, the defaults are used for it
+	for _, pc := range projCommits {
+		loadConfig(c.UI, filepath.Join(pc.Path, project.GTMDir))
+	}
+
 	options := report.OutputOptions{
-		FullMessage: fullMessage,
-		TerminalOff: terminalOff,
-		AppOff:      appOff,
-		Color:       color,
-		Limit:       limit}
+		FullMessage:    fullMessage,
+		ShowMessage:    showMessage,
+		MessageWidth:   messageWidth,
+		TerminalOff:    terminalOff,
+		AppOff:         appOff,
+		Weights:        &report.Weights{Terminal: terminalWeight, App: appWeight},
+		Color:          color,
+		NoColor:        noColor,
+		Limit:          limit,
+		Mailmap:        authors,
+		CoAuthors:      coAuthors,
+		ExistingOnly:   existingOnly,
+		Period:         period,
+		NotesRef:       ref,
+		DurationFormat: durationFormat,
+		DurationPlaces: durationPlaces,
+		Top:            top,
+		Cumulative:     cumulative,
+		Sort:           sortBy,
+		Title:          title,
+		Location:       loc,
+		Paths:          pathList,
+		NoCache:        noCache,
+		FollowRenames:  followRenames,
+		Hours:          hours,
+		Version:        c.Version,
+		Context:        ctx,
+		RelativeDates:  string(relative),
+		Round:          round,
+		RoundMode:      roundMode,
+		WeekStart:      firstDay}
+	if anonymize {
+		options.Anonymizer = report.NewAnonymizer()
+	}
 
 	s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
 	s.Start()
 
-	switch format {
-	case "project":
+	switch {
+	case lifetime:
+		out, err = report.Lifetime(projCommits, options)
+	case allFiles:
+		out, err = report.FileHistory(projCommits, options)
+	case templateFile != "":
+		out, err = report.Template(projCommits, options, filepath.Base(templateFile), templateText)
+	case heatmap:
+		out, err = report.Heatmap(projCommits, options)
+	case splitNewCode:
+		out, err = report.NewCodeSummary(projCommits, options)
+	case by == "author":
+		out, err = report.AuthorSummary(projCommits, options)
+	case by == "weekday":
+		out, err = report.WeekdaySummary(projCommits, options)
+	case by == "language":
+		out, err = report.LanguageSummary(projCommits, options)
+	case compare:
+		out, err = report.Compare(projCommits, options)
+	case period != "":
+		out, err = report.PeriodSummary(projCommits, options)
+	case format == "project":
 		out, err = report.ProjectSummary(projCommits, options)
-	case "summary":
+	case format == "summary":
 		out, err = report.CommitSummary(projCommits, options)
-	case "commits":
+	case format == "commits":
 		out, err = report.Commits(projCommits, options)
-	case "files":
+	case format == "files":
 		out, err = report.Files(projCommits, options)
-	case "timeline-hours":
+	case format == "timeline-hours":
 		out, err = report.Timeline(projCommits, options)
-	case "timeline-commits":
+	case format == "timeline-commits":
 		out, err = report.TimelineCommits(projCommits, options)
+	case format == "csv":
+		out, err = report.CSV(projCommits, options)
+	case format == "markdown":
+		out, err = report.Markdown(projCommits, options)
+	case format == "html":
+		out, err = report.HTML(projCommits, options)
+	case format == "xml":
+		out, err = report.XML(projCommits, options)
+	case format == "jira":
+		out, err = report.JIRA(projCommits, options)
 	}
 
 	s.Stop()
 
+	if errors.Is(err, context.Canceled) {
+		c.UI.Error("\nReport canceled\n")
+		return ExitError
+	}
 	if err != nil {
 		c.UI.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
-	c.UI.Output(out)
 
+	// the mapping has the authors' names and emails, it's only readable by the user
+	if anonymizeMap != "" {
+		if err := util.WriteFileAtomic(anonymizeMap, []byte(options.Anonymizer.Mapping()), 0600); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+	}
+
+	if outFile != "" || gzipOut {
+		if !color {
+			out = util.StripANSI(out)
+		}
+		data := []byte(out + "\n")
+		if gzipOut {
+			if data, err = util.Gzip(data); err != nil {
+				c.UI.Error(err.Error())
+				return exitCode(err)
+			}
+			if outFile != "" && !strings.HasSuffix(outFile, ".gz") {
+				outFile += ".gz"
+			}
+		}
+		if outFile == "" {
+			_, err = os.Stdout.Write(data)
+		} else {
+			err = util.WriteFileAtomic(outFile, data, 0644)
+		}
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+	} else {
+		c.UI.Output(out)
+	}
+
+	// the exit status is non-zero if a -projects path was not a project
+	if len(invalid) > 0 {
+		return ExitNotInitialized
+	}
 	return 0
 }
 
+// relativeFlag is the value of the -relative option, -relative is report.RelativeDates and -relative=both
+// is report.RelativeAndAbsoluteDates, it's blank for absolute dates
+type relativeFlag string
+
+func (r *relativeFlag) String() string { return string(*r) }
+
+func (r *relativeFlag) Set(s string) error {
+	switch s {
+	case "true":
+		*r = report.RelativeDates
+	case "false":
+		*r = ""
+	case report.RelativeAndAbsoluteDates:
+		*r = report.RelativeAndAbsoluteDates
+	default:
+		return fmt.Errorf("must be true, false or both")
+	}
+	return nil
+}
+
+// IsBoolFlag allows -relative without a value
+func (r *relativeFlag) IsBoolFlag() bool { return true }
+
+// authorEmail returns the git user.email for the project at projPath
+func authorEmail(projPath string) (string, error) {
+	email, err := scm.ConfigGet("user.email", projPath)
+	if err != nil {
+		return "", err
+	}
+	if email == "" {
+		return "", fmt.Errorf("\n-author=me requires a git user.email for %s, i.e. git config --global user.email you@example.com\n", projPath)
+	}
+	return email, nil
+}
+
+// projectPath returns the working tree of the git repository at path, the current directory if path is blank.
+// Bare repositories do not have a working tree so their git directory is returned.
+func projectPath(path string) (string, error) {
+	wd := []string{}
+	if path != "" {
+		wd = append(wd, path)
+	}
+	gitRepoPath, err := scm.GitRepoPath(wd...)
+	if err != nil {
+		return "", err
+	}
+	workDir, err := scm.Workdir(gitRepoPath)
+	if err == scm.ErrBareRepo {
+		return gitRepoPath, nil
+	}
+	return workDir, err
+}
+
 // Synopsis return help for report command
 func (c ReportCmd) Synopsis() string {
 	return "Display reports for git repositories"
 }
+
+// pendingInRange returns true if uncommitted time is within the commit limits,
+// pending time has no commit message and is for today
+func pendingInRange(limiter scm.CommitLimiter) bool {
+	if limiter.HasAuthor || limiter.HasMessage {
+		return false
+	}
+	return !limiter.DateRange.IsSet() || limiter.DateRange.Within(util.Now())
+}
+
+// pendingTime returns the project's uncommitted time for the project's git user
+func pendingTime(ctx context.Context, projPath string) (*report.PendingTime, error) {
+	// the report warns about an invalid config
+	config, err := projectConfig(nil, projPath)
+	if err != nil {
+		return nil, err
+	}
+	n, err := metric.ProcessContext(ctx, true, config, projPath)
+	if err != nil {
+		return nil, err
+	}
+	name, err := scm.ConfigGet("user.name", projPath)
+	if err != nil {
+		return nil, err
+	}
+	email, err := scm.ConfigGet("user.email", projPath)
+	if err != nil {
+		return nil, err
+	}
+	return &report.PendingTime{Note: n, Author: name, Email: email}, nil
+}
@@ -5,12 +5,21 @@
 package command
 
 import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/git-time-metric/gtm/note"
 	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/report"
+	"github.com/git-time-metric/gtm/scm"
 	"github.com/git-time-metric/gtm/util"
 	"github.com/mitchellh/cli"
 )
@@ -256,6 +265,71 @@ func TestReportFiles(t *testing.T) {
 	}
 }
 
+func TestReportFileHistory(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("old.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496863.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496923.event", project.GTMDir, filepath.Join("event", "old.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go"), filepath.Join("event", "old.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	// old.go is deleted in a later commit, event.go has time in both commits
+	repo.SaveFile("event.go", "event", "package event")
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("event", "event.go"))
+	cmd := exec.Command("git", "rm", "-q", filepath.Join("event", "old.go"))
+	cmd.Dir = repo.Workdir()
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git rm, want error nil got %s, %s", err, out)
+	}
+	cmd = exec.Command("git", "commit", "-q", "-a", "-m", "Remove old.go")
+	cmd.Dir = repo.Workdir()
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit, want error nil got %s, %s", err, out)
+	}
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	tests := []struct {
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{[]string{"-files"}, []string{"3m  0s  75%  event/event.go", "1m  0s  25%  event/old.go [deleted]", "4m  0s"}, []string{}},
+		{[]string{"-files", "-existing-only"}, []string{"3m  0s 100%  event/event.go"}, []string{"old.go"}},
+		{[]string{"-files", "-top", "1"}, []string{"3m  0s  75%  event/event.go", "1m  0s  25%  1 others"}, []string{"old.go"}},
+	}
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		args := append(tc.args, "-testing=true")
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Fatalf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(ui.OutputWriter.String(), want) {
+				t.Errorf("gtm report(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+			}
+		}
+		for _, notWant := range tc.notWant {
+			if strings.Contains(ui.OutputWriter.String(), notWant) {
+				t.Errorf("gtm report(%+v), want no %s got %s", args, notWant, ui.OutputWriter.String())
+			}
+		}
+	}
+
+	for _, args := range [][]string{{"-existing-only"}, {"-files", "-format", "files"}, {"-files", "-n", "1"}} {
+		args = append(args, "-testing=true")
+		if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm report(%+v), want %d got %d", args, ExitUsage, rc)
+		}
+	}
+}
+
 func TestReportAppsOff(t *testing.T) {
 	repo := util.NewTestRepo(t, false)
 	defer repo.Remove()
@@ -299,17 +373,1780 @@ func TestReportAppsOff(t *testing.T) {
 	}
 }
 
-func TestReportInvalidOption(t *testing.T) {
+func TestReportCSV(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// double quotes are not valid in Windows file names
+	fileName := `ev"ent.go`
+	want := `"event/ev""ent.go",60`
+	if runtime.GOOS == "windows" {
+		fileName = "event.go"
+		want = "event/event.go,60"
+	}
+
+	repo.SaveFile(fileName, "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", fileName))
+
+	repo.Commit(repo.Stage(filepath.Join("event", fileName)))
+
+	// save notes to git repository
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
 	ui := new(cli.MockUi)
 	c := ReportCmd{UI: ui}
 
-	args := []string{"-invalid"}
+	args := []string{"-format", "csv", "-testing=true"}
 	rc := c.Run(args)
 
-	if rc != 1 {
-		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter)
+	if rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
 	}
-	if !strings.Contains(ui.OutputWriter.String(), "Usage:") {
-		t.Errorf("gtm report(%+v), want 'Usage:'  got %d, %s", args, rc, ui.OutputWriter.String())
+
+	for _, w := range []string{"commit,author,date,project,file,seconds", "Rand Om Hacker,2013-03-06T14:30:00-06:00", want} {
+		if !strings.Contains(ui.OutputWriter.String(), w) {
+			t.Errorf("gtm report(%+v), want %s got %s, %s", args, w, ui.OutputWriter.String(), ui.ErrorWriter.String())
+		}
+	}
+}
+
+func TestReportJIRA(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	commit := func(message string, epochs ...int) {
+		repo.SaveFile("event.go", "event", message)
+		for _, e := range epochs {
+			repo.SaveFile(fmt.Sprintf("%d.event", e), project.GTMDir, filepath.Join("event", "event.go"))
+		}
+		repo.Stage(filepath.Join("event", "event.go"))
+		cmd := exec.Command("git", "commit", "-q", "-m", message, "--", filepath.Join("event", "event.go"))
+		cmd.Dir = repo.Workdir()
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git commit, want error nil got %s, %s", err, out)
+		}
+		(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+	}
+	// three minutes split between two issues and a minute without an issue key
+	commit("GTM-1 add events\n\nThe status uses them for GTM-2", 1458496803, 1458500403, 1458504003)
+	commit("Fix typo", 1458507603)
+
+	ui := new(cli.MockUi)
+	args := []string{"-format", "jira", "-n", "2", "-testing=true"}
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+		t.Fatalf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	if len(lines) != 4 || lines[0] != "Issue Key,Started,Time Spent (seconds),Comment" {
+		t.Fatalf("gtm report(%+v), want a header and 3 issues got %s", args, ui.OutputWriter.String())
+	}
+	for i, want := range []string{"GTM-1,", "GTM-2,", project.UnassignedIssue + ","} {
+		if !strings.HasPrefix(lines[i+1], want) {
+			t.Errorf("gtm report(%+v), want line %d to start with %s got %s", args, i+1, want, lines[i+1])
+		}
+	}
+	for i, want := range []string{",90,gtm commits ", ",90,gtm commits ", ",60,gtm commits "} {
+		if !strings.Contains(lines[i+1], want) {
+			t.Errorf("gtm report(%+v), want line %d to contain %s got %s", args, i+1, want, lines[i+1])
+		}
+	}
+}
+
+func TestReportXML(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// angle brackets are not valid in Windows file names
+	fileName := `ev&<ent>.go`
+	want := `<file path="event/ev&amp;&lt;ent&gt;.go" status="m" seconds="60"></file>`
+	if runtime.GOOS == "windows" {
+		fileName = "ev&ent.go"
+		want = `<file path="event/ev&amp;ent.go" status="m" seconds="60"></file>`
+	}
+
+	repo.SaveFile(fileName, "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", fileName))
+
+	repo.Commit(repo.Stage(filepath.Join("event", fileName)))
+
+	// save notes to git repository
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	ui := new(cli.MockUi)
+	c := ReportCmd{UI: ui}
+
+	args := []string{"-format", "xml", "-testing=true"}
+	rc := c.Run(args)
+
+	if rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+
+	for _, w := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<report version="1" seconds="60">`,
+		fmt.Sprintf(`<project name="%s" seconds="60">`, filepath.Base(repo.Workdir())),
+		`author="Rand Om Hacker" email="random@hacker.com" date="2013-03-06T14:30:00-06:00" seconds="60"`,
+		want} {
+		if !strings.Contains(ui.OutputWriter.String(), w) {
+			t.Errorf("gtm report(%+v), want %s got %s, %s", args, w, ui.OutputWriter.String(), ui.ErrorWriter.String())
+		}
+	}
+}
+
+func TestReportByAuthor(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("mailmap", project.GTMDir, "# comment\nRandom Hacker <rh@example.com> <Random@Hacker.com>\n")
+
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+
+	// save notes to git repository
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	ui := new(cli.MockUi)
+	c := ReportCmd{UI: ui}
+
+	args := []string{"-by", "author", "-testing=true"}
+	rc := c.Run(args)
+	if rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	want := "1m  0s 100%    1 Rand Om Hacker <random@hacker.com>"
+	if !strings.Contains(ui.OutputWriter.String(), want) {
+		t.Errorf("gtm report(%+v), want %s got %s, %s", args, want, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+
+	ui.OutputWriter.Reset()
+	args = []string{"-by", "author", "-mailmap", filepath.Join(project.GTMDir, "mailmap"), "-testing=true"}
+	rc = c.Run(args)
+	if rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	want = "1m  0s 100%    1 Random Hacker <rh@example.com>"
+	if !strings.Contains(ui.OutputWriter.String(), want) {
+		t.Errorf("gtm report(%+v), want %s got %s, %s", args, want, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+
+	args = []string{"-by", "invalid", "-testing=true"}
+	if rc = c.Run(args); rc != ExitUsage {
+		t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestReportByAuthorCoAuthors(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// two minutes paired with a co-author, the author is also listed as a co-author
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Stage(filepath.Join("event", "event.go"))
+	message := "Pair on events\n\nCo-authored-by: Pair Partner <pair@example.com>\nco-authored-by: Random <random@hacker.com>\n"
+	cmd := exec.Command("git", "commit", "-q", "-m", message, "--", filepath.Join("event", "event.go"))
+	cmd.Dir = repo.Workdir()
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Rand Om Hacker", "GIT_AUTHOR_EMAIL=random@hacker.com",
+		"GIT_COMMITTER_NAME=Rand Om Hacker", "GIT_COMMITTER_EMAIL=random@hacker.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit, want error nil got %s, %s", err, out)
+	}
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-by", "author"}, []string{"2m  0s 100%    1 Rand Om Hacker <random@hacker.com>"}},
+		{[]string{"-by", "author", "-co-authors", "equal"},
+			[]string{"1m  0s  50%    1 Rand Om Hacker <random@hacker.com>", "1m  0s  50%    1 Pair Partner <pair@example.com>"}},
+	}
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		args := append(tc.args, "-testing=true")
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Fatalf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(ui.OutputWriter.String(), want) {
+				t.Errorf("gtm report(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+			}
+		}
+	}
+
+	for _, args := range [][]string{{"-co-authors", "equal"}, {"-by", "author", "-co-authors", "all"}} {
+		args = append(args, "-testing=true")
+		if rc := (ReportCmd{UI: cli.NewMockUi()}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm report(%+v), want %d got %d", args, ExitUsage, rc)
+		}
+	}
+}
+
+func TestReportAnonymize(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	// a newer commit by another author, paired with the first author
+	repo.SaveFile("event.go", "event", "package event")
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("event", "event.go"))
+	message := "Pair on events\n\nCo-authored-by: Rand Om Hacker <random@hacker.com>\n"
+	cmd := exec.Command("git", "commit", "-q", "-a", "-m", message)
+	cmd.Dir = repo.Workdir()
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Pair Partner", "GIT_AUTHOR_EMAIL=pair@example.com",
+		"GIT_COMMITTER_NAME=Pair Partner", "GIT_COMMITTER_EMAIL=pair@example.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit, want error nil got %s, %s", err, out)
+	}
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	mapFile := filepath.Join(repo.Workdir(), "authors.map")
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-format", "csv", "-n", "2"}, []string{",author-1,", ",author-2,"}},
+		{[]string{"-format", "commits", "-n", "2", "-full-message"}, []string{"Co-authored-by: author-1 <author-1@anonymous.invalid>"}},
+		{[]string{"-by", "author", "-n", "2", "-co-authors", "equal"},
+			[]string{"1m 30s  75%    2 author-1 <author-1@anonymous.invalid>", "30s  25%    1 author-2 <author-2@anonymous.invalid>"}},
+		{[]string{"-format", "xml", "-n", "2", "-anonymize-map", mapFile}, []string{`author="author-2"`}},
+	}
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		args := append(tc.args, "-anonymize", "-testing=true")
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Fatalf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(ui.OutputWriter.String(), want) {
+				t.Errorf("gtm report(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+			}
+		}
+		for _, notWant := range []string{"Rand Om Hacker", "random@hacker.com", "Pair Partner", "pair@example.com"} {
+			if strings.Contains(ui.OutputWriter.String(), notWant) {
+				t.Errorf("gtm report(%+v), want no %s got %s", args, notWant, ui.OutputWriter.String())
+			}
+		}
+	}
+
+	b, err := ioutil.ReadFile(mapFile)
+	util.CheckFatal(t, err)
+	want := "Rand Om Hacker <random@hacker.com> <author-1@anonymous.invalid>\nPair Partner <pair@example.com> <author-2@anonymous.invalid>\n"
+	if string(b) != want {
+		t.Errorf("gtm report(-anonymize-map), want %q got %q", want, string(b))
+	}
+
+	args := []string{"-anonymize-map", mapFile, "-testing=true"}
+	if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm report(%+v), want %d got %d", args, ExitUsage, rc)
+	}
+}
+
+func TestReportByLanguage(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+	repo.SaveFile(project.ConfigFile, project.GTMDir, `{"languages": {".tmpl": "Go"}}`)
+
+	// two minutes of Go, a minute of markdown and a minute for a file with an unknown extension
+	for i, f := range []string{"main.go", "report.tmpl", "README.md", "data.bin"} {
+		repo.SaveFile(f, "", "")
+		repo.SaveFile(fmt.Sprintf("%d.event", 1458496803+i*3600), project.GTMDir, f)
+	}
+	repo.Commit(repo.Stage("main.go", "report.tmpl", "README.md", "data.bin"))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	ui := new(cli.MockUi)
+	args := []string{"-by", "language", "-testing=true"}
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+		t.Fatalf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	for _, want := range []string{"2m  0s  50%    2 Go", "1m  0s  25%    1 Markdown", "1m  0s  25%    1 other", "4m  0s"} {
+		if !strings.Contains(ui.OutputWriter.String(), want) {
+			t.Errorf("gtm report(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+		}
+	}
+}
+
+func TestReportPeriod(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+
+	// save notes to git repository
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-period", "day", "-testing=true"}, "1m  0s Sun Mar 20 2016"},
+		{[]string{"-period", "week", "-testing=true"}, "1m  0s Mon Mar 14 - Sun Mar 20 2016"},
+		{[]string{"-period", "week", "-week-start", "sunday", "-testing=true"}, "1m  0s Sun Mar 20 - Sat Mar 26 2016"},
+		{[]string{"-period", "month", "-testing=true"}, "1m  0s March 2016"},
+		{[]string{"-period", "day", "-tz", "UTC", "-testing=true"}, "1m  0s Sun Mar 20 2016"},
+		{[]string{"-period", "day", "-tz", "Asia/Tokyo", "-testing=true"}, "1m  0s Mon Mar 21 2016"},
+		{[]string{"-format", "commits", "-tz", "UTC", "-testing=true"}, "20:30:00 2013 UTC"},
+	}
+
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		rc := (ReportCmd{UI: ui}).Run(tc.args)
+		if rc != 0 {
+			t.Errorf("gtm report(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+		}
+		if !strings.Contains(ui.OutputWriter.String(), tc.want) {
+			t.Errorf("gtm report(%+v), want %s got %s, %s", tc.args, tc.want, ui.OutputWriter.String(), ui.ErrorWriter.String())
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-period", "year", "-testing=true"},
+		{"-period", "week", "-week-start", "funday", "-testing=true"},
+		{"-period", "day", "-tz", "Mars/Olympus_Mons", "-testing=true"},
+	} {
+		if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestReportWeekStartYearBoundary(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// Fri Jan 01 2016 12:00 UTC
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1451649600.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-period", "week", "-tz", "UTC"}, "1m  0s Mon Dec 28 2015 - Sun Jan 03 2016"},
+		{[]string{"-period", "week", "-tz", "UTC", "-week-start", "mon"}, "1m  0s Mon Dec 28 2015 - Sun Jan 03 2016"},
+		{[]string{"-period", "week", "-tz", "UTC", "-week-start", "sun"}, "1m  0s Sun Dec 27 2015 - Sat Jan 02 2016"},
+		{[]string{"-period", "week", "-tz", "UTC", "-week-start", "sat"}, "1m  0s Sat Dec 26 2015 - Fri Jan 01 2016"},
+		{[]string{"-period", "week", "-tz", "UTC", "-week-start", "Friday"}, "1m  0s Fri Jan 01 - Thu Jan 07 2016"},
+		// it's Sat Jan 02 2016 in Auckland, the start of the next week
+		{[]string{"-period", "week", "-tz", "Pacific/Auckland", "-week-start", "sat"}, "1m  0s Sat Jan 02 - Fri Jan 08 2016"},
+		{[]string{"-by", "weekday", "-tz", "UTC", "-week-start", "sat"}, "0s   0% Saturday"},
+	}
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		args := append(tc.args, "-testing=true")
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		if !strings.Contains(ui.OutputWriter.String(), tc.want) {
+			t.Errorf("gtm report(%+v), want %s got %s", args, tc.want, ui.OutputWriter.String())
+		}
+	}
+}
+
+func TestReportCumulative(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	repo.SaveFile("doc.go", "event", "")
+	repo.SaveFile("1458583203.event", project.GTMDir, filepath.Join("event", "doc.go"))
+	repo.SaveFile("1458583263.event", project.GTMDir, filepath.Join("event", "doc.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "doc.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-format", "summary", "-n", "2", "-cumulative", "-testing=true"}, "3m  0s This is a commit"},
+		{[]string{"-format", "commits", "-n", "2", "-cumulative", "-testing=true"}, "3m  0s          gtm"},
+		{[]string{"-period", "day", "-n", "2", "-cumulative", "-testing=true"}, "2m  0s         3m  0s Mon Mar 21 2016"},
+	}
+
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		rc := (ReportCmd{UI: ui}).Run(tc.args)
+		if rc != 0 {
+			t.Errorf("gtm report(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+		}
+		if !strings.Contains(ui.OutputWriter.String(), tc.want) {
+			t.Errorf("gtm report(%+v), want %s got %s", tc.args, tc.want, ui.OutputWriter.String())
+		}
+	}
+
+	for _, args := range [][]string{{"-format", "files", "-cumulative", "-testing=true"}, {"-heatmap", "-cumulative", "-testing=true"}} {
+		if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestReportSort(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// the older commit has the most time
+	repo.SaveFile("zebra.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "zebra.go"))
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("event", "zebra.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "zebra.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	repo.SaveFile("apple.go", "event", "")
+	repo.SaveFile("1458583203.event", project.GTMDir, filepath.Join("event", "apple.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "apple.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	tests := []struct {
+		args         []string
+		first, after string
+	}{
+		{[]string{"-format", "csv", "-sort", "time"}, "zebra.go", "apple.go"},
+		{[]string{"-format", "files"}, "zebra.go", "apple.go"},
+		{[]string{"-format", "files", "-sort", "name"}, "apple.go", "zebra.go"},
+	}
+
+	for _, tc := range tests {
+		args := append(tc.args, "-n", "2", "-testing=true")
+		ui := new(cli.MockUi)
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		out := ui.OutputWriter.String()
+		if i, j := strings.Index(out, tc.first), strings.Index(out, tc.after); i < 0 || j < 0 || i > j {
+			t.Errorf("gtm report(%+v), want %s before %s got %s", args, tc.first, tc.after, out)
+		}
+	}
+
+	for _, args := range [][]string{{"-sort", "size", "-testing=true"}, {"-format", "html", "-sort", "time", "-testing=true"}} {
+		if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestReportHeatmap(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+
+	// save notes to git repository
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	ui := new(cli.MockUi)
+	c := ReportCmd{UI: ui}
+
+	args := []string{"-heatmap", "-testing=true"}
+	rc := c.Run(args)
+	if rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	for _, want := range []string{"Mon |", "Sun |", "###", "1m  0s"} {
+		if !strings.Contains(ui.OutputWriter.String(), want) {
+			t.Errorf("gtm report(%+v), want %s got %s, %s", args, want, ui.OutputWriter.String(), ui.ErrorWriter.String())
+		}
+	}
+}
+
+func TestReportOut(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+
+	// save notes to git repository
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	outFile := filepath.Join(repo.Workdir(), "report.txt")
+
+	ui := new(cli.MockUi)
+	c := ReportCmd{UI: ui}
+
+	args := []string{"-format", "files", "-out", outFile, "-testing=true"}
+	rc := c.Run(args)
+	if rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if ui.OutputWriter != nil && ui.OutputWriter.String() != "" {
+		t.Errorf("gtm report(%+v), want no output got %s", args, ui.OutputWriter.String())
+	}
+	b, err := ioutil.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("gtm report(%+v), want error nil got %s", args, err)
+	}
+	if !strings.Contains(string(b), "event.go") || strings.Contains(string(b), "\x1b[") {
+		t.Errorf("gtm report(%+v), want 'event.go' without ANSI escapes got %q", args, string(b))
+	}
+}
+
+func TestReportGzip(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	tests := []struct {
+		out  string
+		want string
+	}{
+		{"report.csv", "report.csv.gz"},
+		{"report.gz", "report.gz"},
+	}
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		args := []string{"-format", "csv", "-gzip", "-out", filepath.Join(repo.Workdir(), tc.out), "-testing=true"}
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Fatalf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		f, err := os.Open(filepath.Join(repo.Workdir(), tc.want))
+		if err != nil {
+			t.Fatalf("gtm report(%+v), want error nil got %s", args, err)
+		}
+		r, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			t.Fatalf("gtm report(%+v), want gzip data got %s", args, err)
+		}
+		b, err := ioutil.ReadAll(r)
+		f.Close()
+		if err != nil || !strings.Contains(string(b), "event/event.go") {
+			t.Errorf("gtm report(%+v), want csv with event/event.go got %s, %v", args, string(b), err)
+		}
+	}
+}
+
+func TestReportRef(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+
+	// save notes to a separate notes ref
+	args := []string{"-yes", "-ref", "gtm-billable"}
+	if rc := (CommitCmd{UI: new(cli.MockUi)}).Run(args); rc != 0 {
+		t.Errorf("gtm commit(%+v), want 0 got %d", args, rc)
+	}
+
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"-format", "files", "-testing=true"}, false},
+		{[]string{"-format", "files", "-ref", "refs/notes/gtm-billable", "-testing=true"}, true},
+		{[]string{"-format", "files", "-ref", "gtm-billable", "-testing=true"}, true},
+		{[]string{"-format", "files", "-ref", "gtm-new", "-testing=true"}, false},
+	}
+
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		rc := (ReportCmd{UI: ui}).Run(tc.args)
+		if rc != 0 {
+			t.Errorf("gtm report(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+		}
+		if strings.Contains(ui.OutputWriter.String(), "event.go") != tc.want {
+			t.Errorf("gtm report(%+v), want event.go %t got %s", tc.args, tc.want, ui.OutputWriter.String())
+		}
+	}
+
+	for _, args := range [][]string{{"-ref", "gtm..x", "-testing=true"}} {
+		if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != 1 {
+			t.Errorf("gtm report(%+v), want 1 got %d", args, rc)
+		}
+	}
+	args = []string{"-yes", "-ref", "gtm..x"}
+	if rc := (CommitCmd{UI: new(cli.MockUi)}).Run(args); rc != 1 {
+		t.Errorf("gtm commit(%+v), want 1 got %d", args, rc)
+	}
+}
+
+func TestReportTop(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("doc.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496863.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496923.event", project.GTMDir, filepath.Join("event", "doc.go"))
+
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go"), filepath.Join("event", "doc.go")))
+
+	if rc := (CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"}); rc != 0 {
+		t.Fatalf("gtm commit(-yes), want 0 got %d", rc)
+	}
+
+	ui := new(cli.MockUi)
+	args := []string{"-format", "files", "-top", "1", "-testing=true"}
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "event.go") || strings.Contains(out, "doc.go") || !strings.Contains(out, "1 others") {
+		t.Errorf("gtm report(%+v), want event.go and 1 others got %s", args, out)
+	}
+	if !strings.Contains(out, "3m  0s") {
+		t.Errorf("gtm report(%+v), want total 3m  0s got %s", args, out)
+	}
+
+	args = []string{"-format", "commits", "-top", "1", "-testing=true"}
+	if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestReportMarkdown(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("a|b.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "a|b.go"))
+
+	repo.Commit(repo.Stage(filepath.Join("event", "a|b.go")))
+
+	if rc := (CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"}); rc != 0 {
+		t.Fatalf("gtm commit(-yes), want 0 got %d", rc)
+	}
+
+	ui := new(cli.MockUi)
+	args := []string{"-format", "markdown", "-duration-format", "decimal", "-testing=true"}
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+
+	out := ui.OutputWriter.String()
+	for _, want := range []string{
+		"| Commit    | Date ",
+		"| --------: |\n",
+		"| event/a\\|b.go | 0.02h |    100% |",
+		"| **Total** |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("gtm report(%+v), want %s got %s", args, want, out)
+		}
+	}
+}
+
+func TestReportBareRepo(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	barePath, err := ioutil.TempDir("", "gtm")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir, want error nil got %s", err)
+	}
+	defer os.RemoveAll(barePath)
+
+	for _, args := range [][]string{
+		{"clone", "-q", "--bare", repo.Workdir(), barePath},
+		{"--git-dir", barePath, "fetch", "-q", repo.Workdir(), "refs/notes/gtm-data:refs/notes/gtm-data"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %+v, want error nil got %s, %s", args, err, out)
+		}
+	}
+
+	ui := new(cli.MockUi)
+	args := []string{"-repo", barePath, "-format", "files", "-testing=true"}
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "event.go") {
+		t.Errorf("gtm report(%+v), want event.go got %s", args, ui.OutputWriter.String())
+	}
+
+	args = []string{"-repo", barePath, "-all", "-testing=true"}
+	if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+	}
+
+	// commands that need a working tree fail with a clear error
+	os.Chdir(barePath)
+	ui = new(cli.MockUi)
+	if rc := (StatusCmd{UI: ui}).Run([]string{}); rc != 1 {
+		t.Errorf("gtm status(), want 1 got %d", rc)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "bare") {
+		t.Errorf("gtm status(), want bare repository error got %s", ui.ErrorWriter.String())
+	}
+}
+
+func TestReportWorktree(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+
+	worktree, err := ioutil.TempDir("", "gtm")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir, want error nil got %s", err)
+	}
+	defer os.RemoveAll(worktree)
+	worktree = filepath.Join(worktree, "feature")
+
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %+v, want error nil got %s, %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	// time is recorded and committed in a linked worktree
+	git(repo.Workdir(), "worktree", "add", "-q", "-b", "feature", worktree)
+	os.Chdir(worktree)
+	if rc := (InitCmd{UI: new(cli.MockUi)}).Run([]string{"-tags", "worktree-test"}); rc != 0 {
+		t.Fatalf("gtm init in worktree, want 0 got %d", rc)
+	}
+	if _, err := os.Stat(filepath.Join(repo.Path(), "hooks", "post-commit")); err != nil {
+		t.Errorf("gtm init in worktree, want post-commit hook in the common git dir got %s", err)
+	}
+
+	util.CheckFatal(t, os.MkdirAll(filepath.Join(worktree, "event"), 0700))
+	util.CheckFatal(t, ioutil.WriteFile(filepath.Join(worktree, "event", "event.go"), []byte{}, 0644))
+	util.CheckFatal(t, ioutil.WriteFile(
+		filepath.Join(worktree, project.GTMDir, "1458496803.event"), []byte(filepath.Join("event", "event.go")), 0644))
+	git(worktree, "add", filepath.Join("event", "event.go"))
+	git(worktree, "-c", "user.name=Rand Om Hacker", "-c", "user.email=random@hacker.com", "commit", "-q", "-m", "feature")
+	if rc := (CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"}); rc != 0 {
+		t.Fatalf("gtm commit in worktree, want 0 got %d", rc)
+	}
+	commitID := git(worktree, "rev-parse", "HEAD")
+
+	// notes are shared with the main worktree
+	notes, err := note.ReadCommitNotes(repo.Workdir(), commitID)
+	if err != nil || notes[0].Total() != 60 {
+		t.Errorf("note.ReadCommitNotes(%s, %s), want 60s got %+v, %v", repo.Workdir(), commitID, notes, err)
+	}
+
+	// the commit is reachable from both worktrees and reported once
+	git(repo.Workdir(), "merge", "-q", "--ff-only", "feature")
+	os.Chdir(repo.Workdir())
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{"-tags", "worktree-test"})
+	ui := new(cli.MockUi)
+	args := []string{"-tags", "worktree-test", "-n", "10", "-testing=true"}
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if got := strings.Count(ui.OutputWriter.String(), commitID[:7]); got != 1 {
+		t.Errorf("gtm report(%+v), want commit %s once got %d, %s", args, commitID[:7], got, ui.OutputWriter.String())
+	}
+}
+
+func TestReportHTML(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	ui := new(cli.MockUi)
+	args := []string{"-format", "html", "-title", "<Q&A> hours", "-testing=true"}
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+
+	out := ui.OutputWriter.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<title>&lt;Q&amp;A&gt; hours</title>",
+		"<td>event/event.go</td>",
+		"<td>Sun Mar 20 2016</td>",
+		`style="width: 100.0%"`,
+		"</html>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("gtm report(%+v), want %s got %s", args, want, out)
+		}
+	}
+
+	args = []string{"-title", "hours", "-testing=true"}
+	if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestReportExcludeCommits(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	first := repo.Commit(repo.Stage(filepath.Join("event", "event.go"))).String()
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	// create a merge commit
+	for _, args := range [][]string{
+		{"checkout", "-q", "-b", "side"},
+		{"commit", "-q", "--allow-empty", "-m", "side commit"},
+		{"checkout", "-q", "master"},
+		{"merge", "-q", "--no-ff", "-m", "merge side", "side"},
+	} {
+		args = append([]string{"-c", "user.name=gtm", "-c", "user.email=gtm@example.com"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %+v, want error nil got %s, %s", args, err, out)
+		}
+	}
+
+	tests := []struct {
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{[]string{"-n", "3", "-testing=true"}, []string{"merge side", "side commit", "event.go"}, []string{}},
+		{[]string{"-n", "3", "-no-merges", "-testing=true"}, []string{"side commit", "event.go"}, []string{"merge side"}},
+		{[]string{"-n", "3", "-exclude-commits", first[:7], "-testing=true"}, []string{"merge side", "side commit"}, []string{"event.go"}},
+	}
+
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		if rc := (ReportCmd{UI: ui}).Run(tc.args); rc != 0 {
+			t.Errorf("gtm report(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+			continue
+		}
+		out := ui.OutputWriter.String()
+		for _, want := range tc.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("gtm report(%+v), want %s got %s", tc.args, want, out)
+			}
+		}
+		for _, notWant := range tc.notWant {
+			if strings.Contains(out, notWant) {
+				t.Errorf("gtm report(%+v), want no %s got %s", tc.args, notWant, out)
+			}
+		}
+	}
+
+	args := []string{"-exclude-commits", "xyz", "-testing=true"}
+	if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestReportPath(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("main.go", "cmd", "")
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("cmd", "main.go"))
+	repo.SaveFile("1458496863.event", project.GTMDir, filepath.Join("event", "event.go"))
+	first := repo.Commit(repo.Stage(filepath.Join("cmd", "main.go"), filepath.Join("event", "event.go"))).String()
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	repo.SaveFile("event.go", "event", "package event")
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("event", "event.go"))
+	second := repo.Commit(repo.Stage(filepath.Join("event", "event.go"))).String()
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	ui := new(cli.MockUi)
+	args := []string{"-n", "2", "-format", "files", "-path", "cmd/**", "-testing=true"}
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "main.go") || strings.Contains(out, "event.go") || !strings.Contains(out, "1m  0s") {
+		t.Errorf("gtm report(%+v), want cmd/main.go 1m  0s got %s", args, out)
+	}
+
+	// commits without time for matching files are dropped
+	ui = new(cli.MockUi)
+	args = []string{"-n", "2", "-path", "cmd/**", "-testing=true"}
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	out = ui.OutputWriter.String()
+	if !strings.Contains(out, first[:7]) || strings.Contains(out, second[:7]) {
+		t.Errorf("gtm report(%+v), want commit %s and not %s got %s", args, first[:7], second[:7], out)
+	}
+
+	args = []string{"-path", "[cmd", "-testing=true"}
+	if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestReportFollowRenames(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	gitMv := func(from, to string) {
+		cmd := exec.Command("git", "mv", from, to)
+		cmd.Dir = repo.Workdir()
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git mv %s %s, want error nil got %s, %s", from, to, err, out)
+		}
+	}
+
+	repo.SaveFile("main.go", "cmd", "package main\n\nfunc main() {\n}\n")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("cmd", "main.go"))
+	repo.Commit(repo.Stage(filepath.Join("cmd", "main.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	gitMv(filepath.Join("cmd", "main.go"), filepath.Join("cmd", "app.go"))
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("cmd", "app.go"))
+	repo.Commit(repo.Stage())
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	gitMv(filepath.Join("cmd", "app.go"), filepath.Join("cmd", "gtm.go"))
+	repo.Commit(repo.Stage())
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	ui := new(cli.MockUi)
+	args := []string{"-n", "3", "-format", "files", "-testing=true"}
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "cmd/main.go") || !strings.Contains(out, "cmd/app.go") {
+		t.Errorf("gtm report(%+v), want time for cmd/main.go and cmd/app.go got %s", args, out)
+	}
+
+	ui = new(cli.MockUi)
+	args = []string{"-n", "3", "-format", "files", "-follow-renames", "-testing=true"}
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "2m  0s 100%  cmd/gtm.go") || strings.Contains(out, "cmd/main.go") || strings.Contains(out, "cmd/app.go") {
+		t.Errorf("gtm report(%+v), want 2m  0s for cmd/gtm.go got %s", args, out)
+	}
+}
+
+func TestReportSplitNewCode(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// a minute adding main.go, then a minute changing main.go and a minute adding util.go
+	repo.SaveFile("main.go", "", "package main\n")
+	repo.SaveFile("1458496803.event", project.GTMDir, "main.go")
+	repo.Commit(repo.Stage("main.go"))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	repo.SaveFile("main.go", "", "package main\n\nfunc main() {\n}\n")
+	repo.SaveFile("util.go", "", "package main\n")
+	repo.SaveFile("1458500403.event", project.GTMDir, "main.go")
+	repo.SaveFile("1458504003.event", project.GTMDir, "util.go")
+	repo.Commit(repo.Stage("main.go", "util.go"))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	ui := new(cli.MockUi)
+	args := []string{"-n", "2", "-split-newcode", "-testing=true"}
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+		t.Fatalf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	for _, want := range []string{"2m  0s  67% New", "1m  0s  33% Maintenance", "2.0:1      New to maintenance ratio"} {
+		if !strings.Contains(ui.OutputWriter.String(), want) {
+			t.Errorf("gtm report(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+		}
+	}
+
+	args = []string{"-split-newcode", "-format", "files", "-testing=true"}
+	if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm report(%+v), want %d got %d", args, ExitUsage, rc)
+	}
+}
+
+func TestReportHours(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+	repo.SaveFile(project.ConfigFile, project.GTMDir, `{"working_hours": {"monday": "09:00-17:00"}}`)
+
+	// sunday 18:00 and monday 09:00 UTC
+	repo.SaveFile("weekend.go", "", "")
+	repo.SaveFile("weekday.go", "", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, "weekend.go")
+	repo.SaveFile("1458550803.event", project.GTMDir, "weekday.go")
+	repo.Commit(repo.Stage("weekend.go", "weekday.go"))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	tests := []struct {
+		hours      string
+		want, skip string
+	}{
+		{"working", "weekday.go", "weekend.go"},
+		{"outside", "weekend.go", "weekday.go"},
+	}
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		args := []string{"-format", "files", "-tz", "UTC", "-hours", tc.hours, "-testing=true"}
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		if out := ui.OutputWriter.String(); !strings.Contains(out, "1m  0s 100%  "+tc.want) || strings.Contains(out, tc.skip) {
+			t.Errorf("gtm report(%+v), want 1m  0s for %s got %s", args, tc.want, out)
+		}
+	}
+
+	args := []string{"-hours", "weekend", "-testing=true"}
+	if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestReportCache(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	id := repo.Commit(repo.Stage(filepath.Join("event", "event.go"))).String()
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	run := func(c ReportCmd, args ...string) string {
+		ui := new(cli.MockUi)
+		c.UI = ui
+		args = append(args, "-format", "files", "-testing=true")
+		if rc := c.Run(args); rc != 0 {
+			t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		return ui.OutputWriter.String()
+	}
+
+	// miss, the note is read from git and cached
+	if out := run(ReportCmd{}); !strings.Contains(out, "1m  0s") {
+		t.Errorf("gtm report(), want 1m  0s got %s", out)
+	}
+	cacheFile := filepath.Join(repo.Workdir(), project.GTMDir, report.CacheDir, project.NoteNameSpace, id+".json")
+	if _, err := os.Stat(cacheFile); err != nil {
+		t.Fatalf("gtm report(), want cache file got %s", err)
+	}
+
+	// change the note outside of gtm
+	noteTxt := note.Marshal(note.CommitNote{Files: []note.FileDetail{
+		{SourceFile: "event/event.go", TimeSpent: 120, Timeline: map[int64]int{1458496800: 120}, Status: "m"}}})
+	if err := scm.CreateNoteForCommit(id, noteTxt, project.NoteNameSpace, repo.Workdir()); err != nil {
+		t.Fatal(err)
+	}
+
+	// stale, the note's blob changed so the note is read from git again
+	if out := run(ReportCmd{}); !strings.Contains(out, "2m  0s") {
+		t.Errorf("gtm report() changed note, want 2m  0s got %s", out)
+	}
+	if out := run(ReportCmd{}, "-no-cache"); !strings.Contains(out, "2m  0s") {
+		t.Errorf("gtm report(-no-cache), want 2m  0s got %s", out)
+	}
+
+	// stale, time appended to the note by gtm commit for the same head is reported
+	repo.SaveFile("1458496863.event", project.GTMDir, filepath.Join("event", "event.go"))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+	if out := run(ReportCmd{}); !strings.Contains(out, "3m  0s") {
+		t.Errorf("gtm report() appended note, want 3m  0s got %s", out)
+	}
+
+	// hit, the cached note matches the note's blob
+	cache, err := ioutil.ReadFile(cacheFile)
+	if err != nil || !strings.Contains(string(cache), id) {
+		t.Fatalf("gtm report(), want cached note for %s got %s, %v", id, cache, err)
+	}
+	if out := run(ReportCmd{}); !strings.Contains(out, "3m  0s") {
+		t.Errorf("gtm report() cached, want 3m  0s got %s", out)
+	}
+
+	// stale, the cache is discarded when the version changes
+	if out := run(ReportCmd{Version: "1.0.0"}); !strings.Contains(out, "3m  0s") {
+		t.Errorf("gtm report() new version, want 3m  0s got %s", out)
+	}
+}
+
+func TestReportAppendedNote(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	id := repo.Commit(repo.Stage(filepath.Join("event", "event.go"))).String()
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	run := func() string {
+		ui := new(cli.MockUi)
+		if rc := (ReportCmd{UI: ui}).Run([]string{"-format", "files", "-testing=true"}); rc != 0 {
+			t.Fatalf("gtm report, want 0 got %d, %s", rc, ui.ErrorWriter.String())
+		}
+		return ui.OutputWriter.String()
+	}
+
+	// the note is cached by the first report
+	if out := run(); !strings.Contains(out, "1m  0s") {
+		t.Errorf("gtm report(), want 1m  0s got %s", out)
+	}
+
+	appended := note.CommitNote{Files: []note.FileDetail{
+		{SourceFile: "event/event.go", TimeSpent: 120, Timeline: map[int64]int{1458496860: 120}, Status: "m"}}}
+	if err := note.AppendCommitNoteToRef(repo.Workdir(), project.NoteNameSpace, id, appended); err != nil {
+		t.Fatalf("AppendCommitNoteToRef, want error nil got %s", err)
+	}
+
+	if out := run(); !strings.Contains(out, "3m  0s") {
+		t.Errorf("gtm report() after appending to the note, want 3m  0s got %s", out)
+	}
+}
+
+func TestReportAuthorEmail(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	setEmail := func(email string) {
+		if out, err := exec.Command("git", "config", "user.email", email).CombinedOutput(); err != nil {
+			t.Fatalf("git config user.email %s, want error nil got %s, %s", email, err, out)
+		}
+	}
+
+	tests := []struct {
+		email string
+		args  []string
+		want  bool
+	}{
+		{"someone@else.com", []string{"-author", "random@hacker.com"}, true},
+		{"someone@else.com", []string{"-author", "RANDOM@hacker.com"}, true},
+		{"someone@else.com", []string{"-author", "other@hacker.com"}, false},
+		{"someone@else.com", []string{"-author", "me"}, false},
+		{"random@hacker.com", []string{"-author", "me"}, true},
+		{"random@hacker.com", []string{"-author", "me", "-path", "cmd/**"}, false},
+	}
+
+	for _, tc := range tests {
+		setEmail(tc.email)
+		ui := new(cli.MockUi)
+		args := append(tc.args, "-format", "files", "-testing=true")
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+			continue
+		}
+		if got := strings.Contains(ui.OutputWriter.String(), "event.go"); got != tc.want {
+			t.Errorf("gtm report(%+v) user.email %s, want event.go %t got %s", args, tc.email, tc.want, ui.OutputWriter.String())
+		}
+	}
+}
+
+func TestReportInvalidOption(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := ReportCmd{UI: ui}
+
+	args := []string{"-invalid"}
+	rc := c.Run(args)
+
+	if rc != ExitUsage {
+		t.Errorf("gtm report(%+v), want 3 got %d, %s", args, rc, ui.ErrorWriter)
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "Usage:") {
+		t.Errorf("gtm report(%+v), want 'Usage:'  got %d, %s", args, rc, ui.OutputWriter.String())
+	}
+}
+
+func TestReportTemplate(t *testing.T) {
+	// the example template is relative to this file because tests change the working directory
+	_, testFile, _, _ := runtime.Caller(0)
+	example := filepath.Join(filepath.Dir(testFile), "..", "examples", "timesheet.tmpl")
+
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	custom := filepath.Join(repo.Workdir(), "custom.tmpl")
+	repo.SaveFile("custom.tmpl", "", `{{ range .Commits }}{{ .Hash }} {{ .Author }} {{ dur .Seconds }}{{ range .Files }} {{ .Path }}={{ .Seconds }}{{ end }}{{ end }} total={{ .Seconds }}`)
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			[]string{"-template", example},
+			[]string{"Timesheet", "1m  0s  " + filepath.Base(repo.Workdir()), "1m  0s  event/event.go", "Total (0.02 hours)"},
+		},
+		{
+			[]string{"-template", example, "-duration-format", "decimal"},
+			[]string{"0.02h  Total"},
+		},
+		{
+			[]string{"-template", custom},
+			[]string{"Rand Om Hacker 1m  0s event/event.go=60 total=60"},
+		},
+	}
+	for _, tc := range tests {
+		args := append(tc.args, "-testing=true")
+		ui := new(cli.MockUi)
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(ui.OutputWriter.String(), want) {
+				t.Errorf("gtm report(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+			}
+		}
+	}
+
+	repo.SaveFile("invalid.tmpl", "", `{{ range .Commits }}`)
+	repo.SaveFile("failing.tmpl", "", `{{ .NoSuchField }}`)
+	invalid := []struct {
+		args   []string
+		want   string
+		wantRc int
+	}{
+		{[]string{"-template", filepath.Join(repo.Workdir(), "invalid.tmpl")}, "Invalid template", ExitError},
+		{[]string{"-template", filepath.Join(repo.Workdir(), "failing.tmpl")}, "Unable to execute template", ExitError},
+		{[]string{"-template", filepath.Join(repo.Workdir(), "missing.tmpl")}, "missing.tmpl", ExitError},
+		{[]string{"-template", custom, "-format", "csv"}, "-template option not allowed", ExitUsage},
+	}
+	for _, tc := range invalid {
+		args := append(tc.args, "-testing=true")
+		ui := new(cli.MockUi)
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != tc.wantRc {
+			t.Errorf("gtm report(%+v), want %d got %d", args, tc.wantRc, rc)
+		}
+		if !strings.Contains(ui.ErrorWriter.String(), tc.want) {
+			t.Errorf("gtm report(%+v), want error %s got %s", args, tc.want, ui.ErrorWriter.String())
+		}
+	}
+}
+
+func TestReportIncludePending(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	// two minutes of uncommitted time
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458500463.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	tests := []struct {
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{[]string{"-format", "csv"}, []string{",event/event.go,60"}, []string{"pending"}},
+		{[]string{"-format", "csv", "-include-pending"}, []string{"\npending,", ",event/event.go,120", ",event/event.go,60"}, []string{}},
+		{[]string{"-format", "commits", "-include-pending"}, []string{"Pending, not committed"}, []string{}},
+		{[]string{"-format", "project", "-include-pending"}, []string{"3m  0s"}, []string{}},
+		{[]string{"-format", "csv", "-include-pending", "-message", "commit"}, []string{",event/event.go,60"}, []string{"pending"}},
+	}
+	for _, tc := range tests {
+		args := append(tc.args, "-testing=true")
+		ui := new(cli.MockUi)
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(ui.OutputWriter.String(), want) {
+				t.Errorf("gtm report(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+			}
+		}
+		for _, notWant := range tc.notWant {
+			if strings.Contains(ui.OutputWriter.String(), notWant) {
+				t.Errorf("gtm report(%+v), want no %s got %s", args, notWant, ui.OutputWriter.String())
+			}
+		}
+	}
+
+	// pending time is not saved
+	if !repo.FileExists("1458500403.event", project.GTMDir) {
+		t.Errorf("gtm report(-include-pending), want pending events kept")
+	}
+
+	args := []string{"-include-pending", "-repo", repo.Workdir()}
+	if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestReportLifetime(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// a minute of time for each of two commits
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	repo.SaveFile("event.go", "event", "package event")
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	args := []string{"-lifetime", "-testing=true"}
+	ui := new(cli.MockUi)
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+		t.Fatalf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if want := "2m  0s     Lifetime"; !strings.Contains(ui.OutputWriter.String(), want) {
+		t.Errorf("gtm report(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+	}
+
+	args = []string{"-lifetime", "-n", "1", "-testing=true"}
+	if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestReportRound(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// a minute of time for each of two commits
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	repo.SaveFile("event.go", "event", "package event")
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-format=summary", "-n=2", "-round=15m"},
+			[]string{"15m  0s", "30m  0s", "Rounded up to 15m per commit, unrounded total 2m  0s"}},
+		{[]string{"-format=summary", "-n=2", "-round=15m", "-round-mode=nearest"},
+			[]string{"Rounded to the nearest 15m per commit, unrounded total 2m  0s"}},
+		{[]string{"-lifetime", "-round=15m"}, []string{"30m  0s     Lifetime"}},
+		{[]string{"-format=project", "-n=2", "-round=1h"}, []string{"1h  0m  0s", "Rounded up to 1h per project"}},
+		{[]string{"-period=day", "-n=2", "-round=90s", "-round-mode=down"},
+			[]string{"1m 30s", "Rounded down to 1m30s per day"}},
+	}
+	for _, tc := range tests {
+		args := append(tc.args, "-testing=true")
+		ui := new(cli.MockUi)
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Fatalf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(ui.OutputWriter.String(), want) {
+				t.Errorf("gtm report(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+			}
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-round=15m", "-testing=true"},
+		{"-format=summary", "-round=-15m", "-testing=true"},
+		{"-format=summary", "-round=15m", "-round-mode=sideways", "-testing=true"},
+	} {
+		if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestReportProjects(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	missing := filepath.Join(os.TempDir(), "gtm-missing-project")
+	projectsFile := filepath.Join(os.TempDir(), "gtm-projects.txt")
+	defer os.Remove(projectsFile)
+	util.CheckFatal(t, ioutil.WriteFile(projectsFile, []byte(missing+"\n"+repo.Workdir()+"\n"), 0644))
+
+	// report from outside of the projects
+	os.Chdir(os.TempDir())
+	args := []string{"-format=project", "-projects", projectsFile, "-testing=true"}
+	ui := new(cli.MockUi)
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != ExitNotInitialized {
+		t.Errorf("gtm report(%+v), want 2 for the missing project got %d", args, rc)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), missing) {
+		t.Errorf("gtm report(%+v), want error for %s got %s", args, missing, ui.ErrorWriter.String())
+	}
+	if want := "1m  0s " + filepath.Base(repo.Workdir()); !strings.Contains(ui.OutputWriter.String(), want) {
+		t.Errorf("gtm report(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+	}
+
+	args = []string{"-projects", projectsFile, "-tags", "work", "-testing=true"}
+	if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestReportShowMessage(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	tests := []struct {
+		args    []string
+		want    string
+		notWant string
+	}{
+		{[]string{"-format=csv", "-show-message"}, "event/event.go,60,This is a commit", ""},
+		{[]string{"-format=csv", "-show-message", "-message-width=7"}, "event/event.go,60,This...", "This is"},
+		{[]string{"-format=summary", "-message-width=7"}, "This... [", "This is"},
+		{[]string{"-format=csv"}, "event/event.go,60\n", "This is a commit"},
+	}
+	for _, tc := range tests {
+		args := append(tc.args, "-testing=true")
+		ui := new(cli.MockUi)
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Fatalf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		out := ui.OutputWriter.String()
+		if !strings.Contains(out, tc.want) || (tc.notWant != "" && strings.Contains(out, tc.notWant)) {
+			t.Errorf("gtm report(%+v), want %q and not %q got %s", args, tc.want, tc.notWant, out)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-show-message", "-testing=true"},
+		{"-format=csv", "-message-width=-1", "-testing=true"},
+	} {
+		if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestReportCompare(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// a minute of time on Sun Mar 20 2016
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	saveNow := util.Now
+	defer func() { util.Now = saveNow }()
+
+	tests := []struct {
+		now  time.Time
+		want []string
+	}{
+		{time.Date(2016, 3, 20, 20, 0, 0, 0, time.UTC),
+			[]string{"1m  0s This week  Mon Mar 14 - Sun Mar 20 2016", "0s Last week  Mon Mar 07 - Sun Mar 13 2016", "+1m  0s Change     N/A"}},
+		{time.Date(2016, 3, 22, 9, 0, 0, 0, time.UTC),
+			[]string{"0s This week  Mon Mar 21 - Sun Mar 27 2016", "1m  0s Last week", "-1m  0s Change     -100%"}},
+	}
+	args := []string{"-compare", "-period=week", "-tz=UTC", "-testing=true"}
+	for _, tc := range tests {
+		util.Now = func() time.Time { return tc.now }
+		ui := new(cli.MockUi)
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Fatalf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(ui.OutputWriter.String(), want) {
+				t.Errorf("gtm report(%+v) at %s, want %s got %s", args, tc.now, want, ui.OutputWriter.String())
+			}
+		}
+	}
+
+	// the rounding note's unrounded total is only the time of the compared periods
+	util.Now = func() time.Time { return time.Date(2016, 4, 12, 9, 0, 0, 0, time.UTC) }
+	args = append(args, "-round=15m")
+	ui := new(cli.MockUi)
+	if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+		t.Fatalf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if want := "Rounded up to 15m per week, unrounded total 0s"; !strings.Contains(ui.OutputWriter.String(), want) {
+		t.Errorf("gtm report(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+	}
+
+	for _, args := range [][]string{
+		{"-compare", "-testing=true"},
+		{"-compare", "-period=week", "-format=summary", "-testing=true"},
+	} {
+		if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestReportTrackingStart(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// test commits are dated Mar 06 2013
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	gtmPath := filepath.Join(repo.Workdir(), project.GTMDir)
+	tests := []struct {
+		trackingStart string
+		want          string
+	}{
+		{"", "1m  0s     Lifetime"},
+		{"2013-03-05", "1m  0s     Lifetime"},
+		{"2013-03-08", "0s     Lifetime"},
+	}
+	for _, tc := range tests {
+		util.CheckFatal(t, project.Config{IdleTimeout: 120, TrackingStart: tc.trackingStart}.Save(gtmPath))
+		args := []string{"-lifetime", "-testing=true"}
+		ui := new(cli.MockUi)
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Fatalf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		if got := ui.OutputWriter.String(); !strings.Contains(got, tc.want) || (tc.want[0] == '0' && strings.Contains(got, "1m")) {
+			t.Errorf("gtm report(%+v) tracking start %q, want %s got %s", args, tc.trackingStart, tc.want, got)
+		}
+	}
+}
+
+func TestReportByWeekday(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// a minute of time on Sun Mar 20 2016 18:00 UTC
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	bar := strings.Repeat("#", 40)
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-by=weekday", "-tz=UTC"}, []string{"0s   0% Monday", "1m  0s 100% Sunday    " + bar}},
+		{[]string{"-by=weekday", "-tz=Asia/Tokyo"}, []string{"1m  0s 100% Monday    " + bar, "0s   0% Sunday"}},
+		{[]string{"-by=weekday", "-tz=UTC", "-week-start=sunday"}, []string{"1m  0s 100% Sunday"}},
+	}
+	for _, tc := range tests {
+		args := append(tc.args, "-testing=true")
+		ui := new(cli.MockUi)
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Fatalf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		out := ui.OutputWriter.String()
+		for _, want := range tc.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("gtm report(%+v), want %s got %s", args, want, out)
+			}
+		}
+	}
+
+	// the first day is the week start
+	args := []string{"-by=weekday", "-tz=UTC", "-week-start=sunday", "-testing=true"}
+	ui := new(cli.MockUi)
+	(ReportCmd{UI: ui}).Run(args)
+	if out := ui.OutputWriter.String(); strings.Index(out, "Sunday") > strings.Index(out, "Monday") {
+		t.Errorf("gtm report(%+v), want Sunday first got %s", args, out)
+	}
+}
+
+func TestReportRelative(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+
+	// test commits are dated Wed Mar 06 14:30 2013 in Chicago
+	loc, err := time.LoadLocation("America/Chicago")
+	util.CheckFatal(t, err)
+	saveNow := util.Now
+	defer func() { util.Now = saveNow }()
+	util.Now = func() time.Time { return time.Date(2013, 3, 9, 16, 0, 0, 0, loc) }
+
+	tests := []struct {
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{[]string{}, []string{"Wed Mar 06 14:30:00 2013"}, []string{"ago"}},
+		{[]string{"-relative"}, []string{"3 days ago"}, []string{"Wed Mar 06"}},
+		{[]string{"-relative=both"}, []string{"Wed Mar 06 14:30:00 2013 -0600 (3 days ago)"}, []string{}},
+		{[]string{"-relative", "-format", "markdown"}, []string{"| 3 days ago |"}, []string{}},
+	}
+	for _, tc := range tests {
+		args := append(tc.args, "-testing=true")
+		ui := new(cli.MockUi)
+		if rc := (ReportCmd{UI: ui}).Run(args); rc != 0 {
+			t.Errorf("gtm report(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+			continue
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(ui.OutputWriter.String(), want) {
+				t.Errorf("gtm report(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+			}
+		}
+		for _, notWant := range tc.notWant {
+			if strings.Contains(ui.OutputWriter.String(), notWant) {
+				t.Errorf("gtm report(%+v), want no %s got %s", args, notWant, ui.OutputWriter.String())
+			}
+		}
+	}
+
+	for _, args := range [][]string{{"-relative", "-format", "summary"}, {"-relative=sometimes"}} {
+		if rc := (ReportCmd{UI: new(cli.MockUi)}).Run(append(args, "-testing=true")); rc != ExitUsage {
+			t.Errorf("gtm report(%+v), want 3 got %d", args, rc)
+		}
 	}
 }
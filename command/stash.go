@@ -0,0 +1,110 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/git-time-metric/gtm/metric"
+	"github.com/git-time-metric/gtm/scm"
+	"github.com/git-time-metric/gtm/util"
+	"github.com/mitchellh/cli"
+)
+
+// StashCmd contains methods for the stash command
+type StashCmd struct {
+	UI cli.Ui
+}
+
+// NewStash returns a new StashCmd struct
+func NewStash() (cli.Command, error) {
+	return StashCmd{}, nil
+}
+
+// Help returns help for the stash command
+func (c StashCmd) Help() string {
+	helpText := `
+Usage: gtm stash [options] [<stash commit>]
+
+  Save the pending time of the files in a git stash with the stash so it isn't lost, the time is saved
+  in a git note for the stash commit. Run it after git stash, the latest stash is used if a stash commit,
+  i.e. stash@{1} or its SHA1, is not given. Time for files that are not in the stash, terminal and app time stays pending.
+
+  Run gtm stash -pop before git stash pop or apply to reclaim the stash's time as pending time.
+  Git doesn't have a hook for stashes, these git aliases run gtm with git stash:
+
+    git config --global alias.tstash '!f() { git stash push "$@" && gtm stash -q; }; f'
+    git config --global alias.tpop '!f() { gtm stash -pop -q && git stash pop "$@"; }; f'
+
+Options:
+
+  -pop                       Reclaim the time saved with the stash as pending time
+
+  -q                         Only print errors
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run executes the stash command with args
+func (c StashCmd) Run(args []string) int {
+	var pop, quiet bool
+	cmdFlags := flag.NewFlagSet("stash", flag.ContinueOnError)
+	cmdFlags.BoolVar(&pop, "pop", false, "")
+	cmdFlags.BoolVar(&quiet, "q", false, "")
+	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if cmdFlags.NArg() > 1 {
+		c.UI.Error("\nOnly one stash commit is allowed\n")
+		return ExitUsage
+	}
+
+	if quiet {
+		c.UI = quietUi{c.UI}
+	}
+
+	config, err := projectConfig(c.UI)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+
+	var stashID string
+	if cmdFlags.NArg() == 0 {
+		stashID, err = scm.StashCommit()
+	} else {
+		stashID, err = scm.ResolveCommit(cmdFlags.Arg(0))
+	}
+	if err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+
+	if pop {
+		n, err := metric.Unstash(stashID, config)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		c.UI.Output(fmt.Sprintf("%s reclaimed from stash %s", util.DurationStr(n.Total()), stashID[:7]))
+		return 0
+	}
+
+	n, err := metric.Stash(stashID, config)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+	c.UI.Output(fmt.Sprintf("%s saved with stash %s", util.DurationStr(n.Total()), stashID[:7]))
+	return 0
+}
+
+// Synopsis returns help for the stash command
+func (c StashCmd) Synopsis() string {
+	return "Save pending time with a git stash"
+}
@@ -0,0 +1,109 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-time-metric/gtm/note"
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/scm"
+	"github.com/git-time-metric/gtm/util"
+	"github.com/mitchellh/cli"
+)
+
+func TestStash(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	ui := new(cli.MockUi)
+	if rc := (StashCmd{UI: ui}).Run([]string{}); rc != ExitError || !strings.Contains(ui.ErrorWriter.String(), "Stash not found") {
+		t.Errorf("gtm stash without a stash, want %d and 'Stash not found' got %d, %s", ExitError, rc, ui.ErrorWriter.String())
+	}
+
+	repo.SaveFile("event.go", "event", "")
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+
+	// event.go is stashed, the untracked other.go is not
+	repo.SaveFile("event.go", "event", "package event")
+	repo.SaveFile("other.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496863.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496923.event", project.GTMDir, filepath.Join("event", "other.go"))
+
+	cmd := exec.Command("git", "stash", "push", "-q")
+	cmd.Dir = repo.Workdir()
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git stash, want error nil got %s, %s", err, out)
+	}
+	cmd = exec.Command("git", "rev-parse", "refs/stash")
+	cmd.Dir = repo.Workdir()
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git rev-parse, want error nil got %s", err)
+	}
+	stashID := strings.TrimSpace(string(out))
+
+	ui = new(cli.MockUi)
+	if rc := (StashCmd{UI: ui}).Run([]string{}); rc != 0 {
+		t.Fatalf("gtm stash, want 0 got %d, %s", rc, ui.ErrorWriter.String())
+	}
+	if want := fmt.Sprintf("2m0s saved with stash %s", stashID[:7]); !strings.Contains(ui.OutputWriter.String(), want) {
+		t.Errorf("gtm stash, want %q got %s", want, ui.OutputWriter.String())
+	}
+
+	notes, err := note.ReadCommitNotes(repo.Workdir(), stashID)
+	if err != nil || len(notes[0].Files) != 1 || notes[0].Files[0].SourceFile != filepath.Join("event", "event.go") {
+		t.Errorf("gtm stash, want the stash's note with event.go got %+v, %v", notes, err)
+	}
+
+	name := filepath.Base(repo.Workdir())
+	status := func() string {
+		ui := new(cli.MockUi)
+		if rc := (StatusCmd{UI: ui}).Run([]string{"-format", "tsv"}); rc != 0 {
+			t.Fatalf("gtm status, want 0 got %d, %s", rc, ui.ErrorWriter.String())
+		}
+		return ui.OutputWriter.String()
+	}
+	if got, want := status(), fmt.Sprintf("%s\tevent/other.go\t60\n", name); got != want {
+		t.Errorf("gtm status after gtm stash, want %q got %q", want, got)
+	}
+
+	// the time is reclaimed once, the stash can be given as an abbreviated SHA1
+	for i, want := range []string{"2m0s reclaimed from stash", "0s reclaimed from stash"} {
+		ui = new(cli.MockUi)
+		if rc := (StashCmd{UI: ui}).Run([]string{"-pop", stashID[:7]}); rc != 0 {
+			t.Fatalf("gtm stash -pop %d, want 0 got %d, %s", i, rc, ui.ErrorWriter.String())
+		}
+		if !strings.Contains(ui.OutputWriter.String(), want) {
+			t.Errorf("gtm stash -pop %d, want %q got %s", i, want, ui.OutputWriter.String())
+		}
+	}
+	if got, want := status(), fmt.Sprintf("%s\tevent/event.go\t120\n%s\tevent/other.go\t60\n", name, name); got != want {
+		t.Errorf("gtm status after gtm stash -pop, want %q got %q", want, got)
+	}
+	if noteID, err := scm.NoteID(stashID, project.NoteNameSpace, repo.Workdir()); err != nil || noteID != "" {
+		t.Errorf("gtm stash -pop, want the stash's note removed got %q, %v", noteID, err)
+	}
+
+	if rc := (StashCmd{UI: new(cli.MockUi)}).Run([]string{"-pop", "stash@{5}"}); rc != ExitError {
+		t.Errorf("gtm stash -pop stash@{5}, want %d got %d", ExitError, rc)
+	}
+
+	for _, args := range [][]string{{"-invalid"}, {stashID, stashID}} {
+		if rc := (StashCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm stash(%+v), want %d got %d", args, ExitUsage, rc)
+		}
+	}
+}
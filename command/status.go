@@ -5,14 +5,28 @@
 package command
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/git-time-metric/gtm/epoch"
 	"github.com/git-time-metric/gtm/metric"
 	"github.com/git-time-metric/gtm/note"
 	"github.com/git-time-metric/gtm/project"
 	"github.com/git-time-metric/gtm/report"
+	"github.com/git-time-metric/gtm/scm"
 	"github.com/git-time-metric/gtm/util"
 	"github.com/mitchellh/cli"
 )
@@ -36,96 +50,880 @@ Usage: gtm status [options]
 
 Options:
 
-  -terminal-off=false        Exclude time spent in terminal (Terminal plug-in is required)
+  -terminal-off              Exclude time spent in terminal (Terminal plug-in is required)
 
-  -app-off=false             Exclude time spent in apps
+  -app-off                   Exclude time spent in apps
 
-  -color=false               Always output color even if no terminal is detected, i.e 'gtm status -color | less -R'
+  -terminal-weight=1.0       Multiply time spent in terminal by the weight, i.e. -terminal-weight=0.5 counts terminal time as half,
+                             weighted time is rounded to the nearest second for each hour
 
-  -total-only=false          Only display total pending time
+  -app-weight=1.0            Multiply time spent in apps by the weight
+
+  -apps                      Show time spent in apps by app name in a separate section
+
+  -branch                    Show each project's current branch, or the short commit SHA1 if HEAD is detached
+
+  -insights                  Show the file with the most time and the longest session, consecutive hours with time, after each project
+
+  -detail                    Show each file's number of editing sessions and when it was last active, a session ends
+                             when there are no events for longer than the idle timeout
+
+  -preview                   Show the pending events in each window and the time attributed to each file instead of status,
+                             this is a diagnostic for tuning -idle-timeout and nothing is saved
+
+  -color                     Always output color even if no terminal is detected, i.e 'gtm status -color | less -R'
+
+  -no-color                  Never output color, it takes precedence over -color and the NO_COLOR environment variable
+
+  -min=0s                    Hide files with less time than the minimum duration, they are still included in the total, i.e. -min=30s
+
+  -by=""                     Group time by [dir] instead of by file
+
+  -depth=1                   Number of directory levels to group time by with -by=dir, i.e. -depth=2 groups cmd/gtm/main.go under cmd/gtm/
+
+  -top=0                     Only display the files with the most time, the rest are summed on an others line, 0 displays all files
+
+  -percent=true              Display each file's percentage of the total pending time
+
+  -total-only                Only display total pending time
+
+  -grand-total               Display the total pending time for all of the projects, with -total-only only the grand total is displayed
 
   -long-duration             If total-only, display total pending time in long duration format
 
+  -duration-format=short     Display durations as [short|long|decimal], decimal is hours, i.e. 1.50h (default short)
+
+  -duration-places=2         Number of decimal places for -duration-format=decimal
+
+  -seconds                   If total-only, display total pending time as a plain number of seconds
+
+  -delta                     Like -total-only but display the pending time logged since the last -delta status for the project,
+                             i.e. for a status bar. The first status displays all of the pending time, it starts over when the
+                             pending time decreases or the head commit changes, i.e. the time was committed.
+
   -tags=""                   Project tags to report status for, i.e --tags tag1,tag2
 
-  -all=false                 Show status for all projects
+  -tag-match=any             Show status for projects with any or all of the tags [any|all] (default any)
+
+  -tag-ignore-case           Match -tags regardless of case, i.e. client-a matches Client-A
+
+  -tag-regex                 Treat each of the -tags as a regular expression that must match the whole tag, i.e. -tags='client-.*'
+
+  -all                       Show status for all projects
+
+  -projects=""               Show status for the project paths listed one per line in a file, or stdin with -projects=-,
+                             instead of the current project, paths that are not gtm projects are reported and skipped
+
+  -group-by-tag              Group projects by tag with a subtotal for each tag and a grand total,
+                             projects without tags are grouped under untagged
+
+  -tag-policy=each           Group a project with more than one tag under [each|first] of its tags (default each),
+                             with each the project is in every tag's subtotal and counted once in the grand total
+
+  -out=""                    Write output to a file instead of stdout, colors are removed unless -color is set
+
+  -q                         Only print errors and the output of -total-only, -format=json, -format=tsv or -json-stream,
+                             the exit status is non-zero if a project's status can not be read
+
+  -watch                     Clear the screen and refresh status every interval until interrupted
+
+  -json-stream               Write status as a JSON object on a single line every interval until interrupted,
+                             each object has a sequence number, timestamp and the projects, for use by editor plug-ins
+
+  -interval=5s               Refresh interval when watching or streaming, i.e. -interval=30s
+
+  -jobs=N                    Number of projects to process at the same time (default number of CPUs)
+
+  -width=N                   Fit status lines to this many columns by eliding the middle of long file paths, 0 is no limit
+                             (default the terminal's width or 120 when not writing to a terminal)
+
+  -format=text               Specify output format [text|json|json-schema|tsv] (default text), json-schema prints the
+                             versioned JSON Schema of the json output, the json-stream objects' projects use the same schema.
+                             tsv prints a line of project, file and seconds separated by tabs for each file, tabs, newlines
+                             and backslashes in paths are escaped as \t, \n and \\, i.e. 'gtm status -format=tsv | cut -f2,3'
+
+  -idle-timeout=2m0s         Do not count gaps between events longer than the idle timeout, overrides .gtm/config.json
+
+  -proration=""              Split the time of a minute with events for more than one file [proportional|last], overrides
+                             proration in .gtm/config.json. proportional splits it by each file's number of events, files
+                             edited equally get equal shares, last gives all of it to the file edited last (default proportional)
+
+  -include=""                Only count time for files matching these comma separated glob patterns, i.e. -include='cmd/**'
+
+  -exclude=""                Do not count time for files matching these comma separated glob patterns, i.e. -exclude='*.lock,vendor/**'
+                             Patterns use forward slashes on all platforms, patterns without a slash match the file name
+
+  -dirty-only                Only show time for files that are modified, staged or untracked in the working tree,
+                             terminal and app time is not shown
+
+  -since=""                  Only show time logged after a clock time or RFC3339 timestamp, i.e. -since=09:00
+
+  -since-last-commit         Only show time logged after the project's last commit, all time is shown if there are no commits yet
+
+  -until=""                  Only show time logged before a clock time or RFC3339 timestamp, i.e. -until=17:00
+                             Time is kept by the hour, an hour that straddles -since or -until is prorated
+
+  -range=""                  Also show the time committed for each commit in a git revision range after the pending time,
+                             i.e. -range=HEAD~5..HEAD, commits without time are not shown
+
+  -tz=""                     Time zone for -since and -until clock times, -insights sessions, -detail and -preview times, i.e. -tz=America/New_York or -tz=UTC
+                             Defaults to the local time zone
+
+Webhook:
+
+  Set webhook_url and webhook_threshold, a number of seconds, in the project's .gtm/config.json to POST a JSON
+  payload to the URL when the time logged since the last commit reaches the threshold, i.e.
+  {"webhook_url": "https://hooks.example.com/gtm", "webhook_threshold": 14400}. The webhook is notified once
+  for each commit when status is shown, a webhook that can not be notified is reported as a warning.
+
+Colors:
+
+  Set ANSI color codes in the GTM_COLOR_TOTAL, GTM_COLOR_FILE and GTM_COLOR_HEADING environment variables
+  to change the colors of the project total, file and heading lines, i.e. GTM_COLOR_FILE=32 or GTM_COLOR_TOTAL=1;36.
+  Use none to display an element without color.
+
+  Color is output when a terminal is detected. Setting the NO_COLOR environment variable to any value turns it off,
+  -color turns it on even if NO_COLOR is set and -no-color turns it off even if -color is set.
+
+Default Flags:
+
+  Set default flags separated by spaces in the GTM_STATUS_FLAGS environment variable, i.e.
+  GTM_STATUS_FLAGS="-color -terminal-off". Flags on the command line take precedence over the defaults,
+  i.e. -color=false.
 `
 	return strings.TrimSpace(helpText)
 }
 
 // Run executes status command with args
 func (c StatusCmd) Run(args []string) int {
-	var color, terminalOff, appOff, totalOnly, all, profile, longDuration bool
-	var tags string
+	var color, noColor, terminalOff, appOff, apps, branch, insights, detail, preview, totalOnly, delta, all, profile, longDuration, seconds, watch, jsonStream, percent, grandTotal, groupByTag, quiet, dirtyOnly, sinceLastCommit, tagIgnoreCase, tagRegex bool
+	var tags, tagMatch, tagPolicy, projectsFile, format, since, until, include, exclude, outFile, durationFormat, tz, by, revRange, proration string
+	var jobs, durationPlaces, top, depth, width int
+	var terminalWeight, appWeight float64
+	var idleTimeout, interval, minDuration time.Duration
 	cmdFlags := flag.NewFlagSet("status", flag.ContinueOnError)
 	cmdFlags.BoolVar(&color, "color", false, "Always output color even if no terminal is detected. Use this with pagers i.e 'less -R' or 'more -R'")
+	cmdFlags.BoolVar(&noColor, "no-color", false, "Never output color")
 	cmdFlags.BoolVar(&terminalOff, "terminal-off", false, "Exclude time spent in terminal (Terminal plugin is required)")
 	cmdFlags.BoolVar(&appOff, "app-off", false, "Exclude time spent in apps")
+	cmdFlags.Float64Var(&terminalWeight, "terminal-weight", 1, "Multiply time spent in terminal by the weight")
+	cmdFlags.Float64Var(&appWeight, "app-weight", 1, "Multiply time spent in apps by the weight")
+	cmdFlags.BoolVar(&apps, "apps", false, "Show time spent in apps by app name in a separate section")
+	cmdFlags.BoolVar(&branch, "branch", false, "Show each project's current branch")
+	cmdFlags.BoolVar(&insights, "insights", false, "Show the file with the most time and the longest session")
+	cmdFlags.BoolVar(&detail, "detail", false, "Show each file's number of editing sessions and when it was last active")
+	cmdFlags.BoolVar(&preview, "preview", false, "Show the pending events in each window and the time attributed to each file")
 	cmdFlags.BoolVar(&totalOnly, "total-only", false, "Only display total time")
+	cmdFlags.BoolVar(&grandTotal, "grand-total", false, "Display the total time for all of the projects")
+	cmdFlags.DurationVar(&minDuration, "min", 0, "Hide files with less time than the minimum duration")
+	cmdFlags.IntVar(&top, "top", 0, "Only display the files with the most time")
+	cmdFlags.StringVar(&by, "by", "", "Group time by [dir] instead of by file")
+	cmdFlags.IntVar(&depth, "depth", 1, "Number of directory levels to group time by")
+	cmdFlags.BoolVar(&percent, "percent", true, "Display each file's percentage of the total time")
 	cmdFlags.BoolVar(&longDuration, "long-duration", false, "Display total time in long duration format")
+	cmdFlags.StringVar(&durationFormat, "duration-format", "short", "Display durations as [short|long|decimal]")
+	cmdFlags.IntVar(&durationPlaces, "duration-places", 2, "Number of decimal places for decimal durations")
+	cmdFlags.BoolVar(&seconds, "seconds", false, "Display total time as a plain number of seconds")
+	cmdFlags.BoolVar(&delta, "delta", false, "Display the pending time logged since the last -delta status")
 	cmdFlags.StringVar(&tags, "tags", "", "Project tags to show status on")
+	cmdFlags.StringVar(&tagMatch, "tag-match", "any", "Show status for projects with any or all of the tags [any|all]")
+	cmdFlags.BoolVar(&tagIgnoreCase, "tag-ignore-case", false, "Match tags regardless of case")
+	cmdFlags.BoolVar(&tagRegex, "tag-regex", false, "Treat tags as regular expressions")
 	cmdFlags.BoolVar(&all, "all", false, "Show status for all projects")
+	cmdFlags.StringVar(&projectsFile, "projects", "", "Show status for the project paths listed in a file or stdin")
+	cmdFlags.BoolVar(&groupByTag, "group-by-tag", false, "Group projects by tag with a subtotal for each tag")
+	cmdFlags.StringVar(&tagPolicy, "tag-policy", "each", "Group a project with more than one tag under [each|first] of its tags")
 	cmdFlags.BoolVar(&profile, "profile", false, "Enable profiling")
+	cmdFlags.StringVar(&outFile, "out", "", "Write output to a file instead of stdout")
+	cmdFlags.BoolVar(&quiet, "q", false, "Only print errors and the requested total or JSON")
+	cmdFlags.BoolVar(&watch, "watch", false, "Refresh status until interrupted")
+	cmdFlags.BoolVar(&jsonStream, "json-stream", false, "Write status as newline delimited JSON until interrupted")
+	cmdFlags.DurationVar(&interval, "interval", 5*time.Second, "Refresh interval in watch and stream mode")
+	cmdFlags.IntVar(&jobs, "jobs", runtime.NumCPU(), "Number of projects to process at the same time")
+	cmdFlags.IntVar(&width, "width", 0, "Fit status lines to this many columns, 0 is no limit")
+	cmdFlags.StringVar(&format, "format", "text", "Specify output format [text|json|json-schema|tsv]")
+	cmdFlags.BoolVar(&dirtyOnly, "dirty-only", false, "Only show time for files that are modified, staged or untracked")
+	cmdFlags.StringVar(&since, "since", "", "Only show time logged after a clock time or RFC3339 timestamp")
+	cmdFlags.BoolVar(&sinceLastCommit, "since-last-commit", false, "Only show time logged after the project's last commit")
+	cmdFlags.StringVar(&until, "until", "", "Only show time logged before a clock time or RFC3339 timestamp")
+	cmdFlags.StringVar(&tz, "tz", "", "Time zone for -since and -until clock times")
+	cmdFlags.StringVar(&revRange, "range", "", "Also show the time committed for each commit in a git revision range")
+	cmdFlags.StringVar(&include, "include", "", "Only count time for files matching these glob patterns")
+	cmdFlags.StringVar(&exclude, "exclude", "", "Do not count time for files matching these glob patterns")
+	cmdFlags.DurationVar(&idleTimeout, "idle-timeout", time.Duration(epoch.IdleTimeout)*time.Second, "Do not count gaps between events longer than the idle timeout")
+	cmdFlags.StringVar(&proration, "proration", "", "Split the time of a window with events for more than one file [proportional|last]")
 	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
+	if err := parseEnvFlags(cmdFlags, StatusFlagsEnv); err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
 	if err := cmdFlags.Parse(args); err != nil {
-		return 1
+		return ExitUsage
 	}
+	color, noColor = colorOptions(color, noColor)
 
-	if totalOnly && (all || tags != "") {
-		c.UI.Error("\n-tags and -all options not allowed with -total-only\n")
-		return 1
+	if !util.StringInSlice([]string{"text", "json", "json-schema", "tsv"}, format) {
+		c.UI.Error(fmt.Sprintf("status --format=%s not valid\n", format))
+		return ExitUsage
 	}
 
-	var (
-		err        error
-		commitNote note.CommitNote
-		out        string
-	)
+	// the schema does not depend on the project or other options
+	if format == "json-schema" {
+		schema, err := report.StatusJSONSchema()
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		c.UI.Output(schema)
+		return 0
+	}
 
-	index, err := project.NewIndex()
-	if err != nil {
-		c.UI.Error(err.Error())
-		return 1
+	if !util.StringInSlice([]string{"short", "long", "decimal"}, durationFormat) {
+		c.UI.Error(fmt.Sprintf("status --duration-format=%s not valid\n", durationFormat))
+		return ExitUsage
+	}
+
+	if durationPlaces < 0 {
+		c.UI.Error("\n-duration-places must not be negative\n")
+		return ExitUsage
+	}
+
+	if width < 0 {
+		c.UI.Error("\n-width must not be negative\n")
+		return ExitUsage
+	}
+	widthSet := false
+	cmdFlags.Visit(func(f *flag.Flag) { widthSet = widthSet || f.Name == "width" })
+	if !widthSet {
+		width = defaultWidth
+		if outFile == "" {
+			width = terminalWidth()
+		}
+	}
+
+	// -long-duration is kept for backwards compatibility and is the same as -duration-format=long
+	if longDuration {
+		if durationFormat == "decimal" {
+			c.UI.Error("\n-long-duration option not allowed with -duration-format=decimal\n")
+			return ExitUsage
+		}
+		durationFormat = "long"
+	}
+
+	if terminalWeight < 0 || appWeight < 0 {
+		c.UI.Error("\n-terminal-weight and -app-weight must not be negative\n")
+		return ExitUsage
+	}
+
+	// -delta is a total-only status, the other options that are not allowed with -total-only are checked below
+	if delta {
+		if format != "text" || jsonStream || watch || preview || revRange != "" || groupByTag {
+			c.UI.Error("\n-delta option not allowed with -format, -json-stream, -watch, -preview, -range or -group-by-tag\n")
+			return ExitUsage
+		}
+		totalOnly = true
+	}
+
+	if insights && (format == "json" || format == "tsv" || totalOnly) {
+		c.UI.Error("\n-insights option not allowed with -format=json, -format=tsv or -total-only\n")
+		return ExitUsage
+	}
+
+	if totalOnly && format == "tsv" {
+		c.UI.Error("\n-total-only option not allowed with -format=tsv\n")
+		return ExitUsage
+	}
+
+	if detail && (by == "dir" || totalOnly) {
+		c.UI.Error("\n-detail option not allowed with -by=dir or -total-only\n")
+		return ExitUsage
+	}
+
+	if watch && (format == "json" || format == "tsv") {
+		c.UI.Error("\n-watch option not allowed with -format=json or -format=tsv\n")
+		return ExitUsage
+	}
+
+	if watch && outFile != "" {
+		c.UI.Error("\n-watch option not allowed with -out\n")
+		return ExitUsage
+	}
+
+	if jsonStream {
+		if watch || outFile != "" || format == "tsv" {
+			c.UI.Error("\n-json-stream option not allowed with -watch, -out or -format=tsv\n")
+			return ExitUsage
+		}
+		format = "json"
+	}
+
+	if preview && (format == "json" || format == "tsv" || totalOnly || watch || outFile != "") {
+		c.UI.Error("\n-preview option not allowed with -format=json, -format=tsv, -json-stream, -total-only, -watch or -out\n")
+		return ExitUsage
+	}
+
+	if quiet && (watch || preview) {
+		c.UI.Error("\n-q option not allowed with -watch or -preview\n")
+		return ExitUsage
+	}
+
+	if grandTotal && (format == "json" || format == "tsv") {
+		c.UI.Error("\n-grand-total option not allowed with -format=json, -format=tsv or -json-stream\n")
+		return ExitUsage
+	}
+
+	if revRange != "" && (format == "json" || format == "tsv" || totalOnly || preview || groupByTag) {
+		c.UI.Error("\n-range option not allowed with -format=json, -format=tsv, -json-stream, -total-only, -preview or -group-by-tag\n")
+		return ExitUsage
+	}
+
+	if minDuration < 0 {
+		c.UI.Error("\n-min must not be negative\n")
+		return ExitUsage
+	}
+
+	if !util.StringInSlice([]string{"", "dir"}, by) {
+		c.UI.Error(fmt.Sprintf("status --by=%s not valid\n", by))
+		return ExitUsage
+	}
+
+	if depth < 1 {
+		c.UI.Error("\n-depth must be at least 1\n")
+		return ExitUsage
+	}
+
+	if top < 0 {
+		c.UI.Error("\n-top must not be negative\n")
+		return ExitUsage
+	}
+
+	if interval <= 0 {
+		c.UI.Error("\n-interval must be greater than zero\n")
+		return ExitUsage
+	}
+
+	if jobs < 1 {
+		c.UI.Error("\n-jobs must be at least 1\n")
+		return ExitUsage
+	}
+
+	if !util.StringInSlice([]string{"any", "all"}, tagMatch) {
+		c.UI.Error(fmt.Sprintf("status --tag-match=%s not valid\n", tagMatch))
+		return ExitUsage
+	}
+
+	if !util.StringInSlice([]string{"each", "first"}, tagPolicy) {
+		c.UI.Error(fmt.Sprintf("status --tag-policy=%s not valid\n", tagPolicy))
+		return ExitUsage
+	}
+
+	if groupByTag && (format == "json" || format == "tsv" || totalOnly) {
+		c.UI.Error("\n-group-by-tag option not allowed with -format=json, -format=tsv, -json-stream or -total-only\n")
+		return ExitUsage
+	}
+
+	if idleTimeout < 0 {
+		c.UI.Error("\n-idle-timeout must not be negative\n")
+		return ExitUsage
+	}
+
+	if proration != "" && !util.StringInSlice(project.Prorations, proration) {
+		c.UI.Error(fmt.Sprintf("status --proration=%s not valid\n", proration))
+		return ExitUsage
+	}
+
+	includeList, excludeList := []string{}, []string{}
+	if include != "" {
+		includeList = util.Map(strings.Split(include, ","), strings.TrimSpace)
+	}
+	if exclude != "" {
+		excludeList = util.Map(strings.Split(exclude, ","), strings.TrimSpace)
+	}
+	for _, p := range append(append([]string{}, includeList...), excludeList...) {
+		if _, err := path.Match(p, ""); err != nil {
+			c.UI.Error(fmt.Sprintf("\nInvalid pattern %s\n", p))
+			return ExitUsage
+		}
 	}
 
-	tagList := []string{}
-	if tags != "" {
-		tagList = util.Map(strings.Split(tags, ","), strings.TrimSpace)
+	// an explicit -idle-timeout or -proration takes precedence over the project's config file,
+	// -include and -exclude patterns are added to the project's patterns
+	idleTimeoutSet := false
+	cmdFlags.Visit(func(f *flag.Flag) { idleTimeoutSet = idleTimeoutSet || f.Name == "idle-timeout" })
+	configFor := func(projPath string) (project.Config, error) {
+		cfg, err := projectConfig(c.UI, projPath)
+		if err != nil {
+			return project.Config{}, err
+		}
+		if idleTimeoutSet {
+			cfg.IdleTimeout = int64(idleTimeout.Seconds())
+		}
+		if proration != "" {
+			cfg.Proration = proration
+		}
+		cfg.Include = append(cfg.Include, includeList...)
+		cfg.Exclude = append(cfg.Exclude, excludeList...)
+		return cfg, nil
 	}
 
-	projects, err := index.Get(tagList, all)
+	if projectsFile != "" && (all || tags != "") {
+		c.UI.Error("\n-projects option not allowed with -tags or -all\n")
+		return ExitUsage
+	}
+
+	// multiple projects are supported for total-only when output is json or a grand total
+	if totalOnly && format != "json" && !grandTotal && (all || tags != "" || projectsFile != "") {
+		c.UI.Error("\n-tags, -all and -projects options not allowed with -total-only\n")
+		return ExitUsage
+	}
+
+	if seconds && !totalOnly {
+		c.UI.Error("\n-seconds option is only allowed with -total-only\n")
+		return ExitUsage
+	}
+
+	var err error
+
+	loc := time.Local
+	if tz != "" {
+		if loc, err = time.LoadLocation(tz); err != nil {
+			c.UI.Error(fmt.Sprintf("\nInvalid time zone %s, use a IANA time zone name, i.e. America/New_York or UTC\n", tz))
+			return ExitUsage
+		}
+	}
+
+	var dateRange util.DateRange
+	if since != "" {
+		if dateRange.Start, err = util.ParseTimeInLocation(since, loc); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+	}
+	if sinceLastCommit && since != "" {
+		c.UI.Error("\n-since-last-commit option not allowed with -since\n")
+		return ExitUsage
+	}
+	if until != "" {
+		if dateRange.End, err = util.ParseTimeInLocation(until, loc); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+	}
+	if !dateRange.Start.IsZero() && !dateRange.End.IsZero() && dateRange.End.Before(dateRange.Start) {
+		c.UI.Error("\n-until must not be before -since\n")
+		return ExitUsage
+	}
+
+	theme, err := report.LoadTheme()
 	if err != nil {
 		c.UI.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
-	options := report.OutputOptions{
-		TotalOnly:    totalOnly,
-		LongDuration: longDuration,
-		TerminalOff:  terminalOff,
-		AppOff:       appOff,
-		Color:        color}
-
-	for _, projPath := range projects {
-		if commitNote, err = metric.Process(true, projPath); err != nil {
+	var projects []string
+	var invalid []error
+	if projectsFile != "" {
+		if projects, invalid, err = readProjects(projectsFile); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		for _, err := range invalid {
 			c.UI.Error(err.Error())
-			return 1
 		}
-		o, err := report.Status(commitNote, options, projPath)
+	} else {
+		index, err := project.NewIndex()
 		if err != nil {
 			c.UI.Error(err.Error())
-			return 1
+			return exitCode(err)
+		}
+
+		tagList := []string{}
+		if tags != "" {
+			tagList = util.Map(strings.Split(tags, ","), strings.TrimSpace)
+		}
+
+		match := project.TagMatch{All: tagMatch == "all", IgnoreCase: tagIgnoreCase, Regex: tagRegex}
+		if projects, err = index.GetMatching(tagList, all, match); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
 		}
-		out += o
 	}
 
-	if totalOnly {
-		// plain output, no ansi escape sequences
-		fmt.Print(out)
-	} else {
-		c.UI.Output(out)
+	var groupTags []string
+	var groups map[string][]int
+	if groupByTag {
+		if groupTags, groups, err = tagGroups(projects, tagPolicy == "first"); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		// the grand total counts each project once
+		grandTotal = true
+	}
+
+	dirDepth := 0
+	if by == "dir" {
+		dirDepth = depth
+	}
+
+	options := report.OutputOptions{
+		TotalOnly:      totalOnly,
+		LongDuration:   longDuration,
+		DurationFormat: durationFormat,
+		DurationPlaces: durationPlaces,
+		Seconds:        seconds,
+		ShowPercent:    percent,
+		ShowApps:       apps && !appOff,
+		ShowBranch:     branch,
+		ShowInsights:   insights,
+		ShowDetail:     detail,
+		Location:       loc,
+		MinDuration:    minDuration,
+		Top:            top,
+		DirDepth:       dirDepth,
+		Width:          width,
+		TerminalOff:    terminalOff,
+		AppOff:         appOff,
+		Weights:        &report.Weights{Terminal: terminalWeight, App: appWeight},
+		Color:          color || watch && !noColor,
+		NoColor:        noColor,
+		Theme:          &theme}
+
+	if preview {
+		for _, p := range projects {
+			cfg, err := configFor(p)
+			if err != nil {
+				c.UI.Error(err.Error())
+				return exitCode(err)
+			}
+			attributions, err := metric.Preview(cfg, p)
+			if err != nil {
+				c.UI.Error(err.Error())
+				return exitCode(err)
+			}
+			c.UI.Output(report.Preview(attributions, options, p))
+		}
+		return 0
+	}
+
+	// a status stops processing events when interrupted, watching and streaming stop between refreshes
+	ctx := context.Background()
+	if !watch && !jsonStream {
+		var stop context.CancelFunc
+		ctx, stop = interruptContext()
+		defer stop()
+	}
+
+	// statusResult is a project's status and total pending time, the warning is set if the project's
+	// webhook could not be notified which does not stop the status from being shown
+	type statusResult struct {
+		out     string
+		total   int
+		warning error
+	}
+
+	process := func(projPath string) (statusResult, error) {
+		cfg, err := configFor(projPath)
+		if err != nil {
+			return statusResult{}, err
+		}
+		commitNote, err := metric.ProcessContext(ctx, true, cfg, projPath)
+		if err != nil {
+			return statusResult{}, err
+		}
+		warning := metric.Notify(commitNote.Total(), cfg, projPath)
+		dr := dateRange
+		if sinceLastCommit {
+			head, err := scm.HeadCommit(projPath)
+			if err != nil {
+				return statusResult{}, err
+			}
+			// without commits all of the time is shown
+			if head.ID != "" {
+				dr.Start = head.When
+			}
+		}
+		if dr.IsSet() {
+			commitNote = commitNote.FilterDateRange(dr)
+		}
+		if dirtyOnly {
+			if commitNote, err = filterDirty(commitNote, projPath); err != nil {
+				return statusResult{}, err
+			}
+		}
+		total := options.Weigh(commitNote)
+		if delta {
+			d, err := metric.Delta(total.Total(), projPath)
+			if err != nil {
+				return statusResult{warning: warning}, err
+			}
+			return statusResult{out: report.GrandTotal(d, options), total: d, warning: warning}, nil
+		}
+		var out string
+		switch format {
+		case "json":
+			out, err = report.StatusJSON(commitNote, options, projPath)
+		case "tsv":
+			out, err = report.StatusTSV(commitNote, options, projPath)
+		default:
+			out, err = report.Status(commitNote, options, projPath)
+		}
+		if err != nil || revRange == "" {
+			return statusResult{out: out, total: total.Total(), warning: warning}, err
+		}
+
+		// the committed time is shown after the pending time, the total is only the pending time
+		commits, err := scm.RevList(revRange, projPath)
+		if err != nil {
+			return statusResult{warning: warning}, err
+		}
+		committed, err := report.StatusCommits(commits, revRange, options, projPath)
+		if err != nil {
+			return statusResult{warning: warning}, err
+		}
+		if out != "" {
+			out = "\n" + report.Heading("Pending", options) + strings.TrimPrefix(out, "\n")
+		}
+		return statusResult{out: out + committed, total: total.Total(), warning: warning}, nil
+	}
+
+	// seq is the sequence number of the last status written with -json-stream,
+	// streamErr is set when stdout can no longer be written to
+	seq := 0
+	stdout := bufio.NewWriter(os.Stdout)
+	var streamErr error
+
+	show := func() int {
+		// process projects with a pool of workers, results are kept in project order
+		type result struct {
+			statusResult
+			err error
+		}
+		results := make([]result, len(projects))
+		projectIdx := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < jobs && w < len(projects); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range projectIdx {
+					r, err := process(projects[i])
+					results[i] = result{statusResult: r, err: err}
+				}
+			}()
+		}
+		for i := range projects {
+			projectIdx <- i
+		}
+		close(projectIdx)
+		wg.Wait()
+
+		out := ""
+		// the exit status is non-zero if a project's status can not be read or a -projects path was not a project,
+		// an unexpected error takes precedence over a project that's not initialized
+		code := ExitSuccess
+		if len(invalid) > 0 {
+			code = ExitNotInitialized
+		}
+		total := 0
+		jsonProjects := []json.RawMessage{}
+		for i, r := range results {
+			if r.warning != nil {
+				c.UI.Error(fmt.Sprintf("Warning: unable to notify the webhook for %s, %s", projects[i], r.warning))
+			}
+			if r.err != nil {
+				if len(projects) > 1 {
+					c.UI.Error(fmt.Sprintf("%s: %s", projects[i], r.err))
+				} else {
+					c.UI.Error(r.err.Error())
+				}
+				if code != ExitError {
+					code = exitCode(r.err)
+				}
+				continue
+			}
+			total += r.total
+			if format == "json" {
+				jsonProjects = append(jsonProjects, json.RawMessage(r.out))
+				continue
+			}
+			out += r.out
+		}
+
+		if jsonStream {
+			seq++
+			b, err := json.Marshal(statusStream{Seq: seq, Time: util.Now().Format(time.RFC3339), Projects: jsonProjects})
+			if err != nil {
+				c.UI.Error(err.Error())
+				return exitCode(err)
+			}
+			// flush each line so a plug-in reading from a pipe receives it immediately
+			if _, streamErr = stdout.Write(append(b, '\n')); streamErr == nil {
+				streamErr = stdout.Flush()
+			}
+			if streamErr != nil {
+				return ExitError
+			}
+			return code
+		}
+
+		if format == "json" {
+			b, err := json.MarshalIndent(jsonProjects, "", "  ")
+			if err != nil {
+				c.UI.Error(err.Error())
+				return exitCode(err)
+			}
+			out = string(b) + "\n"
+		} else if totalOnly && grandTotal {
+			out = report.GrandTotal(total, options)
+		} else if !totalOnly && format == "text" {
+			if groupByTag {
+				out = c.groupOutput(groupTags, groups, func(i int) (string, int, bool) {
+					return results[i].out, results[i].total, results[i].err == nil
+				}, options)
+			}
+			if grandTotal {
+				out += "\n" + report.GrandTotal(total, options)
+			}
+			out += "\n"
+		}
+
+		switch {
+		case outFile != "":
+			if !color {
+				out = util.StripANSI(out)
+			}
+			if err := util.WriteFileAtomic(outFile, []byte(out), 0644); err != nil {
+				c.UI.Error(err.Error())
+				return exitCode(err)
+			}
+		case totalOnly && format != "json":
+			// plain output, no ansi escape sequences
+			fmt.Print(out)
+		case format == "tsv":
+			// no blank line when there are no files
+			if out != "" {
+				c.UI.Output(strings.TrimSuffix(out, "\n"))
+			}
+		case quiet && format == "text":
+			// only errors are printed
+		default:
+			c.UI.Output(strings.TrimSuffix(out, "\n"))
+		}
+
+		return code
+	}
+
+	if !watch && !jsonStream {
+		return show()
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	// errors are shown with each refresh, the exit status is the last refresh's so an error that
+	// has not cleared up by the time gtm is interrupted is reported
+	code := ExitSuccess
+	for {
+		if jsonStream {
+			// stop streaming when the reader goes away, i.e. the editor closed the pipe
+			if code = show(); streamErr != nil {
+				return 0
+			}
+		} else {
+			// clear screen and move the cursor to the top left before refreshing
+			fmt.Print("\033[H\033[2J")
+			code = show()
+		}
+		select {
+		case <-sigs:
+			return code
+		case <-ticker.C:
+		}
+	}
+}
+
+// untaggedGroup is the tag group for projects without tags
+const untaggedGroup = "untagged"
+
+// tagGroups returns the tags ordered by name and the indexes of the projects with each tag,
+// if first is true a project is only in the group for the first of its tags
+func tagGroups(projects []string, first bool) ([]string, map[string][]int, error) {
+	groups := map[string][]int{}
+	for i, p := range projects {
+		tags, err := project.LoadTags(filepath.Join(p, project.GTMDir))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(tags) == 0 {
+			tags = []string{untaggedGroup}
+		}
+		if first {
+			tags = tags[:1]
+		}
+		for _, t := range tags {
+			groups[t] = append(groups[t], i)
+		}
+	}
+	groupTags := []string{}
+	for t := range groups {
+		groupTags = append(groupTags, t)
+	}
+	sort.Strings(groupTags)
+	return groupTags, groups, nil
+}
+
+// groupOutput returns the status for each tag group followed by the group's subtotal,
+// result returns a project's status, total and if it was processed without an error
+func (c StatusCmd) groupOutput(
+	groupTags []string, groups map[string][]int, result func(int) (string, int, bool), options report.OutputOptions) string {
+
+	out := ""
+	inGroups := map[int]int{}
+	for _, t := range groupTags {
+		out += "\n" + report.Heading(t, options)
+		subtotal := 0
+		for _, i := range groups[t] {
+			inGroups[i]++
+			o, total, ok := result(i)
+			if !ok {
+				continue
+			}
+			out += o
+			subtotal += total
+		}
+		out += "\n" + report.Subtotal(t+" subtotal", subtotal, options)
+	}
+	for _, n := range inGroups {
+		if n > 1 {
+			out += "\nProjects with more than one tag are in each of their tag's subtotals\n"
+			break
+		}
+	}
+	return out
+}
+
+// statusStream is a status refresh written as a single line of JSON with -json-stream
+type statusStream struct {
+	Seq      int               `json:"seq"`
+	Time     string            `json:"time"`
+	Projects []json.RawMessage `json:"projects"`
+}
+
+// filterDirty keeps the time for files that are modified, staged or untracked in the project's working tree
+func filterDirty(n note.CommitNote, projPath string) (note.CommitNote, error) {
+	status, err := scm.NewStatus(projPath)
+	if err != nil {
+		return note.CommitNote{}, err
+	}
+	fds := []note.FileDetail{}
+	for _, f := range n.Files {
+		if !f.IsApp() && status.IsDirty(f.SourceFile) {
+			fds = append(fds, f)
+		}
 	}
-	return 0
+	return note.CommitNote{Files: fds}, nil
 }
 
 // Synopsis returns help for status command
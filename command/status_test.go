@@ -5,12 +5,18 @@
 package command
 
 import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/report"
+	"github.com/git-time-metric/gtm/scm"
 	"github.com/git-time-metric/gtm/util"
 	"github.com/mitchellh/cli"
 )
@@ -53,6 +59,124 @@ func TestStatusTotalOnly(t *testing.T) {
 	}
 }
 
+func TestStatusSeconds(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	ui := new(cli.MockUi)
+	c := StatusCmd{UI: ui}
+
+	// total-only output is written directly to stdout
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+
+	args := []string{"-total-only", "-seconds", "-long-duration"}
+	rc := c.Run(args)
+
+	w.Close()
+	os.Stdout = stdout
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if string(out) != "60\n" {
+		t.Errorf("gtm status(%+v), want \"60\\n\" got %q", args, string(out))
+	}
+
+	args = []string{"-seconds"}
+	if rc = c.Run(args); rc != ExitUsage {
+		t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestStatusInvalidConfig(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+	repo.SaveFile(project.ConfigFile, project.GTMDir, `{"idle_timeout": -1}`)
+
+	// the defaults are used and the invalid config is reported through the UI
+	ui := new(cli.MockUi)
+	if rc := (StatusCmd{UI: ui}).Run([]string{}); rc != 0 {
+		t.Fatalf("gtm status with an invalid config, want 0 got %d, %s", rc, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "using defaults") {
+		t.Errorf("gtm status with an invalid config, want a warning got %s", ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "event.go") {
+		t.Errorf("gtm status with an invalid config, want event.go got %s", ui.OutputWriter.String())
+	}
+}
+
+func TestStatusDelta(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	outFile := filepath.Join(repo.Workdir(), "delta.txt")
+	args := []string{"-delta", "-seconds", "-out", outFile}
+	status := func(want string) {
+		t.Helper()
+		ui := new(cli.MockUi)
+		if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+			t.Fatalf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		b, err := ioutil.ReadFile(outFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("gtm status(%+v), want %q got %q", args, want, string(b))
+		}
+	}
+
+	// the first status is all of the pending time, then only the time logged since the last status
+	status("60\n")
+	status("0\n")
+	repo.SaveFile("1458496863.event", project.GTMDir, filepath.Join("event", "event.go"))
+	status("60\n")
+
+	// committing the time starts over, even when as much time is logged after the commit
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+	(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458500463.event", project.GTMDir, filepath.Join("event", "event.go"))
+	status("120\n")
+
+	for _, args := range [][]string{{"-delta", "-format", "json"}, {"-delta", "-watch"}, {"-delta", "-range", "HEAD~1..HEAD"}} {
+		if rc := (StatusCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
 func TestStatusAppOff(t *testing.T) {
 	repo := util.NewTestRepo(t, false)
 	defer repo.Remove()
@@ -90,17 +214,1442 @@ func TestStatusAppOff(t *testing.T) {
 	}
 }
 
-func TestStatusInvalidOption(t *testing.T) {
+func TestStatusApps(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("browser.app", project.GTMDir, "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458497804.event", project.GTMDir, filepath.Join(project.GTMDir, "browser.app"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
 	ui := new(cli.MockUi)
 	c := StatusCmd{UI: ui}
 
-	args := []string{"-invalid"}
+	args := []string{"-apps"}
 	rc := c.Run(args)
+	if rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	want := "Apps\n        1m  0s  50% Browser\n"
+	if !strings.Contains(ui.OutputWriter.String(), "event.go") || !strings.Contains(ui.OutputWriter.String(), want) ||
+		strings.Contains(ui.OutputWriter.String(), "[app]") {
+		t.Errorf("gtm status(%+v), want 'event.go' and %q got %s", args, want, ui.OutputWriter.String())
+	}
+	ui.OutputWriter.Reset()
 
-	if rc != 1 {
-		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter)
+	args = []string{"-apps", "-app-off"}
+	rc = c.Run(args)
+	if rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
 	}
-	if !strings.Contains(ui.OutputWriter.String(), "Usage:") {
-		t.Errorf("gtm status(%+v), want 'Usage:' got %d, %s", args, rc, ui.OutputWriter.String())
+	if strings.Contains(ui.OutputWriter.String(), "Apps") || strings.Contains(ui.OutputWriter.String(), "Browser") {
+		t.Errorf("gtm status(%+v), want no apps got %s", args, ui.OutputWriter.String())
+	}
+}
+
+func TestStatusJSON(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{"-tags", "tag1"})
+
+	ui := new(cli.MockUi)
+	c := StatusCmd{UI: ui}
+
+	args := []string{"-format", "json"}
+	rc := c.Run(args)
+	if rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+
+	var got []struct {
+		Tags  []string
+		Total int
+		Files []struct {
+			SourceFile string `json:"source_file"`
+			TimeSpent  int    `json:"time_spent"`
+		}
+	}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("gtm status(%+v), want valid json got %s, %s", args, err, ui.OutputWriter.String())
+	}
+	if len(got) != 1 || got[0].Total != 60 || len(got[0].Files) != 1 ||
+		got[0].Files[0].SourceFile != "event/event.go" || len(got[0].Tags) != 1 {
+		t.Errorf("gtm status(%+v), want 1 project with event/event.go 60s got %+v", args, got)
+	}
+
+	ui.OutputWriter.Reset()
+	args = []string{"-format", "json", "-total-only", "-all"}
+	rc = c.Run(args)
+	if rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if strings.Contains(ui.OutputWriter.String(), "files") {
+		t.Errorf("gtm status(%+v), want no files got %s", args, ui.OutputWriter.String())
+	}
+}
+
+func TestStatusTSV(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("tab\tname.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496863.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496923.event", project.GTMDir, filepath.Join("event", "tab\tname.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	ui := new(cli.MockUi)
+	args := []string{"-format", "tsv"}
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	name := filepath.Base(repo.Workdir())
+	want := fmt.Sprintf("%s\tevent/event.go\t120\n%s\tevent/tab\\tname.go\t60\n", name, name)
+	if got := ui.OutputWriter.String(); got != want {
+		t.Errorf("gtm status(%+v), want %q got %q", args, want, got)
+	}
+
+	for _, args := range [][]string{{"-format", "tsv", "-total-only"}, {"-format", "tsv", "-watch"}} {
+		ui := new(cli.MockUi)
+		if rc := (StatusCmd{UI: ui}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestStatusProration(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	// both files are edited in the same minute, event_test.go last
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("event_test.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496813.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496823.event", project.GTMDir, filepath.Join("event", "event_test.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	name := filepath.Base(repo.Workdir())
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-format", "tsv"}, fmt.Sprintf("%s\tevent/event.go\t40\n%s\tevent/event_test.go\t20\n", name, name)},
+		{[]string{"-format", "tsv", "-proration", "proportional"}, fmt.Sprintf("%s\tevent/event.go\t40\n%s\tevent/event_test.go\t20\n", name, name)},
+		{[]string{"-format", "tsv", "-proration", "last"}, fmt.Sprintf("%s\tevent/event_test.go\t60\n", name)},
+	}
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		if rc := (StatusCmd{UI: ui}).Run(tc.args); rc != 0 {
+			t.Errorf("gtm status(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+		}
+		if got := ui.OutputWriter.String(); got != tc.want {
+			t.Errorf("gtm status(%+v), want %q got %q", tc.args, tc.want, got)
+		}
+	}
+
+	args := []string{"-proration", "equal"}
+	if rc := (StatusCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestStatusBranch(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	branch, err := scm.Branch(repo.Workdir())
+	util.CheckFatal(t, err)
+	want := fmt.Sprintf("%s (%s)", filepath.Base(repo.Workdir()), branch)
+
+	ui := new(cli.MockUi)
+	c := StatusCmd{UI: ui}
+
+	args := []string{"-branch"}
+	if rc := c.Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), want) {
+		t.Errorf("gtm status(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+	}
+
+	ui.OutputWriter.Reset()
+	args = []string{"-branch", "-format", "json"}
+	if rc := c.Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if want := fmt.Sprintf(`"branch": "%s"`, branch); !strings.Contains(ui.OutputWriter.String(), want) {
+		t.Errorf("gtm status(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+	}
+
+	ui.OutputWriter.Reset()
+	args = []string{}
+	if rc := c.Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if strings.Contains(ui.OutputWriter.String(), want) {
+		t.Errorf("gtm status(%+v), want no branch got %s", args, ui.OutputWriter.String())
+	}
+}
+
+func TestStatusInsights(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	ui := new(cli.MockUi)
+	c := StatusCmd{UI: ui}
+
+	// no pending time
+	args := []string{"-insights"}
+	if rc := c.Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if strings.Contains(ui.OutputWriter.String(), "Most active file") {
+		t.Errorf("gtm status(%+v), want no insights got %s", args, ui.OutputWriter.String())
+	}
+
+	// events in two consecutive hours and a later hour
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("event_test.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("event", "event_test.go"))
+	repo.SaveFile("1458511203.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	ui.OutputWriter.Reset()
+	args = []string{"-insights", "-tz", "UTC"}
+	if rc := c.Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	want := "Most active file: event/event.go, longest session: 2m  0s Sun 18:00-20:00"
+	if !strings.Contains(ui.OutputWriter.String(), want) {
+		t.Errorf("gtm status(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+	}
+
+	for _, args := range [][]string{{"-insights", "-format", "json"}, {"-insights", "-total-only"}} {
+		if rc := (StatusCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestStatusDetail(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// event.go is edited twice with a gap longer than the idle timeout
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("event_test.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("event", "event_test.go"))
+	repo.SaveFile("1458511203.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	ui := new(cli.MockUi)
+	args := []string{"-detail", "-tz", "UTC"}
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	for _, want := range []string{
+		"event/event.go (sessions: 2, last active: Sun 22:01)",
+		"event/event_test.go (sessions: 1, last active: Sun 19:01)"} {
+		if !strings.Contains(ui.OutputWriter.String(), want) {
+			t.Errorf("gtm status(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+		}
+	}
+
+	ui = new(cli.MockUi)
+	args = []string{"-tz", "UTC"}
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if strings.Contains(ui.OutputWriter.String(), "sessions") {
+		t.Errorf("gtm status(%+v), want no detail got %s", args, ui.OutputWriter.String())
+	}
+
+	ui = new(cli.MockUi)
+	args = []string{"-detail", "-format", "json"}
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if want := `"sessions": 2`; !strings.Contains(ui.OutputWriter.String(), want) {
+		t.Errorf("gtm status(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+	}
+
+	for _, args := range [][]string{{"-detail", "-by", "dir"}, {"-detail", "-total-only"}} {
+		if rc := (StatusCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestStatusPreview(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// the last window has an event for each file
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("event_test.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496923.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496930.event", project.GTMDir, filepath.Join("event", "event_test.go"))
+
+	ui := new(cli.MockUi)
+	args := []string{"-preview", "-tz", "UTC"}
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	for _, want := range []string{
+		"diagnostic only, nothing is saved",
+		"Sun Mar 20 18:01:00        1         1m  0s  event/event.go",
+		"Sun Mar 20 18:02:00        1            30s  event/event.go\n                           1            30s  event/event_test.go",
+		"2m 30s  event/event.go"} {
+		if !strings.Contains(ui.OutputWriter.String(), want) {
+			t.Errorf("gtm status(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+		}
+	}
+
+	// nothing is saved, status has the same pending time
+	ui = new(cli.MockUi)
+	args = []string{}
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if want := "2m 30s  83% [r] event/event.go"; !strings.Contains(ui.OutputWriter.String(), want) {
+		t.Errorf("gtm status(%+v), want %s got %s", args, want, ui.OutputWriter.String())
+	}
+
+	for _, args := range [][]string{{"-preview", "-format", "json"}, {"-preview", "-total-only"}} {
+		if rc := (StatusCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestStatusGroupByTag(t *testing.T) {
+	newProject := func(tags string) util.TestRepo {
+		repo := util.NewTestRepo(t, false)
+		repo.Seed()
+		os.Chdir(repo.Workdir())
+		(InitCmd{UI: new(cli.MockUi)}).Run([]string{"-tags", tags})
+		repo.SaveFile("event.go", "event", "")
+		repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+		return repo
+	}
+	repo1 := newProject("group-a")
+	defer repo1.Remove()
+	repo2 := newProject("group-a,group-b")
+	defer repo2.Remove()
+
+	tests := []struct {
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{
+			[]string{"-tags", "group-a,group-b", "-group-by-tag"},
+			[]string{"2m  0s          group-a subtotal", "1m  0s          group-b subtotal", "2m  0s          Total", "more than one tag"},
+			[]string{},
+		},
+		{
+			[]string{"-tags", "group-a,group-b", "-group-by-tag", "-tag-policy", "first"},
+			[]string{"2m  0s          group-a subtotal", "2m  0s          Total"},
+			[]string{"group-b subtotal", "more than one tag"},
+		},
+	}
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		if rc := (StatusCmd{UI: ui}).Run(tc.args); rc != 0 {
+			t.Errorf("gtm status(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(ui.OutputWriter.String(), want) {
+				t.Errorf("gtm status(%+v), want %s got %s", tc.args, want, ui.OutputWriter.String())
+			}
+		}
+		for _, notWant := range tc.notWant {
+			if strings.Contains(ui.OutputWriter.String(), notWant) {
+				t.Errorf("gtm status(%+v), want no %s got %s", tc.args, notWant, ui.OutputWriter.String())
+			}
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-group-by-tag", "-format", "json"}, {"-group-by-tag", "-total-only"}, {"-group-by-tag", "-tag-policy", "last"}} {
+		if rc := (StatusCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestStatusEnvFlags(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	defer os.Unsetenv(StatusFlagsEnv)
+	os.Setenv(StatusFlagsEnv, " -format=json  -percent=false ")
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{}, `"source_file": "event/event.go"`},
+		{[]string{"-format", "text"}, "1m  0s [r] event/event.go"},
+	}
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		if rc := (StatusCmd{UI: ui}).Run(tc.args); rc != 0 {
+			t.Errorf("gtm status(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+		}
+		if !strings.Contains(ui.OutputWriter.String(), tc.want) {
+			t.Errorf("gtm status(%+v) with %s, want %s got %s", tc.args, os.Getenv(StatusFlagsEnv), tc.want, ui.OutputWriter.String())
+		}
+	}
+
+	for _, env := range []string{"-invalid", "-color json"} {
+		os.Setenv(StatusFlagsEnv, env)
+		ui := new(cli.MockUi)
+		if rc := (StatusCmd{UI: ui}).Run([]string{}); rc != 1 {
+			t.Errorf("gtm status() with %s, want 1 got %d", env, rc)
+		}
+		if !strings.Contains(ui.ErrorWriter.String(), StatusFlagsEnv) {
+			t.Errorf("gtm status() with %s, want error for %s got %s", env, StatusFlagsEnv, ui.ErrorWriter.String())
+		}
+	}
+}
+
+func TestStatusSinceUntil(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	ui := new(cli.MockUi)
+	c := StatusCmd{UI: ui}
+
+	args := []string{"-since", "2016-03-20T18:00:00Z", "-until", "2016-03-20T19:00:00Z"}
+	rc := c.Run(args)
+	if rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "event.go") {
+		t.Errorf("gtm status(%+v), want 'event.go' got %s", args, ui.OutputWriter.String())
+	}
+
+	ui.OutputWriter.Reset()
+	args = []string{"-since", "2016-03-20T19:00:00Z"}
+	rc = c.Run(args)
+	if rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if strings.Contains(ui.OutputWriter.String(), "event.go") {
+		t.Errorf("gtm status(%+v), want not 'event.go' got %s", args, ui.OutputWriter.String())
+	}
+
+	args = []string{"-since", "5pm"}
+	if rc = c.Run(args); rc != 1 {
+		t.Errorf("gtm status(%+v), want 1 got %d", args, rc)
+	}
+
+	args = []string{"-since", "09:00", "-tz", "Mars/Olympus_Mons"}
+	if rc = c.Run(args); rc != ExitUsage {
+		t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestStatusExclude(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("Gemfile.lock", "", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496863.event", project.GTMDir, "Gemfile.lock")
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	ui := new(cli.MockUi)
+	c := StatusCmd{UI: ui}
+
+	args := []string{"-exclude", "*.lock, vendor/**"}
+	rc := c.Run(args)
+	if rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "event.go") || strings.Contains(ui.OutputWriter.String(), "Gemfile.lock") {
+		t.Errorf("gtm status(%+v), want 'event.go' and not 'Gemfile.lock' got %s", args, ui.OutputWriter.String())
+	}
+
+	ui.OutputWriter.Reset()
+	args = []string{"-include", "event/**"}
+	rc = c.Run(args)
+	if rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "event.go") || strings.Contains(ui.OutputWriter.String(), "Gemfile.lock") {
+		t.Errorf("gtm status(%+v), want 'event.go' and not 'Gemfile.lock' got %s", args, ui.OutputWriter.String())
+	}
+
+	args = []string{"-exclude", "[*.lock"}
+	if rc = c.Run(args); rc != ExitUsage {
+		t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestStatusTagMatch(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{"-tags", "tag1,tag2"})
+
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"-format", "json", "-tags", "tag1,tag3"}, 1},
+		{[]string{"-format", "json", "-tags", "tag1,tag3", "-tag-match", "any"}, 1},
+		{[]string{"-format", "json", "-tags", "tag1,tag3", "-tag-match", "all"}, 0},
+		{[]string{"-format", "json", "-tags", "tag1,tag2", "-tag-match", "all"}, 1},
+		{[]string{"-format", "json", "-tags", "TAG1"}, 0},
+		{[]string{"-format", "json", "-tags", "TAG1", "-tag-ignore-case"}, 1},
+		{[]string{"-format", "json", "-tags", "tag[0-9]", "-tag-regex"}, 1},
+		{[]string{"-format", "json", "-tags", "tag", "-tag-regex"}, 0},
+	}
+
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		rc := (StatusCmd{UI: ui}).Run(tc.args)
+		if rc != 0 {
+			t.Errorf("gtm status(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+		}
+		var got []json.RawMessage
+		if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+			t.Fatalf("gtm status(%+v), want valid json got %s, %s", tc.args, err, ui.OutputWriter.String())
+		}
+		if len(got) != tc.want {
+			t.Errorf("gtm status(%+v), want %d projects got %d", tc.args, tc.want, len(got))
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-tags", "tag1", "-tag-match", "some"},
+		{"-tags", "tag(", "-tag-regex"},
+	} {
+		if rc := (StatusCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestStatusJobs(t *testing.T) {
+	repos := []util.TestRepo{}
+	for i := 0; i < 3; i++ {
+		repo := util.NewTestRepo(t, false)
+		defer repo.Remove()
+		repo.Seed()
+		os.Chdir(repo.Workdir())
+
+		repo.SaveFile("event.go", "event", "")
+		repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+		(InitCmd{UI: new(cli.MockUi)}).Run([]string{"-tags", "jobs"})
+		repos = append(repos, repo)
+	}
+
+	ui := new(cli.MockUi)
+	c := StatusCmd{UI: ui}
+
+	args := []string{"-format", "json", "-tags", "jobs", "-jobs", "2"}
+	rc := c.Run(args)
+	if rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+
+	var got []struct {
+		Path  string
+		Total int
+	}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("gtm status(%+v), want valid json got %s, %s", args, err, ui.OutputWriter.String())
+	}
+	if len(got) != len(repos) {
+		t.Fatalf("gtm status(%+v), want %d projects got %+v", args, len(repos), got)
+	}
+	for i := range got {
+		if got[i].Total != 60 || (i > 0 && got[i-1].Path > got[i].Path) {
+			t.Errorf("gtm status(%+v), want projects in order with 60s each got %+v", args, got)
+		}
+	}
+
+	args = []string{"-jobs", "0"}
+	if rc = c.Run(args); rc != ExitUsage {
+		t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestStatusGrandTotal(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		repo := util.NewTestRepo(t, false)
+		defer repo.Remove()
+		repo.Seed()
+		os.Chdir(repo.Workdir())
+
+		repo.SaveFile("event.go", "event", "")
+		repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+		repo.SaveFile("terminal.app", project.GTMDir, "")
+		repo.SaveFile("1458496863.event", project.GTMDir, filepath.Join(project.GTMDir, "terminal.app"))
+
+		(InitCmd{UI: new(cli.MockUi)}).Run([]string{"-tags", "grand"})
+	}
+
+	// total-only output is written directly to stdout
+	totalOnly := func(args []string) string {
+		stdout := os.Stdout
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.Stdout = w
+		ui := new(cli.MockUi)
+		rc := (StatusCmd{UI: ui}).Run(args)
+		w.Close()
+		os.Stdout = stdout
+		out, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rc != 0 {
+			t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		return string(out)
+	}
+
+	args := []string{"-tags", "grand", "-grand-total", "-total-only", "-seconds"}
+	if got := totalOnly(args); got != "240\n" {
+		t.Errorf("gtm status(%+v), want \"240\\n\" got %q", args, got)
+	}
+	args = []string{"-tags", "grand", "-grand-total", "-total-only", "-seconds", "-terminal-off"}
+	if got := totalOnly(args); got != "120\n" {
+		t.Errorf("gtm status(%+v), want \"120\\n\" got %q", args, got)
+	}
+
+	ui := new(cli.MockUi)
+	args = []string{"-tags", "grand", "-grand-total"}
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "4m  0s") || !strings.Contains(out, "Total") {
+		t.Errorf("gtm status(%+v), want grand total 4m  0s got %s", args, out)
+	}
+
+	args = []string{"-grand-total", "-format", "json"}
+	if rc := (StatusCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestStatusWatchOptions(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	for _, args := range [][]string{
+		{"-watch", "-format", "json"},
+		{"-watch", "-interval", "0s"},
+		{"-watch", "-interval", "-1s"},
+	} {
+		ui := new(cli.MockUi)
+		if rc := (StatusCmd{UI: ui}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestStatusJSONStream(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	ui := new(cli.MockUi)
+	c := StatusCmd{UI: ui}
+
+	// the stream is written directly to stdout
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	args := []string{"-json-stream", "-interval", "10ms"}
+	done := make(chan int)
+	go func() { done <- c.Run(args) }()
+
+	scanner := bufio.NewScanner(r)
+	for seq := 1; seq <= 2; seq++ {
+		if !scanner.Scan() {
+			t.Fatalf("gtm status(%+v), want line %d got %v", args, seq, scanner.Err())
+		}
+		var got struct {
+			Seq      int
+			Time     string
+			Projects []struct{ Total int }
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &got); err != nil {
+			t.Fatalf("gtm status(%+v), want valid json got %s, %s", args, err, scanner.Text())
+		}
+		if got.Seq != seq || got.Time == "" || len(got.Projects) != 1 || got.Projects[0].Total != 60 {
+			t.Errorf("gtm status(%+v), want seq %d with 1 project of 60s got %+v", args, seq, got)
+		}
+	}
+
+	// closing the pipe stops the stream
+	r.Close()
+	if rc := <-done; rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	w.Close()
+
+	for _, args := range [][]string{
+		{"-json-stream", "-watch"},
+		{"-json-stream", "-out", "status.txt"},
+	} {
+		if rc := (StatusCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestStatusOut(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	outFile := filepath.Join(repo.Workdir(), "status.txt")
+
+	tests := []struct {
+		args     []string
+		wantANSI bool
+	}{
+		{[]string{"-out", outFile}, false},
+		{[]string{"-out", outFile, "-color"}, true},
+	}
+
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		rc := (StatusCmd{UI: ui}).Run(tc.args)
+		if rc != 0 {
+			t.Errorf("gtm status(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+		}
+		if ui.OutputWriter != nil && ui.OutputWriter.String() != "" {
+			t.Errorf("gtm status(%+v), want no output got %s", tc.args, ui.OutputWriter.String())
+		}
+		b, err := ioutil.ReadFile(outFile)
+		if err != nil {
+			t.Fatalf("gtm status(%+v), want error nil got %s", tc.args, err)
+		}
+		if !strings.Contains(string(b), "event.go") {
+			t.Errorf("gtm status(%+v), want 'event.go' got %s", tc.args, string(b))
+		}
+		if strings.Contains(string(b), "\x1b[") != tc.wantANSI {
+			t.Errorf("gtm status(%+v), want ANSI escapes %t got %q", tc.args, tc.wantANSI, string(b))
+		}
+	}
+
+	args := []string{"-out", outFile, "-watch"}
+	if rc := (StatusCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestStatusPercent(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	tests := []struct {
+		args        []string
+		wantPercent bool
+	}{
+		{[]string{}, true},
+		{[]string{"-percent"}, true},
+		{[]string{"-percent=false"}, false},
+	}
+
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		rc := (StatusCmd{UI: ui}).Run(tc.args)
+		if rc != 0 {
+			t.Errorf("gtm status(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+		}
+		if !strings.Contains(ui.OutputWriter.String(), "[r] event/event.go") {
+			t.Errorf("gtm status(%+v), want '[r] event/event.go' got %s", tc.args, ui.OutputWriter.String())
+		}
+		if strings.Contains(ui.OutputWriter.String(), "1m  0s 100% [r]") != tc.wantPercent {
+			t.Errorf("gtm status(%+v), want percent %t got %s", tc.args, tc.wantPercent, ui.OutputWriter.String())
+		}
+	}
+}
+
+func TestStatusMin(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("event_test.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496863.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496923.event", project.GTMDir, filepath.Join("event", "event_test.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	ui := new(cli.MockUi)
+	c := StatusCmd{UI: ui}
+
+	args := []string{"-min", "90s"}
+	rc := c.Run(args)
+	if rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "event.go") || strings.Contains(ui.OutputWriter.String(), "event_test.go") {
+		t.Errorf("gtm status(%+v), want 'event.go' and not 'event_test.go' got %s", args, ui.OutputWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "3m  0s") {
+		t.Errorf("gtm status(%+v), want total '3m  0s' got %s", args, ui.OutputWriter.String())
+	}
+
+	args = []string{"-min", "30"}
+	if rc = c.Run(args); rc != ExitUsage {
+		t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestStatusDurationFormat(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496863.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{}, "2m  0s"},
+		{[]string{"-duration-format", "decimal"}, "0.03h"},
+		{[]string{"-duration-format", "decimal", "-duration-places", "3"}, "0.033h"},
+		{[]string{"-duration-format", "long"}, "2 minutes"},
+		{[]string{"-long-duration"}, "2 minutes"},
+	}
+
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		if rc := (StatusCmd{UI: ui}).Run(tc.args); rc != 0 {
+			t.Errorf("gtm status(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+			continue
+		}
+		if !strings.Contains(ui.OutputWriter.String(), tc.want) {
+			t.Errorf("gtm status(%+v), want %s got %s", tc.args, tc.want, ui.OutputWriter.String())
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-duration-format", "hours"},
+		{"-duration-format", "decimal", "-duration-places", "-1"},
+		{"-duration-format", "decimal", "-long-duration"},
+	} {
+		if rc := (StatusCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestStatusTop(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("event_test.go", "event", "")
+	repo.SaveFile("doc.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496863.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496923.event", project.GTMDir, filepath.Join("event", "event_test.go"))
+	repo.SaveFile("1458496983.event", project.GTMDir, filepath.Join("event", "doc.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	ui := new(cli.MockUi)
+	args := []string{"-top", "1"}
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "event.go") || strings.Contains(out, "event_test.go") || strings.Contains(out, "doc.go") {
+		t.Errorf("gtm status(%+v), want event.go only got %s", args, out)
+	}
+	if !strings.Contains(out, "2m  0s  50%     2 others") {
+		t.Errorf("gtm status(%+v), want others line got %s", args, out)
+	}
+	if !strings.Contains(out, "4m  0s") {
+		t.Errorf("gtm status(%+v), want total 4m  0s got %s", args, out)
+	}
+
+	ui = new(cli.MockUi)
+	args = []string{"-top", "0"}
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "doc.go") || strings.Contains(out, "others") {
+		t.Errorf("gtm status(%+v), want all files got %s", args, out)
+	}
+
+	args = []string{"-top", "-1"}
+	if rc := (StatusCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestStatusByDir(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("doc.go", filepath.Join("event", "sub"), "")
+	repo.SaveFile("main.go", "", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496863.event", project.GTMDir, filepath.Join("event", "sub", "doc.go"))
+	repo.SaveFile("1458496923.event", project.GTMDir, "main.go")
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	tests := []struct {
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{[]string{"-by", "dir"}, []string{"2m  0s  67%     event/\n", "1m  0s  33%     ./\n"}, []string{"event.go", "event/sub/"}},
+		{[]string{"-by", "dir", "-depth", "2"}, []string{"event/\n", "event/sub/\n", "./\n"}, []string{"doc.go"}},
+		{[]string{}, []string{"[r] event/event.go", "[r] event/sub/doc.go", "[r] main.go"}, []string{"event/\n"}},
+	}
+
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		if rc := (StatusCmd{UI: ui}).Run(tc.args); rc != 0 {
+			t.Errorf("gtm status(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+			continue
+		}
+		out := ui.OutputWriter.String()
+		for _, want := range tc.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("gtm status(%+v), want %s got %s", tc.args, want, out)
+			}
+		}
+		for _, notWant := range tc.notWant {
+			if strings.Contains(out, notWant) {
+				t.Errorf("gtm status(%+v), want no %s got %s", tc.args, notWant, out)
+			}
+		}
+	}
+
+	for _, args := range [][]string{{"-by", "file"}, {"-by", "dir", "-depth", "0"}} {
+		if rc := (StatusCmd{UI: new(cli.MockUi)}).Run(args); rc != ExitUsage {
+			t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+		}
+	}
+}
+
+func TestStatusColorTheme(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	defer os.Unsetenv("GTM_COLOR_FILE")
+	defer os.Unsetenv("GTM_COLOR_TOTAL")
+
+	ui := new(cli.MockUi)
+	args := []string{"-color"}
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "\033[1;97m") || strings.Contains(out, "\033[32m") {
+		t.Errorf("gtm status(%+v), want default colors got %q", args, out)
+	}
+
+	os.Setenv("GTM_COLOR_FILE", "32")
+	os.Setenv("GTM_COLOR_TOTAL", "none")
+	ui = new(cli.MockUi)
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Errorf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); strings.Contains(out, "\033[1;97m") || !strings.Contains(out, "\033[32m") {
+		t.Errorf("gtm status(%+v) GTM_COLOR_FILE=32 GTM_COLOR_TOTAL=none, want green files and no total color got %q", args, out)
+	}
+
+	os.Setenv("GTM_COLOR_FILE", "green")
+	if rc := (StatusCmd{UI: new(cli.MockUi)}).Run(args); rc != 1 {
+		t.Errorf("gtm status(%+v) GTM_COLOR_FILE=green, want 1 got %d", args, rc)
+	}
+}
+
+func TestStatusNoColor(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	defer os.Unsetenv("NO_COLOR")
+
+	tests := []struct {
+		args      []string
+		noColor   string
+		wantColor bool
+	}{
+		{[]string{"-color"}, "", true},
+		{[]string{"-color"}, "1", true},
+		{[]string{"-color", "-no-color"}, "", false},
+		{[]string{"-no-color"}, "", false},
+		{[]string{"-color", "-no-color"}, "1", false},
+	}
+
+	for _, tc := range tests {
+		os.Setenv("NO_COLOR", tc.noColor)
+		ui := new(cli.MockUi)
+		if rc := (StatusCmd{UI: ui}).Run(tc.args); rc != 0 {
+			t.Errorf("gtm status(%+v) NO_COLOR=%q, want 0 got %d, %s", tc.args, tc.noColor, rc, ui.ErrorWriter.String())
+		}
+		if got := strings.Contains(ui.OutputWriter.String(), "\033["); got != tc.wantColor {
+			t.Errorf("gtm status(%+v) NO_COLOR=%q, want color %t got %q", tc.args, tc.noColor, tc.wantColor, ui.OutputWriter.String())
+		}
+	}
+}
+
+func TestStatusInvalidOption(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := StatusCmd{UI: ui}
+
+	args := []string{"-invalid"}
+	rc := c.Run(args)
+
+	if rc != ExitUsage {
+		t.Errorf("gtm status(%+v), want 3 got %d, %s", args, rc, ui.ErrorWriter)
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "Usage:") {
+		t.Errorf("gtm status(%+v), want 'Usage:' got %d, %s", args, rc, ui.OutputWriter.String())
+	}
+}
+
+func TestStatusQuiet(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-q"}, ""},
+		{[]string{"-q", "-format", "json"}, `"total": 60`},
+	}
+	for _, tc := range tests {
+		ui := cli.NewMockUi()
+		if rc := (StatusCmd{UI: ui}).Run(tc.args); rc != 0 {
+			t.Errorf("gtm status(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+		}
+		got := ui.OutputWriter.String()
+		if (tc.want == "" && got != "") || !strings.Contains(got, tc.want) {
+			t.Errorf("gtm status(%+v), want output %q got %q", tc.args, tc.want, got)
+		}
+	}
+
+	args := []string{"-q", "-watch"}
+	ui := cli.NewMockUi()
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+	}
+
+	// errors are still printed
+	os.Chdir(os.TempDir())
+	args = []string{"-q"}
+	ui = cli.NewMockUi()
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != ExitNotInitialized || ui.ErrorWriter.String() == "" {
+		t.Errorf("gtm status(%+v) outside of a project, want 2 and an error got %d, %q", args, rc, ui.ErrorWriter.String())
+	}
+}
+
+func TestStatusDirtyOnly(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("clean.go", "event", "")
+	repo.SaveFile("dirty.go", "event", "")
+	repo.Commit(repo.Stage(filepath.Join("event", "clean.go"), filepath.Join("event", "dirty.go")))
+	repo.SaveFile("dirty.go", "event", "package event")
+	repo.SaveFile("new.go", "event", "")
+
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "clean.go"))
+	repo.SaveFile("1458496863.event", project.GTMDir, filepath.Join("event", "dirty.go"))
+	repo.SaveFile("1458496923.event", project.GTMDir, filepath.Join("event", "new.go"))
+
+	args := []string{"-dirty-only"}
+	ui := new(cli.MockUi)
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Fatalf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	for _, want := range []string{"dirty.go", "new.go", "2m  0s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("gtm status(%+v), want %s got %s", args, want, out)
+		}
+	}
+	if strings.Contains(out, "clean.go") {
+		t.Errorf("gtm status(%+v), want no clean.go got %s", args, out)
+	}
+}
+
+func TestStatusProjects(t *testing.T) {
+	projects := []string{}
+	for i := 0; i < 2; i++ {
+		repo := util.NewTestRepo(t, false)
+		defer repo.Remove()
+		repo.Seed()
+		os.Chdir(repo.Workdir())
+
+		repo.SaveFile("event.go", "event", "")
+		repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+		(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+		projects = append(projects, repo.Workdir())
+	}
+
+	missing := filepath.Join(os.TempDir(), "gtm-missing-project")
+	projectsFile := filepath.Join(os.TempDir(), "gtm-projects.txt")
+	defer os.Remove(projectsFile)
+	content := strings.Join([]string{projects[0], "", missing, projects[1]}, "\n")
+	util.CheckFatal(t, ioutil.WriteFile(projectsFile, []byte(content), 0644))
+
+	ui := cli.NewMockUi()
+	args := []string{"-format", "json", "-projects", projectsFile}
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != ExitNotInitialized {
+		t.Errorf("gtm status(%+v), want 2 for the missing project got %d", args, rc)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), missing) {
+		t.Errorf("gtm status(%+v), want error for %s got %s", args, missing, ui.ErrorWriter.String())
+	}
+
+	var got []struct {
+		Path  string
+		Total int
+	}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("gtm status(%+v), want valid json got %s, %s", args, err, ui.OutputWriter.String())
+	}
+	if len(got) != len(projects) {
+		t.Fatalf("gtm status(%+v), want %d projects got %+v", args, len(projects), got)
+	}
+	for i := range got {
+		if got[i].Total != 60 {
+			t.Errorf("gtm status(%+v), want 60s for each project got %+v", args, got)
+		}
+	}
+
+	args = []string{"-projects", projectsFile, "-all"}
+	if rc := (StatusCmd{UI: cli.NewMockUi()}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestStatusSinceLastCommit(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// a minute of time in 2010, before the test commit dates
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1262304000.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	total := func(args []string) int {
+		ui := new(cli.MockUi)
+		if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+			t.Fatalf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+		}
+		var got []struct{ Total int }
+		if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil || len(got) != 1 {
+			t.Fatalf("gtm status(%+v), want json for one project got %v, %s", args, err, ui.OutputWriter.String())
+		}
+		return got[0].Total
+	}
+
+	args := []string{"-format", "json", "-since-last-commit"}
+	if got := total(args); got != 60 {
+		t.Errorf("gtm status(%+v) without commits, want 60 got %d", args, got)
+	}
+
+	repo.Seed()
+	if got := total(args); got != 0 {
+		t.Errorf("gtm status(%+v) after a commit, want 0 got %d", args, got)
+	}
+
+	args = []string{"-since-last-commit", "-since", "09:00"}
+	if rc := (StatusCmd{UI: cli.NewMockUi()}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestStatusRange(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	// a minute committed for main.go and then util.go, a commit without time and a minute pending for other.go
+	for i, f := range []string{"main.go", "util.go"} {
+		repo.SaveFile(f, "", "")
+		repo.SaveFile(fmt.Sprintf("%d.event", 1458496803+i*3600), project.GTMDir, f)
+		repo.Commit(repo.Stage(f))
+		(CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"})
+	}
+	repo.Seed()
+	repo.SaveFile("other.go", "", "")
+	repo.SaveFile("1458508003.event", project.GTMDir, "other.go")
+
+	ui := new(cli.MockUi)
+	args := []string{"-range", "HEAD~2..HEAD"}
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Fatalf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	for _, want := range []string{"Pending", "other.go", "Committed HEAD~2..HEAD", "util.go", "This is a commit", "1m  0s          HEAD~2..HEAD total"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("gtm status(%+v), want %s got %s", args, want, out)
+		}
+	}
+	if strings.Contains(out, "main.go") || strings.Index(out, "Pending") > strings.Index(out, "HEAD~2..HEAD") {
+		t.Errorf("gtm status(%+v), want pending time followed by the time for the commits in the range got %s", args, out)
+	}
+
+	tests := []struct {
+		args   []string
+		wantRc int
+	}{
+		{[]string{"-range", "HEAD~2"}, ExitError},
+		{[]string{"-range", "HEAD~2..HEAD", "-format", "json"}, ExitUsage},
+		{[]string{"-range", "HEAD~2..HEAD", "-total-only"}, ExitUsage},
+	}
+	for _, tc := range tests {
+		if rc := (StatusCmd{UI: cli.NewMockUi()}).Run(tc.args); rc != tc.wantRc {
+			t.Errorf("gtm status(%+v), want %d got %d", tc.args, tc.wantRc, rc)
+		}
+	}
+}
+
+func TestStatusWidth(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	dir := filepath.Join("internal", "some", "deeply", "nested", "package")
+	repo.SaveFile("handler.go", dir, "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join(dir, "handler.go"))
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-width=0"}, filepath.Join(dir, "handler.go")},
+		{[]string{"-width=50"}, "internal/som.../handler.go"},
+		{[]string{"-width=50", "-percent=false"}, "internal/some/dee.../handler.go"},
+	}
+	for _, tc := range tests {
+		ui := new(cli.MockUi)
+		if rc := (StatusCmd{UI: ui}).Run(tc.args); rc != 0 {
+			t.Fatalf("gtm status(%+v), want 0 got %d, %s", tc.args, rc, ui.ErrorWriter.String())
+		}
+		if !strings.Contains(ui.OutputWriter.String(), filepath.FromSlash(tc.want)) {
+			t.Errorf("gtm status(%+v), want %s got %s", tc.args, tc.want, ui.OutputWriter.String())
+		}
+	}
+
+	args := []string{"-width=-1"}
+	if rc := (StatusCmd{UI: cli.NewMockUi()}).Run(args); rc != ExitUsage {
+		t.Errorf("gtm status(%+v), want 3 got %d", args, rc)
+	}
+}
+
+func TestStatusJSONSchema(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	ui := new(cli.MockUi)
+	args := []string{"-format=json-schema"}
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Fatalf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	type object struct {
+		Properties map[string]interface{}
+		Required   []string
+	}
+	var schema struct {
+		Version     int
+		Type        string
+		Definitions map[string]object
+	}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &schema); err != nil {
+		t.Fatalf("gtm status(%+v), want valid json got %s, %s", args, err, ui.OutputWriter.String())
+	}
+	if schema.Version != report.SchemaVersion || schema.Type != "array" {
+		t.Errorf("gtm status(%+v), want an array schema version %d got %+v", args, report.SchemaVersion, schema)
+	}
+
+	// the json output round trips through the schema's properties
+	ui = new(cli.MockUi)
+	args = []string{"-format=json", "-branch", "-detail"}
+	if rc := (StatusCmd{UI: ui}).Run(args); rc != 0 {
+		t.Fatalf("gtm status(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter.String())
+	}
+	var projects []map[string]json.RawMessage
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &projects); err != nil || len(projects) != 1 {
+		t.Fatalf("gtm status(%+v), want json for one project got %v, %s", args, err, ui.OutputWriter.String())
+	}
+	check := func(name string, values map[string]json.RawMessage) {
+		def, ok := schema.Definitions[name]
+		if !ok {
+			t.Fatalf("gtm status -format=json-schema, want definition %s got %+v", name, schema.Definitions)
+		}
+		for k := range values {
+			if _, ok := def.Properties[k]; !ok {
+				t.Errorf("gtm status(%+v), %s property %s is not in the schema", args, name, k)
+			}
+		}
+		for _, k := range def.Required {
+			if _, ok := values[k]; !ok {
+				t.Errorf("gtm status(%+v), required %s property %s is missing", args, name, k)
+			}
+		}
+	}
+	check("project", projects[0])
+	var files []map[string]json.RawMessage
+	util.CheckFatal(t, json.Unmarshal(projects[0]["files"], &files))
+	if len(files) == 0 {
+		t.Fatalf("gtm status(%+v), want files got %s", args, ui.OutputWriter.String())
+	}
+	for _, f := range files {
+		check("file", f)
 	}
 }
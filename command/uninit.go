@@ -32,18 +32,24 @@ Usage: gtm uninit [options]
 Options:
 
   -yes                       Turn off without asking for confirmation.
+  -q                         Only print errors
 `
 	return strings.TrimSpace(helpText)
 }
 
 // Run executes uninit command with args
 func (c UninitCmd) Run(args []string) int {
-	var yes bool
+	var yes, quiet bool
 	cmdFlags := flag.NewFlagSet("uninit", flag.ContinueOnError)
 	cmdFlags.BoolVar(&yes, "yes", false, "")
+	cmdFlags.BoolVar(&quiet, "q", false, "")
 	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
-		return 1
+		return ExitUsage
+	}
+
+	if quiet {
+		c.UI = quietUi{c.UI}
 	}
 
 	confirm := yes
@@ -52,7 +58,7 @@ func (c UninitCmd) Run(args []string) int {
 		response, err := c.UI.Ask("Remove GTM tracking for the current git repository (y/n)?")
 		if err != nil {
 			c.UI.Error(err.Error())
-			return 1
+			return exitCode(err)
 		}
 		confirm = strings.TrimSpace(strings.ToLower(response)) == "y"
 	}
@@ -64,7 +70,7 @@ func (c UninitCmd) Run(args []string) int {
 		)
 		if m, err = project.Uninitialize(); err != nil {
 			c.UI.Error(err.Error())
-			return 1
+			return exitCode(err)
 		}
 		c.UI.Output(m)
 	}
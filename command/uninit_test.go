@@ -56,8 +56,8 @@ func TestUninitInvalidOption(t *testing.T) {
 	args := []string{"-invalid"}
 	rc := c.Run(args)
 
-	if rc != 1 {
-		t.Errorf("gtm uninit(%+v), want 0 got %d, %s", args, rc, ui.ErrorWriter)
+	if rc != ExitUsage {
+		t.Errorf("gtm uninit(%+v), want 3 got %d, %s", args, rc, ui.ErrorWriter)
 	}
 	if !strings.Contains(ui.OutputWriter.String(), "Usage:") {
 		t.Errorf("gtm uninit(%+v), want 'Usage:'  got %d, %s", args, rc, ui.OutputWriter.String())
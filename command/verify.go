@@ -11,6 +11,10 @@ import (
 	"os"
 	"strings"
 
+	"github.com/git-time-metric/gtm/note"
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/report"
+	"github.com/git-time-metric/gtm/scm"
 	"github.com/hashicorp/go-version"
 	"github.com/mitchellh/cli"
 )
@@ -38,29 +42,67 @@ func (c VerifyCmd) output(s string) {
 func (c VerifyCmd) Help() string {
 	helpText := `
 Usage: gtm verify <version-constraint>
+       gtm verify -notes [options]
 
   Check if gtm satisfies a Semantic Version 2.0 constraint.
+
+  With -notes, check the time data saved in git notes for commits reachable from the head commit.
+  Entries that can not be parsed, entries with negative time and files not in the commit's tree
+  are reported. Exits with a non-zero status if problems are found.
+
+  With -notes -migrate, rewrite the git notes saved with an older version of the note format
+  in the latest version.
+
+Options:
+
+  -notes                     Check the time data saved in git notes instead of the gtm version.
+
+  -fix                       Remove entries that can not be parsed from the git notes.
+
+  -migrate                   Rewrite git notes saved with an older note format in the latest format.
+
+  -ref=""                    Git notes ref to check, i.e. -ref=refs/notes/gtm-billable
+                             Defaults to notes_ref in .gtm/config.json or refs/notes/gtm-data
 `
 	return strings.TrimSpace(helpText)
 }
 
 // Run executes verify commands with args
 func (c VerifyCmd) Run(args []string) int {
+	var (
+		notes   bool
+		fix     bool
+		migrate bool
+		ref     string
+	)
 	cmdFlags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	cmdFlags.BoolVar(&notes, "notes", false, "")
+	cmdFlags.BoolVar(&fix, "fix", false, "")
+	cmdFlags.BoolVar(&migrate, "migrate", false, "")
+	cmdFlags.StringVar(&ref, "ref", "", "")
 	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
-		return 1
+		return ExitUsage
 	}
 
-	if len(args) == 0 {
+	if notes {
+		return c.verifyNotes(fix, migrate, ref)
+	}
+
+	if fix || migrate || ref != "" {
+		c.UI.Error("The -fix, -migrate and -ref options require -notes")
+		return ExitUsage
+	}
+
+	if len(cmdFlags.Args()) == 0 {
 		c.UI.Error("Unable to verify version, version constraint not provided")
-		return 1
+		return ExitUsage
 	}
 
-	valid, err := c.check(args[0])
+	valid, err := c.check(cmdFlags.Args()[0])
 	if err != nil {
 		c.UI.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	// NOTE a newline is not sent when outputting to terminal.
@@ -76,6 +118,100 @@ func (c VerifyCmd) Synopsis() string {
 	return "Check if gtm satisfies a Semantic Version 2.0 constraint"
 }
 
+func (c VerifyCmd) verifyNotes(fix, migrate bool, ref string) int {
+	rootPath, gtmPath, err := project.Paths()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+
+	nameSpace := loadConfig(c.UI, gtmPath).NoteNameSpace()
+	if ref != "" {
+		notesRef, err := project.NotesRef(ref)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		nameSpace = strings.TrimPrefix(notesRef, "refs/notes/")
+	}
+
+	// no limits returns all commits reachable from head
+	commitIDs, err := scm.CommitIDs(scm.CommitLimiter{}, rootPath)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return exitCode(err)
+	}
+
+	var problemCnt, fixedCnt, migratedCnt int
+	for _, id := range commitIDs {
+		n, err := scm.ReadNote(id, nameSpace, false, rootPath)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		if n.Note == "" {
+			continue
+		}
+
+		if fix {
+			repaired, removed := note.Repair(n.Note)
+			if removed > 0 {
+				if err := scm.CreateNoteForCommit(id, repaired, nameSpace, rootPath); err != nil {
+					c.UI.Error(err.Error())
+					return exitCode(err)
+				}
+				c.output(fmt.Sprintf("%s %s: removed %d invalid entries\n", id[:7], n.Summary, removed))
+				fixedCnt += removed
+				n.Note = repaired
+			}
+		}
+
+		if migrate && note.NeedsMigration(n.Note) {
+			// notes that can not be unmarshalled are reported as problems below
+			if cn, err := note.UnMarshal(n.Note); err == nil {
+				migrated := note.Marshal(cn)
+				if err := scm.CreateNoteForCommit(id, migrated, nameSpace, rootPath); err != nil {
+					c.UI.Error(err.Error())
+					return exitCode(err)
+				}
+				c.output(fmt.Sprintf("%s %s: migrated to version %d\n", id[:7], n.Summary, note.Version))
+				migratedCnt++
+				n.Note = migrated
+			}
+		}
+
+		files, err := scm.TreeFiles(id, rootPath)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+		for _, p := range note.Verify(n.Note, files) {
+			c.output(fmt.Sprintf("%s %s: %s\n", id[:7], n.Summary, p))
+			problemCnt++
+		}
+	}
+
+	if fixedCnt > 0 || migratedCnt > 0 {
+		// cached notes for reports are out of date
+		if err := report.ClearCache(gtmPath); err != nil {
+			c.UI.Error(err.Error())
+			return exitCode(err)
+		}
+	}
+	if fixedCnt > 0 {
+		c.output(fmt.Sprintf("Removed %d invalid entries\n", fixedCnt))
+	}
+	if migratedCnt > 0 {
+		c.output(fmt.Sprintf("Migrated %d notes to version %d\n", migratedCnt, note.Version))
+	}
+	if problemCnt > 0 {
+		c.output(fmt.Sprintf("Found %d problems\n", problemCnt))
+		return ExitError
+	}
+	c.output("No problems found\n")
+	return 0
+}
+
 func (c VerifyCmd) check(constraint string) (bool, error) {
 	// Our version tags can have a 'v' prefix
 	// Strip v prefix if it exists because it's not valid for a Semantic version
@@ -6,8 +6,14 @@ package command
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/scm"
+	"github.com/git-time-metric/gtm/util"
 	"github.com/mitchellh/cli"
 )
 
@@ -57,3 +63,61 @@ func TestVerify(t *testing.T) {
 		t.Errorf("gtm verify(%+v), want '%s' got '%s', %s", args, want, ui.OutputWriter.String(), ui.ErrorWriter.String())
 	}
 }
+
+func TestVerifyNotes(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	(InitCmd{UI: new(cli.MockUi)}).Run([]string{})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	commitID := repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+
+	if rc := (CommitCmd{UI: new(cli.MockUi)}).Run([]string{"-yes"}); rc != 0 {
+		t.Fatalf("gtm commit(-yes), want 0 got %d", rc)
+	}
+
+	verify := func(args []string, wantRc int, want string) {
+		ui := new(cli.MockUi)
+		c := VerifyCmd{UI: ui, Version: "1.0.0", Out: new(bytes.Buffer)}
+		if rc := c.Run(args); rc != wantRc {
+			t.Errorf("gtm verify(%+v), want %d got %d, %s", args, wantRc, rc, ui.ErrorWriter.String())
+		}
+		if !strings.Contains(c.Out.String(), want) {
+			t.Errorf("gtm verify(%+v), want %s got %s", args, want, c.Out.String())
+		}
+	}
+
+	verify([]string{"-notes"}, 0, "No problems found")
+
+	noteTxt := "[ver:1,total:40]\nevent/event.go:60,1458496800:60,m\nbad-entry\nmissing.go:-20,1458496800:-20,r\n"
+	if err := scm.CreateNoteForCommit(commitID.String(), noteTxt, project.NoteNameSpace, repo.Workdir()); err != nil {
+		t.Fatalf("scm.CreateNoteForCommit, want error nil got %s", err)
+	}
+
+	verify([]string{"-notes"}, 1, "Found 3 problems")
+	verify([]string{"-notes", "-fix"}, 1, "Removed 1 invalid entries")
+	verify([]string{"-notes"}, 1, "Found 2 problems")
+	verify([]string{"-notes", "-ref", "gtm-billable"}, 0, "No problems found")
+	verify([]string{"-fix"}, ExitUsage, "")
+
+	// notes saved with the latest version are not rewritten
+	verify([]string{"-notes", "-migrate"}, 1, "Found 2 problems")
+	if c := (VerifyCmd{UI: new(cli.MockUi), Out: new(bytes.Buffer)}); c.Run([]string{"-notes", "-migrate"}) != 1 || strings.Contains(c.Out.String(), "Migrated") {
+		t.Errorf("gtm verify(-notes -migrate), want no notes migrated got %s", c.Out.String())
+	}
+	verify([]string{"-migrate"}, ExitUsage, "")
+
+	// notes saved by a newer gtm are reported and not removed
+	noteTxt = "[ver:99,total:60]\nevent/event.go:60,1458496800:60,m\n"
+	if err := scm.CreateNoteForCommit(commitID.String(), noteTxt, project.NoteNameSpace, repo.Workdir()); err != nil {
+		t.Fatalf("scm.CreateNoteForCommit, want error nil got %s", err)
+	}
+	verify([]string{"-notes", "-fix", "-migrate"}, 1, "version 99 is newer than version 1")
+	n, err := scm.ReadNote(commitID.String(), project.NoteNameSpace, false, repo.Workdir())
+	if err != nil || !strings.Contains(n.Note, "event/event.go:60") {
+		t.Errorf("gtm verify(-notes -fix -migrate), want note unchanged got %s, %v", n.Note, err)
+	}
+}
@@ -9,7 +9,9 @@ import "github.com/git-time-metric/gtm/util"
 // WindowSize is number seconds in an epoch window
 const WindowSize = 60
 
-// IdleTimeout is the number of seconds to record idle events for
+// IdleTimeout is the default number of seconds between events to record idle events for,
+// gaps between events longer than IdleTimeout are not counted. Projects may override it
+// with idle_timeout in their config file.
 var IdleTimeout int64 = 120
 
 // Minute rounds epoch seconds down to the nearst epoch minute
@@ -17,6 +19,11 @@ func Minute(t int64) int64 {
 	return (t / int64(WindowSize)) * WindowSize
 }
 
+// Window rounds epoch seconds down to the nearest window of size seconds
+func Window(t, size int64) int64 {
+	return (t / size) * size
+}
+
 // MinuteNow returns the epoch minute for the current time
 func MinuteNow() int64 {
 	return Minute(util.Now().Unix())
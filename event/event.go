@@ -15,12 +15,26 @@ import (
 	"github.com/git-time-metric/gtm/project"
 )
 
+// Entry is an event for a source file at an epoch, the source file's path is relative to the project's root
+type Entry struct {
+	Epoch      int64
+	SourcePath string
+}
+
+// Source paths of the events recorded when the screen is locked and unlocked, they are not source files.
+// Time is not counted from a lock event until an unlock event, see Windows.
+const (
+	LockSource   = ".gtm/screen.lock"
+	UnlockSource = ".gtm/screen.unlock"
+)
+
 func pathFromSource(f string) (string, string, error) {
 	if fileInfo, err := os.Stat(f); os.IsNotExist(err) || fileInfo.IsDir() {
 		return "", "", project.ErrFileNotFound
 	}
 
-	repoPath, gtmPath, err := project.Paths(filepath.Dir(f))
+	// a file in a submodule that's not initialized is tracked in the superproject
+	repoPath, gtmPath, err := project.SourcePaths(filepath.Dir(f))
 	if err != nil {
 		return "", "", err
 	}
@@ -30,6 +44,11 @@ func pathFromSource(f string) (string, string, error) {
 		return "", "", err
 	}
 
+	// event files are written to the data directory, by default the gtm directory
+	if gtmPath, err = project.DataPath(repoPath); err != nil {
+		return "", "", err
+	}
+
 	return sourcePath, gtmPath, nil
 }
 
@@ -50,7 +69,8 @@ func readEventFile(filePath string) (string, error) {
 	return strings.Replace(string(b), "\n", "", -1), nil
 }
 
-func removeFiles(files []string) error {
+// RemoveFiles removes the event files, it's used to purge events once they are processed
+func RemoveFiles(files []string) error {
 	for _, file := range files {
 		if err := os.Remove(file); err != nil {
 			return err
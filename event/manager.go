@@ -8,10 +8,13 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/git-time-metric/gtm/epoch"
+	"github.com/git-time-metric/gtm/project"
 	"github.com/git-time-metric/gtm/util"
 )
 
@@ -25,21 +28,64 @@ func Record(file string) error {
 	return writeEventFile(sourcePath, gtmPath)
 }
 
+// RecordLock creates an event for the screen being locked, or unlocked if locked is false,
+// for the project in projPath or the working directory if projPath is not provided
+func RecordLock(locked bool, projPath ...string) error {
+	rootPath, _, err := project.Paths(projPath...)
+	if err != nil {
+		return err
+	}
+
+	// event files are written to the data directory, by default the gtm directory
+	dataPath, err := project.DataPath(rootPath)
+	if err != nil {
+		return err
+	}
+
+	if locked {
+		return writeEventFile(LockSource, dataPath)
+	}
+	return writeEventFile(UnlockSource, dataPath)
+}
+
 // Process scans the gtmPath for event files and processes them.
 // If interim is true, event files are not purged.
-func Process(gtmPath string, interim bool) (map[int64]map[string]int, error) {
+// Events are bucketed into windows of windowSize seconds.
+// Idle events are added between consecutive events that are within idleTimeout seconds of each other,
+// when the gap between events exceeds idleTimeout the span is not counted towards any file.
+// Time while the screen is locked is not counted, see Windows.
+//
+// Deprecated: Process only reads the event files, metric.Process reads them with the other event
+// sources through the metric.EventSource pipeline. Use Read and Windows to read the event files.
+func Process(gtmPath string, interim bool, idleTimeout, windowSize int64) (map[int64]map[string]int, error) {
 	defer util.Profile()()
 
-	events := make(map[int64]map[string]int)
+	entries, files, err := Read(gtmPath)
+	if err != nil {
+		return make(map[int64]map[string]int), err
+	}
+
+	events := Windows(entries, idleTimeout, windowSize)
+
+	if !interim {
+		if err := RemoveFiles(files); err != nil {
+			return events, err
+		}
+	}
 
+	return events, nil
+}
+
+// Read returns the events in the event files in gtmPath and the event files that were read,
+// the files are removed once the events are saved. Event files that can not be read are removed.
+func Read(gtmPath string) ([]Entry, []string, error) {
 	files, err := ioutil.ReadDir(gtmPath)
 	if err != nil {
-		return events, err
+		return []Entry{}, []string{}, err
 	}
 
-	filesToRemove := []string{}
-	var prevEpoch int64
-	var prevFilePath string
+	filesRead := []string{}
+	entries := []Entry{}
 	for i := range files {
 
 		if !strings.HasSuffix(files[i].Name(), ".event") {
@@ -47,7 +93,7 @@ func Process(gtmPath string, interim bool) (map[int64]map[string]int, error) {
 		}
 
 		eventFilePath := filepath.Join(gtmPath, files[i].Name())
-		filesToRemove = append(filesToRemove, eventFilePath)
+		filesRead = append(filesRead, eventFilePath)
 
 		s := strings.SplitN(files[i].Name(), ".", 2)
 		if len(s) != 2 {
@@ -58,7 +104,6 @@ func Process(gtmPath string, interim bool) (map[int64]map[string]int, error) {
 		if err != nil {
 			continue
 		}
-		fileEpoch = epoch.Minute(fileEpoch)
 
 		sourcePath, err := readEventFile(eventFilePath)
 		if err != nil {
@@ -67,29 +112,125 @@ func Process(gtmPath string, interim bool) (map[int64]map[string]int, error) {
 			continue
 		}
 
-		if _, ok := events[fileEpoch]; !ok {
-			events[fileEpoch] = make(map[string]int)
+		entries = append(entries, Entry{Epoch: fileEpoch, SourcePath: sourcePath})
+	}
+
+	return entries, filesRead, nil
+}
+
+// Windows buckets the events into windows of windowSize seconds and counts the events for each source file
+// in each window. Idle events are added between consecutive events that are within idleTimeout seconds of
+// each other, when the gap between events exceeds idleTimeout the span is not counted towards any file.
+// The screen is locked from a LockSource event until an UnlockSource event, events while it's locked are
+// dropped and idle events are not added for the span regardless of idleTimeout.
+func Windows(entries []Entry, idleTimeout, windowSize int64) map[int64]map[string]int {
+	events := make(map[int64]map[string]int)
+
+	// event files are listed by file name, order by epoch in case of
+	// epochs with a differing number of digits or events recorded out of order
+	sorted := append([]Entry{}, entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Epoch < sorted[j].Epoch })
+
+	windowed := []Entry{}
+	locked := false
+	for _, e := range sorted {
+		switch e.SourcePath {
+		case LockSource:
+			locked = true
+		case UnlockSource:
+			locked = false
+		default:
+			if locked {
+				continue
+			}
+		}
+		windowed = append(windowed, Entry{Epoch: epoch.Window(e.Epoch, windowSize), SourcePath: e.SourcePath})
+	}
+
+	var prevEpoch int64
+	var prevFilePath string
+	for _, e := range windowed {
+		isLock := e.SourcePath == LockSource || e.SourcePath == UnlockSource
+		if !isLock {
+			if _, ok := events[e.Epoch]; !ok {
+				events[e.Epoch] = make(map[string]int)
+			}
+			events[e.Epoch][e.SourcePath]++
 		}
-		events[fileEpoch][sourcePath]++
 
-		// Add idle events
-		if prevEpoch != 0 && prevFilePath != "" {
-			for e := prevEpoch + epoch.WindowSize; e < fileEpoch && e <= prevEpoch+epoch.IdleTimeout; e += epoch.WindowSize {
-				if _, ok := events[e]; !ok {
-					events[e] = make(map[string]int)
+		// Add idle events, skip if the gap is beyond the idle timeout
+		if prevEpoch != 0 && prevFilePath != "" && e.Epoch-prevEpoch <= idleTimeout {
+			for ep := prevEpoch + windowSize; ep < e.Epoch; ep += windowSize {
+				if _, ok := events[ep]; !ok {
+					events[ep] = make(map[string]int)
 				}
-				events[e][prevFilePath]++
+				events[ep][prevFilePath]++
 			}
 		}
-		prevEpoch = fileEpoch
-		prevFilePath = sourcePath
+		prevEpoch = e.Epoch
+		prevFilePath = e.SourcePath
+		// time up to a lock is counted, time after it isn't
+		if isLock {
+			prevFilePath = ""
+		}
 	}
 
-	if !interim {
-		if err := removeFiles(filesToRemove); err != nil {
-			return events, err
+	return events
+}
+
+// Debounce returns the events in epoch order without the events for a file that are less than debounce after the
+// file's previous event that was kept, if no other event is between them. A run of events for the same file
+// keeps an event at least every debounce so the time it spans is still counted, the time lost is less than
+// debounce at the end of a run. Lock and unlock events are always kept. Events are not coalesced if debounce
+// is not positive.
+func Debounce(entries []Entry, debounce time.Duration) []Entry {
+	sorted := append([]Entry{}, entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Epoch < sorted[j].Epoch })
+	if debounce <= 0 {
+		return sorted
+	}
+
+	kept := []Entry{}
+	for _, e := range sorted {
+		if len(kept) > 0 {
+			prev := kept[len(kept)-1]
+			isLock := e.SourcePath == LockSource || e.SourcePath == UnlockSource
+			if !isLock && e.SourcePath == prev.SourcePath && time.Duration(e.Epoch-prev.Epoch)*time.Second < debounce {
+				continue
+			}
 		}
+		kept = append(kept, e)
 	}
+	return kept
+}
 
-	return events, nil
+// Route moves the event files in gtmPath for source files in the subdirectory dir to toGtmPath,
+// source paths are made relative to dir. It moves time in a submodule to the submodule's project.
+func Route(gtmPath, dir, toGtmPath string) error {
+	files, err := ioutil.ReadDir(gtmPath)
+	if err != nil {
+		return err
+	}
+
+	prefix := filepath.Clean(dir) + string(filepath.Separator)
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".event") {
+			continue
+		}
+
+		eventFilePath := filepath.Join(gtmPath, f.Name())
+		sourcePath, err := readEventFile(eventFilePath)
+		if err != nil || !strings.HasPrefix(filepath.Clean(sourcePath), prefix) {
+			continue
+		}
+
+		routed := strings.TrimPrefix(filepath.Clean(sourcePath), prefix)
+		if err := ioutil.WriteFile(filepath.Join(toGtmPath, f.Name()), []byte(routed), 0644); err != nil {
+			return err
+		}
+		if err := os.Remove(eventFilePath); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -11,7 +11,9 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/git-time-metric/gtm/epoch"
 	"github.com/git-time-metric/gtm/project"
 	"github.com/git-time-metric/gtm/util"
 )
@@ -90,7 +92,7 @@ func TestProcess(t *testing.T) {
 	workdir := repo.Workdir()
 	gtmPath := filepath.Join(workdir, project.GTMDir)
 
-	got, err := Process(gtmPath, true)
+	got, err := Process(gtmPath, true, epoch.IdleTimeout, epoch.WindowSize)
 	if err != nil {
 		t.Fatalf("Process(%s, %s, true), want error nil, got %s", workdir, gtmPath, err)
 	}
@@ -98,7 +100,7 @@ func TestProcess(t *testing.T) {
 		t.Errorf("Process(%s, %s, true)\nwant:\n%+v\ngot:\n%+v\n", workdir, gtmPath, expected, got)
 	}
 
-	got, err = Process(gtmPath, false)
+	got, err = Process(gtmPath, false, epoch.IdleTimeout, epoch.WindowSize)
 	if err != nil {
 		t.Fatalf("Process(%s, %s, true), want error nil, got %s", workdir, gtmPath, err)
 	}
@@ -113,3 +115,184 @@ func TestProcess(t *testing.T) {
 		t.Fatalf("Process(%s, %s, true), want file count 0, got %d", workdir, gtmPath, len(files))
 	}
 }
+
+func TestProcessIdleTimeout(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+
+	curDir, err := os.Getwd()
+	util.CheckFatal(t, err)
+	defer os.Chdir(curDir)
+
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("event_test.go", "event", "")
+	// gap of 120 seconds, within the idle timeout
+	repo.SaveFile("1458496800.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496920.event", project.GTMDir, filepath.Join("event", "event.go"))
+	// gap of 180 seconds, exceeds the idle timeout
+	repo.SaveFile("1458497100.event", project.GTMDir, filepath.Join("event", "event_test.go"))
+	// out of order epoch, sorts after the other event files by file name
+	repo.SaveFile("999999960.event", project.GTMDir, filepath.Join("event", "event_test.go"))
+
+	expected := map[int64]map[string]int{
+		int64(999999960):  {filepath.Join("event", "event_test.go"): 1},
+		int64(1458496800): {filepath.Join("event", "event.go"): 1},
+		int64(1458496860): {filepath.Join("event", "event.go"): 1},
+		int64(1458496920): {filepath.Join("event", "event.go"): 1},
+		int64(1458497100): {filepath.Join("event", "event_test.go"): 1},
+	}
+
+	gtmPath := filepath.Join(repo.Workdir(), project.GTMDir)
+
+	got, err := Process(gtmPath, true, 120, epoch.WindowSize)
+	if err != nil {
+		t.Fatalf("Process(%s, true, 120), want error nil, got %s", gtmPath, err)
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Process(%s, true, 120)\nwant:\n%+v\ngot:\n%+v\n", gtmPath, expected, got)
+	}
+
+	expected[int64(1458496980)] = map[string]int{filepath.Join("event", "event.go"): 1}
+	expected[int64(1458497040)] = map[string]int{filepath.Join("event", "event.go"): 1}
+
+	got, err = Process(gtmPath, true, 180, epoch.WindowSize)
+	if err != nil {
+		t.Fatalf("Process(%s, true, 180), want error nil, got %s", gtmPath, err)
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Process(%s, true, 180)\nwant:\n%+v\ngot:\n%+v\n", gtmPath, expected, got)
+	}
+}
+
+func TestProcessScreenLock(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+
+	curDir, err := os.Getwd()
+	util.CheckFatal(t, err)
+	defer os.Chdir(curDir)
+
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("event_test.go", "event", "")
+	repo.SaveFile("1458496800.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496860.event", project.GTMDir, filepath.Join("event", "event.go"))
+	// the screen is locked for several file events, all within the idle timeout
+	repo.SaveFile("1458496870.event", project.GTMDir, LockSource)
+	repo.SaveFile("1458496920.event", project.GTMDir, filepath.Join("event", "event_test.go"))
+	repo.SaveFile("1458496980.event", project.GTMDir, filepath.Join("event", "event_test.go"))
+	repo.SaveFile("1458497000.event", project.GTMDir, UnlockSource)
+	repo.SaveFile("1458497100.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458497160.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	expected := map[int64]map[string]int{
+		int64(1458496800): {filepath.Join("event", "event.go"): 1},
+		int64(1458496860): {filepath.Join("event", "event.go"): 1},
+		int64(1458497100): {filepath.Join("event", "event.go"): 1},
+		int64(1458497160): {filepath.Join("event", "event.go"): 1},
+	}
+
+	gtmPath := filepath.Join(repo.Workdir(), project.GTMDir)
+
+	got, err := Process(gtmPath, true, 300, epoch.WindowSize)
+	if err != nil {
+		t.Fatalf("Process(%s, true, 300), want error nil, got %s", gtmPath, err)
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Process(%s, true, 300)\nwant:\n%+v\ngot:\n%+v\n", gtmPath, expected, got)
+	}
+}
+
+func TestProcessWindowSize(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+
+	curDir, err := os.Getwd()
+	util.CheckFatal(t, err)
+	defer os.Chdir(curDir)
+
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("event_test.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496811.event", project.GTMDir, filepath.Join("event", "event_test.go"))
+	repo.SaveFile("1458496835.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458496943.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	// 30 second windows attribute the first minute's events to separate files
+	expected := map[int64]map[string]int{
+		int64(1458496800): {filepath.Join("event", "event.go"): 1, filepath.Join("event", "event_test.go"): 1},
+		int64(1458496830): {filepath.Join("event", "event.go"): 1},
+		int64(1458496860): {filepath.Join("event", "event.go"): 1},
+		int64(1458496890): {filepath.Join("event", "event.go"): 1},
+		int64(1458496920): {filepath.Join("event", "event.go"): 1},
+	}
+
+	gtmPath := filepath.Join(repo.Workdir(), project.GTMDir)
+
+	got, err := Process(gtmPath, true, epoch.IdleTimeout, 30)
+	if err != nil {
+		t.Fatalf("Process(%s, true, %d, 30), want error nil, got %s", gtmPath, epoch.IdleTimeout, err)
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Process(%s, true, %d, 30)\nwant:\n%+v\ngot:\n%+v\n", gtmPath, epoch.IdleTimeout, expected, got)
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	a := filepath.Join("event", "event.go")
+	b := filepath.Join("event", "event_test.go")
+
+	// a save every second for 10 minutes, then another file and a lock
+	entries := []Entry{}
+	for ep := int64(1458496800); ep < 1458497400; ep++ {
+		entries = append(entries, Entry{Epoch: ep, SourcePath: a})
+	}
+	entries = append(entries,
+		Entry{Epoch: 1458497400, SourcePath: b},
+		Entry{Epoch: 1458497401, SourcePath: a},
+		Entry{Epoch: 1458497401, SourcePath: LockSource},
+		Entry{Epoch: 1458497402, SourcePath: UnlockSource})
+
+	if got := Debounce(entries, 0); !reflect.DeepEqual(got, entries) {
+		t.Errorf("Debounce(0), want all events got %d of %d", len(got), len(entries))
+	}
+
+	got := Debounce(entries, 5*time.Second)
+	// one in five of the saves is kept, events for another file and locks are not coalesced
+	if len(got) != 124 {
+		t.Errorf("Debounce(5s), want 124 events got %d", len(got))
+	}
+	if got[len(got)-4].SourcePath != b || got[len(got)-3].SourcePath != a ||
+		got[len(got)-2].SourcePath != LockSource || got[len(got)-1].SourcePath != UnlockSource {
+		t.Errorf("Debounce(5s), want the other file's event, the save after it and the lock events kept got %+v", got[len(got)-4:])
+	}
+
+	// coalescing preserves the time counted within the window size
+	count := func(events map[int64]map[string]int) int {
+		n := 0
+		for _, files := range events {
+			for range files {
+				n++
+			}
+		}
+		return n
+	}
+	for _, windowSize := range []int64{epoch.WindowSize, 30} {
+		want := count(Windows(entries, epoch.IdleTimeout, windowSize))
+		coalesced := count(Windows(got, epoch.IdleTimeout, windowSize))
+		if coalesced > want || want-coalesced > 1 {
+			t.Errorf("Debounce(5s) window size %d, want %d windows with time within one window got %d", windowSize, want, coalesced)
+		}
+	}
+
+	// a debounce of less than a second only coalesces events in the same second
+	same := []Entry{{Epoch: 1458496800, SourcePath: a}, {Epoch: 1458496800, SourcePath: a}, {Epoch: 1458496801, SourcePath: a}}
+	if got := Debounce(same, 500*time.Millisecond); len(got) != 2 {
+		t.Errorf("Debounce(500ms), want 2 events got %+v", got)
+	}
+}
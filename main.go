@@ -20,8 +20,14 @@ func main() {
 	profileFunc := util.Profile(fmt.Sprintf("%+v", os.Args))
 	util.Debug.Printf("%+v", os.Args)
 	ui := &cli.ColoredUi{ErrorColor: cli.UiColorRed, Ui: &cli.BasicUi{Writer: os.Stdout, Reader: os.Stdin}}
+	// -C, -data-dir and -git options are handled for all commands before the command is run
+	args, err := command.GlobalOptions(os.Args[1:])
+	if err != nil {
+		ui.Error(err.Error())
+		os.Exit(command.ExitUsage)
+	}
 	c := cli.NewCLI("gtm", Version)
-	c.Args = os.Args[1:]
+	c.Args = args
 	c.Commands = map[string]cli.CommandFactory{
 		"init": func() (cli.Command, error) {
 			return &command.InitCmd{
@@ -40,7 +46,8 @@ func main() {
 		},
 		"report": func() (cli.Command, error) {
 			return &command.ReportCmd{
-				UI: ui,
+				UI:      ui,
+				Version: Version,
 			}, nil
 		},
 		"status": func() (cli.Command, error) {
@@ -64,6 +71,36 @@ func main() {
 				UI: ui,
 			}, nil
 		},
+		"compact": func() (cli.Command, error) {
+			return &command.CompactCmd{
+				UI: ui,
+			}, nil
+		},
+		"import": func() (cli.Command, error) {
+			return &command.ImportCmd{
+				UI: ui,
+			}, nil
+		},
+		"hooks": func() (cli.Command, error) {
+			return &command.HooksCmd{
+				UI: ui,
+			}, nil
+		},
+		"metrics": func() (cli.Command, error) {
+			return &command.MetricsCmd{
+				UI: ui,
+			}, nil
+		},
+		"doctor": func() (cli.Command, error) {
+			return &command.DoctorCmd{
+				UI: ui,
+			}, nil
+		},
+		"stash": func() (cli.Command, error) {
+			return &command.StashCmd{
+				UI: ui,
+			}, nil
+		},
 	}
 
 	exitStatus, err := c.Run()
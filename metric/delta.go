@@ -0,0 +1,64 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package metric
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/scm"
+	"github.com/git-time-metric/gtm/util"
+)
+
+// deltaFile records the pending time last reported by Delta and the head commit at the time,
+// it's kept in the project's data directory
+const deltaFile = "delta.json"
+
+// Delta returns the pending seconds logged since Delta was last called for the project and saves pending as the
+// last reported time. All of the pending seconds are returned the first time. The last reported time is reset when
+// the head commit changes or pending is less than it, i.e. the time was committed, and all of the pending seconds
+// are returned.
+func Delta(pending int, projPath ...string) (int, error) {
+	rootPath, _, err := project.Paths(projPath...)
+	if err != nil {
+		return 0, err
+	}
+	dataPath, err := project.DataPath(rootPath)
+	if err != nil {
+		return 0, err
+	}
+	head, err := scm.HeadCommit(rootPath)
+	if err != nil {
+		return 0, err
+	}
+
+	// the head commit is blank until the project has commits
+	last := struct {
+		Commit  string `json:"commit"`
+		Pending int    `json:"pending"`
+	}{}
+	statePath := filepath.Join(dataPath, deltaFile)
+	delta := pending
+	if b, err := ioutil.ReadFile(statePath); err == nil {
+		if err := json.Unmarshal(b, &last); err == nil && last.Commit == head.ID && pending >= last.Pending {
+			delta = pending - last.Pending
+		}
+	} else if !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	last.Commit, last.Pending = head.ID, pending
+	b, err := json.Marshal(last)
+	if err != nil {
+		return 0, err
+	}
+	if err := util.WriteFileAtomic(statePath, b, 0644); err != nil {
+		return 0, err
+	}
+	return delta, nil
+}
@@ -5,6 +5,10 @@
 package metric
 
 import (
+	"context"
+	"fmt"
+	"path/filepath"
+
 	"github.com/git-time-metric/gtm/event"
 	"github.com/git-time-metric/gtm/note"
 	"github.com/git-time-metric/gtm/project"
@@ -14,43 +18,57 @@ import (
 
 // Process events for last git commit and save time spent as a git note
 // If interim is true, process events for the current working and staged files
-func Process(interim bool, projPath ...string) (note.CommitNote, error) {
+// Events are bucketed into windows of the config's granularity, config is the project's config,
+// see project.ReadConfig, with any command options that take precedence over it applied
+func Process(interim bool, config project.Config, projPath ...string) (note.CommitNote, error) {
+	return ProcessContext(context.Background(), interim, config, projPath...)
+}
+
+// ProcessContext is like Process but stops when ctx is canceled, the error wraps ctx.Err().
+// Event and metric files are not changed if it's canceled, once saving time in a git note
+// starts it's not canceled so time is not lost.
+func ProcessContext(ctx context.Context, interim bool, config project.Config, projPath ...string) (note.CommitNote, error) {
 	defer util.Profile()()
 
-	rootPath, gtmPath, err := project.Paths(projPath...)
-	if err != nil {
+	canceled := func() error {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("Unable to process events, %w", err)
+		}
+		return nil
+	}
+	if err := canceled(); err != nil {
 		return note.CommitNote{}, err
 	}
 
-	// load any saved metrics
-	metricMap, err := loadMetrics(gtmPath)
+	rootPath, _, err := project.Paths(projPath...)
 	if err != nil {
 		return note.CommitNote{}, err
 	}
 
-	// process event files
-	epochEventMap, err := event.Process(gtmPath, interim)
+	// events and metrics are kept in the data directory, by default the gtm directory
+	dataPath, err := project.DataPath(rootPath)
 	if err != nil {
 		return note.CommitNote{}, err
 	}
 
-	// allocate time for events
-	for ep := range epochEventMap {
-		err := allocateTime(ep, metricMap, epochEventMap[ep])
-		if err != nil {
-			return note.CommitNote{}, err
-		}
+	metricMap, err := pendingMetrics(rootPath, dataPath, config, interim, canceled)
+	if err != nil {
+		return note.CommitNote{}, err
 	}
 
 	var commitNote note.CommitNote
 
 	if interim {
+		if err := canceled(); err != nil {
+			return note.CommitNote{}, err
+		}
+
 		commitMap, readonlyMap, err := buildInterimCommitMaps(metricMap, projPath...)
 		if err != nil {
 			return note.CommitNote{}, err
 		}
 
-		commitNote, err = buildCommitNote(rootPath, commitMap, readonlyMap)
+		commitNote, err = buildCommitNote(rootPath, commitMap, readonlyMap, config.WindowSize(), config.IdleTimeout)
 		if err != nil {
 			return note.CommitNote{}, err
 		}
@@ -61,18 +79,94 @@ func Process(interim bool, projPath ...string) (note.CommitNote, error) {
 			return note.CommitNote{}, err
 		}
 
-		commitNote, err = buildCommitNote(rootPath, commitMap, readonlyMap)
+		commitNote, err = buildCommitNote(rootPath, commitMap, readonlyMap, config.WindowSize(), config.IdleTimeout)
 		if err != nil {
 			return note.CommitNote{}, err
 		}
 
-		if err := scm.CreateNote(note.Marshal(commitNote), project.NoteNameSpace); err != nil {
+		// the time is appended to the head commit's note so time saved earlier for the commit is kept
+		head, err := scm.HeadCommit(rootPath)
+		if err != nil {
 			return note.CommitNote{}, err
 		}
-		if err := saveAndPurgeMetrics(gtmPath, metricMap, commitMap, readonlyMap); err != nil {
+		if head.ID == "" {
+			return note.CommitNote{}, scm.ErrHeadUnborn
+		}
+		if err := note.AppendCommitNoteToRef(rootPath, config.NoteNameSpace(), head.ID, commitNote); err != nil {
+			return note.CommitNote{}, err
+		}
+		if err := saveAndPurgeMetrics(dataPath, metricMap, commitMap, readonlyMap); err != nil {
 			return note.CommitNote{}, err
 		}
 	}
 
 	return commitNote, nil
 }
+
+// pendingMetrics returns the saved metrics with the time of the events allocated to them, the events are purged
+// unless interim. The metrics are not saved, see saveAndPurgeMetrics. Events are not purged if canceled returns an error.
+func pendingMetrics(rootPath, dataPath string, config project.Config, interim bool, canceled func() error) (map[string]FileMetric, error) {
+	// load any saved metrics
+	metricMap, err := loadMetrics(dataPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// events are purged when they are processed unless interim
+	if err := canceled(); err != nil {
+		return nil, err
+	}
+
+	// time in initialized submodules is saved in the submodules' notes
+	if err := routeSubmodules(rootPath, dataPath); err != nil {
+		return nil, err
+	}
+
+	// read events from the event files and the other event sources
+	entries, purges, err := readEvents(rootPath, dataPath, config, interim)
+	if err != nil {
+		return nil, err
+	}
+	epochEventMap := event.Windows(entries, config.IdleTimeout, config.WindowSize())
+	if !interim {
+		for _, purge := range purges {
+			if err := purge(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	filterUntracked(epochEventMap, config)
+
+	// allocate time for events
+	active := lastActive(entries, config.WindowSize())
+	for ep := range epochEventMap {
+		err := allocateWindow(ep, metricMap, epochEventMap[ep], active, config)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return metricMap, nil
+}
+
+// routeSubmodules moves events for files in the project's initialized submodules to the submodules'
+// data paths if the project tracks submodules, i.e. events recorded before a submodule was initialized
+func routeSubmodules(rootPath, dataPath string) error {
+	subs, err := project.Submodules(rootPath)
+	if err != nil {
+		return err
+	}
+	for _, s := range subs {
+		subDataPath, err := project.DataPath(s)
+		if err != nil {
+			return err
+		}
+		dir, err := filepath.Rel(rootPath, s)
+		if err != nil {
+			return err
+		}
+		if err := event.Route(dataPath, dir, subDataPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
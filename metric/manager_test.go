@@ -5,11 +5,15 @@
 package metric
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/git-time-metric/gtm/note"
 	"github.com/git-time-metric/gtm/project"
 	"github.com/git-time-metric/gtm/scm"
 	"github.com/git-time-metric/gtm/util"
@@ -35,7 +39,7 @@ func TestFullCommit(t *testing.T) {
 	treeID := repo.Stage(filepath.Join("event", "event.go"), filepath.Join("event", "event_test.go"))
 	commitID := repo.Commit(treeID)
 
-	_, err = Process(false)
+	_, err = Process(false, project.ReadConfig(project.GTMDir))
 	if err != nil {
 		t.Fatalf("Process(false) - test full commit, want error nil, got %s", err)
 	}
@@ -54,6 +58,42 @@ func TestFullCommit(t *testing.T) {
 	}
 }
 
+func TestCommitAppendsNote(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+
+	curDir, err := os.Getwd()
+	util.CheckFatal(t, err)
+	defer os.Chdir(curDir)
+
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	commitID := repo.Commit(repo.Stage(filepath.Join("event", "event.go")))
+
+	if _, err := Process(false, project.ReadConfig(project.GTMDir)); err != nil {
+		t.Fatalf("Process(false), want error nil, got %s", err)
+	}
+
+	// time processed again for the same head commit is added to its note
+	repo.SaveFile("1458500403.event", project.GTMDir, filepath.Join("event", "event.go"))
+	if _, err := Process(false, project.ReadConfig(project.GTMDir)); err != nil {
+		t.Fatalf("Process(false) with a note for the head commit, want error nil, got %s", err)
+	}
+
+	n, err := scm.ReadNote(commitID.String(), "gtm-data", false)
+	util.CheckFatal(t, err)
+	if blocks := strings.Count(n.Note, "[ver:"); blocks != 2 {
+		t.Errorf("Process(false) twice, want 2 note blocks got %d, %s", blocks, n.Note)
+	}
+	cn, err := note.UnMarshal(n.Note)
+	util.CheckFatal(t, err)
+	if cn.Total() != 120 {
+		t.Errorf("Process(false) twice, want total 120 got %d, %s", cn.Total(), n.Note)
+	}
+}
+
 func TestPartialCommit(t *testing.T) {
 	repo := util.NewTestRepo(t, false)
 	defer repo.Remove()
@@ -78,7 +118,7 @@ func TestPartialCommit(t *testing.T) {
 	treeID = repo.Stage(filepath.Join("event", "event_test.go"))
 	commitID = repo.Commit(treeID)
 
-	_, err = Process(false)
+	_, err = Process(false, project.ReadConfig(project.GTMDir))
 	if err != nil {
 		t.Fatalf("Process(false) - test full commit, want error nil, got %s", err)
 	}
@@ -116,7 +156,7 @@ func TestInterim(t *testing.T) {
 	treeID := repo.Stage(filepath.Join("event", "event.go"), filepath.Join("event", "event_test.go"))
 	commitID := repo.Commit(treeID)
 
-	commitNote, err := Process(true)
+	commitNote, err := Process(true, project.ReadConfig(project.GTMDir))
 	if err != nil {
 		t.Fatalf("Process(false) - test full commit, want error nil, got %s", err)
 	}
@@ -132,3 +172,73 @@ func TestInterim(t *testing.T) {
 		t.Errorf("Process(true) - test interim, want total 300, got %d", commitNote.Total())
 	}
 }
+
+func TestInterimConfig(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+
+	curDir, err := os.Getwd()
+	util.CheckFatal(t, err)
+	defer os.Chdir(curDir)
+
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("event.lock", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458497103.event", project.GTMDir, filepath.Join("event", "event.go"))
+	repo.SaveFile("1458497403.event", project.GTMDir, filepath.Join("event", "event.lock"))
+	repo.SaveFile(project.ConfigFile, project.GTMDir, `{"idle_timeout": 600, "extensions": ["go"]}`)
+
+	treeID := repo.Stage(filepath.Join("event", "event.go"), filepath.Join("event", "event.lock"))
+	repo.Commit(treeID)
+
+	commitNote, err := Process(true, project.ReadConfig(project.GTMDir))
+	if err != nil {
+		t.Fatalf("Process(true) - test interim config, want error nil, got %s", err)
+	}
+
+	// the idle timeout of 600s counts the 5 minute gaps, event.lock is not tracked
+	if commitNote.Total() != 600 {
+		t.Errorf("Process(true) - test interim config, want total 600, got %d", commitNote.Total())
+	}
+	for _, f := range commitNote.Files {
+		if f.SourceFile == filepath.Join("event", "event.lock") {
+			t.Errorf("Process(true) - test interim config, want event.lock excluded, got %+v", commitNote.Files)
+		}
+	}
+}
+
+func TestProcessCanceled(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+
+	curDir, err := os.Getwd()
+	util.CheckFatal(t, err)
+	defer os.Chdir(curDir)
+
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("1458496803.event", project.GTMDir, filepath.Join("event", "event.go"))
+
+	treeID := repo.Stage(filepath.Join("event", "event.go"))
+	commitID := repo.Commit(treeID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = ProcessContext(ctx, false, project.ReadConfig(project.GTMDir))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ProcessContext(canceled, false), want error context.Canceled, got %v", err)
+	}
+
+	if !repo.FileExists("1458496803.event", filepath.Join(repo.Workdir(), project.GTMDir)) {
+		t.Errorf("ProcessContext(canceled, false), want event file not removed")
+	}
+	n, err := scm.ReadNote(commitID.String(), "gtm-data", false)
+	util.CheckFatal(t, err)
+	if n.Note != "" {
+		t.Errorf("ProcessContext(canceled, false), want no git note saved, got %s", n.Note)
+	}
+}
@@ -6,6 +6,7 @@ package metric
 
 import (
 	"crypto/sha1"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -15,27 +16,53 @@ import (
 	"strings"
 
 	"github.com/git-time-metric/gtm/epoch"
+	"github.com/git-time-metric/gtm/event"
 	"github.com/git-time-metric/gtm/note"
+	"github.com/git-time-metric/gtm/project"
 	"github.com/git-time-metric/gtm/scm"
 	"github.com/git-time-metric/gtm/util"
 )
 
+var (
+	// ErrNotInitialized is returned by Process when the project is not initialized for time tracking,
+	// it's the same error as project.ErrNotInitialized
+	ErrNotInitialized = project.ErrNotInitialized
+	// ErrCorruptMetric is returned when a metric file can not be parsed, use errors.Is to check for it
+	ErrCorruptMetric = errors.New("Unable to parse metric file")
+)
+
 // getFileID returns the SHA1 checksum for filePath
 func getFileID(filePath string) string {
 	return fmt.Sprintf("%x", sha1.Sum([]byte(filepath.ToSlash(filePath))))
 }
 
-// allocateTime calculates access time for each file within an epoch window
-func allocateTime(ep int64, metricMap map[string]FileMetric, eventMap map[string]int) error {
+// allocateTime calculates access time for each file within an epoch window of windowSize seconds.
+// If last is set all of the window's time goes to it, see project.ProrationLast, otherwise the time is split
+// in proportion to each file's events, see project.ProrationProportional.
+func allocateTime(ep int64, metricMap map[string]FileMetric, eventMap map[string]int, windowSize int, last string) error {
 	total := 0
 	for file := range eventMap {
 		total += eventMap[file]
 	}
 
-	lastFileID := ""
-	timeAllocated := 0
+	// files are allocated in order so the time does not depend on map iteration order
+	files := make([]string, 0, len(eventMap))
 	for file := range eventMap {
-		t := int(float64(eventMap[file]) / float64(total) * float64(epoch.WindowSize))
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	busiestFileID := ""
+	busiestEvents := 0
+	timeAllocated := 0
+	for _, file := range files {
+		if last != "" && file != last {
+			continue
+		}
+		t := int(float64(eventMap[file]) / float64(total) * float64(windowSize))
+		if last != "" {
+			t = windowSize
+		}
 		fileID := getFileID(file)
 
 		var (
@@ -59,18 +86,82 @@ func allocateTime(ep int64, metricMap map[string]FileMetric, eventMap map[string
 		metricMap[fileID] = fm
 
 		timeAllocated += t
-		lastFileID = fileID
+		if eventMap[file] > busiestEvents {
+			busiestFileID, busiestEvents = fileID, eventMap[file]
+		}
 	}
-	// let's make sure all of the EpochWindowSize seconds are allocated
-	// we put the remaining on the last file
-	if lastFileID != "" && timeAllocated < epoch.WindowSize {
-		fm := metricMap[lastFileID]
-		fm.AddTimeSpent(ep, epoch.WindowSize-timeAllocated)
-		metricMap[lastFileID] = fm
+	// let's make sure all of the windowSize seconds are allocated
+	// we put the remaining on the file with the most events
+	if busiestFileID != "" && timeAllocated < windowSize {
+		fm := metricMap[busiestFileID]
+		fm.AddTimeSpent(ep, windowSize-timeAllocated)
+		metricMap[busiestFileID] = fm
 	}
 	return nil
 }
 
+// lastActive returns the source files of each window of windowSize seconds in the order of their last event
+// in the window, the file edited last in the window is last
+func lastActive(entries []event.Entry, windowSize int64) map[int64][]string {
+	sorted := append([]event.Entry{}, entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Epoch < sorted[j].Epoch })
+
+	active := map[int64][]string{}
+	for _, e := range sorted {
+		w := epoch.Window(e.Epoch, windowSize)
+		files := []string{}
+		for _, f := range active[w] {
+			if f != e.SourcePath {
+				files = append(files, f)
+			}
+		}
+		active[w] = append(files, e.SourcePath)
+	}
+	return active
+}
+
+// lastFile returns the file in the window's events that was edited last, active are the window's files
+// in the order of their last event. A window with only idle events, for a gap between events, has the file
+// edited before the gap, if there's more than one the file with the most events is returned.
+func lastFile(eventMap map[string]int, active []string) string {
+	for i := len(active) - 1; i >= 0; i-- {
+		if _, ok := eventMap[active[i]]; ok {
+			return active[i]
+		}
+	}
+	last := ""
+	for file, n := range eventMap {
+		if last == "" || n > eventMap[last] || (n == eventMap[last] && file < last) {
+			last = file
+		}
+	}
+	return last
+}
+
+// allocateWindow allocates the time of a window of events with the project's proration policy,
+// active are the files of each window in the order of their last event, see lastActive
+func allocateWindow(ep int64, metricMap map[string]FileMetric, eventMap map[string]int, active map[int64][]string, config project.Config) error {
+	last := ""
+	if config.Proration == project.ProrationLast {
+		last = lastFile(eventMap, active[ep])
+	}
+	return allocateTime(ep, metricMap, eventMap, int(config.WindowSize()), last)
+}
+
+// filterUntracked removes events for source files that are not tracked by the project's configuration
+func filterUntracked(epochEventMap map[int64]map[string]int, config project.Config) {
+	for ep, eventMap := range epochEventMap {
+		for file := range eventMap {
+			if !config.Tracked(file) {
+				delete(eventMap, file)
+			}
+		}
+		if len(eventMap) == 0 {
+			delete(epochEventMap, ep)
+		}
+	}
+}
+
 // FileMetric contains the source file and it's time metrics
 type FileMetric struct {
 	Updated    bool // Updated signifies if we need to save the metric file
@@ -105,6 +196,23 @@ func (f *FileMetric) SortEpochs() []int64 {
 	return keys
 }
 
+// sessions returns the number of editing sessions and the end of the last session as a Unix epoch.
+// The timeline must not be downsampled, a new session starts when the gap after the previous window
+// of windowSize seconds with time is longer than the idle timeout.
+func (f *FileMetric) sessions(windowSize, idleTimeout int64) (int, int64) {
+	epochs := f.SortEpochs()
+	if len(epochs) == 0 {
+		return 0, 0
+	}
+	n := 1
+	for i := 1; i < len(epochs); i++ {
+		if epochs[i]-(epochs[i-1]+windowSize) > idleTimeout {
+			n++
+		}
+	}
+	return n, epochs[len(epochs)-1] + windowSize
+}
+
 // FileMetricByTime is an array of FileMetrics
 type FileMetricByTime []FileMetric
 
@@ -139,23 +247,23 @@ func unMarshalFileMetric(b []byte, filePath string) (FileMetric, error) {
 	for i := 0; i < len(parts); i++ {
 		subparts := strings.Split(parts[i], ":")
 		if len(subparts) != 2 {
-			return FileMetric{}, fmt.Errorf("Unable to parse metric file %s, invalid format", filePath)
+			return FileMetric{}, fmt.Errorf("%w %s, invalid format", ErrCorruptMetric, filePath)
 		}
 		if i == 0 {
 			fileName = subparts[0]
 			totalTimeSpent, err = strconv.Atoi(subparts[1])
 			if err != nil {
-				return FileMetric{}, fmt.Errorf("Unable to parse metric file %s, invalid time, %s", filePath, err)
+				return FileMetric{}, fmt.Errorf("%w %s, invalid time, %s", ErrCorruptMetric, filePath, err)
 			}
 			continue
 		}
 		ep, err := strconv.ParseInt(subparts[0], 10, 64)
 		if err != nil {
-			return FileMetric{}, fmt.Errorf("Unable to parse metric file %s, invalid epoch, %s", filePath, err)
+			return FileMetric{}, fmt.Errorf("%w %s, invalid epoch, %s", ErrCorruptMetric, filePath, err)
 		}
 		timeSpent, err := strconv.Atoi(subparts[1])
 		if err != nil {
-			return FileMetric{}, fmt.Errorf("Unable to parse metric file %s, invalid time,  %s", filePath, err)
+			return FileMetric{}, fmt.Errorf("%w %s, invalid time,  %s", ErrCorruptMetric, filePath, err)
 		}
 		timeline[ep] += timeSpent
 	}
@@ -288,17 +396,20 @@ func buildCommitMaps(metricMap map[string]FileMetric) (map[string]FileMetric, ma
 	return commitMap, readonlyMap, nil
 }
 
-// buildCommitNote creates a CommitNote for files in the commit and readonly maps in git repo at rootPath
+// buildCommitNote creates a CommitNote for files in the commit and readonly maps in git repo at rootPath,
+// the project's window size and idle timeout are used to count each file's editing sessions
 func buildCommitNote(
 	rootPath string,
 	commitMap map[string]FileMetric,
-	readonlyMap map[string]FileMetric) (note.CommitNote, error) {
+	readonlyMap map[string]FileMetric,
+	windowSize, idleTimeout int64) (note.CommitNote, error) {
 
 	defer util.Profile()()
 
 	flsModified := []note.FileDetail{}
 
 	for _, fm := range commitMap {
+		sessions, lastActive := fm.sessions(windowSize, idleTimeout)
 		fm.Downsample()
 		status := "m"
 		if _, err := os.Stat(filepath.Join(rootPath, fm.SourceFile)); os.IsNotExist(err) {
@@ -306,11 +417,13 @@ func buildCommitNote(
 		}
 		flsModified = append(
 			flsModified,
-			note.FileDetail{SourceFile: fm.SourceFile, TimeSpent: fm.TimeSpent, Timeline: fm.Timeline, Status: status})
+			note.FileDetail{SourceFile: fm.SourceFile, TimeSpent: fm.TimeSpent, Timeline: fm.Timeline, Status: status,
+				Sessions: sessions, LastActive: lastActive})
 	}
 
 	flsReadonly := []note.FileDetail{}
 	for _, fm := range readonlyMap {
+		sessions, lastActive := fm.sessions(windowSize, idleTimeout)
 		fm.Downsample()
 		status := "r"
 		if _, err := os.Stat(filepath.Join(rootPath, fm.SourceFile)); os.IsNotExist(err) {
@@ -318,7 +431,8 @@ func buildCommitNote(
 		}
 		flsReadonly = append(
 			flsReadonly,
-			note.FileDetail{SourceFile: fm.SourceFile, TimeSpent: fm.TimeSpent, Timeline: fm.Timeline, Status: status})
+			note.FileDetail{SourceFile: fm.SourceFile, TimeSpent: fm.TimeSpent, Timeline: fm.Timeline, Status: status,
+				Sessions: sessions, LastActive: lastActive})
 	}
 	fls := append(flsModified, flsReadonly...)
 	sort.Sort(sort.Reverse(note.FileByTime(fls)))
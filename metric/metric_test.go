@@ -5,9 +5,13 @@
 package metric
 
 import (
+	"errors"
 	"path/filepath"
 	"reflect"
 	"testing"
+
+	"github.com/git-time-metric/gtm/event"
+	"github.com/git-time-metric/gtm/project"
 )
 
 func TestAllocateTime(t *testing.T) {
@@ -45,7 +49,7 @@ func TestAllocateTime(t *testing.T) {
 			metricOrig[k] = v
 
 		}
-		if err := allocateTime(1, tc.metric, tc.event); err != nil {
+		if err := allocateTime(1, tc.metric, tc.event, 60, ""); err != nil {
 			t.Errorf("allocateTime(%+v, %+v) want error nil got %s", metricOrig, tc.event, err)
 		}
 
@@ -55,6 +59,74 @@ func TestAllocateTime(t *testing.T) {
 	}
 }
 
+func TestAllocateProration(t *testing.T) {
+	// a.go and b.go are edited concurrently in the first two windows, the third window is an idle gap after b.go
+	entries := []event.Entry{
+		{Epoch: 1458496800, SourcePath: "a.go"},
+		{Epoch: 1458496810, SourcePath: "b.go"},
+		{Epoch: 1458496820, SourcePath: "a.go"},
+		{Epoch: 1458496830, SourcePath: "b.go"},
+		{Epoch: 1458496865, SourcePath: "a.go"},
+		{Epoch: 1458496870, SourcePath: "a.go"},
+		{Epoch: 1458496875, SourcePath: "b.go"},
+		{Epoch: 1458496985, SourcePath: "a.go"},
+	}
+
+	cases := []struct {
+		proration string
+		expected  map[string]map[int64]int
+	}{
+		{
+			"",
+			map[string]map[int64]int{
+				"a.go": {1458496800: 30, 1458496860: 40, 1458496980: 60},
+				"b.go": {1458496800: 30, 1458496860: 20, 1458496920: 60}},
+		},
+		{
+			project.ProrationProportional,
+			map[string]map[int64]int{
+				"a.go": {1458496800: 30, 1458496860: 40, 1458496980: 60},
+				"b.go": {1458496800: 30, 1458496860: 20, 1458496920: 60}},
+		},
+		{
+			project.ProrationLast,
+			map[string]map[int64]int{
+				"a.go": {1458496980: 60},
+				"b.go": {1458496800: 60, 1458496860: 60, 1458496920: 60}},
+		},
+	}
+
+	for _, tc := range cases {
+		config := project.Config{IdleTimeout: 120, Proration: tc.proration}
+		epochEventMap := event.Windows(entries, config.IdleTimeout, config.WindowSize())
+		active := lastActive(entries, config.WindowSize())
+
+		metricMap := map[string]FileMetric{}
+		for ep, eventMap := range epochEventMap {
+			if err := allocateWindow(ep, metricMap, eventMap, active, config); err != nil {
+				t.Fatalf("allocateWindow(%d, %+v) %s, want error nil got %s", ep, eventMap, tc.proration, err)
+			}
+		}
+
+		got := map[string]map[int64]int{}
+		for _, fm := range metricMap {
+			got[fm.SourceFile] = fm.Timeline
+		}
+		if !reflect.DeepEqual(tc.expected, got) {
+			t.Errorf("allocateWindow() proration %q\nwant:\n%+v\ngot:\n%+v\n", tc.proration, tc.expected, got)
+		}
+	}
+}
+
+func TestUnMarshalFileMetricCorrupt(t *testing.T) {
+	for _, s := range []string{"event/event.go", "event/event.go:x", "event/event.go:60,x:60"} {
+		_, err := unMarshalFileMetric([]byte(s), "1.metric")
+		if !errors.Is(err, ErrCorruptMetric) {
+			t.Errorf("unMarshalFileMetric(%q), want error %s got %v", s, ErrCorruptMetric, err)
+		}
+	}
+}
+
 func TestFileID(t *testing.T) {
 	want := "6f53bc90ba625b5afaac80b422b44f1f609d6367"
 	got := getFileID(filepath.Join("event", "event.go"))
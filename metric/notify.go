@@ -0,0 +1,133 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package metric
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/scm"
+	"github.com/git-time-metric/gtm/util"
+)
+
+// webhookFile records the head commit the webhook was last notified for and the webhook's failures,
+// it's kept in the project's data directory
+const webhookFile = "webhook.json"
+
+// webhookTimeout limits how long a webhook can delay gtm status
+const webhookTimeout = 5 * time.Second
+
+// A webhook that fails is not retried for webhookRetry, the wait doubles with each failure in a row
+// up to webhookMaxRetry so a webhook that's down does not delay every gtm status
+const (
+	webhookRetry    = time.Minute
+	webhookMaxRetry = time.Hour
+)
+
+// WebhookPayload is the JSON posted to a project's webhook_url when its pending time reaches the webhook_threshold
+type WebhookPayload struct {
+	Project   string `json:"project"`
+	Path      string `json:"path"`
+	Commit    string `json:"commit,omitempty"`
+	Pending   int    `json:"pending"`
+	Threshold int64  `json:"threshold"`
+	Message   string `json:"message"`
+}
+
+// Notify posts a WebhookPayload to the config's webhook_url if the pending seconds reached the webhook_threshold.
+// The webhook is notified once for each head commit, so time logged after the next commit can notify it again.
+// Nothing is sent if the project does not have a webhook_url. After the webhook fails it's not retried until
+// the wait for its failures in a row has passed, see webhookRetry.
+func Notify(pending int, config project.Config, projPath ...string) error {
+	if config.WebhookURL == "" || int64(pending) < config.WebhookThreshold {
+		return nil
+	}
+	rootPath, _, err := project.Paths(projPath...)
+	if err != nil {
+		return err
+	}
+
+	dataPath, err := project.DataPath(rootPath)
+	if err != nil {
+		return err
+	}
+	head, err := scm.HeadCommit(rootPath)
+	if err != nil {
+		return err
+	}
+
+	// the head commit is blank until the project has commits
+	notified := struct {
+		Commit string `json:"commit"`
+		// Failures is the number of times in a row the webhook failed, it's not retried before RetryAt
+		Failures int   `json:"failures,omitempty"`
+		RetryAt  int64 `json:"retry_at,omitempty"`
+	}{}
+	statePath := filepath.Join(dataPath, webhookFile)
+	if b, err := ioutil.ReadFile(statePath); err == nil {
+		if err := json.Unmarshal(b, &notified); err == nil {
+			if notified.Commit == head.ID || util.Now().Unix() < notified.RetryAt {
+				return nil
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	payload := WebhookPayload{
+		Project:   filepath.Base(rootPath),
+		Path:      rootPath,
+		Commit:    head.ID,
+		Pending:   pending,
+		Threshold: config.WebhookThreshold,
+		Message:   fmt.Sprintf("%s logged since the last commit", util.DurationStrLong(pending)),
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if postErr := post(config.WebhookURL, b); postErr != nil {
+		wait := webhookRetry << uint(notified.Failures)
+		if wait > webhookMaxRetry || wait <= 0 {
+			wait = webhookMaxRetry
+		}
+		notified.Failures++
+		notified.RetryAt = util.Now().Add(wait).Unix()
+		if b, err = json.Marshal(notified); err != nil {
+			return err
+		}
+		if err := util.WriteFileAtomic(statePath, b, 0644); err != nil {
+			return err
+		}
+		return postErr
+	}
+
+	notified.Commit, notified.Failures, notified.RetryAt = head.ID, 0, 0
+	if b, err = json.Marshal(notified); err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(statePath, b, 0644)
+}
+
+// post sends the JSON payload to the webhook url, a response that's not 2xx is an error
+func post(url string, payload []byte) error {
+	client := http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s returned %s", url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package metric
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/util"
+)
+
+func TestNotify(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+
+	curDir, err := os.Getwd()
+	util.CheckFatal(t, err)
+	defer os.Chdir(curDir)
+
+	os.Chdir(repo.Workdir())
+	util.CheckFatal(t, os.MkdirAll(project.GTMDir, 0700))
+
+	payloads := []WebhookPayload{}
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("webhook, want a JSON payload got %s", err)
+		}
+		payloads = append(payloads, p)
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	saveNow := util.Now
+	defer func() { util.Now = saveNow }()
+	now := time.Now()
+	util.Now = func() time.Time { return now }
+
+	// without a webhook_url nothing is sent
+	if err := Notify(3600, project.DefaultConfig()); err != nil || len(payloads) != 0 {
+		t.Errorf("Notify() without a webhook, want no error and no payloads got %v, %+v", err, payloads)
+	}
+
+	config := project.DefaultConfig()
+	config.WebhookURL, config.WebhookThreshold = ts.URL, 3600
+
+	status = http.StatusInternalServerError
+	if err := Notify(3600, config); err == nil {
+		t.Errorf("Notify() with a failing webhook, want error got nil")
+	}
+
+	// the failed webhook is not retried until the wait for the failure has passed
+	status = http.StatusOK
+	if err := Notify(3600, config); err != nil || len(payloads) != 1 {
+		t.Errorf("Notify() after a failure, want the webhook not retried got %v, %+v", err, payloads)
+	}
+	now = now.Add(webhookRetry)
+
+	for _, pending := range []int{3599, 3600, 7200} {
+		if err := Notify(pending, config); err != nil {
+			t.Errorf("Notify(%d), want error nil got %s", pending, err)
+		}
+	}
+	// the failed notification is retried and the webhook is notified once for the head commit
+	if len(payloads) != 2 || payloads[1].Pending != 3600 || payloads[1].Threshold != 3600 || payloads[1].Commit == "" {
+		t.Errorf("Notify(), want 2 payloads with 3600s pending got %+v", payloads)
+	}
+
+	repo.SaveFile("main.go", "", "")
+	repo.Commit(repo.Stage("main.go"))
+	if err := Notify(3600, config); err != nil || len(payloads) != 3 {
+		t.Errorf("Notify() after a commit, want the webhook notified again got %v, %+v", err, payloads)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package metric
+
+import (
+	"sort"
+
+	"github.com/git-time-metric/gtm/event"
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/util"
+)
+
+// Attribution is how the time for a window of events is allocated to source files
+type Attribution struct {
+	// Window is the Unix epoch the window starts at
+	Window int64
+	// Events is the number of events by source file, including idle events added
+	// for gaps shorter than the idle timeout
+	Events map[string]int
+	// Seconds is the time allocated by source file
+	Seconds map[string]int
+}
+
+// Preview returns the attribution of the pending events for the project, ordered by window.
+// It's for diagnosing totals, event files are not purged and nothing is saved.
+// Time from events that have already been processed into metric files is not included, config is as for Process.
+func Preview(config project.Config, projPath ...string) ([]Attribution, error) {
+	defer util.Profile()()
+
+	rootPath, _, err := project.Paths(projPath...)
+	if err != nil {
+		return nil, err
+	}
+
+	dataPath, err := project.DataPath(rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// events are not purged
+	entries, _, err := readEvents(rootPath, dataPath, config, true)
+	if err != nil {
+		return nil, err
+	}
+	epochEventMap := event.Windows(entries, config.IdleTimeout, config.WindowSize())
+	filterUntracked(epochEventMap, config)
+
+	attributions := []Attribution{}
+	active := lastActive(entries, config.WindowSize())
+	for ep, eventMap := range epochEventMap {
+		// each window is allocated on its own so the time is only for the window's events
+		metricMap := map[string]FileMetric{}
+		if err := allocateWindow(ep, metricMap, eventMap, active, config); err != nil {
+			return nil, err
+		}
+		a := Attribution{Window: ep, Events: map[string]int{}, Seconds: map[string]int{}}
+		for file, n := range eventMap {
+			a.Events[file] = n
+		}
+		for _, fm := range metricMap {
+			a.Seconds[fm.SourceFile] = fm.TimeSpent
+		}
+		attributions = append(attributions, a)
+	}
+	sort.Slice(attributions, func(i, j int) bool { return attributions[i].Window < attributions[j].Window })
+
+	return attributions, nil
+}
@@ -0,0 +1,285 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package metric
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/git-time-metric/gtm/event"
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/util"
+)
+
+// EventSource contributes events to Process. The event files written by gtm record for the editor,
+// terminal and app plug-ins are read by FileEventSource, other sources are registered with
+// RegisterEventSource or declared in a project's config as a CommandEventSource.
+type EventSource interface {
+	// Name identifies the source in errors
+	Name() string
+	// Events returns the source's pending events for the project at rootPath, dataPath is the project's data directory.
+	// Purge is called once the events are saved, it must remove them so they are not returned again.
+	Events(rootPath, dataPath string) (entries []event.Entry, purge func() error, err error)
+}
+
+var (
+	eventSourcesMu sync.Mutex
+	eventSources   = []EventSource{FileEventSource{}}
+)
+
+// RegisterEventSource adds a source of events for all projects, i.e. from the init func of a package
+// built into gtm. Sources are read in the order they are registered after FileEventSource.
+func RegisterEventSource(s EventSource) {
+	eventSourcesMu.Lock()
+	defer eventSourcesMu.Unlock()
+	eventSources = append(eventSources, s)
+}
+
+// commandCacheFile caches the events read from the project's command sources for interim reads, i.e. gtm status,
+// so a command is run at most once every commandCacheTTL. It's kept in the project's data directory.
+const commandCacheFile = "sources.json"
+
+// commandCacheTTL is how long the events read from a command source are reused for interim reads
+const commandCacheTTL = time.Minute
+
+// readEvents returns the events of the registered sources and the project's configured command sources in epoch
+// order, the purge funcs remove the events once they are saved. If interim the events of the command sources are
+// cached, see commandCacheFile, otherwise the commands are run and the cache is removed since the events are purged.
+func readEvents(rootPath, dataPath string, config project.Config, interim bool) ([]event.Entry, []func() error, error) {
+	eventSourcesMu.Lock()
+	sources := append([]EventSource{}, eventSources...)
+	eventSourcesMu.Unlock()
+	if len(config.EventSources) > 0 {
+		cache := &commandCache{path: filepath.Join(dataPath, commandCacheFile)}
+		if interim {
+			cache.load()
+			defer cache.save()
+		} else if err := cache.remove(); err != nil {
+			return []event.Entry{}, []func() error{}, err
+		}
+		for _, cmd := range config.EventSources {
+			var s EventSource = CommandEventSource{Command: cmd}
+			if interim {
+				s = cachedEventSource{source: CommandEventSource{Command: cmd}, cache: cache}
+			}
+			sources = append(sources, s)
+		}
+	}
+
+	entries := []event.Entry{}
+	purges := []func() error{}
+	for _, s := range sources {
+		e, purge, err := s.Events(rootPath, dataPath)
+		if err != nil {
+			return []event.Entry{}, []func() error{}, fmt.Errorf("Unable to read events from %s, %s", s.Name(), err)
+		}
+		entries = append(entries, e...)
+		purges = append(purges, purge)
+	}
+	// rapid events for the same file are coalesced before time is allocated, see project.Config.Debounce
+	return event.Debounce(entries, time.Duration(config.Debounce)*time.Millisecond), purges, nil
+}
+
+// commandCache is the events read from command sources by command, see commandCacheFile
+type commandCache struct {
+	Sources map[string]cachedEvents `json:"sources"`
+	path    string
+	updated bool
+}
+
+type cachedEvents struct {
+	// Read is the epoch the events were read
+	Read    int64         `json:"read"`
+	Entries []event.Entry `json:"entries"`
+}
+
+// load reads the cache, a cache that can not be read is empty
+func (c *commandCache) load() {
+	c.Sources = map[string]cachedEvents{}
+	b, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(b, c); err != nil || c.Sources == nil {
+		c.Sources = map[string]cachedEvents{}
+	}
+}
+
+// save writes the cache if it was updated, it's only a cache so errors are ignored
+func (c *commandCache) save() {
+	if !c.updated {
+		return
+	}
+	if b, err := json.Marshal(c); err == nil {
+		_ = util.WriteFileAtomic(c.path, b, 0644)
+	}
+}
+
+// remove removes the cache file, a cache that does not exist is not an error
+func (c *commandCache) remove() error {
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// cachedEventSource is a command source for interim reads, its events are read from the cache if they were read
+// within commandCacheTTL, otherwise the command is run and its events are cached. The events are not purged.
+type cachedEventSource struct {
+	source CommandEventSource
+	cache  *commandCache
+}
+
+// Name returns the command
+func (s cachedEventSource) Name() string { return s.source.Name() }
+
+// Events returns the command source's cached events or runs the command
+func (s cachedEventSource) Events(rootPath, dataPath string) ([]event.Entry, func() error, error) {
+	now := util.Now().Unix()
+	if c, ok := s.cache.Sources[s.source.Command]; ok && now >= c.Read && now-c.Read < int64(commandCacheTTL.Seconds()) {
+		return c.Entries, func() error { return nil }, nil
+	}
+	entries, _, err := s.source.Events(rootPath, dataPath)
+	if err != nil {
+		return entries, nil, err
+	}
+	s.cache.Sources[s.source.Command] = cachedEvents{Read: now, Entries: entries}
+	s.cache.updated = true
+	return entries, func() error { return nil }, nil
+}
+
+// FileEventSource reads the event files written by gtm record in the project's data directory
+type FileEventSource struct{}
+
+// Name returns the name of the source
+func (FileEventSource) Name() string { return "event files" }
+
+// Events returns the events in the event files, purge removes the files
+func (FileEventSource) Events(rootPath, dataPath string) ([]event.Entry, func() error, error) {
+	entries, files, err := event.Read(dataPath)
+	if err != nil {
+		return []event.Entry{}, nil, err
+	}
+	return entries, func() error { return event.RemoveFiles(files) }, nil
+}
+
+// CommandEventSource runs an external command in the project's root directory to read its events.
+//
+// The command is run with the argument read and writes one event per line to stdout, the event's
+// epoch in seconds and the path of the source file separated by a space. Paths are relative to the
+// project's root directory or absolute paths within it. Blank lines and lines starting with # are ignored.
+//
+//	1458496803 src/index.html
+//	1458496811 /home/me/project/src/app.js
+//
+// Once the events are saved the command is run with the arguments purge and the newest epoch read,
+// it must remove the events at or before the epoch.
+type CommandEventSource struct {
+	// Command is the command and its arguments separated by spaces
+	Command string
+}
+
+// Name returns the command
+func (s CommandEventSource) Name() string { return s.Command }
+
+// Check returns an error if the command can not be found, i.e. it's not installed or not in the PATH
+func (s CommandEventSource) Check() error {
+	fields := strings.Fields(s.Command)
+	if len(fields) == 0 {
+		return fmt.Errorf("command is blank")
+	}
+	_, err := exec.LookPath(fields[0])
+	return err
+}
+
+// Events runs the command to read its events, purge runs it to remove them
+func (s CommandEventSource) Events(rootPath, dataPath string) ([]event.Entry, func() error, error) {
+	out, err := s.run(rootPath, "read")
+	if err != nil {
+		return []event.Entry{}, nil, err
+	}
+
+	entries := []event.Entry{}
+	var newest int64
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		e, err := parseEventLine(line, rootPath)
+		if err != nil {
+			return []event.Entry{}, nil, fmt.Errorf("line %d %q, %s", n, line, err)
+		}
+		if e.Epoch > newest {
+			newest = e.Epoch
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return []event.Entry{}, nil, err
+	}
+
+	purge := func() error {
+		if len(entries) == 0 {
+			return nil
+		}
+		_, err := s.run(rootPath, "purge", strconv.FormatInt(newest, 10))
+		return err
+	}
+	return entries, purge, nil
+}
+
+// run runs the command in the project's root directory with the arguments and returns its stdout
+func (s CommandEventSource) run(rootPath string, args ...string) ([]byte, error) {
+	fields := strings.Fields(s.Command)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("command is blank")
+	}
+	cmd := exec.Command(fields[0], append(fields[1:], args...)...)
+	cmd.Dir = rootPath
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s, %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// parseEventLine parses an event line of the CommandEventSource protocol
+func parseEventLine(line, rootPath string) (event.Entry, error) {
+	s := strings.SplitN(line, " ", 2)
+	if len(s) != 2 || strings.TrimSpace(s[1]) == "" {
+		return event.Entry{}, fmt.Errorf("want an epoch and a path")
+	}
+	epoch, err := strconv.ParseInt(s[0], 10, 64)
+	if err != nil || epoch <= 0 {
+		return event.Entry{}, fmt.Errorf("invalid epoch %s", s[0])
+	}
+	sourcePath := filepath.Clean(filepath.FromSlash(strings.TrimSpace(s[1])))
+	if filepath.IsAbs(sourcePath) {
+		if sourcePath, err = filepath.Rel(rootPath, sourcePath); err != nil {
+			return event.Entry{}, err
+		}
+	}
+	if sourcePath == "." || sourcePath == ".." || strings.HasPrefix(sourcePath, ".."+string(filepath.Separator)) {
+		return event.Entry{}, fmt.Errorf("path is not within the project")
+	}
+	return event.Entry{Epoch: epoch, SourcePath: sourcePath}, nil
+}
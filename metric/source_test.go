@@ -0,0 +1,173 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package metric
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/git-time-metric/gtm/event"
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/util"
+)
+
+type testEventSource struct {
+	entries []event.Entry
+	purged  *bool
+}
+
+func (s testEventSource) Name() string { return "test" }
+
+func (s testEventSource) Events(rootPath, dataPath string) ([]event.Entry, func() error, error) {
+	return s.entries, func() error { *s.purged = true; return nil }, nil
+}
+
+func TestRegisterEventSource(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+
+	curDir, err := os.Getwd()
+	util.CheckFatal(t, err)
+	defer os.Chdir(curDir)
+
+	os.Chdir(repo.Workdir())
+
+	saved := eventSources
+	defer func() { eventSources = saved }()
+
+	purged := false
+	RegisterEventSource(testEventSource{
+		entries: []event.Entry{{Epoch: 1458496803, SourcePath: filepath.Join("event", "event.go")}},
+		purged:  &purged,
+	})
+
+	repo.SaveFile("event.go", "event", "")
+	repo.SaveFile("event_test.go", "event", "")
+	repo.SaveFile("1458496811.event", project.GTMDir, filepath.Join("event", "event_test.go"))
+	repo.Commit(repo.Stage(filepath.Join("event", "event.go"), filepath.Join("event", "event_test.go")))
+
+	commitNote, err := Process(true, project.ReadConfig(project.GTMDir))
+	if err != nil {
+		t.Fatalf("Process(true) with a registered source, want error nil, got %s", err)
+	}
+	// the window's minute is split between the registered source's event and the event file's event
+	if commitNote.Total() != 60 || len(commitNote.Files) != 2 {
+		t.Errorf("Process(true) with a registered source, want 60s for 2 files, got %+v", commitNote)
+	}
+	if purged {
+		t.Errorf("Process(true) with a registered source, want events not purged")
+	}
+
+	if _, err := Process(false, project.ReadConfig(project.GTMDir)); err != nil {
+		t.Fatalf("Process(false) with a registered source, want error nil, got %s", err)
+	}
+	if !purged {
+		t.Errorf("Process(false) with a registered source, want events purged")
+	}
+}
+
+func TestCommandEventSource(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+
+	curDir, err := os.Getwd()
+	util.CheckFatal(t, err)
+	defer os.Chdir(curDir)
+
+	os.Chdir(repo.Workdir())
+
+	script := `if [ "$1" = read ]; then
+  echo read >> reads
+  echo "# browser events"
+  echo "1458496803 web/index.html"
+  echo "1458496811 $PWD/web/app.js"
+else
+  echo "$@" > purged
+fi
+`
+	repo.SaveFile("events.sh", "", script)
+	repo.SaveFile("index.html", "web", "")
+	repo.SaveFile("app.js", "web", "")
+	repo.Commit(repo.Stage(filepath.Join("web", "index.html"), filepath.Join("web", "app.js")))
+	util.CheckFatal(t, os.MkdirAll(project.GTMDir, 0700))
+	config := project.DefaultConfig()
+	config.EventSources = []string{"sh events.sh"}
+	util.CheckFatal(t, config.Save(project.GTMDir))
+
+	commitNote, err := Process(true, project.ReadConfig(project.GTMDir))
+	if err != nil {
+		t.Fatalf("Process(true) with a command source, want error nil, got %s", err)
+	}
+	if commitNote.Total() != 60 || len(commitNote.Files) != 2 {
+		t.Errorf("Process(true) with a command source, want 60s for 2 files, got %+v", commitNote)
+	}
+	if repo.FileExists("purged", repo.Workdir()) {
+		t.Errorf("Process(true) with a command source, want events not purged")
+	}
+
+	// interim reads reuse the command's cached events
+	commitNote, err = Process(true, project.ReadConfig(project.GTMDir))
+	if err != nil || commitNote.Total() != 60 {
+		t.Errorf("Process(true) with a cached command source, want 60s got %+v, %v", commitNote, err)
+	}
+	reads := func() int {
+		b, err := ioutil.ReadFile("reads")
+		util.CheckFatal(t, err)
+		return strings.Count(string(b), "read")
+	}
+	if n := reads(); n != 1 {
+		t.Errorf("Process(true) twice with a command source, want the command run once got %d", n)
+	}
+
+	if _, err := Process(false, project.ReadConfig(project.GTMDir)); err != nil {
+		t.Fatalf("Process(false) with a command source, want error nil, got %s", err)
+	}
+	b, err := ioutil.ReadFile("purged")
+	util.CheckFatal(t, err)
+	if want := "purge 1458496811"; strings.TrimSpace(string(b)) != want {
+		t.Errorf("Process(false) with a command source, want %s got %s", want, b)
+	}
+	if n := reads(); n != 2 {
+		t.Errorf("Process(false) with a command source, want the command run got %d reads", n)
+	}
+	if repo.FileExists(commandCacheFile, project.GTMDir) {
+		t.Errorf("Process(false) with a command source, want the cache removed")
+	}
+}
+
+func TestParseEventLine(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "project")
+	tests := []struct {
+		line string
+		want event.Entry
+		err  bool
+	}{
+		{"1458496803 web/index.html", event.Entry{Epoch: 1458496803, SourcePath: filepath.Join("web", "index.html")}, false},
+		{"1458496803 " + filepath.Join(root, "web", "app.js"), event.Entry{Epoch: 1458496803, SourcePath: filepath.Join("web", "app.js")}, false},
+		{"1458496803 file with spaces.txt", event.Entry{Epoch: 1458496803, SourcePath: "file with spaces.txt"}, false},
+		{"1458496803", event.Entry{}, true},
+		{"now index.html", event.Entry{}, true},
+		{"1458496803 ../other/index.html", event.Entry{}, true},
+		{"1458496803 " + filepath.Join(string(filepath.Separator), "other", "index.html"), event.Entry{}, true},
+	}
+	for _, tc := range tests {
+		got, err := parseEventLine(tc.line, root)
+		if (err != nil) != tc.err {
+			t.Errorf("parseEventLine(%q), want error %t got %v", tc.line, tc.err, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("parseEventLine(%q), want %+v got %+v", tc.line, tc.want, got)
+		}
+	}
+}
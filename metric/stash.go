@@ -0,0 +1,111 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package metric
+
+import (
+	"github.com/git-time-metric/gtm/note"
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/scm"
+)
+
+// Stash moves the pending time of the files changed by the stash commit stashID, see scm.StashCommit, to a git note
+// for the stash commit in the project's notes ref so the time isn't lost while the work is stashed. It's run after
+// git stash. Time for files that are not in the stash, i.e. read-only and untracked files, terminal and app time,
+// stays pending. The stash's time is reclaimed as pending time by Unstash.
+func Stash(stashID string, config project.Config, projPath ...string) (note.CommitNote, error) {
+	rootPath, _, err := project.Paths(projPath...)
+	if err != nil {
+		return note.CommitNote{}, err
+	}
+
+	dataPath, err := project.DataPath(rootPath)
+	if err != nil {
+		return note.CommitNote{}, err
+	}
+
+	// the stash commit's changes are the working tree's changes when it was stashed
+	stash, err := scm.ReadNote(stashID, config.NoteNameSpace(), true, rootPath)
+	if err != nil {
+		return note.CommitNote{}, err
+	}
+
+	metricMap, err := pendingMetrics(rootPath, dataPath, config, false, func() error { return nil })
+	if err != nil {
+		return note.CommitNote{}, err
+	}
+
+	stashMap := map[string]FileMetric{}
+	for _, f := range stash.Stats.Files {
+		fileID := getFileID(f)
+		if fm, ok := metricMap[fileID]; ok {
+			stashMap[fileID] = fm
+		}
+	}
+
+	stashNote, err := buildCommitNote(rootPath, stashMap, map[string]FileMetric{}, config.WindowSize(), config.IdleTimeout)
+	if err != nil {
+		return note.CommitNote{}, err
+	}
+	// git stash reverts the working tree, files added by the stash are modified not deleted
+	for i := range stashNote.Files {
+		stashNote.Files[i].Status = "m"
+	}
+
+	if len(stashNote.Files) > 0 {
+		if err := note.AppendCommitNoteToRef(rootPath, config.NoteNameSpace(), stash.ID, stashNote); err != nil {
+			return note.CommitNote{}, err
+		}
+	}
+	if err := saveAndPurgeMetrics(dataPath, metricMap, stashMap, map[string]FileMetric{}); err != nil {
+		return note.CommitNote{}, err
+	}
+	return stashNote, nil
+}
+
+// Unstash reclaims the time saved for the stash commit stashID by Stash as pending time, the stash's note is
+// removed so the time is only reclaimed once. It's run before git stash pop since the stash commit is dropped
+// by the pop.
+func Unstash(stashID string, config project.Config, projPath ...string) (note.CommitNote, error) {
+	rootPath, _, err := project.Paths(projPath...)
+	if err != nil {
+		return note.CommitNote{}, err
+	}
+
+	dataPath, err := project.DataPath(rootPath)
+	if err != nil {
+		return note.CommitNote{}, err
+	}
+
+	notes, err := note.ReadCommitNotesFromRef(rootPath, config.NoteNameSpace(), stashID)
+	if err != nil {
+		return note.CommitNote{}, err
+	}
+	stashNote := notes[0]
+	if len(stashNote.Files) == 0 {
+		return stashNote, nil
+	}
+
+	metricMap, err := loadMetrics(dataPath)
+	if err != nil {
+		return note.CommitNote{}, err
+	}
+	for _, f := range stashNote.Files {
+		fm, ok := metricMap[getFileID(f.SourceFile)]
+		if !ok {
+			fm = FileMetric{SourceFile: f.SourceFile, Timeline: map[int64]int{}}
+		}
+		for ep, t := range f.Timeline {
+			fm.AddTimeSpent(ep, t)
+		}
+		if err := writeMetricFile(dataPath, fm); err != nil {
+			return note.CommitNote{}, err
+		}
+	}
+
+	if err := scm.RemoveNoteForCommit(stashID, config.NoteNameSpace(), rootPath); err != nil {
+		return note.CommitNote{}, err
+	}
+	return stashNote, nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package note_test
+
+import (
+	"fmt"
+
+	"github.com/git-time-metric/gtm/note"
+)
+
+func ExampleReadCommitNotes() {
+	notes, err := note.ReadCommitNotes("/path/to/repo", "e4fb3b6f8a7c1cd3c9a8b6d5e1f0a2b3c4d5e6f7")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, n := range notes {
+		for _, f := range n.Files {
+			fmt.Printf("%s %ds\n", f.SourceFile, f.TimeSpent)
+		}
+		fmt.Printf("total %ds\n", n.Total())
+	}
+}
+
+func ExampleWriteCommitNote() {
+	n := note.CommitNote{
+		Files: []note.FileDetail{
+			{
+				SourceFile: "main.go",
+				TimeSpent:  120,
+				Timeline:   map[int64]int{1460066400: 120},
+				Status:     "m",
+			},
+		},
+	}
+	if err := note.WriteCommitNote("/path/to/repo", "e4fb3b6f8a7c1cd3c9a8b6d5e1f0a2b3c4d5e6f7", n); err != nil {
+		fmt.Println(err)
+	}
+}
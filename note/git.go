@@ -0,0 +1,167 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package note
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/scm"
+)
+
+// ReadCommitNotes returns the time metrics for each of the SHA1 commit ids in the git repository at repoPath.
+// The commit notes are returned in the same order as the commit ids, a commit without time metrics
+// returns a CommitNote without files.
+func ReadCommitNotes(repoPath string, commitIDs ...string) ([]CommitNote, error) {
+	return ReadCommitNotesFromRef(repoPath, project.NoteNameSpace, commitIDs...)
+}
+
+// ReadCommitNotesFromRef is like ReadCommitNotes but reads the time metrics from the git notes ref,
+// i.e. refs/notes/gtm-billable. A notes ref that does not exist yet has no time metrics.
+func ReadCommitNotesFromRef(repoPath string, ref string, commitIDs ...string) ([]CommitNote, error) {
+	return ReadCommitNotesFromRefContext(context.Background(), repoPath, ref, commitIDs...)
+}
+
+// ReadCommitNotesFromRefContext is like ReadCommitNotesFromRef but stops reading when ctx is canceled,
+// the error wraps ctx.Err()
+func ReadCommitNotesFromRefContext(ctx context.Context, repoPath string, ref string, commitIDs ...string) ([]CommitNote, error) {
+	ref, err := project.NotesRef(ref)
+	if err != nil {
+		return []CommitNote{}, err
+	}
+	nameSpace := strings.TrimPrefix(ref, "refs/notes/")
+
+	read := func(commitID string) (CommitNote, error) {
+		return readNote(repoPath, nameSpace, commitID)
+	}
+	notes := []CommitNote{}
+	err = WalkCommits(ctx, commitIDs, read, func(commitID string, n CommitNote) error {
+		notes = append(notes, n)
+		return nil
+	})
+	if err != nil {
+		return []CommitNote{}, err
+	}
+	return notes, nil
+}
+
+// ErrStopWalk is returned by a Walk function to stop walking the notes without an error
+var ErrStopWalk = errors.New("stop walking notes")
+
+// Walk calls fn with the time metrics of each commit reachable from the head of the git repository at repoPath
+// that has time metrics in the git notes ref, newest first. The notes are read one commit at a time so time can be
+// totaled for a large history without holding all of the notes in memory. Walking stops at the first error fn
+// returns and it's returned, unless it's ErrStopWalk.
+func Walk(repoPath string, ref string, fn func(commitID string, n CommitNote) error) error {
+	return WalkContext(context.Background(), repoPath, ref, fn)
+}
+
+// WalkContext is like Walk but stops when ctx is canceled, the error wraps ctx.Err()
+func WalkContext(ctx context.Context, repoPath string, ref string, fn func(commitID string, n CommitNote) error) error {
+	ref, err := project.NotesRef(ref)
+	if err != nil {
+		return err
+	}
+	nameSpace := strings.TrimPrefix(ref, "refs/notes/")
+
+	read := func(commitID string) (CommitNote, error) {
+		return readNote(repoPath, nameSpace, commitID)
+	}
+	err = scm.WalkCommits(func(commitID string) error {
+		return walkCommit(ctx, commitID, read, func(commitID string, n CommitNote) error {
+			if len(n.Files) == 0 {
+				return nil
+			}
+			return fn(commitID, n)
+		})
+	}, repoPath)
+	if errors.Is(err, ErrStopWalk) {
+		return nil
+	}
+	return err
+}
+
+// WalkCommits is like WalkContext but walks the SHA1 commit ids in order instead of the commits reachable from the
+// head, a commit without time metrics is walked with an empty CommitNote. Each commit's time metrics are read by
+// read so they can be read from a cache.
+func WalkCommits(ctx context.Context, commitIDs []string, read func(commitID string) (CommitNote, error), fn func(commitID string, n CommitNote) error) error {
+	for _, id := range commitIDs {
+		if err := walkCommit(ctx, id, read, fn); err != nil {
+			if errors.Is(err, ErrStopWalk) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// walkCommit calls fn with the commit's time metrics unless ctx is canceled
+func walkCommit(ctx context.Context, commitID string, read func(commitID string) (CommitNote, error), fn func(commitID string, n CommitNote) error) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("Unable to read notes, %w", err)
+	}
+	n, err := read(commitID)
+	if err != nil {
+		return err
+	}
+	return fn(commitID, n)
+}
+
+// readNote returns the time metrics in the git note of the SHA1 commit id in the notes namespace, i.e. gtm-data
+func readNote(repoPath, nameSpace, commitID string) (CommitNote, error) {
+	n, err := scm.ReadNote(commitID, nameSpace, false, repoPath)
+	if err != nil {
+		return CommitNote{}, err
+	}
+	return UnMarshal(n.Note)
+}
+
+// WriteCommitNote saves the time metrics for the SHA1 commit id in the git repository at repoPath,
+// replacing any time metrics already saved for the commit
+func WriteCommitNote(repoPath string, commitID string, n CommitNote) error {
+	return WriteCommitNoteToRef(repoPath, project.NoteNameSpace, commitID, n)
+}
+
+// WriteCommitNoteToRef is like WriteCommitNote but saves the time metrics to the git notes ref,
+// the notes ref is created if it does not exist
+func WriteCommitNoteToRef(repoPath string, ref string, commitID string, n CommitNote) error {
+	ref, err := project.NotesRef(ref)
+	if err != nil {
+		return err
+	}
+	return scm.CreateNoteForCommit(commitID, Marshal(n), strings.TrimPrefix(ref, "refs/notes/"), repoPath)
+}
+
+// AppendCommitNote adds the time metrics to the ones already saved for the SHA1 commit id in the git repository
+// at repoPath, the time is appended to the commit's git note instead of rewriting it, see Append
+func AppendCommitNote(repoPath string, commitID string, n CommitNote) error {
+	return AppendCommitNoteToRef(repoPath, project.NoteNameSpace, commitID, n)
+}
+
+// AppendCommitNoteToRef is like AppendCommitNote but saves the time metrics to the git notes ref,
+// the notes ref is created if it does not exist
+func AppendCommitNoteToRef(repoPath string, ref string, commitID string, n CommitNote) error {
+	ref, err := project.NotesRef(ref)
+	if err != nil {
+		return err
+	}
+	nameSpace := strings.TrimPrefix(ref, "refs/notes/")
+	existing, err := scm.ReadNote(commitID, nameSpace, false, repoPath)
+	if err != nil {
+		return err
+	}
+	noteTxt, err := Append(existing.Note, n)
+	if err != nil {
+		return err
+	}
+	if noteTxt == existing.Note {
+		return nil
+	}
+	return scm.CreateNoteForCommit(commitID, noteTxt, nameSpace, repoPath)
+}
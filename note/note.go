@@ -5,17 +5,22 @@
 package note
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"path/filepath"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/git-time-metric/gtm/project"
 	"github.com/git-time-metric/gtm/util"
 )
 
+// ErrCorruptNote is returned when a git note can not be unmarshalled, use errors.Is to check for it
+var ErrCorruptNote = errors.New("Unable to unmarshal time logged")
+
 // CommitNote contains the time metrics for a commit
 type CommitNote struct {
 	Files []FileDetail
@@ -43,6 +48,182 @@ func (n CommitNote) FilterOutApp() CommitNote {
 	return CommitNote{Files: fds}
 }
 
+// Weigh returns the commit note with terminal time multiplied by the terminal weight and other app time
+// multiplied by the app weight, file time is not changed. Weighted time is rounded to the nearest second
+// for each hour of a timeline and the time spent is the sum of the rounded hours, so a weighted total can
+// differ by a few seconds from the weighted unrounded total. Entries with no time left are removed.
+func (n CommitNote) Weigh(terminal, app float64) CommitNote {
+	fds := []FileDetail{}
+	for _, f := range n.Files {
+		w := 1.0
+		switch {
+		case f.IsTerminal():
+			w = terminal
+		case f.IsApp():
+			w = app
+		}
+		if w == 1 {
+			fds = append(fds, f)
+			continue
+		}
+		fd := FileDetail{SourceFile: f.SourceFile, Status: f.Status, Timeline: map[int64]int{},
+			Sessions: f.Sessions, LastActive: f.LastActive}
+		if len(f.Timeline) == 0 {
+			fd.TimeSpent = int(math.Round(float64(f.TimeSpent) * w))
+		}
+		for ep, secs := range f.Timeline {
+			if t := int(math.Round(float64(secs) * w)); t > 0 {
+				fd.Timeline[ep] = t
+				fd.TimeSpent += t
+			}
+		}
+		if fd.TimeSpent > 0 {
+			fds = append(fds, fd)
+		}
+	}
+	return CommitNote{Files: fds}
+}
+
+// FilterPaths keeps the source files that match any of the glob patterns, i.e. cmd/**.
+// Terminal and app time is not kept because it is not for a file path.
+func (n CommitNote) FilterPaths(patterns []string) CommitNote {
+	fds := []FileDetail{}
+	for _, f := range n.Files {
+		if !f.IsApp() && util.MatchAnyGlob(patterns, f.SourceFile) {
+			fds = append(fds, f)
+		}
+	}
+	return CommitNote{Files: fds}
+}
+
+// FilterDateRange filters out time outside of the date range from the commit note.
+// Timelines are stored in hourly buckets, time for an hour that straddles the start or end
+// of the date range is prorated by the portion of the hour that is within the date range.
+func (n CommitNote) FilterDateRange(dr util.DateRange) CommitNote {
+	fds := []FileDetail{}
+	for _, f := range n.Files {
+		fd := FileDetail{SourceFile: f.SourceFile, Status: f.Status, Timeline: map[int64]int{},
+			Sessions: f.Sessions, LastActive: f.LastActive}
+		for ep, secs := range f.Timeline {
+			start := time.Unix(ep, 0)
+			end := start.Add(time.Hour)
+			if !dr.Start.IsZero() && dr.Start.After(start) {
+				start = dr.Start
+			}
+			if !dr.End.IsZero() && dr.End.Before(end) {
+				end = dr.End
+			}
+			if !end.After(start) {
+				continue
+			}
+			t := int(float64(secs) * float64(end.Sub(start)) / float64(time.Hour))
+			if t > 0 {
+				fd.Timeline[ep] = t
+				fd.TimeSpent += t
+			}
+		}
+		if fd.TimeSpent > 0 {
+			fds = append(fds, fd)
+		}
+	}
+	return CommitNote{Files: fds}
+}
+
+// FilterHours keeps the portion of each hour of the timelines returned by portion, from 0 to keep none of the
+// hour's time to 1 to keep all of it, i.e. the portion of the hour within working hours. Files without time left
+// are removed.
+func (n CommitNote) FilterHours(portion func(hour time.Time) float64) CommitNote {
+	fds := []FileDetail{}
+	for _, f := range n.Files {
+		fd := FileDetail{SourceFile: f.SourceFile, Status: f.Status, Timeline: map[int64]int{},
+			Sessions: f.Sessions, LastActive: f.LastActive}
+		for ep, secs := range f.Timeline {
+			t := int(math.Round(float64(secs) * portion(time.Unix(ep, 0))))
+			if t > 0 {
+				fd.Timeline[ep] = t
+				fd.TimeSpent += t
+			}
+		}
+		if fd.TimeSpent > 0 {
+			fds = append(fds, fd)
+		}
+	}
+	return CommitNote{Files: fds}
+}
+
+// Compact collapses each file's hourly timeline into a single entry at the file's first hour.
+// The time spent per file and the commit total are not changed.
+func (n CommitNote) Compact() CommitNote {
+	fds := []FileDetail{}
+	for _, f := range n.Files {
+		fd := FileDetail{SourceFile: f.SourceFile, TimeSpent: f.TimeSpent, Status: f.Status, Timeline: map[int64]int{}}
+		if epochs := f.SortEpochs(); len(epochs) > 0 {
+			secs := 0
+			for _, e := range epochs {
+				secs += f.Timeline[e]
+			}
+			fd.Timeline[epochs[0]] = secs
+		}
+		fds = append(fds, fd)
+	}
+	return CommitNote{Files: fds}
+}
+
+// Merge returns the commit note with the files from o added, time for a file that is in both notes is summed.
+// A file's status is taken from o if it is modified or deleted.
+func (n CommitNote) Merge(o CommitNote) CommitNote {
+	fds := []FileDetail{}
+	idx := map[string]int{}
+	for _, f := range append(append([]FileDetail{}, n.Files...), o.Files...) {
+		i, ok := idx[f.SourceFile]
+		if !ok {
+			idx[f.SourceFile] = len(fds)
+			fds = append(fds, FileDetail{SourceFile: f.SourceFile, Status: f.Status, Timeline: map[int64]int{}})
+			i = len(fds) - 1
+		} else if f.Status == "m" || f.Status == "d" {
+			fds[i].Status = f.Status
+		}
+		fds[i].TimeSpent += f.TimeSpent
+		for ep, secs := range f.Timeline {
+			fds[i].Timeline[ep] += secs
+		}
+	}
+	sort.Stable(sort.Reverse(FileByTime(fds)))
+	return CommitNote{Files: fds}
+}
+
+// Rename returns the commit note with the files in paths moved to their new path, paths are keyed by the old path.
+// Time for files that end up with the same path is summed.
+func (n CommitNote) Rename(paths map[string]string) CommitNote {
+	if len(paths) == 0 {
+		return n
+	}
+	fds := []FileDetail{}
+	for _, f := range n.Files {
+		if p, ok := paths[filepath.ToSlash(f.SourceFile)]; ok {
+			f.SourceFile = p
+		}
+		fds = append(fds, f)
+	}
+	return CommitNote{}.Merge(CommitNote{Files: fds})
+}
+
+// Split returns the time for the files in added, i.e. added by the commit, and the time for the other files.
+// Terminal and app time is not for a file so it's in neither of the notes.
+func (n CommitNote) Split(added map[string]bool) (CommitNote, CommitNote) {
+	newCode, existing := CommitNote{}, CommitNote{}
+	for _, f := range n.Files {
+		switch {
+		case f.IsTerminal() || f.IsApp():
+		case added[filepath.ToSlash(f.SourceFile)]:
+			newCode.Files = append(newCode.Files, f)
+		default:
+			existing.Files = append(existing.Files, f)
+		}
+	}
+	return newCode, existing
+}
+
 // Total returns the total time for a commit note
 func (n CommitNote) Total() int {
 	total := 0
@@ -54,7 +235,7 @@ func (n CommitNote) Total() int {
 
 // Marshal converts a commit note to a serialized string
 func Marshal(n CommitNote) string {
-	s := fmt.Sprintf("[ver:%s,total:%d]\n", "1", n.Total())
+	s := fmt.Sprintf("[ver:%d,total:%d]\n", Version, n.Total())
 	for _, fl := range n.Files {
 		// nomralize file paths to unix convention
 		s += fmt.Sprintf("%s:%d,", filepath.ToSlash(fl.SourceFile), fl.TimeSpent)
@@ -66,83 +247,61 @@ func Marshal(n CommitNote) string {
 	return s
 }
 
-// UnMarshal unserializes a git note string into a commit note
+// Append returns the serialized note s with the time in n added as a new block instead of rewriting s.
+// UnMarshal sums the time of a file that is in more than one block, so the result reads the same as
+// the merge of the notes. A blank s returns Marshal(n) and a note without files leaves s as is.
+// If s was written with an older version of the note format it's migrated and rewritten as a single block.
+func Append(s string, n CommitNote) (string, error) {
+	if strings.TrimSpace(s) == "" {
+		return Marshal(n), nil
+	}
+	existing, err := UnMarshal(s)
+	if err != nil {
+		return "", err
+	}
+	if NeedsMigration(s) {
+		return Marshal(existing.Merge(n)), nil
+	}
+	if len(n.Files) == 0 {
+		return s, nil
+	}
+	return strings.TrimRight(s, "\n") + "\n\n" + Marshal(n), nil
+}
+
+// UnMarshal unserializes a git note string into a commit note,
+// blocks written with an older version of the note format are migrated to the current version
 func UnMarshal(s string) (CommitNote, error) {
-	var (
-		version string
-		files   = []FileDetail{}
-	)
+	files := []FileDetail{}
 
-	reHeader := regexp.MustCompile(`\[ver:\d+,total:\d+]`)
-	reHeaderVals := regexp.MustCompile(`\d+`)
+	blocks, err := splitBlocks(s)
+	if err != nil {
+		return CommitNote{}, err
+	}
 
-	lines := strings.Split(s, "\n")
-	for lineIdx := 0; lineIdx < len(lines); lineIdx++ {
-		switch {
-		case strings.TrimSpace(lines[lineIdx]) == "":
-			version = ""
-		case reHeader.MatchString(lines[lineIdx]):
-			if matches := reHeaderVals.FindAllString(lines[lineIdx], 2); len(matches) == 2 {
-				version = matches[0]
-			} else {
-				return CommitNote{}, fmt.Errorf("Unable to unmarshal time logged, header format invalid, %s", lines[lineIdx])
-			}
-		case version == "1":
-			fieldGroups := strings.Split(lines[lineIdx], ",")
-			if len(fieldGroups) < 3 {
-				return CommitNote{}, fmt.Errorf("Unable to unmarshal time logged, format invalid, %s", lines[lineIdx])
-			}
+	for _, b := range blocks {
+		entries, err := b.migrate()
+		if err != nil {
+			return CommitNote{}, err
+		}
 
-			var (
-				filePath     string
-				fileTotal    int
-				fileStatus   string
-				fileTimeline = map[int64]int{}
-			)
-
-			for groupIdx := range fieldGroups {
-				fieldVals := strings.Split(fieldGroups[groupIdx], ":")
-				switch {
-				case groupIdx == 0 && len(fieldVals) == 2:
-					// file name and total, filename:total
-					filePath = fieldVals[0]
-					t, err := strconv.Atoi(fieldVals[1])
-					if err != nil {
-						return CommitNote{}, fmt.Errorf("Unable to unmarshal time logged, format invalid, %s", err)
-					}
-					fileTotal = t
-				case groupIdx == len(fieldGroups)-1 && len(fieldVals) == 1:
-					// file status of m or r
-					fileStatus = fieldVals[0]
-				case len(fieldVals) == 2:
-					// epoch timeline, epoch:total
-					e, err := strconv.ParseInt(fieldVals[0], 10, 64)
-					if err != nil {
-						return CommitNote{}, fmt.Errorf("Unable to unmarshal time logged, format invalid, %s", err)
-					}
-					t, err := strconv.Atoi(fieldVals[1])
-					if err != nil {
-						return CommitNote{}, fmt.Errorf("Unable to unmarshal time logged, format invalid, %s", err)
-					}
-					fileTimeline[e] = t
-				default:
-					// error
-					return CommitNote{}, fmt.Errorf("Unable to unmarshal time logged, format invalid")
-				}
+		for _, entry := range entries {
+			fd, err := unMarshalEntry(entry)
+			if err != nil {
+				return CommitNote{}, err
 			}
 
 			// check for existing file path and merge if found
 			// for example, this can happen when rewriting commits with git commit --amend
 			found := false
 			for idx := range files {
-				if files[idx].SourceFile == filePath {
-					for epoch, secs := range fileTimeline {
+				if files[idx].SourceFile == fd.SourceFile {
+					for epoch, secs := range fd.Timeline {
 						files[idx].TimeSpent += secs
 						files[idx].Timeline[epoch] += secs
 					}
 					// only change file status if modified or deleted
-					if fileStatus == "m" || fileStatus == "d" {
-						files[idx].Status = fileStatus
+					if fd.Status == "m" || fd.Status == "d" {
+						files[idx].Status = fd.Status
 					}
 					found = true
 					break
@@ -150,28 +309,66 @@ func UnMarshal(s string) (CommitNote, error) {
 			}
 
 			if !found {
-				files = append(files,
-					FileDetail{
-						SourceFile: filePath,
-						TimeSpent:  fileTotal,
-						Timeline:   fileTimeline,
-						Status:     fileStatus})
+				files = append(files, fd)
 			}
-
-		default:
-			return CommitNote{}, fmt.Errorf("Unable to unmarshal time logged, unknown version %s", version)
 		}
 	}
 	sort.Sort(sort.Reverse(FileByTime(files)))
 	return CommitNote{Files: files}, nil
 }
 
+// unMarshalEntry unserializes a file's entry in the current version of the note format,
+// filename:total,epoch:total,...,status
+func unMarshalEntry(entry string) (FileDetail, error) {
+	fieldGroups := strings.Split(entry, ",")
+	if len(fieldGroups) < 3 {
+		return FileDetail{}, fmt.Errorf("%w, format invalid, %s", ErrCorruptNote, entry)
+	}
+
+	fd := FileDetail{Timeline: map[int64]int{}}
+	for groupIdx := range fieldGroups {
+		fieldVals := strings.Split(fieldGroups[groupIdx], ":")
+		switch {
+		case groupIdx == 0 && len(fieldVals) == 2:
+			// file name and total, filename:total
+			fd.SourceFile = fieldVals[0]
+			t, err := strconv.Atoi(fieldVals[1])
+			if err != nil {
+				return FileDetail{}, fmt.Errorf("%w, format invalid, %s", ErrCorruptNote, err)
+			}
+			fd.TimeSpent = t
+		case groupIdx == len(fieldGroups)-1 && len(fieldVals) == 1:
+			// file status of m or r
+			fd.Status = fieldVals[0]
+		case len(fieldVals) == 2:
+			// epoch timeline, epoch:total
+			e, err := strconv.ParseInt(fieldVals[0], 10, 64)
+			if err != nil {
+				return FileDetail{}, fmt.Errorf("%w, format invalid, %s", ErrCorruptNote, err)
+			}
+			t, err := strconv.Atoi(fieldVals[1])
+			if err != nil {
+				return FileDetail{}, fmt.Errorf("%w, format invalid, %s", ErrCorruptNote, err)
+			}
+			fd.Timeline[e] = t
+		default:
+			// error
+			return FileDetail{}, fmt.Errorf("%w, format invalid", ErrCorruptNote)
+		}
+	}
+	return fd, nil
+}
+
 // FileDetail contains a source file's time metrics
 type FileDetail struct {
 	SourceFile string
 	TimeSpent  int
 	Timeline   map[int64]int
 	Status     string
+	// Sessions is the number of editing sessions and LastActive is the Unix epoch the file was last active,
+	// they are only set for pending time and are not saved in git notes
+	Sessions   int   `json:",omitempty"`
+	LastActive int64 `json:",omitempty"`
 }
 
 // ShortenSourceFile shortens source file to length n
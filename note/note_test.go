@@ -5,8 +5,16 @@
 package note
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/util"
 )
 
 func TestUnMarshallTimeLog(t *testing.T) {
@@ -80,3 +88,509 @@ environment/drone/test.go:60,1460070000:60,r
 	}
 
 }
+
+func TestUnMarshalCorruptNote(t *testing.T) {
+	for _, s := range []string{
+		"[ver:1,total:60]\nevent/event.go",
+		"[ver:1,total:60]\nevent/event.go:x,1460070000:60,m",
+		"[ver:9,total:60]\nevent/event.go:60,1460070000:60,m",
+	} {
+		_, err := UnMarshal(s)
+		if !errors.Is(err, ErrCorruptNote) {
+			t.Errorf("UnMarshal(%q), want error %s got %v", s, ErrCorruptNote, err)
+		}
+		if err != nil && !strings.HasPrefix(err.Error(), "Unable to unmarshal time logged, ") {
+			t.Errorf("UnMarshal(%q), want error message prefix %s got %s", s, ErrCorruptNote, err)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	// version 0 is a made up format without timelines, filename:total:status
+	migrations[0] = func(entries []string) ([]string, error) {
+		migrated := []string{}
+		for _, e := range entries {
+			f := strings.Split(e, ":")
+			if len(f) != 3 {
+				return nil, errors.New("invalid entry")
+			}
+			migrated = append(migrated, fmt.Sprintf("%s:%s,1458496800:%s,%s", f[0], f[1], f[1], f[2]))
+		}
+		return migrated, nil
+	}
+	defer delete(migrations, 0)
+
+	v0 := "[ver:0,total:90]\nevent/event.go:60:m\nevent/event_test.go:30:r\n"
+	v1 := "[ver:1,total:90]\nevent/event.go:60,1458496800:60,m\nevent/event_test.go:30,1458496800:30,r\n"
+
+	if !NeedsMigration(v0) || NeedsMigration(v1) {
+		t.Errorf("NeedsMigration, want true for version 0 and false for version 1")
+	}
+
+	want, err := UnMarshal(v1)
+	if err != nil {
+		t.Fatalf("UnMarshal(%q), want error nil got %s", v1, err)
+	}
+	for _, s := range []string{v0, v1, v0 + "\n" + v1} {
+		got, err := UnMarshal(s)
+		if err != nil {
+			t.Fatalf("UnMarshal(%q), want error nil got %s", s, err)
+		}
+		if s == v0+"\n"+v1 {
+			// blocks for the same files are summed
+			want = want.Merge(want)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("UnMarshal(%q), want %+v got %+v", s, want, got)
+		}
+		// marshalling writes the latest version
+		if m := Marshal(got); NeedsMigration(m) || !strings.HasPrefix(m, "[ver:1,") {
+			t.Errorf("Marshal(UnMarshal(%q)), want version 1 got %s", s, m)
+		}
+		if rt, err := UnMarshal(Marshal(got)); err != nil || !reflect.DeepEqual(rt, got) {
+			t.Errorf("UnMarshal(Marshal(UnMarshal(%q))), want %+v got %+v, %v", s, got, rt, err)
+		}
+	}
+
+	for _, s := range []string{"[ver:0,total:60]\nevent/event.go:60", "[ver:2,total:60]\nevent/event.go:60,1458496800:60,m"} {
+		if _, err := UnMarshal(s); !errors.Is(err, ErrCorruptNote) {
+			t.Errorf("UnMarshal(%q), want error %s got %v", s, ErrCorruptNote, err)
+		}
+	}
+	if _, err := UnMarshal("[ver:2,total:60]\n"); !errors.Is(err, ErrNoteVersion) {
+		t.Errorf("UnMarshal(version 2), want error %s got %v", ErrNoteVersion, err)
+	}
+}
+
+func TestFilterDateRange(t *testing.T) {
+	n := CommitNote{
+		Files: []FileDetail{
+			{
+				SourceFile: "event/event.go",
+				TimeSpent:  1800,
+				Timeline:   map[int64]int{int64(1460066400): 1200, int64(1460070000): 600},
+				Status:     "m"},
+			{
+				SourceFile: "event/event_test.go",
+				TimeSpent:  300,
+				Timeline:   map[int64]int{int64(1460073600): 300},
+				Status:     "m"},
+		},
+	}
+
+	cases := []struct {
+		DateRange util.DateRange
+		Want      CommitNote
+	}{
+		{
+			util.DateRange{Start: time.Unix(1460066400+1800, 0)},
+			CommitNote{
+				Files: []FileDetail{
+					{
+						SourceFile: "event/event.go",
+						TimeSpent:  1200,
+						Timeline:   map[int64]int{int64(1460066400): 600, int64(1460070000): 600},
+						Status:     "m"},
+					{
+						SourceFile: "event/event_test.go",
+						TimeSpent:  300,
+						Timeline:   map[int64]int{int64(1460073600): 300},
+						Status:     "m"},
+				},
+			},
+		},
+		{
+			util.DateRange{Start: time.Unix(1460070000, 0), End: time.Unix(1460073600, 0)},
+			CommitNote{
+				Files: []FileDetail{
+					{
+						SourceFile: "event/event.go",
+						TimeSpent:  600,
+						Timeline:   map[int64]int{int64(1460070000): 600},
+						Status:     "m"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		got := n.FilterDateRange(tc.DateRange)
+		if !reflect.DeepEqual(tc.Want, got) {
+			t.Errorf("FilterDateRange(%s), want:\n%+v\n got:\n%+v\n", tc.DateRange, tc.Want, got)
+		}
+	}
+}
+
+func TestWeigh(t *testing.T) {
+	file := FileDetail{
+		SourceFile: "event/event.go",
+		TimeSpent:  1200,
+		Timeline:   map[int64]int{int64(1460066400): 1200},
+		Status:     "m"}
+	terminal := FileDetail{
+		SourceFile: ".gtm/terminal.app",
+		TimeSpent:  901,
+		Timeline:   map[int64]int{int64(1460066400): 301, int64(1460070000): 600},
+		Status:     "r"}
+	browser := FileDetail{
+		SourceFile: ".gtm/browser.app",
+		TimeSpent:  1,
+		Timeline:   map[int64]int{int64(1460066400): 1},
+		Status:     "r"}
+	n := CommitNote{Files: []FileDetail{file, terminal, browser}}
+
+	cases := []struct {
+		Terminal, App float64
+		Want          CommitNote
+	}{
+		{1, 1, n},
+		{0, 1, CommitNote{Files: []FileDetail{file, browser}}},
+		{1, 0, CommitNote{Files: []FileDetail{file, terminal}}},
+		{
+			0.5, 0.4,
+			CommitNote{
+				Files: []FileDetail{
+					file,
+					{
+						SourceFile: ".gtm/terminal.app",
+						TimeSpent:  451,
+						Timeline:   map[int64]int{int64(1460066400): 151, int64(1460070000): 300},
+						Status:     "r"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		got := n.Weigh(tc.Terminal, tc.App)
+		if !reflect.DeepEqual(tc.Want, got) {
+			t.Errorf("Weigh(%v, %v), want:\n%+v\n got:\n%+v\n", tc.Terminal, tc.App, tc.Want, got)
+		}
+	}
+}
+
+func TestReadWriteCommitNotes(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+
+	repo.SaveFile("main.go", "", "")
+	commitID := repo.Commit(repo.Stage("main.go")).String()
+
+	notes, err := ReadCommitNotes(repo.Workdir(), commitID)
+	if err != nil {
+		t.Fatalf("ReadCommitNotes(), want error nil got %s", err)
+	}
+	if len(notes) != 1 || len(notes[0].Files) != 0 {
+		t.Errorf("ReadCommitNotes(), want 1 note without files got %+v", notes)
+	}
+
+	want := CommitNote{
+		Files: []FileDetail{
+			{SourceFile: "main.go", TimeSpent: 120, Timeline: map[int64]int{1460066400: 120}, Status: "m"},
+		},
+	}
+	for i := 0; i < 2; i++ {
+		if err := WriteCommitNote(repo.Workdir(), commitID, want); err != nil {
+			t.Fatalf("WriteCommitNote(), want error nil got %s", err)
+		}
+	}
+
+	notes, err = ReadCommitNotes(repo.Workdir(), commitID)
+	if err != nil {
+		t.Fatalf("ReadCommitNotes(), want error nil got %s", err)
+	}
+	if len(notes) != 1 || !reflect.DeepEqual(notes[0], want) {
+		t.Errorf("ReadCommitNotes(), want %+v got %+v", want, notes)
+	}
+
+	// notes refs are kept separately, a ref that does not exist yet has no time metrics
+	notes, err = ReadCommitNotesFromRef(repo.Workdir(), "refs/notes/gtm-billable", commitID)
+	if err != nil {
+		t.Fatalf("ReadCommitNotesFromRef(), want error nil got %s", err)
+	}
+	if len(notes) != 1 || len(notes[0].Files) != 0 {
+		t.Errorf("ReadCommitNotesFromRef(), want 1 note without files got %+v", notes)
+	}
+	if err := WriteCommitNoteToRef(repo.Workdir(), "gtm-billable", commitID, CommitNote{}); err != nil {
+		t.Fatalf("WriteCommitNoteToRef(), want error nil got %s", err)
+	}
+	notes, err = ReadCommitNotes(repo.Workdir(), commitID)
+	if err != nil || len(notes) != 1 || !reflect.DeepEqual(notes[0], want) {
+		t.Errorf("ReadCommitNotes(), want %+v got %+v, %v", want, notes, err)
+	}
+	if err := WriteCommitNoteToRef(repo.Workdir(), "gtm..billable", commitID, want); err == nil {
+		t.Errorf("WriteCommitNoteToRef(gtm..billable), want error got nil")
+	}
+
+	if _, err := ReadCommitNotes(repo.Workdir(), "invalid"); err == nil {
+		t.Errorf("ReadCommitNotes(invalid), want error got nil")
+	}
+}
+
+func TestReadCommitNotesFromRefContext(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+
+	repo.SaveFile("main.go", "", "")
+	commitID := repo.Commit(repo.Stage("main.go")).String()
+
+	n := CommitNote{Files: []FileDetail{{SourceFile: "main.go", TimeSpent: 60, Timeline: map[int64]int{1460066400: 60}, Status: "m"}}}
+	if err := WriteCommitNote(repo.Workdir(), commitID, n); err != nil {
+		t.Fatalf("WriteCommitNote(), want error nil got %s", err)
+	}
+
+	notes, err := ReadCommitNotesFromRefContext(context.Background(), repo.Workdir(), project.NoteNameSpace, commitID)
+	if err != nil || len(notes) != 1 || !reflect.DeepEqual(notes[0], n) {
+		t.Errorf("ReadCommitNotesFromRefContext(), want %+v and error nil got %+v, %v", n, notes, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	notes, err = ReadCommitNotesFromRefContext(ctx, repo.Workdir(), project.NoteNameSpace, commitID)
+	if !errors.Is(err, context.Canceled) || len(notes) != 0 {
+		t.Errorf("ReadCommitNotesFromRefContext() canceled, want no notes and error %s got %+v, %v", context.Canceled, notes, err)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+
+	repo.SaveFile("main.go", "", "")
+	first := repo.Commit(repo.Stage("main.go")).String()
+	repo.SaveFile("main.go", "", "package main")
+	second := repo.Commit(repo.Stage("main.go")).String()
+
+	n := CommitNote{Files: []FileDetail{{SourceFile: "main.go", TimeSpent: 60, Timeline: map[int64]int{1460066400: 60}, Status: "m"}}}
+	for _, id := range []string{first, second} {
+		if err := WriteCommitNote(repo.Workdir(), id, n); err != nil {
+			t.Fatalf("WriteCommitNote(), want error nil got %s", err)
+		}
+	}
+
+	// the seed commit does not have time metrics and is skipped
+	ids := []string{}
+	err := Walk(repo.Workdir(), project.NoteNameSpace, func(commitID string, cn CommitNote) error {
+		if !reflect.DeepEqual(cn, n) {
+			t.Errorf("Walk(), want note %+v got %+v", n, cn)
+		}
+		ids = append(ids, commitID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk(), want error nil got %s", err)
+	}
+	if !reflect.DeepEqual(ids, []string{second, first}) {
+		t.Errorf("Walk(), want commits %v got %v", []string{second, first}, ids)
+	}
+
+	ids = []string{}
+	err = Walk(repo.Workdir(), project.NoteNameSpace, func(commitID string, cn CommitNote) error {
+		ids = append(ids, commitID)
+		return ErrStopWalk
+	})
+	if err != nil || len(ids) != 1 {
+		t.Errorf("Walk() with ErrStopWalk, want 1 commit and error nil got %v, %v", ids, err)
+	}
+
+	want := errors.New("walk failed")
+	err = Walk(repo.Workdir(), project.NoteNameSpace, func(commitID string, cn CommitNote) error { return want })
+	if err != want {
+		t.Errorf("Walk(), want error %s got %v", want, err)
+	}
+
+	if err := Walk(repo.Workdir(), "gtm-billable", func(commitID string, cn CommitNote) error {
+		t.Errorf("Walk(gtm-billable), want no notes got %s", commitID)
+		return nil
+	}); err != nil {
+		t.Errorf("Walk(gtm-billable), want error nil got %s", err)
+	}
+}
+
+func TestWalkCommits(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+
+	repo.SaveFile("main.go", "", "")
+	first := repo.Commit(repo.Stage("main.go")).String()
+	repo.SaveFile("main.go", "", "package main")
+	second := repo.Commit(repo.Stage("main.go")).String()
+
+	n := CommitNote{Files: []FileDetail{{SourceFile: "main.go", TimeSpent: 60, Timeline: map[int64]int{1460066400: 60}, Status: "m"}}}
+	if err := WriteCommitNote(repo.Workdir(), second, n); err != nil {
+		t.Fatalf("WriteCommitNote(), want error nil got %s", err)
+	}
+
+	// the commits are walked in order, a commit without time metrics has an empty note
+	read := func(commitID string) (CommitNote, error) {
+		return readNote(repo.Workdir(), project.NoteNameSpace, commitID)
+	}
+	totals := []int{}
+	err := WalkCommits(context.Background(), []string{first, second}, read, func(commitID string, cn CommitNote) error {
+		totals = append(totals, cn.Total())
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(totals, []int{0, 60}) {
+		t.Errorf("WalkCommits(), want totals [0 60] and error nil got %v, %v", totals, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = WalkCommits(ctx, []string{first, second}, read, func(commitID string, cn CommitNote) error {
+		t.Errorf("WalkCommits() canceled, want no notes got %s", commitID)
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("WalkCommits() canceled, want error %s got %v", context.Canceled, err)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	a := CommitNote{Files: []FileDetail{
+		{SourceFile: "main.go", TimeSpent: 725, Timeline: map[int64]int{1460066400: 705, 1460070000: 20}, Status: "r"},
+		{SourceFile: "util.go", TimeSpent: 60, Timeline: map[int64]int{1460066400: 60}, Status: "m"},
+	}}
+	b := CommitNote{Files: []FileDetail{
+		{SourceFile: "main.go", TimeSpent: 100, Timeline: map[int64]int{1460070000: 40, 1460073600: 60}, Status: "m"},
+		{SourceFile: "new.go", TimeSpent: 30, Timeline: map[int64]int{1460073600: 30}, Status: "r"},
+	}}
+
+	cases := []struct {
+		existing string
+		n        CommitNote
+		want     CommitNote
+	}{
+		{"", b, b},
+		{Marshal(a), b, a.Merge(b)},
+		{Marshal(a), CommitNote{}, a},
+		{Marshal(a) + "\n" + Marshal(b), a, a.Merge(b).Merge(a)},
+	}
+
+	for _, tc := range cases {
+		s, err := Append(tc.existing, tc.n)
+		if err != nil {
+			t.Fatalf("Append(%q), want error nil got %s", tc.existing, err)
+		}
+		if !strings.HasPrefix(s, strings.TrimRight(tc.existing, "\n")) {
+			t.Errorf("Append(%q), want the existing note kept got %q", tc.existing, s)
+		}
+		got, err := UnMarshal(s)
+		if err != nil {
+			t.Fatalf("UnMarshal(%q), want error nil got %s", s, err)
+		}
+		if got.Total() != tc.want.Total() {
+			t.Errorf("Append(%q) total, want %d got %d", tc.existing, tc.want.Total(), got.Total())
+		}
+		if !reflect.DeepEqual(filesBySource(got), filesBySource(tc.want)) {
+			t.Errorf("Append(%q), want %+v got %+v", tc.existing, tc.want, got)
+		}
+	}
+
+	if _, err := Append("[ver:1,total:60]\nmain.go:x,m\n", b); !errors.Is(err, ErrCorruptNote) {
+		t.Errorf("Append(corrupt note), want ErrCorruptNote got %v", err)
+	}
+}
+
+// filesBySource returns the note's files keyed by source file so notes can be compared regardless of file order
+func filesBySource(n CommitNote) map[string]FileDetail {
+	m := map[string]FileDetail{}
+	for _, f := range n.Files {
+		m[f.SourceFile] = f
+	}
+	return m
+}
+
+func TestRename(t *testing.T) {
+	n := CommitNote{Files: []FileDetail{
+		{SourceFile: "main.go", TimeSpent: 60, Timeline: map[int64]int{1460066400: 60}, Status: "r"},
+		{SourceFile: "app.go", TimeSpent: 120, Timeline: map[int64]int{1460066400: 120}, Status: "m"},
+		{SourceFile: "util.go", TimeSpent: 30, Timeline: map[int64]int{1460066400: 30}, Status: "r"},
+	}}
+
+	got := n.Rename(map[string]string{"main.go": "app.go", "other.go": "new.go"})
+	want := CommitNote{Files: []FileDetail{
+		{SourceFile: "app.go", TimeSpent: 180, Timeline: map[int64]int{1460066400: 180}, Status: "m"},
+		{SourceFile: "util.go", TimeSpent: 30, Timeline: map[int64]int{1460066400: 30}, Status: "r"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Rename(), want %+v got %+v", want, got)
+	}
+	if n.Files[0].SourceFile != "main.go" || n.Files[1].TimeSpent != 120 {
+		t.Errorf("Rename(), want the note not changed got %+v", n)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	n := CommitNote{Files: []FileDetail{
+		{SourceFile: "main.go", TimeSpent: 60, Timeline: map[int64]int{1460066400: 60}, Status: "m"},
+		{SourceFile: "app.go", TimeSpent: 120, Timeline: map[int64]int{1460066400: 120}, Status: "m"},
+		{SourceFile: ".gtm/terminal.app", TimeSpent: 30, Timeline: map[int64]int{1460066400: 30}, Status: "r"},
+	}}
+
+	newCode, existing := n.Split(map[string]bool{"app.go": true, ".gtm/terminal.app": true})
+	if want := []FileDetail{n.Files[1]}; !reflect.DeepEqual(newCode.Files, want) {
+		t.Errorf("Split(), want new code %+v got %+v", want, newCode.Files)
+	}
+	if want := []FileDetail{n.Files[0]}; !reflect.DeepEqual(existing.Files, want) {
+		t.Errorf("Split(), want existing %+v got %+v", want, existing.Files)
+	}
+}
+
+func TestAppendCommitNote(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+
+	repo.SaveFile("main.go", "", "")
+	commitID := repo.Commit(repo.Stage("main.go")).String()
+
+	a := CommitNote{Files: []FileDetail{{SourceFile: "main.go", TimeSpent: 120, Timeline: map[int64]int{1460066400: 120}, Status: "m"}}}
+	b := CommitNote{Files: []FileDetail{{SourceFile: "main.go", TimeSpent: 60, Timeline: map[int64]int{1460070000: 60}, Status: "m"}}}
+
+	for _, n := range []CommitNote{a, b} {
+		if err := AppendCommitNote(repo.Workdir(), commitID, n); err != nil {
+			t.Fatalf("AppendCommitNote(), want error nil got %s", err)
+		}
+	}
+
+	notes, err := ReadCommitNotes(repo.Workdir(), commitID)
+	if err != nil {
+		t.Fatalf("ReadCommitNotes(), want error nil got %s", err)
+	}
+	if want := a.Merge(b); len(notes) != 1 || !reflect.DeepEqual(notes[0], want) {
+		t.Errorf("ReadCommitNotes(), want %+v got %+v", want, notes)
+	}
+
+	if err := AppendCommitNoteToRef(repo.Workdir(), "gtm..billable", commitID, a); err == nil {
+		t.Errorf("AppendCommitNoteToRef(gtm..billable), want error got nil")
+	}
+}
+
+func TestVerifyRepair(t *testing.T) {
+	s := "[ver:1,total:60]\nsrc/a.go:60,1458496800:60,m\nsrc/b.go:x,m\n.gtm/terminal.app:30,1458496800:30,r\nold.go:10,1458496800:10,d\nc.go:-5,1458496800:-5,r\n"
+
+	want := []string{
+		"unable to parse entry src/b.go:x,m",
+		"negative time for c.go",
+		"c.go is not in the commit tree",
+	}
+	if got := Verify(s, []string{"src/a.go"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Verify(%s), want %+v got %+v", s, want, got)
+	}
+
+	repaired, removed := Repair(s)
+	if removed != 1 {
+		t.Errorf("Repair(%s), want 1 removed got %d", s, removed)
+	}
+	if got := Verify(repaired, []string{"src/a.go", "c.go"}); !reflect.DeepEqual(got, []string{"negative time for c.go"}) {
+		t.Errorf("Verify(%s), want negative time for c.go got %+v", repaired, got)
+	}
+
+	if got, removed := Repair(repaired); got != repaired || removed != 0 {
+		t.Errorf("Repair(%s), want unchanged got %s, %d removed", repaired, got, removed)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package note
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	reNoteHeader = regexp.MustCompile(`\[ver:\d+,total:\d+]`)
+	reHeaderVals = regexp.MustCompile(`\d+`)
+)
+
+// Verify checks a serialized git note and returns a description of each problem found.
+// Entries that can not be unmarshalled, entries with negative time and source files
+// that are not in treeFiles are reported. Deleted files and app events are not expected to be in the tree.
+func Verify(s string, treeFiles []string) []string {
+	problems := []string{}
+
+	valid, invalid := splitEntries(s)
+	for _, line := range invalid {
+		problems = append(problems, fmt.Sprintf("unable to parse entry %s", line))
+	}
+
+	n, err := UnMarshal(valid)
+	if err != nil {
+		return append(problems, err.Error())
+	}
+
+	inTree := map[string]bool{}
+	for _, f := range treeFiles {
+		inTree[filepath.ToSlash(f)] = true
+	}
+
+	for _, f := range n.Files {
+		negative := f.TimeSpent < 0
+		for _, secs := range f.Timeline {
+			if secs < 0 {
+				negative = true
+			}
+		}
+		if negative {
+			problems = append(problems, fmt.Sprintf("negative time for %s", f.SourceFile))
+		}
+		if f.Status != "d" && !f.IsApp() && !inTree[filepath.ToSlash(f.SourceFile)] {
+			problems = append(problems, fmt.Sprintf("%s is not in the commit tree", f.SourceFile))
+		}
+	}
+
+	return problems
+}
+
+// Repair removes the entries that can not be unmarshalled from a serialized git note,
+// returning the repaired note and the number of entries removed
+func Repair(s string) (string, int) {
+	valid, invalid := splitEntries(s)
+	if len(invalid) == 0 {
+		return s, 0
+	}
+	n, err := UnMarshal(valid)
+	if err != nil {
+		return s, 0
+	}
+	return Marshal(n), len(invalid)
+}
+
+// headerErr returns the error for a block with only the header
+func headerErr(header string) error {
+	if header == "" {
+		return nil
+	}
+	_, err := UnMarshal(header)
+	return err
+}
+
+// splitEntries separates the lines of a serialized git note into a note that can be unmarshalled
+// and the lines that can not be unmarshalled
+func splitEntries(s string) (string, []string) {
+	var (
+		header  string
+		valid   []string
+		invalid []string
+	)
+
+	for _, line := range strings.Split(s, "\n") {
+		switch {
+		case strings.TrimSpace(line) == "":
+			header = ""
+			valid = append(valid, line)
+		case reNoteHeader.MatchString(line):
+			header = line
+			valid = append(valid, line)
+		case errors.Is(headerErr(header), ErrNoteVersion):
+			// entries written by a newer gtm are not removed, unmarshalling the note reports the version
+			valid = append(valid, line)
+		default:
+			if _, err := UnMarshal(header + "\n" + line); err != nil || header == "" {
+				invalid = append(invalid, line)
+				continue
+			}
+			valid = append(valid, line)
+		}
+	}
+
+	return strings.Join(valid, "\n"), invalid
+}
@@ -0,0 +1,100 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package note
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of the serialized note format written by Marshal.
+// A change to the format increments Version and adds a migration from the previous version.
+const Version = 1
+
+// ErrNoteVersion is returned when a git note's version can not be read, i.e. it was written by a newer gtm.
+// It wraps ErrCorruptNote, use errors.Is to check for it.
+var ErrNoteVersion = fmt.Errorf("%w, note version not supported", ErrCorruptNote)
+
+// migrations upgrade the entries of a block of a serialized note to the next version,
+// they are keyed by the version they upgrade from
+var migrations = map[int]func(entries []string) ([]string, error){}
+
+// noteBlock is a header and the entries that follow it in a serialized note,
+// git notes that are appended to, i.e. when rewriting commits, have more than one block
+type noteBlock struct {
+	version int
+	entries []string
+}
+
+// headerVersion returns the version in a block header, ok is false if the line is not a header
+func headerVersion(line string) (version int, ok bool, err error) {
+	if !reNoteHeader.MatchString(line) {
+		return 0, false, nil
+	}
+	matches := reHeaderVals.FindAllString(line, 2)
+	if len(matches) != 2 {
+		return 0, true, fmt.Errorf("%w, header format invalid, %s", ErrCorruptNote, line)
+	}
+	if version, err = strconv.Atoi(matches[0]); err != nil {
+		return 0, true, fmt.Errorf("%w, header format invalid, %s", ErrCorruptNote, line)
+	}
+	return version, true, nil
+}
+
+// splitBlocks splits a serialized note into blocks, a blank line ends a block
+func splitBlocks(s string) ([]noteBlock, error) {
+	blocks := []noteBlock{}
+	inBlock := false
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) == "" {
+			inBlock = false
+			continue
+		}
+		version, isHeader, err := headerVersion(line)
+		switch {
+		case err != nil:
+			return nil, err
+		case isHeader:
+			blocks = append(blocks, noteBlock{version: version})
+			inBlock = true
+		case !inBlock:
+			return nil, fmt.Errorf("%w, unknown version", ErrCorruptNote)
+		default:
+			blocks[len(blocks)-1].entries = append(blocks[len(blocks)-1].entries, line)
+		}
+	}
+	return blocks, nil
+}
+
+// migrate returns a block's entries upgraded to the current Version
+func (b noteBlock) migrate() ([]string, error) {
+	if b.version > Version {
+		return nil, fmt.Errorf("%w, version %d is newer than version %d, upgrade gtm to read it", ErrNoteVersion, b.version, Version)
+	}
+	entries := b.entries
+	for v := b.version; v < Version; v++ {
+		m, ok := migrations[v]
+		if !ok {
+			return nil, fmt.Errorf("%w, unknown version %d", ErrNoteVersion, v)
+		}
+		var err error
+		if entries, err = m(entries); err != nil {
+			return nil, fmt.Errorf("%w, unable to migrate version %d, %s", ErrCorruptNote, v, err)
+		}
+	}
+	return entries, nil
+}
+
+// NeedsMigration returns true if a block of the serialized note was written with an older version,
+// Marshal(UnMarshal(s)) upgrades the note to the current version
+func NeedsMigration(s string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		if v, ok, err := headerVersion(line); ok && err == nil && v < Version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// holidayLayout is the date format of Holidays
+const holidayLayout = "2006-01-02"
+
+// clockRange is a range of working hours in a day in minutes after midnight
+type clockRange struct {
+	start, end int
+}
+
+// Calendar is a project's working hours for each day of the week and its holidays, see Config.Calendar
+type Calendar struct {
+	hours    map[time.Weekday][]clockRange
+	holidays map[string]bool
+}
+
+// Calendar returns the project's calendar of working hours, it's nil if the config does not have
+// working_hours or holidays. Days without working hours are not work days, if there are no working_hours
+// every day that's not a holiday is a work day.
+func (c Config) Calendar() (*Calendar, error) {
+	if len(c.WorkingHours) == 0 && len(c.Holidays) == 0 {
+		return nil, nil
+	}
+
+	cal := &Calendar{hours: map[time.Weekday][]clockRange{}, holidays: map[string]bool{}}
+	if len(c.WorkingHours) == 0 {
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			cal.hours[d] = []clockRange{{start: 0, end: 24 * 60}}
+		}
+	}
+	for day, hours := range c.WorkingHours {
+		weekday, ok := parseWeekday(day)
+		if !ok {
+			return nil, fmt.Errorf("working_hours day %s is not valid, use a day of the week i.e. monday", day)
+		}
+		for _, h := range strings.Split(hours, ",") {
+			r, err := parseClockRange(strings.TrimSpace(h))
+			if err != nil {
+				return nil, fmt.Errorf("working_hours for %s are not valid, %s", day, err)
+			}
+			cal.hours[weekday] = append(cal.hours[weekday], r)
+		}
+	}
+	for _, h := range c.Holidays {
+		if _, err := time.Parse(holidayLayout, h); err != nil {
+			return nil, fmt.Errorf("holidays must be dates, i.e. 2016-12-25")
+		}
+		cal.holidays[h] = true
+	}
+	return cal, nil
+}
+
+// parseWeekday returns the weekday for its English name, i.e. monday or Monday
+func parseWeekday(s string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), s) {
+			return d, true
+		}
+	}
+	return time.Sunday, false
+}
+
+var clockRegex = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+
+// parseClockRange parses a range of clock times in a day, i.e. 09:00-17:00, the end can be 24:00
+func parseClockRange(s string) (clockRange, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return clockRange{}, fmt.Errorf("%s must be a range of clock times, i.e. 09:00-17:00", s)
+	}
+	var mins [2]int
+	for i, p := range parts {
+		matches := clockRegex.FindStringSubmatch(strings.TrimSpace(p))
+		if matches == nil {
+			return clockRange{}, fmt.Errorf("%s must be a range of clock times, i.e. 09:00-17:00", s)
+		}
+		h, _ := strconv.Atoi(matches[1])
+		m, _ := strconv.Atoi(matches[2])
+		if m > 59 || h*60+m > 24*60 {
+			return clockRange{}, fmt.Errorf("%s must be a range of clock times, i.e. 09:00-17:00", s)
+		}
+		mins[i] = h*60 + m
+	}
+	if mins[0] >= mins[1] {
+		return clockRange{}, fmt.Errorf("%s must start before it ends", s)
+	}
+	return clockRange{start: mins[0], end: mins[1]}, nil
+}
+
+// Portion returns the portion of the duration d from start that's within working hours in the time zone loc,
+// from 0 when none of it is to 1 when all of it is
+func (c *Calendar) Portion(start time.Time, d time.Duration, loc *time.Location) float64 {
+	if d <= 0 {
+		return 0
+	}
+	start = start.In(loc)
+	end := start.Add(d)
+
+	var working time.Duration
+	for day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc); day.Before(end); day = day.AddDate(0, 0, 1) {
+		if c.holidays[day.Format(holidayLayout)] {
+			continue
+		}
+		for _, r := range c.hours[day.Weekday()] {
+			// clock times are set on the day so working hours are kept when daylight saving time changes
+			rStart := time.Date(day.Year(), day.Month(), day.Day(), 0, r.start, 0, 0, loc)
+			rEnd := time.Date(day.Year(), day.Month(), day.Day(), 0, r.end, 0, 0, loc)
+			if rStart.Before(start) {
+				rStart = start
+			}
+			if rEnd.After(end) {
+				rEnd = end
+			}
+			if rEnd.After(rStart) {
+				working += rEnd.Sub(rStart)
+			}
+		}
+	}
+	return float64(working) / float64(d)
+}
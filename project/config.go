@@ -0,0 +1,275 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/git-time-metric/gtm/epoch"
+	"github.com/git-time-metric/gtm/util"
+)
+
+// ConfigFile is the name of the project configuration file within the gtm directory
+const ConfigFile = "config.json"
+
+// Config contains per project settings, settings not in the config file keep their defaults
+type Config struct {
+	// IdleTimeout is the number of seconds between events that are counted as time spent
+	IdleTimeout int64 `json:"idle_timeout"`
+	// NotesRef is the git notes ref time is saved to and reported from, i.e. refs/notes/gtm-billable,
+	// refs/notes/gtm-data is used if blank
+	NotesRef string `json:"notes_ref,omitempty"`
+	// Extensions limits time tracking to source files with these extensions, i.e. [".go", ".md"],
+	// all files are tracked if empty
+	Extensions []string `json:"extensions,omitempty"`
+	// Include limits time tracking to source files matching these glob patterns, i.e. ["cmd/**"],
+	// all files are tracked if empty
+	Include []string `json:"include,omitempty"`
+	// Exclude stops time tracking for source files matching these glob patterns, i.e. ["*.lock", "vendor/**"]
+	Exclude []string `json:"exclude,omitempty"`
+	// Granularity is the number of seconds in each window events are bucketed into, it must evenly divide an hour
+	// and defaults to epoch.WindowSize when zero. Smaller windows split time between files more precisely but
+	// increase the size of the metric files kept in the gtm directory until commit, git notes are downsampled
+	// to the hour and are the same size for any granularity.
+	Granularity int64 `json:"granularity,omitempty"`
+	// Submodules tracks time in the project's initialized submodules in the submodules' own notes,
+	// otherwise time in a submodule that's not initialized is tracked in the project. It's set by gtm init -submodules.
+	Submodules bool `json:"submodules,omitempty"`
+	// EventSources are commands that contribute events for the project, i.e. ["gtm-browser-events --profile work"],
+	// see metric.CommandEventSource for the line protocol the commands write. gtm status reuses the events a command
+	// wrote within the last minute instead of running it each time.
+	EventSources []string `json:"event_sources,omitempty"`
+	// TrackingStart is the date time tracking started for the project, i.e. "2016-03-01", reports ignore commits
+	// before the start of the day in the local time zone. Commits of any date are reported if blank.
+	TrackingStart string `json:"tracking_start,omitempty"`
+	// WebhookURL is sent a HTTP POST with a JSON payload when the time logged since the last commit
+	// reaches WebhookThreshold, i.e. "https://hooks.example.com/gtm". It's checked by gtm status.
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// WebhookThreshold is the number of seconds of pending time that notifies the WebhookURL, i.e. 14400 for 4h
+	WebhookThreshold int64 `json:"webhook_threshold,omitempty"`
+	// WorkingHours are the clock time ranges of work for each day of the week, i.e. {"monday": "09:00-17:00"} or
+	// {"friday": "09:00-12:00,13:00-15:00"}, days that are not listed are not work days. See Calendar.
+	WorkingHours map[string]string `json:"working_hours,omitempty"`
+	// Holidays are the dates that are not work days, i.e. ["2016-12-25"]
+	Holidays []string `json:"holidays,omitempty"`
+	// Languages maps file extensions, or the names of files without one, to the programming language reported
+	// for the files by gtm report -by=language, i.e. {".tmpl": "Go", "Jenkinsfile": "Groovy"}. They take
+	// precedence over the built-in Languages.
+	Languages map[string]string `json:"languages,omitempty"`
+	// IssueKeyPattern is the regular expression for the issue keys in commit messages that gtm report -format=jira
+	// totals time by, i.e. "#[0-9]+". DefaultIssueKeyRegex, JIRA's issue keys, is used if blank.
+	IssueKeyPattern string `json:"issue_key_regex,omitempty"`
+	// Proration is how the time of a window is split between the files edited in it, [proportional|last],
+	// ProrationProportional is used if blank
+	Proration string `json:"proration,omitempty"`
+	// Debounce is the number of milliseconds within which consecutive events for the same file are coalesced into
+	// one before time is allocated, i.e. 2000 for editors that save on every change, see event.Debounce. Event times
+	// are whole seconds so 1000 or less only coalesces events in the same second. Events are not coalesced when zero.
+	Debounce int64 `json:"debounce,omitempty"`
+}
+
+// Proration policies for splitting the time of a window of events between the files edited in it
+const (
+	// ProrationProportional splits the window's seconds between the files in proportion to their events,
+	// files that are edited concurrently with the same number of events get an equal share.
+	// Seconds left over from rounding go to the file with the most events.
+	ProrationProportional = "proportional"
+	// ProrationLast gives all of the window's seconds to the file that was edited last in the window
+	ProrationLast = "last"
+)
+
+// Prorations are the valid Proration policies
+var Prorations = []string{ProrationProportional, ProrationLast}
+
+// DefaultConfig returns the configuration used when a project does not have a config file
+func DefaultConfig() Config {
+	return Config{IdleTimeout: epoch.IdleTimeout}
+}
+
+// NoteNameSpace returns the git notes namespace for the config's notes ref
+func (c Config) NoteNameSpace() string {
+	if c.NotesRef == "" {
+		return NoteNameSpace
+	}
+	return strings.TrimPrefix(c.NotesRef, "refs/notes/")
+}
+
+// trackingStartLayout is the date format of TrackingStart
+const trackingStartLayout = "2006-01-02"
+
+// TrackingStartTime returns the start of the TrackingStart date in the local time zone,
+// it's the zero time if TrackingStart is blank or invalid
+func (c Config) TrackingStartTime() time.Time {
+	if c.TrackingStart == "" {
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation(trackingStartLayout, c.TrackingStart, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// WindowSize returns the number of seconds in each event window
+func (c Config) WindowSize() int64 {
+	if c.Granularity == 0 {
+		return epoch.WindowSize
+	}
+	return c.Granularity
+}
+
+// LoadConfig reads the configuration for the project in the gtmPath directory.
+// If the config file does not exist the defaults are returned, if it can not be read
+// or is invalid the defaults are returned along with an error.
+func LoadConfig(gtmPath string) (Config, error) {
+	c := DefaultConfig()
+
+	raw, err := ioutil.ReadFile(filepath.Join(gtmPath, ConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return c, err
+	}
+
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return DefaultConfig(), fmt.Errorf("Unable to read %s, %s", filepath.Join(gtmPath, ConfigFile), err)
+	}
+	if err := c.validate(); err != nil {
+		return DefaultConfig(), fmt.Errorf("Invalid %s, %s", filepath.Join(gtmPath, ConfigFile), err)
+	}
+
+	return c, nil
+}
+
+// ReadConfig is like LoadConfig but the defaults are returned without an error if the config can not be read
+// or is invalid, commands use LoadConfig to warn about it
+func ReadConfig(gtmPath string) Config {
+	c, _ := LoadConfig(gtmPath)
+	return c
+}
+
+// Save writes the configuration to the gtmPath directory
+func (c Config) Save(gtmPath string) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(gtmPath, ConfigFile), append(b, '\n'), 0644)
+}
+
+// Tracked returns true if time spent in the source file should be counted
+func (c Config) Tracked(sourcePath string) bool {
+	if AppEventFileContentRegex.MatchString(sourcePath) {
+		return true
+	}
+	if len(c.Extensions) > 0 && !c.hasExtension(sourcePath) {
+		return false
+	}
+	if len(c.Include) > 0 && !util.MatchAnyGlob(c.Include, sourcePath) {
+		return false
+	}
+	return !util.MatchAnyGlob(c.Exclude, sourcePath)
+}
+
+func (c Config) hasExtension(sourcePath string) bool {
+	ext := strings.ToLower(filepath.Ext(sourcePath))
+	for _, e := range c.Extensions {
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		if strings.ToLower(e) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+var notesRefRegex = regexp.MustCompile(`^refs/notes/[A-Za-z0-9_\-]+([./][A-Za-z0-9_\-]+)*$`)
+
+// NotesRef returns the full git notes ref, ref can be a full ref, i.e. refs/notes/gtm-data, or a notes namespace, i.e. gtm-data
+func NotesRef(ref string) (string, error) {
+	if !strings.HasPrefix(ref, "refs/notes/") {
+		ref = "refs/notes/" + ref
+	}
+	if !notesRefRegex.MatchString(ref) {
+		return "", fmt.Errorf("invalid notes ref %s", ref)
+	}
+	return ref, nil
+}
+
+func (c Config) validate() error {
+	if c.IdleTimeout < 0 {
+		return fmt.Errorf("idle_timeout must not be negative")
+	}
+	if c.Debounce < 0 {
+		return fmt.Errorf("debounce must not be negative")
+	}
+	if c.Granularity < 0 || (c.Granularity > 0 && 3600%c.Granularity != 0) {
+		return fmt.Errorf("granularity must be a number of seconds that evenly divides an hour")
+	}
+	if c.NotesRef != "" {
+		if _, err := NotesRef(c.NotesRef); err != nil {
+			return err
+		}
+	}
+	if c.TrackingStart != "" {
+		if _, err := time.Parse(trackingStartLayout, c.TrackingStart); err != nil {
+			return fmt.Errorf("tracking_start must be a date, i.e. 2016-03-01")
+		}
+	}
+	if c.WebhookURL != "" {
+		if u, err := url.Parse(c.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook_url must be a http or https URL")
+		}
+		if c.WebhookThreshold <= 0 {
+			return fmt.Errorf("webhook_threshold must be greater than zero")
+		}
+	}
+	if c.WebhookThreshold < 0 {
+		return fmt.Errorf("webhook_threshold must not be negative")
+	}
+	if _, err := c.Calendar(); err != nil {
+		return err
+	}
+	for k, lang := range c.Languages {
+		if strings.TrimSpace(k) == "" || k == "." || strings.TrimSpace(lang) == "" {
+			return fmt.Errorf("languages must not have a blank extension or language")
+		}
+	}
+	if c.Proration != "" && !util.StringInSlice(Prorations, c.Proration) {
+		return fmt.Errorf("proration must be one of %s", strings.Join(Prorations, ", "))
+	}
+	if c.IssueKeyPattern != "" {
+		if _, err := regexp.Compile(c.IssueKeyPattern); err != nil {
+			return fmt.Errorf("issue_key_regex is not a valid regular expression, %s", err)
+		}
+	}
+	for _, cmd := range c.EventSources {
+		if strings.TrimSpace(cmd) == "" {
+			return fmt.Errorf("event_sources must not have a blank command")
+		}
+	}
+	for _, p := range append(append([]string{}, c.Include...), c.Exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %s", p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/git-time-metric/gtm/epoch"
+)
+
+func TestLoadConfig(t *testing.T) {
+	gtmPath, err := ioutil.TempDir("", "gtm")
+	if err != nil {
+		t.Fatalf("Unable to create tempory directory %s, %s", gtmPath, err)
+	}
+	defer os.RemoveAll(gtmPath)
+
+	c, err := LoadConfig(gtmPath)
+	if err != nil {
+		t.Errorf("LoadConfig() missing config, want error nil got %s", err)
+	}
+	if !reflect.DeepEqual(c, DefaultConfig()) {
+		t.Errorf("LoadConfig() missing config, want %+v got %+v", DefaultConfig(), c)
+	}
+
+	want := Config{IdleTimeout: 300, Extensions: []string{".go", "md"}}
+	if err := want.Save(gtmPath); err != nil {
+		t.Fatalf("Save(), want error nil got %s", err)
+	}
+	c, err = LoadConfig(gtmPath)
+	if err != nil {
+		t.Errorf("LoadConfig(), want error nil got %s", err)
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("LoadConfig(), want %+v got %+v", want, c)
+	}
+
+	// settings not in the config file keep their defaults
+	if err := ioutil.WriteFile(filepath.Join(gtmPath, ConfigFile), []byte(`{"extensions": [".go"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err = LoadConfig(gtmPath)
+	if err != nil {
+		t.Errorf("LoadConfig() partial config, want error nil got %s", err)
+	}
+	if c.IdleTimeout != epoch.IdleTimeout {
+		t.Errorf("LoadConfig() partial config, want idle timeout %d got %d", epoch.IdleTimeout, c.IdleTimeout)
+	}
+
+	for _, raw := range []string{`{"idle_timeout": `, `{"idle_timeout": -1}`, `{"exclude": ["[*.lock"]}`, `{"notes_ref": "refs/notes/a..b"}`, `{"granularity": 7}`, `{"granularity": -30}`, `{"tracking_start": "March 1"}`,
+		`{"webhook_url": "ftp://example.com", "webhook_threshold": 60}`, `{"webhook_url": "https://example.com/gtm"}`, `{"webhook_threshold": -1}`,
+		`{"working_hours": {"funday": "09:00-17:00"}}`, `{"working_hours": {"monday": "17:00-09:00"}}`, `{"working_hours": {"monday": "9am-5pm"}}`, `{"holidays": ["25/12"]}`,
+		`{"languages": {".tmpl": ""}}`, `{"languages": {"": "Go"}}`, `{"issue_key_regex": "GTM-[0-9"}`, `{"proration": "equal"}`, `{"debounce": -500}`} {
+		if err := ioutil.WriteFile(filepath.Join(gtmPath, ConfigFile), []byte(raw), 0644); err != nil {
+			t.Fatal(err)
+		}
+		c, err = LoadConfig(gtmPath)
+		if err == nil {
+			t.Errorf("LoadConfig() %s, want error got nil", raw)
+		}
+		if !reflect.DeepEqual(c, DefaultConfig()) {
+			t.Errorf("LoadConfig() %s, want %+v got %+v", raw, DefaultConfig(), c)
+		}
+	}
+}
+
+func TestConfigWindowSize(t *testing.T) {
+	if got := DefaultConfig().WindowSize(); got != epoch.WindowSize {
+		t.Errorf("WindowSize() default, want %d got %d", epoch.WindowSize, got)
+	}
+	if got := (Config{Granularity: 30}).WindowSize(); got != 30 {
+		t.Errorf("WindowSize() granularity 30, want 30 got %d", got)
+	}
+}
+
+func TestConfigTracked(t *testing.T) {
+	tests := []struct {
+		config Config
+		path   string
+		want   bool
+	}{
+		{Config{}, "main.lock", true},
+		{Config{Extensions: []string{".go", "md"}}, filepath.Join("event", "event.go"), true},
+		{Config{Extensions: []string{".go", "md"}}, "README.MD", true},
+		{Config{Extensions: []string{".go", "md"}}, "main.lock", false},
+		{Config{Extensions: []string{".go"}}, filepath.Join(GTMDir, "terminal.app"), true},
+		{Config{Exclude: []string{"*.lock", "vendor/**"}}, "Gemfile.lock", false},
+		{Config{Exclude: []string{"*.lock", "vendor/**"}}, filepath.Join("vendor", "x", "x.go"), false},
+		{Config{Exclude: []string{"*.lock", "vendor/**"}}, filepath.Join("event", "event.go"), true},
+		{Config{Include: []string{"cmd/**"}}, filepath.Join("cmd", "main.go"), true},
+		{Config{Include: []string{"cmd/**"}}, "main.go", false},
+		{Config{Include: []string{"cmd/**"}, Exclude: []string{"*_test.go"}}, filepath.Join("cmd", "main_test.go"), false},
+	}
+
+	for _, tc := range tests {
+		if got := tc.config.Tracked(tc.path); got != tc.want {
+			t.Errorf("Tracked(%s) with %+v, want %t got %t", tc.path, tc.config, tc.want, got)
+		}
+	}
+}
+
+func TestConfigCalendar(t *testing.T) {
+	if cal, err := DefaultConfig().Calendar(); cal != nil || err != nil {
+		t.Errorf("Calendar() default, want nil got %+v, %v", cal, err)
+	}
+
+	cfg := Config{
+		WorkingHours: map[string]string{"monday": "09:00-17:00", "Friday": "09:00-12:00, 13:00-24:00"},
+		Holidays:     []string{"2016-03-21"},
+	}
+	cal, err := cfg.Calendar()
+	if err != nil {
+		t.Fatalf("Calendar(), want error nil got %s", err)
+	}
+
+	tests := []struct {
+		start string
+		want  float64
+	}{
+		{"2016-03-14T09:00:00Z", 1},   // monday
+		{"2016-03-14T08:30:00Z", 0.5}, // monday, starts at 09:00
+		{"2016-03-15T10:00:00Z", 0},   // tuesday is not a work day
+		{"2016-03-18T12:30:00Z", 0.5}, // friday, lunch until 13:00
+		{"2016-03-18T23:30:00Z", 0.5}, // friday until midnight
+		{"2016-03-21T10:00:00Z", 0},   // holiday
+	}
+	for _, tc := range tests {
+		start, err := time.Parse(time.RFC3339, tc.start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cal.Portion(start, time.Hour, time.UTC); got != tc.want {
+			t.Errorf("Portion(%s), want %v got %v", tc.start, tc.want, got)
+		}
+	}
+
+	// without working hours every day that's not a holiday is a work day
+	cal, err = (Config{Holidays: []string{"2016-03-21"}}).Calendar()
+	if err != nil {
+		t.Fatalf("Calendar() holidays, want error nil got %s", err)
+	}
+	for start, want := range map[string]float64{"2016-03-20T18:00:00Z": 1, "2016-03-21T18:00:00Z": 0} {
+		s, _ := time.Parse(time.RFC3339, start)
+		if got := cal.Portion(s, time.Hour, time.UTC); got != want {
+			t.Errorf("Portion(%s) holidays, want %v got %v", start, want, got)
+		}
+	}
+}
+
+func TestNotesRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{"gtm-data", "refs/notes/gtm-data", false},
+		{"refs/notes/gtm-billable", "refs/notes/gtm-billable", false},
+		{"gtm/personal", "refs/notes/gtm/personal", false},
+		{"", "", true},
+		{"refs/notes/", "", true},
+		{"gtm..data", "", true},
+		{"gtm data", "", true},
+		{"gtm/", "", true},
+	}
+
+	for _, tc := range tests {
+		got, err := NotesRef(tc.ref)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("NotesRef(%s), want error %t got %v", tc.ref, tc.wantErr, err)
+		}
+		if got != tc.want {
+			t.Errorf("NotesRef(%s), want %s got %s", tc.ref, tc.want, got)
+		}
+	}
+
+	if got := (Config{}).NoteNameSpace(); got != NoteNameSpace {
+		t.Errorf("NoteNameSpace(), want %s got %s", NoteNameSpace, got)
+	}
+	if got := (Config{NotesRef: "refs/notes/gtm-billable"}).NoteNameSpace(); got != "gtm-billable" {
+		t.Errorf("NoteNameSpace(), want gtm-billable got %s", got)
+	}
+}
+
+func TestConfigIssueKeys(t *testing.T) {
+	tests := []struct {
+		pattern string
+		message string
+		want    []string
+	}{
+		{"", "GTM-42 fix the report\n\nRelated to GTM-7 and GTM-42", []string{"GTM-42", "GTM-7"}},
+		{"", "Fix the report", []string{UnassignedIssue}},
+		{"", "gtm-42 is lower case", []string{UnassignedIssue}},
+		{"#[0-9]+", "Fix #12, see GTM-42", []string{"#12"}},
+		{"#[0-9", "Fix #12, see GTM-42", []string{UnassignedIssue}},
+	}
+	for _, tc := range tests {
+		c := Config{IssueKeyPattern: tc.pattern}
+		if got := c.IssueKeys(tc.message); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("IssueKeys(%q) with %q, want %+v got %+v", tc.message, tc.pattern, tc.want, got)
+		}
+	}
+}
+
+func TestConfigLanguage(t *testing.T) {
+	c := Config{Languages: map[string]string{".tmpl": "Go", ".JS": "Node.js", "Jenkinsfile": "Groovy"}}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"cmd/main.go", "Go"},
+		{"Main.GO", "Go"},
+		{"templates/report.tmpl", "Go"},
+		{"web/app.js", "Node.js"},
+		{"Makefile", "Makefile"},
+		{"ci/Jenkinsfile", "Groovy"},
+		{"README", OtherLanguage},
+		{"data.bin", OtherLanguage},
+		{".gtm/terminal.app", OtherLanguage},
+	}
+	for _, tc := range tests {
+		if got := c.Language(tc.path); got != tc.want {
+			t.Errorf("Language(%s), want %s got %s", tc.path, tc.want, got)
+		}
+	}
+	if got := DefaultConfig().Language("web/app.js"); got != "JavaScript" {
+		t.Errorf("Language(web/app.js) without overrides, want JavaScript got %s", got)
+	}
+}
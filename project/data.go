@@ -0,0 +1,59 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// DataDirEnv is the environment variable for a directory to keep event and metric files in instead of
+// the project's gtm directory, i.e. a RAM disk. Each project has its own sub directory within it.
+// Tags, config and git notes are not affected.
+const DataDirEnv = "GTM_DATA_DIR"
+
+// DataPath returns the directory event and metric files are kept in for the project in workDir.
+// If GTM_DATA_DIR is set it must be an existing writable directory, the project's sub directory is created if needed.
+func DataPath(workDir string) (string, error) {
+	dataDir := os.Getenv(DataDirEnv)
+	if dataDir == "" {
+		return filepath.Join(workDir, GTMDir), nil
+	}
+
+	fileInfo, err := os.Stat(dataDir)
+	if err != nil || !fileInfo.IsDir() {
+		return "", fmt.Errorf("%s %s is not a directory", DataDirEnv, dataDir)
+	}
+
+	p := dataPath(dataDir, workDir)
+	if _, err := os.Stat(p); err == nil {
+		return p, nil
+	}
+
+	// check the data directory is writable before the project's sub directory is created
+	f, err := ioutil.TempFile(dataDir, ".gtm")
+	if err != nil {
+		return "", fmt.Errorf("%s %s is not writable, %s", DataDirEnv, dataDir, err)
+	}
+	f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(p, 0700); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// dataPath returns the project's sub directory in dataDir,
+// it's named by the project's directory name and a hash of its path to keep projects with the same name apart
+func dataPath(dataDir, workDir string) string {
+	sum := sha1.Sum([]byte(filepath.ToSlash(workDir)))
+	return filepath.Join(dataDir, fmt.Sprintf("%s-%x", filepath.Base(workDir), sum[:6]))
+}
@@ -6,11 +6,13 @@ package project
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
-	"os/user"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -35,19 +37,65 @@ func NewIndex() (Index, error) {
 	return i, nil
 }
 
+// TagMatch is how Index.GetMatching matches the tags of projects, tags are matched exactly by default
+type TagMatch struct {
+	// All requires projects to have all of the tags, otherwise any of the tags
+	All bool
+	// IgnoreCase matches tags regardless of case, i.e. client-a matches Client-A
+	IgnoreCase bool
+	// Regex treats the tags as regular expressions that must match the whole tag, i.e. client-.*
+	Regex bool
+}
+
+// matchers returns a func for each of the tags that returns true if a project's tag matches it
+func (m TagMatch) matchers(tags []string) ([]func(string) bool, error) {
+	matchers := []func(string) bool{}
+	for _, t := range tags {
+		t := t
+		switch {
+		case m.Regex:
+			expr := "^(?:" + t + ")$"
+			if m.IgnoreCase {
+				expr = "(?i)" + expr
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("%w %s, %s", ErrInvalidTagRegex, t, err)
+			}
+			matchers = append(matchers, re.MatchString)
+		case m.IgnoreCase:
+			matchers = append(matchers, func(tag string) bool { return strings.EqualFold(tag, t) })
+		default:
+			matchers = append(matchers, func(tag string) bool { return tag == t })
+		}
+	}
+	return matchers, nil
+}
+
 // Get finds projects by tags or all projects or the project in the current directory
-func (i *Index) Get(tags []string, all bool) ([]string, error) {
+// and its initialized submodules if the project tracks submodules.
+// If matchAll is true projects must have all of the tags, otherwise any of the tags.
+func (i *Index) Get(tags []string, all bool, matchAll bool) ([]string, error) {
+	return i.GetMatching(tags, all, TagMatch{All: matchAll})
+}
+
+// GetMatching is like Get with the tags matched by match
+func (i *Index) GetMatching(tags []string, all bool, match TagMatch) ([]string, error) {
 	switch {
 	case all:
 		err := i.clean()
 		return i.projects(), err
 	case len(tags) > 0:
+		matchers, err := match.matchers(tags)
+		if err != nil {
+			return []string{}, err
+		}
 		if err := i.clean(); err != nil {
 			return []string{}, err
 		}
 		projectsWithTags := []string{}
 		for _, p := range i.projects() {
-			found, err := i.hasTags(p, tags)
+			found, err := i.hasTags(p, matchers, match.All)
 			if err != nil {
 				return []string{}, nil
 			}
@@ -62,16 +110,83 @@ func (i *Index) Get(tags []string, all bool) ([]string, error) {
 		if err != nil {
 			return []string{}, err
 		}
-		if _, ok := i.Projects[curProjPath]; !ok {
-			i.add(curProjPath)
+		// time in submodules is part of the project when the project tracks submodules
+		subs, err := Submodules(curProjPath)
+		if err != nil {
+			return []string{}, err
+		}
+		projPaths := append([]string{curProjPath}, subs...)
+		added := false
+		for _, p := range projPaths {
+			if _, ok := i.Projects[p]; !ok {
+				i.add(p)
+				added = true
+			}
+		}
+		if added {
 			if err := i.save(); err != nil {
 				return []string{}, err
 			}
 		}
-		return []string{curProjPath}, nil
+		return projPaths, nil
 	}
 }
 
+// ProjectInfo contains the details of a tracked project
+type ProjectInfo struct {
+	// Path is the project's working tree
+	Path string
+	// Tags are the project's tags
+	Tags []string
+	// LastActivity is the last time time tracking data in the project's .gtm directory changed
+	LastActivity time.Time
+}
+
+// List returns the details of all tracked projects sorted by path,
+// projects that are no longer initialized are removed from the index
+func (i *Index) List() ([]ProjectInfo, error) {
+	if err := i.clean(); err != nil {
+		return []ProjectInfo{}, err
+	}
+	infos := []ProjectInfo{}
+	for _, p := range i.projects() {
+		info, err := i.info(p)
+		if err != nil {
+			return []ProjectInfo{}, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (i *Index) info(projectPath string) (ProjectInfo, error) {
+	gtmPath := filepath.Join(projectPath, GTMDir)
+	tags, err := LoadTags(gtmPath)
+	if err != nil {
+		return ProjectInfo{}, err
+	}
+
+	// the index time is when the project was last added to the index
+	lastActivity := i.Projects[projectPath]
+	files, err := ioutil.ReadDir(gtmPath)
+	if err != nil {
+		return ProjectInfo{}, err
+	}
+	// event and metric files may be kept in a data directory
+	if dataDir := os.Getenv(DataDirEnv); dataDir != "" {
+		if dataFiles, err := ioutil.ReadDir(dataPath(dataDir, projectPath)); err == nil {
+			files = append(files, dataFiles...)
+		}
+	}
+	for _, f := range files {
+		if f.ModTime().After(lastActivity) {
+			lastActivity = f.ModTime()
+		}
+	}
+
+	return ProjectInfo{Path: projectPath, Tags: tags, LastActivity: lastActivity}, nil
+}
+
 func (i *Index) add(p string) {
 	i.Projects[p] = time.Now()
 }
@@ -90,11 +205,11 @@ func (i *Index) projects() []string {
 }
 
 func (i *Index) path() (string, error) {
-	u, err := user.Current()
+	dir, err := userDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(u.HomeDir, ".git-time-metric", "project.json"), nil
+	return filepath.Join(dir, "project.json"), nil
 }
 
 func (i *Index) load() error {
@@ -131,19 +246,30 @@ func (i *Index) save() error {
 	return ioutil.WriteFile(p, bytes, 0644)
 }
 
-func (i *Index) hasTags(projectPath string, tagsToFind []string) (bool, error) {
+func (i *Index) hasTags(projectPath string, tagsToFind []func(string) bool, matchAll bool) (bool, error) {
 	tags, err := LoadTags(filepath.Join(projectPath, ".gtm"))
 	if err != nil {
 		return false, err
 	}
-	for _, t1 := range tags {
-		for _, t2 := range tagsToFind {
-			if t1 == t2 {
-				return true, nil
+	if len(tagsToFind) == 0 {
+		return false, nil
+	}
+	for _, matches := range tagsToFind {
+		found := false
+		for _, t1 := range tags {
+			if matches(t1) {
+				found = true
+				break
 			}
 		}
+		if found && !matchAll {
+			return true, nil
+		}
+		if !found && matchAll {
+			return false, nil
+		}
 	}
-	return false, nil
+	return matchAll, nil
 }
 
 func (i *Index) removeNotFound(projectPath string) {
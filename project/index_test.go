@@ -0,0 +1,140 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIndexHasTags(t *testing.T) {
+	projPath, err := ioutil.TempDir("", "gtm")
+	if err != nil {
+		t.Fatalf("Unable to create tempory directory %s, %s", projPath, err)
+	}
+	defer os.RemoveAll(projPath)
+
+	if err := os.MkdirAll(filepath.Join(projPath, GTMDir), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveTags([]string{"work", "go"}, filepath.Join(projPath, GTMDir)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tags     []string
+		matchAll bool
+		want     bool
+	}{
+		{[]string{}, false, false},
+		{[]string{}, true, false},
+		{[]string{"work"}, false, true},
+		{[]string{"work"}, true, true},
+		{[]string{"work", "home"}, false, true},
+		{[]string{"work", "home"}, true, false},
+		{[]string{"work", "go"}, true, true},
+		{[]string{"home"}, false, false},
+		{[]string{"home"}, true, false},
+	}
+
+	i := Index{Projects: map[string]time.Time{}}
+	for _, tc := range tests {
+		matchers, err := TagMatch{}.matchers(tc.tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := i.hasTags(projPath, matchers, tc.matchAll)
+		if err != nil {
+			t.Errorf("hasTags(%v, %t), want error nil got %s", tc.tags, tc.matchAll, err)
+		}
+		if got != tc.want {
+			t.Errorf("hasTags(%v, %t), want %t got %t", tc.tags, tc.matchAll, tc.want, got)
+		}
+	}
+}
+
+func TestIndexTagMatch(t *testing.T) {
+	projPath, err := ioutil.TempDir("", "gtm")
+	if err != nil {
+		t.Fatalf("Unable to create tempory directory %s, %s", projPath, err)
+	}
+	defer os.RemoveAll(projPath)
+
+	if err := os.MkdirAll(filepath.Join(projPath, GTMDir), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveTags([]string{"Client-A", "go"}, filepath.Join(projPath, GTMDir)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tags  []string
+		match TagMatch
+		want  bool
+	}{
+		{[]string{"client-a"}, TagMatch{}, false},
+		{[]string{"client-a"}, TagMatch{IgnoreCase: true}, true},
+		{[]string{"Client-.*"}, TagMatch{}, false},
+		{[]string{"Client-.*"}, TagMatch{Regex: true}, true},
+		{[]string{"client-.*"}, TagMatch{Regex: true}, false},
+		{[]string{"client-.*"}, TagMatch{Regex: true, IgnoreCase: true}, true},
+		{[]string{"Client"}, TagMatch{Regex: true}, false},
+		{[]string{"client-.*", "rust"}, TagMatch{Regex: true, IgnoreCase: true, All: true}, false},
+	}
+
+	i := Index{Projects: map[string]time.Time{}}
+	for _, tc := range tests {
+		matchers, err := tc.match.matchers(tc.tags)
+		if err != nil {
+			t.Fatalf("matchers(%v), want error nil got %s", tc.tags, err)
+		}
+		got, err := i.hasTags(projPath, matchers, tc.match.All)
+		if err != nil {
+			t.Errorf("hasTags(%v, %+v), want error nil got %s", tc.tags, tc.match, err)
+		}
+		if got != tc.want {
+			t.Errorf("hasTags(%v, %+v), want %t got %t", tc.tags, tc.match, tc.want, got)
+		}
+	}
+
+	if _, err := (TagMatch{Regex: true}).matchers([]string{"client-("}); err == nil {
+		t.Errorf("matchers(client-(), want invalid regular expression error got nil")
+	}
+}
+
+func TestIndexInfo(t *testing.T) {
+	projPath, err := ioutil.TempDir("", "gtm")
+	if err != nil {
+		t.Fatalf("Unable to create tempory directory %s, %s", projPath, err)
+	}
+	defer os.RemoveAll(projPath)
+
+	gtmPath := filepath.Join(projPath, GTMDir)
+	if err := os.MkdirAll(gtmPath, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveTags([]string{"work"}, gtmPath); err != nil {
+		t.Fatal(err)
+	}
+	eventTime := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := ioutil.WriteFile(filepath.Join(gtmPath, "1458496803.event"), []byte("event.go"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(gtmPath, "1458496803.event"), eventTime, eventTime); err != nil {
+		t.Fatal(err)
+	}
+
+	i := Index{Projects: map[string]time.Time{projPath: time.Now().Add(-time.Hour)}}
+	got, err := i.info(projPath)
+	if err != nil {
+		t.Fatalf("info(%s), want error nil got %s", projPath, err)
+	}
+	if got.Path != projPath || len(got.Tags) != 1 || got.Tags[0] != "work" || !got.LastActivity.Equal(eventTime) {
+		t.Errorf("info(%s), want path %s, tags [work] and last activity %s got %+v", projPath, projPath, eventTime, got)
+	}
+}
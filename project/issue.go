@@ -0,0 +1,57 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"regexp"
+	"sync"
+)
+
+// DefaultIssueKeyRegex matches JIRA issue keys, i.e. GTM-42, in commit messages
+const DefaultIssueKeyRegex = `\b[A-Z][A-Z0-9_]+-[0-9]+\b`
+
+// UnassignedIssue is the issue of commits without an issue key in their message
+const UnassignedIssue = "unassigned"
+
+var defaultIssueKeyRegex = regexp.MustCompile(DefaultIssueKeyRegex)
+
+// issueKeyRegexes caches the compiled IssueKeyPatterns so a pattern is compiled once instead of for each commit
+var issueKeyRegexes sync.Map
+
+// issueKeyRegex returns the regular expression for issue keys, the config's IssueKeyPattern or DefaultIssueKeyRegex.
+// It's nil if the IssueKeyPattern is not a valid regular expression, the error is returned by the config's validation.
+func (c Config) issueKeyRegex() *regexp.Regexp {
+	if c.IssueKeyPattern == "" {
+		return defaultIssueKeyRegex
+	}
+	if re, ok := issueKeyRegexes.Load(c.IssueKeyPattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	// an invalid pattern is cached as nil
+	re, _ := regexp.Compile(c.IssueKeyPattern)
+	issueKeyRegexes.Store(c.IssueKeyPattern, re)
+	return re
+}
+
+// IssueKeys returns the issue keys in a commit message in the order they first appear,
+// UnassignedIssue is returned if there are none
+func (c Config) IssueKeys(message string) []string {
+	re := c.issueKeyRegex()
+	if re == nil {
+		return []string{UnassignedIssue}
+	}
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, k := range re.FindAllString(message, -1) {
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return []string{UnassignedIssue}
+	}
+	return keys
+}
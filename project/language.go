@@ -0,0 +1,95 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// OtherLanguage is the language of files with an extension that's not in Languages or the project's config
+const OtherLanguage = "other"
+
+// Languages maps file extensions, and the names of files without one, to their programming language
+var Languages = map[string]string{
+	".bash":       "Shell",
+	".c":          "C",
+	".cc":         "C++",
+	".clj":        "Clojure",
+	".cpp":        "C++",
+	".cs":         "C#",
+	".css":        "CSS",
+	".cxx":        "C++",
+	".dart":       "Dart",
+	".ex":         "Elixir",
+	".exs":        "Elixir",
+	".elm":        "Elm",
+	".erl":        "Erlang",
+	".fs":         "F#",
+	".go":         "Go",
+	".groovy":     "Groovy",
+	".h":          "C",
+	".hpp":        "C++",
+	".hs":         "Haskell",
+	".htm":        "HTML",
+	".html":       "HTML",
+	".java":       "Java",
+	".js":         "JavaScript",
+	".json":       "JSON",
+	".jsx":        "JavaScript",
+	".kt":         "Kotlin",
+	".kts":        "Kotlin",
+	".less":       "CSS",
+	".lua":        "Lua",
+	".m":          "Objective-C",
+	".md":         "Markdown",
+	".ml":         "OCaml",
+	".php":        "PHP",
+	".pl":         "Perl",
+	".ps1":        "PowerShell",
+	".py":         "Python",
+	".r":          "R",
+	".rb":         "Ruby",
+	".rs":         "Rust",
+	".sass":       "CSS",
+	".scala":      "Scala",
+	".scss":       "CSS",
+	".sh":         "Shell",
+	".sql":        "SQL",
+	".swift":      "Swift",
+	".tf":         "Terraform",
+	".toml":       "TOML",
+	".ts":         "TypeScript",
+	".tsx":        "TypeScript",
+	".vim":        "Vim script",
+	".vue":        "Vue",
+	".xml":        "XML",
+	".yaml":       "YAML",
+	".yml":        "YAML",
+	".zsh":        "Shell",
+	"dockerfile":  "Dockerfile",
+	"gnumakefile": "Makefile",
+	"makefile":    "Makefile",
+}
+
+// Language returns the programming language of the source file by its extension, or its name if it does not have one.
+// The config's Languages take precedence over the built-in Languages, files that are not matched are OtherLanguage.
+func (c Config) Language(sourcePath string) string {
+	name := strings.ToLower(path.Base(filepath.ToSlash(sourcePath)))
+	key := path.Ext(name)
+	if key == "" {
+		key = name
+	}
+	for k, lang := range c.Languages {
+		if strings.ToLower(k) == key {
+			return lang
+		}
+	}
+	if lang, ok := Languages[key]; ok {
+		return lang
+	}
+	return OtherLanguage
+}
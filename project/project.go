@@ -26,6 +26,8 @@ var (
 	ErrNotInitialized = errors.New("Git Time Metric is not initialized")
 	// ErrFileNotFound is raised when record an event for a file that does not exist
 	ErrFileNotFound = errors.New("File does not exist")
+	// ErrInvalidTagRegex is raised when a tag is not a valid regular expression for TagMatch.Regex
+	ErrInvalidTagRegex = errors.New("Invalid tag regular expression")
 	// AppEventFileContentRegex regex for app event files
 	AppEventFileContentRegex = regexp.MustCompile(`\.gtm[\\/](?P<appName>.*)\.app`)
 )
@@ -38,11 +40,9 @@ var (
 			Command: "gtm commit --yes",
 			RE:      regexp.MustCompile(`(?s)[/:a-zA-Z0-9$_=()"\.\|\-\\ ]*gtm(.exe"|)\s+commit\s+--yes\.*`)},
 	}
-	// GitConfig is map of git configuration settings
-	GitConfig = map[string]string{
-		"alias.pushgtm":    "push origin refs/notes/gtm-data",
-		"alias.fetchgtm":   "fetch origin refs/notes/gtm-data:refs/notes/gtm-data",
-		"notes.rewriteref": "refs/notes/gtm-data"}
+	// GitConfig is map of git configuration settings for the default notes namespace,
+	// projects are initialized with the settings for their config's namespace, see noteGitConfig
+	GitConfig = noteGitConfig(NoteNameSpace)
 	// GitIgnore is file ignore to apply to git repo
 	GitIgnore = "/.gtm/"
 )
@@ -54,6 +54,15 @@ const (
 	GTMDir = ".gtm"
 )
 
+// noteGitConfig returns the git configuration settings to push, fetch and rewrite the notes in the namespace
+func noteGitConfig(nameSpace string) map[string]string {
+	ref := "refs/notes/" + nameSpace
+	return map[string]string{
+		"alias.pushgtm":    "push origin " + ref,
+		"alias.fetchgtm":   fmt.Sprintf("fetch origin %s:%s", ref, ref),
+		"notes.rewriteref": ref}
+}
+
 const initMsgTpl string = `
 {{print "Git Time Metric initialized for " (.ProjectPath) | printf (.HeaderFormat) }}
 
@@ -89,6 +98,88 @@ func Initialize(terminal bool, tags []string, clearTags bool) (string, error) {
 		return "", err
 	}
 
+	return initialize(wd, terminal, tags, clearTags)
+}
+
+// InitializeSubmodules initializes the checked out submodules of the project in the current working directory
+// for time tracking and sets the project's Submodules config so time in them is saved in their own notes.
+// Submodules are initialized with the terminal and tags options, the project must be initialized first.
+func InitializeSubmodules(terminal bool, tags []string, clearTags bool) (string, error) {
+	rootPath, gtmPath, err := Paths()
+	if err != nil {
+		return "", err
+	}
+
+	config, err := LoadConfig(gtmPath)
+	if err != nil {
+		return "", err
+	}
+	if !config.Submodules {
+		config.Submodules = true
+		if err := config.Save(gtmPath); err != nil {
+			return "", err
+		}
+	}
+
+	subs, err := checkedOutSubmodules(rootPath)
+	if err != nil {
+		return "", err
+	}
+
+	msgs := []string{}
+	for _, s := range subs {
+		m, err := initialize(s, terminal, tags, clearTags)
+		if err != nil {
+			return "", err
+		}
+		msgs = append(msgs, m)
+	}
+	return strings.Join(msgs, ""), nil
+}
+
+// checkedOutSubmodules returns the working trees of the project's submodules that are checked out
+func checkedOutSubmodules(rootPath string) ([]string, error) {
+	subs, err := scm.Submodules(rootPath)
+	if err != nil {
+		return []string{}, err
+	}
+	workDirs := []string{}
+	for _, s := range subs {
+		p := filepath.Join(rootPath, s)
+		gitRepoPath, err := scm.GitRepoPath(p)
+		if err != nil {
+			continue
+		}
+		// a submodule that's not checked out is an empty directory in the project's working tree
+		if workDir, err := scm.Workdir(gitRepoPath); err != nil || workDir != p {
+			continue
+		}
+		workDirs = append(workDirs, p)
+	}
+	return workDirs, nil
+}
+
+// Submodules returns the working trees of the project's initialized submodules if the project's
+// config tracks time in submodules
+func Submodules(rootPath string) ([]string, error) {
+	if !ReadConfig(filepath.Join(rootPath, GTMDir)).Submodules {
+		return []string{}, nil
+	}
+	subs, err := checkedOutSubmodules(rootPath)
+	if err != nil {
+		return []string{}, err
+	}
+	initialized := []string{}
+	for _, s := range subs {
+		if _, err := os.Stat(filepath.Join(s, GTMDir)); err == nil {
+			initialized = append(initialized, s)
+		}
+	}
+	return initialized, nil
+}
+
+// initialize initializes the git repo with the working directory wd for time tracking
+func initialize(wd string, terminal bool, tags []string, clearTags bool) (string, error) {
 	gitRepoPath, err := scm.GitRepoPath(wd)
 	if err != nil {
 		return "", fmt.Errorf(
@@ -100,6 +191,9 @@ func Initialize(terminal bool, tags []string, clearTags bool) (string, error) {
 	}
 
 	workDirRoot, err := scm.Workdir(gitRepoPath)
+	if err == scm.ErrBareRepo {
+		return "", err
+	}
 	if err != nil {
 		return "", fmt.Errorf(
 			"Unable to intialize Git Time Metric, Git working tree root not found in %s", workDirRoot)
@@ -116,6 +210,14 @@ func Initialize(terminal bool, tags []string, clearTags bool) (string, error) {
 		if err := os.MkdirAll(gtmPath, 0700); err != nil {
 			return "", err
 		}
+		// new projects without tags get the user's default tags, -clear-tags skips them
+		if !clearTags && !hasTags(tags) {
+			userConfig, err := LoadUserConfig()
+			if err != nil {
+				return "", err
+			}
+			tags = userConfig.DefaultTags
+		}
 	}
 
 	if clearTags {
@@ -142,11 +244,18 @@ func Initialize(terminal bool, tags []string, clearTags bool) (string, error) {
 		_ = os.Remove(filepath.Join(gtmPath, "terminal.app"))
 	}
 
-	if err := scm.SetHooks(GitHooks, gitRepoPath); err != nil {
+	// hooks and config are shared by all of the repo's worktrees
+	gitCommonDir, err := scm.GitCommonDir(gitRepoPath)
+	if err != nil {
+		return "", err
+	}
+
+	if err := scm.SetHooks(GitHooks, gitCommonDir); err != nil {
 		return "", err
 	}
 
-	if err := scm.ConfigSet(GitConfig, gitRepoPath); err != nil {
+	gitConfig := noteGitConfig(ReadConfig(gtmPath).NoteNameSpace())
+	if err := scm.ConfigSet(gitConfig, gitCommonDir); err != nil {
 		return "", err
 	}
 
@@ -175,7 +284,7 @@ func Initialize(terminal bool, tags []string, clearTags bool) (string, error) {
 			headerFormat,
 			workDirRoot,
 			GitHooks,
-			GitConfig,
+			gitConfig,
 			GitIgnore,
 			terminal,
 		})
@@ -211,16 +320,24 @@ func Uninitialize() (string, error) {
 			"Unable to unintialize Git Time Metric, Git repository not found in %s", gitRepoPath)
 	}
 
-	workDir, _ := scm.Workdir(gitRepoPath)
+	workDir, err := scm.Workdir(gitRepoPath)
+	if err == scm.ErrBareRepo {
+		return "", err
+	}
 	gtmPath := filepath.Join(workDir, GTMDir)
 	if _, err := os.Stat(gtmPath); os.IsNotExist(err) {
 		return "", fmt.Errorf(
 			"Unable to uninitialize Git Time Metric, %s directory not found", gtmPath)
 	}
-	if err := scm.RemoveHooks(GitHooks, gitRepoPath); err != nil {
+	gitCommonDir, err := scm.GitCommonDir(gitRepoPath)
+	if err != nil {
+		return "", err
+	}
+	if err := scm.RemoveHooks(GitHooks, gitCommonDir); err != nil {
 		return "", err
 	}
-	if err := scm.ConfigRemove(GitConfig, gitRepoPath); err != nil {
+	gitConfig := noteGitConfig(ReadConfig(gtmPath).NoteNameSpace())
+	if err := scm.ConfigRemove(gitConfig, gitCommonDir); err != nil {
 		return "", err
 	}
 	if err := scm.IgnoreRemove(GitIgnore, workDir); err != nil {
@@ -229,6 +346,11 @@ func Uninitialize() (string, error) {
 	if err := os.RemoveAll(gtmPath); err != nil {
 		return "", err
 	}
+	if dataDir := os.Getenv(DataDirEnv); dataDir != "" {
+		if err := os.RemoveAll(dataPath(dataDir, workDir)); err != nil {
+			return "", err
+		}
+	}
 
 	headerFormat := "%s"
 	if isatty.IsTerminal(os.Stdout.Fd()) && runtime.GOOS != "windows" {
@@ -247,7 +369,7 @@ func Uninitialize() (string, error) {
 			headerFormat,
 			workDir,
 			GitHooks,
-			GitConfig,
+			gitConfig,
 			GitIgnore})
 
 	if err != nil {
@@ -270,30 +392,50 @@ func Uninitialize() (string, error) {
 
 //Clean removes any event or metrics files from project in the current working directory
 func Clean(dr util.DateRange, terminalOnly bool, appOnly bool) error {
-	wd, err := os.Getwd()
+	files, err := CleanFiles(dr, terminalOnly, appOnly)
 	if err != nil {
 		return err
 	}
+	for _, fp := range files {
+		if err := os.Remove(fp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CleanFiles returns the paths of the event and metric files Clean removes, committed git notes are never removed
+func CleanFiles(dr util.DateRange, terminalOnly bool, appOnly bool) ([]string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
 
 	gitRepoPath, err := scm.GitRepoPath(wd)
 	if err != nil {
-		return fmt.Errorf("Unable to clean, Git repository not found in %s", gitRepoPath)
+		return nil, fmt.Errorf("Unable to clean, Git repository not found in %s", gitRepoPath)
 	}
 
 	workDir, err := scm.Workdir(gitRepoPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	gtmPath := filepath.Join(workDir, GTMDir)
 	if _, err := os.Stat(gtmPath); os.IsNotExist(err) {
-		return fmt.Errorf("Unable to clean GTM data, %s directory not found", gtmPath)
+		return nil, fmt.Errorf("Unable to clean GTM data, %s directory not found", gtmPath)
+	}
+
+	// event and metric files are kept in the data directory, by default the gtm directory
+	if gtmPath, err = DataPath(workDir); err != nil {
+		return nil, err
 	}
 
 	files, err := ioutil.ReadDir(gtmPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	remove := []string{}
 	for _, f := range files {
 		if !strings.HasSuffix(f.Name(), ".event") &&
 			!strings.HasSuffix(f.Name(), ".metric") {
@@ -307,7 +449,7 @@ func Clean(dr util.DateRange, terminalOnly bool, appOnly bool) error {
 		if (terminalOnly || appOnly) && strings.HasSuffix(f.Name(), ".event") {
 			b, err := ioutil.ReadFile(fp)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			if terminalOnly {
@@ -321,11 +463,9 @@ func Clean(dr util.DateRange, terminalOnly bool, appOnly bool) error {
 			}
 		}
 
-		if err := os.Remove(fp); err != nil {
-			return err
-		}
+		remove = append(remove, fp)
 	}
-	return nil
+	return remove, nil
 }
 
 // Paths returns the root git repo and gtm paths
@@ -346,6 +486,9 @@ func Paths(wd ...string) (string, string, error) {
 	}
 
 	workDir, err := scm.Workdir(gitRepoPath)
+	if err == scm.ErrBareRepo {
+		return "", "", err
+	}
 	if err != nil {
 		return "", "", ErrNotInitialized
 	}
@@ -357,6 +500,30 @@ func Paths(wd ...string) (string, string, error) {
 	return workDir, gtmPath, nil
 }
 
+// SourcePaths returns the root git repo and gtm paths of the project time is tracked in for source files in dir.
+// It's the project of the repo dir is in, unless the repo is a submodule that's not initialized then it's
+// the project of the submodule's superproject.
+func SourcePaths(dir string) (string, string, error) {
+	rootPath, gtmPath, err := Paths(dir)
+	if err != ErrNotInitialized {
+		return rootPath, gtmPath, err
+	}
+
+	gitRepoPath, gerr := scm.GitRepoPath(dir)
+	if gerr != nil {
+		return "", "", err
+	}
+	workDir, gerr := scm.Workdir(gitRepoPath)
+	if gerr != nil {
+		return "", "", err
+	}
+	super, gerr := scm.Superproject(workDir)
+	if gerr != nil || super == "" {
+		return "", "", err
+	}
+	return SourcePaths(super)
+}
+
 func removeTags(gtmPath string) error {
 	files, err := ioutil.ReadDir(gtmPath)
 	if err != nil {
@@ -388,6 +555,16 @@ func LoadTags(gtmPath string) ([]string, error) {
 	return tags, nil
 }
 
+// hasTags returns true if there are tags that are not blank
+func hasTags(tags []string) bool {
+	for _, t := range tags {
+		if strings.TrimSpace(t) != "" {
+			return true
+		}
+	}
+	return false
+}
+
 func saveTags(tags []string, gtmPath string) error {
 	if len(tags) > 0 {
 		for _, t := range tags {
@@ -306,3 +306,74 @@ func TestClean(t *testing.T) {
 		}
 	}
 }
+
+func TestInitializeDefaultTags(t *testing.T) {
+	dir, err := userDir()
+	util.CheckFatal(t, err)
+	util.CheckFatal(t, os.MkdirAll(dir, 0700))
+	configPath := filepath.Join(dir, UserConfigFile)
+	if saved, err := ioutil.ReadFile(configPath); err == nil {
+		defer ioutil.WriteFile(configPath, saved, 0644)
+	} else {
+		defer os.Remove(configPath)
+	}
+	util.CheckFatal(t, ioutil.WriteFile(configPath, []byte(`{"default_tags": ["work", "go"]}`), 0644))
+
+	savedCurDir, _ := os.Getwd()
+	defer os.Chdir(savedCurDir)
+
+	tests := []struct {
+		tags      []string
+		clearTags bool
+		want      []string
+	}{
+		{[]string{""}, false, []string{"go", "work"}},
+		{[]string{"home"}, false, []string{"home"}},
+		{[]string{""}, true, []string{}},
+	}
+	for _, tc := range tests {
+		repo := util.NewTestRepo(t, false)
+		os.Chdir(repo.Workdir())
+
+		_, err := Initialize(false, tc.tags, tc.clearTags)
+		util.CheckFatal(t, err)
+		got, err := LoadTags(filepath.Join(repo.Workdir(), GTMDir))
+		util.CheckFatal(t, err)
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("Initialize(false, %v, %t), want tags %v got %v", tc.tags, tc.clearTags, tc.want, got)
+		}
+
+		// existing projects are not given the default tags
+		util.CheckFatal(t, removeTags(filepath.Join(repo.Workdir(), GTMDir)))
+		_, err = Initialize(false, []string{}, false)
+		util.CheckFatal(t, err)
+		if got, _ := LoadTags(filepath.Join(repo.Workdir(), GTMDir)); len(got) != 0 {
+			t.Errorf("Initialize() an existing project, want no tags got %v", got)
+		}
+		repo.Remove()
+	}
+}
+
+func TestInitializeNotesRef(t *testing.T) {
+	savedCurDir, _ := os.Getwd()
+	defer os.Chdir(savedCurDir)
+
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	os.Chdir(repo.Workdir())
+
+	repo.SaveFile(ConfigFile, GTMDir, `{"notes_ref": "refs/notes/gtm-billable"}`)
+	_, err := Initialize(false, []string{}, false)
+	util.CheckFatal(t, err)
+
+	b, err := exec.Command("git", "config", "-l").Output()
+	util.CheckFatal(t, err)
+	for _, want := range []string{
+		"alias.pushgtm=push origin refs/notes/gtm-billable",
+		"alias.fetchgtm=fetch origin refs/notes/gtm-billable:refs/notes/gtm-billable",
+		"notes.rewriteref=refs/notes/gtm-billable"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("Initialize() with notes_ref, want %s got %s", want, string(b))
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// UserConfigFile is the name of the user's configuration file in the ~/.git-time-metric directory
+const UserConfigFile = "config.json"
+
+// UserConfig contains the user's settings for all projects
+type UserConfig struct {
+	// DefaultTags are the tags for new projects initialized without tags, i.e. ["work"],
+	// projects that are already initialized are not changed
+	DefaultTags []string `json:"default_tags,omitempty"`
+}
+
+// userDir returns the ~/.git-time-metric directory with the user's config and the project index
+func userDir() (string, error) {
+	u, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(u.HomeDir, ".git-time-metric"), nil
+}
+
+// LoadUserConfig reads the user's configuration, an empty configuration is returned if the file does not exist
+func LoadUserConfig() (UserConfig, error) {
+	c := UserConfig{}
+
+	dir, err := userDir()
+	if err != nil {
+		return c, err
+	}
+	p := filepath.Join(dir, UserConfigFile)
+	raw, err := ioutil.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return c, err
+	}
+
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return UserConfig{}, fmt.Errorf("Unable to read %s, %s", p, err)
+	}
+	for _, t := range c.DefaultTags {
+		if strings.TrimSpace(t) == "" || strings.ContainsAny(t, `/\`) {
+			return UserConfig{}, fmt.Errorf("Invalid %s, default tag %q is not valid", p, t)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Anonymizer replaces the names and emails of authors with pseudonyms, i.e. author-1 <author-1@anonymous.invalid>,
+// numbered in the order the authors are first seen. An author's email is always given the same pseudonym
+// so their time can still be totaled. Use Mapping to de-anonymize a report.
+type Anonymizer struct {
+	pseudonyms map[string]string
+	authors    []MailmapEntry
+}
+
+// NewAnonymizer returns an Anonymizer without any authors
+func NewAnonymizer() *Anonymizer {
+	return &Anonymizer{pseudonyms: map[string]string{}}
+}
+
+// anonymize returns the pseudonym name and email for an author
+func (a *Anonymizer) anonymize(name, email string) (string, string) {
+	key := strings.ToLower(email)
+	if key == "" {
+		key = strings.ToLower(name)
+	}
+	pseudonym, ok := a.pseudonyms[key]
+	if !ok {
+		pseudonym = fmt.Sprintf("author-%d", len(a.authors)+1)
+		a.pseudonyms[key] = pseudonym
+		a.authors = append(a.authors, MailmapEntry{Name: name, Email: email})
+	}
+	return pseudonym, pseudonym + "@anonymous.invalid"
+}
+
+// Mapping returns the authors and their pseudonyms in git's mailmap format, one per line,
+// i.e. Name <email> <author-1@anonymous.invalid>
+func (a *Anonymizer) Mapping() string {
+	b := new(strings.Builder)
+	for i, e := range a.authors {
+		fmt.Fprintf(b, "%s <%s> <author-%d@anonymous.invalid>\n", e.Name, e.Email, i+1)
+	}
+	return b.String()
+}
+
+// anonymize replaces the author of each commit, and the authors in its Co-authored-by trailers, with pseudonyms.
+// Authors are resolved with the mailmap first so an author with more than one email has one pseudonym.
+// Authors are numbered from the oldest commit, notes are sorted newest first.
+func (c commitNoteDetails) anonymize(a *Anonymizer, mailmap Mailmap) {
+	for i := len(c) - 1; i >= 0; i-- {
+		if c[i].Author == "" && c[i].Email == "" {
+			// note could not be read
+			continue
+		}
+		c[i].Author, c[i].Email = a.anonymize(mailmap.resolve(c[i].Author, c[i].Email))
+		c[i].Message = coAuthorRegex.ReplaceAllStringFunc(c[i].Message, func(trailer string) string {
+			m := coAuthorRegex.FindStringSubmatch(trailer)
+			name, email := a.anonymize(mailmap.resolve(m[1], m[2]))
+			return fmt.Sprintf("Co-authored-by: %s <%s>", name, email)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"sort"
+
+	"github.com/git-time-metric/gtm/note"
+)
+
+type appEntry struct {
+	Name    string
+	Seconds int
+}
+
+// appTotals returns the time spent in each app, most time first
+func appTotals(n note.CommitNote) []appEntry {
+	totals := map[string]int{}
+	for _, f := range n.Files {
+		if f.IsApp() {
+			totals[f.GetAppName()] += f.TimeSpent
+		}
+	}
+
+	apps := []appEntry{}
+	for name, secs := range totals {
+		apps = append(apps, appEntry{Name: name, Seconds: secs})
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		if apps[i].Seconds != apps[j].Seconds {
+			return apps[i].Seconds > apps[j].Seconds
+		}
+		return apps[i].Name < apps[j].Name
+	})
+
+	return apps
+}
@@ -0,0 +1,186 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Mailmap maps commit emails to an author's canonical name and email
+type Mailmap map[string]MailmapEntry
+
+// MailmapEntry contains the canonical name and email for an author
+type MailmapEntry struct {
+	Name  string
+	Email string
+}
+
+var mailmapEmailRegex = regexp.MustCompile(`<([^>]*)>`)
+
+// LoadMailmap reads a file in git's mailmap format, i.e. Proper Name <proper@email.xx> <commit@email.xx>
+func LoadMailmap(path string) (Mailmap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Mailmap{}, err
+	}
+	defer f.Close()
+
+	m := Mailmap{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		matches := mailmapEmailRegex.FindAllStringSubmatchIndex(line, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		name := strings.TrimSpace(line[:matches[0][0]])
+		properEmail := line[matches[0][2]:matches[0][3]]
+		commitEmail := properEmail
+		if len(matches) > 1 {
+			commitEmail = line[matches[1][2]:matches[1][3]]
+		} else {
+			// Proper Name <commit@email.xx>, only the name is replaced
+			properEmail = ""
+		}
+		m[strings.ToLower(commitEmail)] = MailmapEntry{Name: name, Email: properEmail}
+	}
+	return m, scanner.Err()
+}
+
+// resolve returns the canonical name and email for an author
+func (m Mailmap) resolve(name, email string) (string, string) {
+	if e, ok := m[strings.ToLower(email)]; ok {
+		if e.Name != "" {
+			name = e.Name
+		}
+		if e.Email != "" {
+			email = e.Email
+		}
+	}
+	return name, email
+}
+
+// Policies for attributing a commit's time to the authors in its Co-authored-by trailers
+const (
+	CoAuthorsPrimary = "primary"
+	CoAuthorsEqual   = "equal"
+)
+
+// CoAuthorPolicies are the valid values of the OutputOptions' CoAuthors
+var CoAuthorPolicies = []string{CoAuthorsPrimary, CoAuthorsEqual}
+
+var coAuthorRegex = regexp.MustCompile(`(?mi)^co-authored-by:[ \t]*([^<\n]*?)[ \t]*<([^>\n]+)>[ \t]*$`)
+
+// coAuthors returns the names and emails in the Co-authored-by trailers of a commit message
+func coAuthors(message string) []MailmapEntry {
+	entries := []MailmapEntry{}
+	for _, m := range coAuthorRegex.FindAllStringSubmatch(message, -1) {
+		entries = append(entries, MailmapEntry{Name: m[1], Email: m[2]})
+	}
+	return entries
+}
+
+type authorEntry struct {
+	Name    string
+	Email   string
+	Commits int
+	Seconds int
+}
+
+type authorEntries []authorEntry
+
+func (a authorEntries) Len() int      { return len(a) }
+func (a authorEntries) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a authorEntries) Less(i, j int) bool {
+	if a[i].Seconds != a[j].Seconds {
+		return a[i].Seconds < a[j].Seconds
+	}
+	return a[i].Email > a[j].Email
+}
+
+func (a authorEntries) Total() int {
+	total := 0
+	for _, e := range a {
+		total += e.Seconds
+	}
+	return total
+}
+
+// round returns the authors with each author's time rounded
+func (a authorEntries) round(options OutputOptions) authorEntries {
+	for i := range a {
+		a[i].Seconds = options.round(a[i].Seconds)
+	}
+	return a
+}
+
+// authorTotals totals the time by author one commit note at a time, with the CoAuthorsEqual policy each commit's
+// time is split equally between the commit author and its co-authors, the commit author gets the seconds that
+// don't split evenly
+type authorTotals struct {
+	mailmap Mailmap
+	policy  string
+	authors map[string]authorEntry
+}
+
+func newAuthorTotals(mailmap Mailmap, policy string) *authorTotals {
+	return &authorTotals{mailmap: mailmap, policy: policy, authors: map[string]authorEntry{}}
+}
+
+func (t *authorTotals) add(n commitNoteDetail) {
+	if n.Email == "" && n.Author == "" {
+		// note could not be read
+		return
+	}
+	commitAuthors := []MailmapEntry{{Name: n.Author, Email: n.Email}}
+	if t.policy == CoAuthorsEqual {
+		commitAuthors = append(commitAuthors, coAuthors(n.Message)...)
+	}
+
+	// an author listed more than once, i.e. as the author and a co-author, is counted once
+	keys := []string{}
+	entries := map[string]authorEntry{}
+	for _, a := range commitAuthors {
+		name, email := t.mailmap.resolve(a.Name, a.Email)
+		key := strings.ToLower(email)
+		if _, ok := entries[key]; ok {
+			continue
+		}
+		keys = append(keys, key)
+		entries[key] = authorEntry{Name: name, Email: email}
+	}
+
+	total := n.Note.Total()
+	for i, key := range keys {
+		seconds := total / len(keys)
+		if i == 0 {
+			seconds += total % len(keys)
+		}
+		entry, ok := t.authors[key]
+		if !ok {
+			entry = entries[key]
+		}
+		entry.Commits++
+		entry.Seconds += seconds
+		t.authors[key] = entry
+	}
+}
+
+// entries returns the authors' totals, most time first
+func (t *authorTotals) entries() authorEntries {
+	authors := make(authorEntries, 0, len(t.authors))
+	for _, entry := range t.authors {
+		authors = append(authors, entry)
+	}
+	sort.Sort(sort.Reverse(authors))
+	return authors
+}
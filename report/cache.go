@@ -0,0 +1,108 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/git-time-metric/gtm/note"
+	"github.com/git-time-metric/gtm/scm"
+	"github.com/git-time-metric/gtm/util"
+)
+
+// CacheDir is the name of the directory within the gtm directory that caches commit notes read for reports,
+// each note is cached in its own file so reports read and write one cached note at a time
+const CacheDir = "cache"
+
+// cachedNote is a commit note and the commit details needed for reports
+type cachedNote struct {
+	ID      string
+	Summary string
+	Message string
+	Author  string
+	Email   string
+	When    time.Time
+	Note    note.CommitNote
+	// NoteID is the SHA1 of the note's blob when it was cached
+	NoteID string
+	// Stats is nil if the commit stats were not calculated when the note was cached
+	Stats *scm.CommitStats `json:",omitempty"`
+}
+
+// cacheEntry is a cached note and the version of gtm that cached it
+type cacheEntry struct {
+	Version string
+	Note    cachedNote
+}
+
+// noteCache caches commit notes by notes namespace and commit SHA1. Commits are immutable but their notes
+// are not, time is appended to a note by gtm commit and notes are merged by fetches, so a cached note is only
+// used while the SHA1 of the note's blob is unchanged. A note cached by a different version of gtm is not used.
+// A nil noteCache does not cache.
+type noteCache struct {
+	version string
+	dir     string
+}
+
+// newNoteCache returns the cache in the gtmPath directory, nothing is read until a note is looked up
+func newNoteCache(gtmPath, version string) *noteCache {
+	return &noteCache{version: version, dir: filepath.Join(gtmPath, CacheDir)}
+}
+
+func (c *noteCache) path(nameSpace, commitID string) string {
+	return filepath.Join(c.dir, filepath.FromSlash(nameSpace), commitID+".json")
+}
+
+// get returns the cached note for the commit if the note's blob is still noteID,
+// if calcStats is true the commit stats must also be cached
+func (c *noteCache) get(nameSpace, commitID, noteID string, calcStats bool) (cachedNote, bool) {
+	if c == nil {
+		return cachedNote{}, false
+	}
+	raw, err := ioutil.ReadFile(c.path(nameSpace, commitID))
+	if err != nil {
+		return cachedNote{}, false
+	}
+	e := cacheEntry{}
+	if err := json.Unmarshal(raw, &e); err != nil || e.Version != c.version {
+		return cachedNote{}, false
+	}
+	n := e.Note
+	if n.ID != commitID || n.NoteID != noteID || (calcStats && n.Stats == nil) {
+		return cachedNote{}, false
+	}
+	return n, true
+}
+
+// put caches the note, commits without time are not cached because time may be saved for them later.
+// The report does not depend on the cache, the note is not cached if the gtm directory does not exist
+// or the note can not be written.
+func (c *noteCache) put(nameSpace string, n cachedNote) {
+	if c == nil || len(n.Note.Files) == 0 {
+		return
+	}
+	if _, err := os.Stat(filepath.Dir(c.dir)); err != nil {
+		return
+	}
+	b, err := json.Marshal(cacheEntry{Version: c.version, Note: n})
+	if err != nil {
+		return
+	}
+	p := c.path(nameSpace, n.ID)
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return
+	}
+	_ = util.WriteFileAtomic(p, b, 0644)
+}
+
+// ClearCache removes the commit note cache in the gtmPath directory,
+// it must be called when notes are rewritten
+func ClearCache(gtmPath string) error {
+	return os.RemoveAll(filepath.Join(gtmPath, CacheDir))
+}
@@ -0,0 +1,127 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/git-time-metric/gtm/util"
+)
+
+// comparePeriodNames are the names of the current and prior day, week and month
+var comparePeriodNames = map[string][2]string{
+	"day":   {"Today", "Yesterday"},
+	"week":  {"This week", "Last week"},
+	"month": {"This month", "Last month"},
+}
+
+type compareEntry struct {
+	Name string
+	periodEntry
+}
+
+type comparison struct {
+	Current compareEntry
+	Prior   compareEntry
+	// unrounded is the time of both periods before rounding
+	unrounded int
+}
+
+// Delta returns the difference between the current and prior period's time with a leading + or -
+func (c comparison) Delta(formatDuration func(int) string) string {
+	delta := c.Current.Seconds - c.Prior.Seconds
+	switch {
+	case delta > 0:
+		return "+" + formatDuration(delta)
+	case delta < 0:
+		return "-" + formatDuration(-delta)
+	default:
+		return formatDuration(0)
+	}
+}
+
+// Change returns the percentage change from the prior period, it's N/A when there is no time in the prior period
+func (c comparison) Change() string {
+	if c.Prior.Seconds == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%+.0f%%", float64(c.Current.Seconds-c.Prior.Seconds)/float64(c.Prior.Seconds)*100)
+}
+
+// compare returns the time for the day, week or month that includes now and the one before it
+func (c commitNoteDetails) compare(options OutputOptions, now time.Time) comparison {
+	loc := options.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	period := options.Period
+	currentStart := periodStart(now.In(loc), period, options.WeekStart)
+	var priorStart time.Time
+	switch period {
+	case "week":
+		priorStart = currentStart.AddDate(0, 0, -7)
+	case "month":
+		priorStart = currentStart.AddDate(0, -1, 0)
+	default:
+		priorStart = currentStart.AddDate(0, 0, -1)
+	}
+
+	names := comparePeriodNames[period]
+	cmp := comparison{
+		Current: compareEntry{Name: names[0], periodEntry: periodEntry{Start: currentStart, Label: periodLabel(currentStart, period)}},
+		Prior:   compareEntry{Name: names[1], periodEntry: periodEntry{Start: priorStart, Label: periodLabel(priorStart, period)}},
+	}
+	periods := c.periods(period, options.WeekStart, loc)
+	for _, p := range periods {
+		if p.Start.Equal(currentStart) || p.Start.Equal(priorStart) {
+			cmp.unrounded += p.Seconds
+		}
+	}
+	for _, p := range periods.round(options) {
+		switch {
+		case p.Start.Equal(currentStart):
+			cmp.Current.Seconds = p.Seconds
+		case p.Start.Equal(priorStart):
+			cmp.Prior.Seconds = p.Seconds
+		}
+	}
+	return cmp
+}
+
+// Compare returns the time for the current day, week or month compared with the prior one
+func Compare(projects []ProjectCommits, options OutputOptions) (string, error) {
+	notes, err := retrieveNotes(projects, options, false, "")
+	if err != nil {
+		return "", err
+	}
+	notes = options.limitNotes(notes)
+
+	cmp := notes.compare(options, util.Now())
+
+	b := new(bytes.Buffer)
+	t := template.Must(template.New("Compare").Funcs(options.funcMap()).Parse(compareTpl))
+	cf := colorFormater{color: options.Color, noColor: options.NoColor}
+	err = t.Execute(
+		b,
+		struct {
+			Rows       []compareEntry
+			Delta      string
+			Change     string
+			BoldFormat string
+		}{
+			[]compareEntry{cmp.Current, cmp.Prior},
+			cmp.Delta(options.FormatDuration),
+			cmp.Change(),
+			cf.white(true),
+		})
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(options.roundingNote(options.Period, cmp.unrounded))
+	return b.String(), nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/git-time-metric/gtm/note"
+)
+
+// dirPrefix returns the directory of sourceFile limited to depth directories, i.e. cmd/gtm/,
+// files in the root of the project return ./
+func dirPrefix(sourceFile string, depth int) string {
+	dirs := strings.Split(filepath.ToSlash(sourceFile), "/")
+	dirs = dirs[:len(dirs)-1]
+	if len(dirs) > depth {
+		dirs = dirs[:depth]
+	}
+	if len(dirs) == 0 {
+		return "./"
+	}
+	return strings.Join(dirs, "/") + "/"
+}
+
+// dirTotals groups the files of the commit note by their directory up to depth directories deep,
+// apps are not grouped. Directories are returned without a status, most time first.
+func dirTotals(n note.CommitNote, depth int) note.CommitNote {
+	dirs := map[string]note.FileDetail{}
+	fds := []note.FileDetail{}
+	for _, f := range n.Files {
+		if f.IsApp() {
+			fds = append(fds, f)
+			continue
+		}
+		prefix := dirPrefix(f.SourceFile, depth)
+		d, ok := dirs[prefix]
+		if !ok {
+			d = note.FileDetail{SourceFile: prefix, Timeline: map[int64]int{}}
+		}
+		d.TimeSpent += f.TimeSpent
+		for epoch, secs := range f.Timeline {
+			d.Timeline[epoch] += secs
+		}
+		dirs[prefix] = d
+	}
+
+	for _, d := range dirs {
+		fds = append(fds, d)
+	}
+	sort.Slice(fds, func(i, j int) bool {
+		if fds[i].TimeSpent != fds[j].TimeSpent {
+			return fds[i].TimeSpent > fds[j].TimeSpent
+		}
+		return fds[i].SourceFile < fds[j].SourceFile
+	})
+
+	return note.CommitNote{Files: fds}
+}
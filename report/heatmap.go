@@ -0,0 +1,73 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"strings"
+	"time"
+)
+
+type heatmapEntries [7]timelineEntry
+
+// newHeatmapEntries returns the days of the week without time, starting with Monday
+func newHeatmapEntries() heatmapEntries {
+	var h heatmapEntries
+	for i := range h {
+		h[i].Day = time.Weekday((i + 1) % 7).String()[:3]
+	}
+	return h
+}
+
+// add totals the commit note's seconds spent by day of the week and hour of the day in loc
+func (h *heatmapEntries) add(n commitNoteDetail, loc *time.Location) {
+	for _, f := range n.Note.Files {
+		for epoch, secs := range f.Timeline {
+			t := epochTime(epoch, loc)
+			h[(int(t.Weekday())+6)%7].add(secs, t.Hour())
+		}
+	}
+}
+
+func (h heatmapEntries) HourMaxSeconds() int {
+	max := 0
+	for _, entry := range h {
+		for _, secs := range entry.Hours {
+			if secs > max {
+				max = secs
+			}
+		}
+	}
+	return max
+}
+
+func (h heatmapEntries) Seconds() int {
+	total := 0
+	for _, entry := range h {
+		total += entry.Seconds
+	}
+	return total
+}
+
+// ShadeForVal determines the shade to return for a value, plain ASCII characters are used if ascii is true
+func ShadeForVal(val, max int, ascii bool) string {
+	const shadeWidth int = 3
+
+	shades := []string{`░`, `▒`, `▓`, `█`}
+	if ascii {
+		shades = []string{`.`, `:`, `*`, `#`}
+	}
+
+	if val <= 0 || max <= 0 {
+		return strings.Repeat(" ", shadeWidth)
+	}
+
+	// scale so that any time spent gets at least the lightest shade
+	idx := (val*len(shades) - 1) / max
+	if idx > len(shades)-1 {
+		idx = len(shades) - 1
+	}
+
+	return strings.Repeat(shades[idx], shadeWidth)
+}
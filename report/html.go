@@ -0,0 +1,68 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+
+	"github.com/git-time-metric/gtm/util"
+)
+
+// HTML returns a self-contained HTML document with the time spent per commit, per day and per file
+func HTML(projects []ProjectCommits, options OutputOptions) (string, error) {
+	notes, err := retrieveNotes(projects, options, false, "Mon Jan 02 2006")
+	if err != nil {
+		return "", err
+	}
+	notes = options.limitNotes(notes)
+
+	// commits without time are not reported
+	withTime := commitNoteDetails{}
+	for _, n := range notes {
+		if len(n.Note.Files) > 0 {
+			withTime = append(withTime, n)
+		}
+	}
+
+	title := options.Title
+	if title == "" {
+		title = "Git Time Metric Report"
+	}
+
+	days := withTime.periods("day", options.WeekStart, options.Location)
+	maxSeconds := 0
+	for _, d := range days {
+		if d.Seconds > maxSeconds {
+			maxSeconds = d.Seconds
+		}
+	}
+
+	b := new(bytes.Buffer)
+	t := htmltemplate.Must(htmltemplate.New("HTML").Funcs(htmltemplate.FuncMap{
+		"FormatDuration": options.FormatDuration,
+		"Percent":        util.Percent,
+		"Subject":        options.subject,
+	}).Parse(htmlTpl))
+	err = t.Execute(
+		b,
+		struct {
+			Title      string
+			Notes      commitNoteDetails
+			Days       periodEntries
+			MaxSeconds int
+			Files      fileEntries
+		}{
+			title,
+			withTime,
+			days,
+			maxSeconds,
+			withTime.files(),
+		})
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
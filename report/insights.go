@@ -0,0 +1,71 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/git-time-metric/gtm/note"
+	"github.com/git-time-metric/gtm/util"
+)
+
+// insightsEntry contains stats derived from a commit note's time
+type insightsEntry struct {
+	// File is the file, or app, with the most time
+	File string
+	// Session is the time spent in the longest run of consecutive hours with time
+	Session      int
+	SessionStart time.Time
+	SessionEnd   time.Time
+}
+
+// insights returns the file with the most time and the longest session, sessions are consecutive
+// hours with time because timelines are kept by the hour. Nil is returned if the note has no time.
+func insights(n note.CommitNote, loc *time.Location) *insightsEntry {
+	if n.Total() == 0 {
+		return nil
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+
+	in := &insightsEntry{}
+	most := 0
+	hours := map[int64]int{}
+	for _, f := range n.Files {
+		// ties are broken by name so the file is the same on every refresh
+		if f.TimeSpent > most || (f.TimeSpent == most && f.SourceFile < in.File) {
+			most = f.TimeSpent
+			in.File = f.SourceFile
+			if f.IsApp() {
+				in.File = f.GetAppName() + " app"
+			}
+		}
+		for ep, secs := range f.Timeline {
+			hours[ep] += secs
+		}
+	}
+
+	epochs := []int64{}
+	for ep := range hours {
+		epochs = append(epochs, ep)
+	}
+	sort.Sort(util.ByInt64(epochs))
+
+	start, secs := int64(0), 0
+	for i, ep := range epochs {
+		if i == 0 || ep != epochs[i-1]+3600 {
+			start, secs = ep, 0
+		}
+		secs += hours[ep]
+		if secs > in.Session {
+			in.Session = secs
+			in.SessionStart = time.Unix(start, 0).In(loc)
+			in.SessionEnd = time.Unix(ep+3600, 0).In(loc)
+		}
+	}
+	return in
+}
@@ -0,0 +1,107 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/git-time-metric/gtm/project"
+)
+
+type issueEntry struct {
+	Key     string
+	Started time.Time
+	Seconds int
+	Commits []string
+}
+
+type issueEntries []issueEntry
+
+func (e issueEntries) Len() int      { return len(e) }
+func (e issueEntries) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e issueEntries) Less(i, j int) bool {
+	if (e[i].Key == project.UnassignedIssue) != (e[j].Key == project.UnassignedIssue) {
+		return e[j].Key == project.UnassignedIssue
+	}
+	return e[i].Key < e[j].Key
+}
+
+// issues totals the time by the issue keys in the commit messages, a commit's time is split equally between its
+// issues with the remainder going to the first. Commits without an issue key are project.UnassignedIssue.
+// Issues are sorted by key with unassigned last, each issue is started at its earliest commit.
+func (c commitNoteDetails) issues() issueEntries {
+	issuesMap := map[string]*issueEntry{}
+	for _, n := range c {
+		total := n.Note.Total()
+		if total == 0 {
+			continue
+		}
+		issueKeys := project.DefaultConfig().IssueKeys
+		if n.issueKeys != nil {
+			issueKeys = n.issueKeys
+		}
+		keys := issueKeys(strings.TrimSpace(n.Subject + "\n\n" + n.Message))
+		share := total / len(keys)
+		for i, k := range keys {
+			e, ok := issuesMap[k]
+			if !ok {
+				e = &issueEntry{Key: k, Started: n.When}
+				issuesMap[k] = e
+			}
+			e.Seconds += share
+			if i == 0 {
+				e.Seconds += total - share*len(keys)
+			}
+			if n.When.Before(e.Started) {
+				e.Started = n.When
+			}
+			e.Commits = append(e.Commits, n.Hash)
+		}
+	}
+
+	issues := make(issueEntries, 0, len(issuesMap))
+	for _, e := range issuesMap {
+		issues = append(issues, *e)
+	}
+	sort.Sort(issues)
+	return issues
+}
+
+// JIRA returns the time for each issue key in the commit messages as CSV for a JIRA worklog import,
+// the columns are the issue key, the worklog's start, the time spent in seconds and a comment with the commits.
+// See project.Config.IssueKeys for how issue keys are matched.
+func JIRA(projects []ProjectCommits, options OutputOptions) (string, error) {
+	notes, err := retrieveNotes(projects, options, false, "")
+	if err != nil {
+		return "", err
+	}
+	notes = options.limitNotes(notes)
+
+	b := new(bytes.Buffer)
+	w := csv.NewWriter(b)
+	if err := w.Write([]string{"Issue Key", "Started", "Time Spent (seconds)", "Comment"}); err != nil {
+		return "", err
+	}
+	for _, e := range notes.issues() {
+		record := []string{
+			e.Key,
+			e.Started.Format(time.RFC3339),
+			strconv.Itoa(e.Seconds),
+			"gtm commits " + strings.Join(e.Commits, " ")}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"sort"
+
+	"github.com/git-time-metric/gtm/project"
+)
+
+type languageEntry struct {
+	Name    string
+	Files   int
+	Seconds int
+}
+
+type languageEntries []languageEntry
+
+func (l languageEntries) Len() int      { return len(l) }
+func (l languageEntries) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l languageEntries) Less(i, j int) bool {
+	if l[i].Seconds != l[j].Seconds {
+		return l[i].Seconds < l[j].Seconds
+	}
+	return l[i].Name > l[j].Name
+}
+
+func (l languageEntries) Total() int {
+	total := 0
+	for _, e := range l {
+		total += e.Seconds
+	}
+	return total
+}
+
+func (l languageEntries) MaxSeconds() int {
+	max := 0
+	for _, e := range l {
+		if e.Seconds > max {
+			max = e.Seconds
+		}
+	}
+	return max
+}
+
+// round returns the languages with each language's time rounded
+func (l languageEntries) round(options OutputOptions) languageEntries {
+	for i := range l {
+		l[i].Seconds = options.round(l[i].Seconds)
+	}
+	return l
+}
+
+// languageTotals totals the time by the programming language of each file one commit note at a time.
+// Terminal and app time, and files with an unknown extension, are project.OtherLanguage.
+type languageTotals struct {
+	languages map[string]languageEntry
+	files     map[string]map[string]bool
+}
+
+func newLanguageTotals() *languageTotals {
+	return &languageTotals{languages: map[string]languageEntry{}, files: map[string]map[string]bool{}}
+}
+
+func (t *languageTotals) add(n commitNoteDetail) {
+	language := project.DefaultConfig().Language
+	if n.language != nil {
+		language = n.language
+	}
+	for _, f := range n.Note.Files {
+		name := language(f.SourceFile)
+		entry := t.languages[name]
+		entry.Name = name
+		entry.Seconds += f.TimeSpent
+		t.languages[name] = entry
+
+		// a file is counted once for each project
+		if t.files[name] == nil {
+			t.files[name] = map[string]bool{}
+		}
+		t.files[name][n.Project+":"+f.SourceFile] = true
+	}
+}
+
+// entries returns the languages' totals, most time first
+func (t *languageTotals) entries() languageEntries {
+	languages := make(languageEntries, 0, len(t.languages))
+	for name, entry := range t.languages {
+		entry.Files = len(t.files[name])
+		languages = append(languages, entry)
+	}
+	sort.Sort(sort.Reverse(languages))
+	return languages
+}
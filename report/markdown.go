@@ -0,0 +1,117 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/git-time-metric/gtm/util"
+)
+
+// Markdown returns the time spent per commit and per file as GitHub flavored Markdown tables
+func Markdown(projects []ProjectCommits, options OutputOptions) (string, error) {
+	notes, err := retrieveNotes(projects, options, false, "")
+	if err != nil {
+		return "", err
+	}
+	notes = options.limitNotes(notes)
+	options.sortNotes(notes, false)
+
+	commitRows := [][]string{}
+	for _, n := range notes {
+		if len(n.Note.Files) == 0 {
+			continue
+		}
+		commitRows = append(commitRows,
+			[]string{n.Hash, n.Date, n.Author, n.Project, options.subject(n.Subject), options.FormatDuration(n.Note.Total())})
+	}
+	if len(commitRows) == 0 {
+		return "", nil
+	}
+	commitRows = append(commitRows,
+		[]string{"**Total**", "", "", "", "", fmt.Sprintf("**%s**", options.FormatDuration(notes.Total()))})
+
+	files := notes.files()
+	options.sortFiles(files)
+	fileRows := [][]string{}
+	for _, f := range files {
+		name := f.Filename
+		if f.IsApp() {
+			name = fmt.Sprintf("[app] %s", f.GetAppName())
+		}
+		fileRows = append(fileRows,
+			[]string{name, options.FormatDuration(f.Seconds), fmt.Sprintf("%.0f%%", util.Percent(f.Seconds, files.Total()))})
+	}
+
+	b := new(bytes.Buffer)
+	b.WriteString(markdownTable(
+		[]string{"Commit", "Date", "Author", "Project", "Subject", "Time"},
+		[]bool{false, false, false, false, false, true},
+		commitRows))
+	b.WriteString("\n")
+	b.WriteString(markdownTable(
+		[]string{"File", "Time", "Percent"},
+		[]bool{false, true, true},
+		fileRows))
+	return b.String(), nil
+}
+
+// markdownTable returns the rows as a Markdown table with the columns padded to the same width
+func markdownTable(header []string, alignRight []bool, rows [][]string) string {
+	widths := make([]int, len(header))
+	cells := append([][]string{header}, rows...)
+	for i := range cells {
+		for j := range cells[i] {
+			cells[i][j] = markdownEscape(cells[i][j])
+			if w := utf8.RuneCountInString(cells[i][j]); w > widths[j] {
+				widths[j] = w
+			}
+		}
+	}
+	for j := range widths {
+		// a delimiter row needs at least three dashes
+		if widths[j] < 3 {
+			widths[j] = 3
+		}
+	}
+
+	pad := func(s string, width int, right bool) string {
+		p := strings.Repeat(" ", width-utf8.RuneCountInString(s))
+		if right {
+			return p + s
+		}
+		return s + p
+	}
+
+	b := new(bytes.Buffer)
+	writeRow := func(row []string) {
+		for j, cell := range row {
+			fmt.Fprintf(b, "| %s ", pad(cell, widths[j], alignRight[j]))
+		}
+		b.WriteString("|\n")
+	}
+
+	writeRow(cells[0])
+	for j := range widths {
+		if alignRight[j] {
+			fmt.Fprintf(b, "| %s: ", strings.Repeat("-", widths[j]-1))
+		} else {
+			fmt.Fprintf(b, "| %s ", strings.Repeat("-", widths[j]))
+		}
+	}
+	b.WriteString("|\n")
+	for _, row := range cells[1:] {
+		writeRow(row)
+	}
+	return b.String()
+}
+
+// markdownEscape escapes pipe characters so they do not split a table cell
+func markdownEscape(s string) string {
+	return strings.Replace(s, "|", `\|`, -1)
+}
@@ -0,0 +1,41 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+// newCodeSplit is the time spent on files added by commits, new code, and on existing files, maintenance,
+// Other is terminal and app time
+type newCodeSplit struct {
+	New         int
+	Maintenance int
+	Other       int
+}
+
+// Code returns the time spent on new and existing files
+func (s newCodeSplit) Code() int {
+	return s.New + s.Maintenance
+}
+
+func (s newCodeSplit) MaxSeconds() int {
+	if s.New > s.Maintenance {
+		return s.New
+	}
+	return s.Maintenance
+}
+
+// Ratio returns the time spent on new code for each second of maintenance
+func (s newCodeSplit) Ratio() float64 {
+	if s.Maintenance == 0 {
+		return 0
+	}
+	return float64(s.New) / float64(s.Maintenance)
+}
+
+// add splits the note's time using the files added by its commit
+func (s *newCodeSplit) add(n commitNoteDetail) {
+	newCode, existing := n.Note.Split(n.Added)
+	s.New += newCode.Total()
+	s.Maintenance += existing.Total()
+	s.Other += n.Note.Total() - newCode.Total() - existing.Total()
+}
@@ -0,0 +1,142 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type periodEntry struct {
+	Start      time.Time
+	Label      string
+	Seconds    int
+	Cumulative int
+}
+
+type periodEntries []periodEntry
+
+func (p periodEntries) Total() int {
+	total := 0
+	for _, e := range p {
+		total += e.Seconds
+	}
+	return total
+}
+
+// epochTime returns the time for epoch in loc, the local time zone is used if loc is nil
+func epochTime(epoch int64, loc *time.Location) time.Time {
+	if loc == nil {
+		return time.Unix(epoch, 0)
+	}
+	return time.Unix(epoch, 0).In(loc)
+}
+
+// ParseWeekday returns the day of the week for its name or its three letter abbreviation, i.e. saturday or sat,
+// the name is not case sensitive
+func ParseWeekday(name string) (time.Weekday, error) {
+	name = strings.ToLower(name)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		day := strings.ToLower(d.String())
+		if name == day || name == day[:3] {
+			return d, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("%s is not a day of the week", name)
+}
+
+// periodStart returns the start of the day, week or month containing t, weeks start on weekStart
+func periodStart(t time.Time, period string, weekStart time.Weekday) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case "week":
+		return day.AddDate(0, 0, -((int(day.Weekday()) - int(weekStart) + 7) % 7))
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return day
+	}
+}
+
+func periodLabel(start time.Time, period string) string {
+	switch period {
+	case "week":
+		end := start.AddDate(0, 0, 6)
+		// a week that spans the end of the year shows both years
+		if end.Year() != start.Year() {
+			return start.Format("Mon Jan 02 2006") + " - " + end.Format("Mon Jan 02 2006")
+		}
+		return start.Format("Mon Jan 02") + " - " + end.Format("Mon Jan 02 2006")
+	case "month":
+		return start.Format("January 2006")
+	default:
+		return start.Format("Mon Jan 02 2006")
+	}
+}
+
+// periods totals the seconds spent by day, week or month in loc, the local time zone is used if loc is nil
+func (c commitNoteDetails) periods(period string, weekStart time.Weekday, loc *time.Location) periodEntries {
+	t := newPeriodTotals(period, weekStart, loc)
+	for _, n := range c {
+		t.add(n)
+	}
+	return t.entries()
+}
+
+// periodTotals totals the time by day, week or month one commit note at a time, see commitNoteDetails.periods
+type periodTotals struct {
+	period    string
+	weekStart time.Weekday
+	loc       *time.Location
+	periods   map[time.Time]periodEntry
+}
+
+func newPeriodTotals(period string, weekStart time.Weekday, loc *time.Location) *periodTotals {
+	return &periodTotals{period: period, weekStart: weekStart, loc: loc, periods: map[time.Time]periodEntry{}}
+}
+
+func (t *periodTotals) add(n commitNoteDetail) {
+	for _, f := range n.Note.Files {
+		for epoch, secs := range f.Timeline {
+			start := periodStart(epochTime(epoch, t.loc), t.period, t.weekStart)
+			entry, ok := t.periods[start]
+			if !ok {
+				entry = periodEntry{Start: start, Label: periodLabel(start, t.period)}
+			}
+			entry.Seconds += secs
+			t.periods[start] = entry
+		}
+	}
+}
+
+// entries returns the periods' totals in time order with the cumulative time
+func (t *periodTotals) entries() periodEntries {
+	periods := periodEntries{}
+	for _, e := range t.periods {
+		periods = append(periods, e)
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+
+	total := 0
+	for i := range periods {
+		total += periods[i].Seconds
+		periods[i].Cumulative = total
+	}
+
+	return periods
+}
+
+// round returns the periods with each period's time rounded and the cumulative time totaled from the rounded times
+func (p periodEntries) round(options OutputOptions) periodEntries {
+	total := 0
+	for i := range p {
+		p[i].Seconds = options.round(p[i].Seconds)
+		total += p[i].Seconds
+		p[i].Cumulative = total
+	}
+	return p
+}
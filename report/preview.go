@@ -0,0 +1,59 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/git-time-metric/gtm/metric"
+)
+
+// Preview returns the events in each window and the time attributed to each file side by side,
+// followed by each file's total. It's a diagnostic for the project at projPath and is labeled as such.
+func Preview(attributions []metric.Attribution, options OutputOptions, projPath string) string {
+	loc := options.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	b := new(bytes.Buffer)
+	fmt.Fprintf(b, "\nPreview of pending event attribution for %s (diagnostic only, nothing is saved)\n\n", filepath.Base(projPath))
+	if len(attributions) == 0 {
+		fmt.Fprint(b, "No pending events\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(b, "%-20s %7s %14s  %s\n", "Window", "Events", "Attributed", "File")
+	totals := map[string]int{}
+	for _, a := range attributions {
+		files := []string{}
+		for f := range a.Events {
+			files = append(files, f)
+		}
+		sort.Strings(files)
+		window := time.Unix(a.Window, 0).In(loc).Format("Mon Jan 02 15:04:05")
+		for _, f := range files {
+			fmt.Fprintf(b, "%-20s %7d %14s  %s\n", window, a.Events[f], options.FormatDuration(a.Seconds[f]), f)
+			totals[f] += a.Seconds[f]
+			// the window is only shown on its first line
+			window = ""
+		}
+	}
+
+	files := []string{}
+	for f := range totals {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	fmt.Fprint(b, "\n")
+	for _, f := range files {
+		fmt.Fprintf(b, "%-20s %7s %14s  %s\n", "", "", options.FormatDuration(totals[f]), f)
+	}
+	return b.String()
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/git-time-metric/gtm/note"
+)
+
+// PrometheusProject contains a project's commits, tags and pending time for the Prometheus metrics
+type PrometheusProject struct {
+	ProjectCommits
+	Tags    []string
+	Pending note.CommitNote
+}
+
+// prometheusMetrics are the names, types and help of the metrics in the order they are written
+var prometheusMetrics = []struct {
+	name, kind, help string
+	value            func(pending, committed, commits int) int
+}{
+	{
+		"gtm_pending_seconds", "gauge", "Time spent in the project that is not committed yet.",
+		func(pending, committed, commits int) int { return pending },
+	},
+	{
+		"gtm_committed_seconds", "gauge", "Time saved in the git notes of the commits reachable from HEAD.",
+		func(pending, committed, commits int) int { return committed },
+	},
+	{
+		"gtm_commits", "gauge", "Number of commits reachable from HEAD with time saved in git notes.",
+		func(pending, committed, commits int) int { return commits },
+	},
+}
+
+// Prometheus returns the projects' time in the Prometheus text exposition format.
+// Each metric is labeled by project name, path and comma separated tags, i.e.
+//
+//	# HELP gtm_pending_seconds Time spent in the project that is not committed yet.
+//	# TYPE gtm_pending_seconds gauge
+//	gtm_pending_seconds{project="gtm",path="/src/gtm",tags="work"} 120
+//
+// Committed time is a gauge and not a counter because rewriting history or notes can reduce it.
+func Prometheus(projects []PrometheusProject, options OutputOptions) (string, error) {
+	type values struct {
+		labels                      string
+		pending, committed, commits int
+	}
+
+	ps := make([]PrometheusProject, len(projects))
+	copy(ps, projects)
+	sort.SliceStable(ps, func(i, j int) bool { return ps[i].Path < ps[j].Path })
+
+	vs := []values{}
+	for _, p := range ps {
+		v := values{
+			labels: fmt.Sprintf(`project=%s,path=%s,tags=%s`,
+				prometheusLabel(filepath.Base(p.Path)), prometheusLabel(p.Path), prometheusLabel(strings.Join(p.Tags, ","))),
+			pending: options.Weigh(p.Pending).Total(),
+		}
+		// each project is read on its own because worktrees of a repo share commits
+		notes, err := retrieveNotes([]ProjectCommits{p.ProjectCommits}, options, false, "")
+		if err != nil {
+			return "", err
+		}
+		for _, n := range notes {
+			if t := n.Note.Total(); t > 0 {
+				v.committed += t
+				v.commits++
+			}
+		}
+		vs = append(vs, v)
+	}
+
+	b := new(bytes.Buffer)
+	for _, m := range prometheusMetrics {
+		fmt.Fprintf(b, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(b, "# TYPE %s %s\n", m.name, m.kind)
+		for _, v := range vs {
+			fmt.Fprintf(b, "%s{%s} %d\n", m.name, v.labels, m.value(v.pending, v.committed, v.commits))
+		}
+	}
+	return b.String(), nil
+}
+
+// prometheusLabel quotes a label value, backslashes, double quotes and line feeds are escaped
+func prometheusLabel(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
@@ -19,38 +19,151 @@ import (
 
 const (
 	defaultDateFormat = "Mon Jan 02 15:04:05 2006 MST"
+	// PendingID is the commit id and hash of the entry for a project's uncommitted time
+	PendingID = "pending"
 )
 
-func retrieveNotes(projects []ProjectCommits, terminalOff, appOff, calcStats bool, dateFormat string) commitNoteDetails {
+// Hours of a project's working hours calendar that are reported, see project.Config.Calendar
+const (
+	HoursWorking = "working"
+	HoursOutside = "outside"
+)
+
+// HoursOptions are the valid values of the OutputOptions' Hours
+var HoursOptions = []string{HoursWorking, HoursOutside}
+
+// filterHours returns the commit note with the time within the calendar's working hours, or outside of them
+// for HoursOutside, in the options' Location. Without a calendar all of the time is within working hours.
+func (o OutputOptions) filterHours(n note.CommitNote, cal *project.Calendar) note.CommitNote {
+	switch {
+	case o.Hours == "":
+		return n
+	case cal == nil && o.Hours == HoursOutside:
+		return note.CommitNote{}
+	case cal == nil:
+		return n
+	}
+	loc := o.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return n.FilterHours(func(hour time.Time) float64 {
+		p := cal.Portion(hour, time.Hour, loc)
+		if o.Hours == HoursOutside {
+			return 1 - p
+		}
+		return p
+	})
+}
+
+// retrieveNotes reads the commit notes for the projects from the options' NotesRef, if NotesRef is blank each project's
+// configured notes ref is used. Commit times are converted to the options' Location if it's not nil.
+// Reading stops when the options' Context is canceled, the error wraps the context's error.
+func retrieveNotes(projects []ProjectCommits, options OutputOptions, calcStats bool, dateFormat string) (commitNoteDetails, error) {
 	notes := commitNoteDetails{}
+	err := walkNotes(projects, options, calcStats, dateFormat, func(n commitNoteDetail) error {
+		notes = append(notes, n)
+		return nil
+	})
+	if err != nil {
+		return commitNoteDetails{}, err
+	}
+	sort.Sort(notes)
+	if options.Anonymizer != nil {
+		notes.anonymize(options.Anonymizer, options.Mailmap)
+	}
+	return notes, nil
+}
+
+// walkNotes is like retrieveNotes but calls fn with each commit note as it's read instead of returning them,
+// the notes are in the projects' commit order followed by each project's pending time and are not anonymized.
+// Each project's notes are streamed by note.WalkCommits. A note that can not be read is an empty commitNoteDetail.
+// Walking stops at the first error fn returns.
+func walkNotes(projects []ProjectCommits, options OutputOptions, calcStats bool, dateFormat string, fn func(n commitNoteDetail) error) error {
+	ctx := options.context()
 
 	if dateFormat == "" {
 		dateFormat = defaultDateFormat
 	}
 
+	// worktrees of a repo share commits and notes, a commit is only reported once
+	seen := map[string]bool{}
 	for _, p := range projects {
-		for _, c := range p.Commits {
+		repoKey := p.Path
+		if gitRepoPath, err := scm.GitRepoPath(p.Path); err == nil {
+			if commonDir, err := scm.GitCommonDir(gitRepoPath); err == nil {
+				repoKey = commonDir
+			}
+		}
 
-			n, err := scm.ReadNote(c, project.NoteNameSpace, calcStats, p.Path)
-			if err != nil {
-				notes = append(notes, commitNoteDetail{})
-				continue
+		gtmPath := filepath.Join(p.Path, project.GTMDir)
+		cfg := project.ReadConfig(gtmPath)
+		// ReadConfig returns the default config, without a calendar, if the config is not valid
+		cal, _ := cfg.Calendar()
+		nameSpace := cfg.NoteNameSpace()
+		// commits before time tracking started for the project are not reported
+		trackingStart := cfg.TrackingStartTime()
+		if options.NotesRef != "" {
+			nameSpace = strings.TrimPrefix(options.NotesRef, "refs/notes/")
+		}
+
+		var cache *noteCache
+		if !options.NoCache {
+			cache = newNoteCache(gtmPath, options.Version)
+		}
+
+		// renames are detected for the commits that are reported, see scm.Renames
+		var renames map[string]map[string]string
+		if options.FollowRenames {
+			var err error
+			if renames, err = scm.Renames(p.Commits, p.Path); err != nil {
+				return err
 			}
+		}
 
-			when := n.When.Format(dateFormat)
+		// the files added by each commit are only needed to split time between new and existing files
+		var added map[string]map[string]bool
+		if options.splitNewCode {
+			var err error
+			if added, err = scm.AddedFiles(p.Commits, p.Path); err != nil {
+				return err
+			}
+		}
 
-			var commitNote note.CommitNote
-			commitNote, err = note.UnMarshal(n.Note)
-			if err != nil {
-				commitNote = note.CommitNote{}
+		commits := make([]string, 0, len(p.Commits))
+		for _, c := range p.Commits {
+			if !seen[repoKey+":"+c] {
+				seen[repoKey+":"+c] = true
+				commits = append(commits, c)
+			}
+		}
+
+		// read caches the commit details of the note it reads for the walk function
+		var n cachedNote
+		read := func(commitID string) (note.CommitNote, error) {
+			var err error
+			if n, err = readNote(cache, commitID, nameSpace, calcStats, p.Path); err != nil {
+				// a commit that can not be read is reported as an empty commitNoteDetail
+				n = cachedNote{}
+			}
+			return n.Note, nil
+		}
+
+		err := note.WalkCommits(ctx, commits, read, func(commitID string, cn note.CommitNote) error {
+			if n.ID == "" {
+				return fn(commitNoteDetail{})
 			}
 
-			if terminalOff {
-				commitNote = commitNote.FilterOutTerminal()
+			if n.When.Before(trackingStart) {
+				return nil
 			}
-			if appOff {
-				commitNote = commitNote.FilterOutApp()
+
+			if options.Location != nil {
+				n.When = n.When.In(options.Location)
 			}
+			when := options.formatDate(n.When, dateFormat)
+
+			commitNote := options.Weigh(options.filterHours(cn.Rename(renames[n.ID]), cal))
 
 			id := n.ID
 			if len(id) > 7 {
@@ -60,9 +173,16 @@ func retrieveNotes(projects []ProjectCommits, terminalOff, appOff, calcStats boo
 			message := strings.TrimPrefix(n.Message, n.Summary)
 			message = strings.TrimSpace(message)
 
-			notes = append(notes,
+			stats := scm.CommitStats{}
+			if n.Stats != nil {
+				stats = *n.Stats
+			}
+
+			return fn(
 				commitNoteDetail{
+					ID:         n.ID,
 					Author:     n.Author,
+					Email:      n.Email,
 					Date:       when,
 					When:       n.When,
 					Hash:       id,
@@ -70,15 +190,139 @@ func retrieveNotes(projects []ProjectCommits, terminalOff, appOff, calcStats boo
 					Message:    message,
 					Note:       commitNote,
 					Project:    filepath.Base(p.Path),
-					LineAdd:    fmt.Sprintf("+%d", n.Stats.Insertions),
-					LineDel:    fmt.Sprintf("-%d", n.Stats.Deletions),
-					LineDiff:   fmt.Sprintf("%d", n.Stats.Insertions-n.Stats.Deletions),
-					ChangeRate: fmt.Sprintf("%.0f", n.Stats.ChangeRatePerHour(commitNote.Total())),
+					LineAdd:    fmt.Sprintf("+%d", stats.Insertions),
+					LineDel:    fmt.Sprintf("-%d", stats.Deletions),
+					LineDiff:   fmt.Sprintf("%d", stats.Insertions-stats.Deletions),
+					ChangeRate: fmt.Sprintf("%.0f", stats.ChangeRatePerHour(commitNote.Total())),
+					Added:      renameAdded(added[n.ID], renames[n.ID]),
+					language:   cfg.Language,
+					issueKeys:  cfg.IssueKeys,
 				})
+		})
+		if err != nil {
+			return err
+		}
+
+		if p.Pending != nil && p.Pending.Note.Total() > 0 {
+			when := util.Now()
+			if options.Location != nil {
+				when = when.In(options.Location)
+			}
+			commitNote := options.Weigh(options.filterHours(p.Pending.Note, cal))
+			var pendingAdded map[string]bool
+			if options.splitNewCode {
+				var err error
+				if pendingAdded, err = untrackedFiles(p.Path, commitNote); err != nil {
+					return err
+				}
+			}
+			err := fn(
+				commitNoteDetail{
+					ID:         PendingID,
+					Author:     p.Pending.Author,
+					Email:      p.Pending.Email,
+					Date:       options.formatDate(when, dateFormat),
+					When:       when,
+					Hash:       PendingID,
+					Subject:    "Pending, not committed",
+					Note:       commitNote,
+					Project:    filepath.Base(p.Path),
+					LineAdd:    "+0",
+					LineDel:    "-0",
+					LineDiff:   "0",
+					ChangeRate: "0",
+					Added:      pendingAdded,
+					Pending:    true,
+					language:   cfg.Language,
+					issueKeys:  cfg.IssueKeys,
+				})
+			if err != nil {
+				return err
+			}
 		}
 	}
-	sort.Sort(notes)
-	return notes
+	return nil
+}
+
+// renameAdded returns the added files with the paths moved to their new path like CommitNote.Rename
+func renameAdded(added map[string]bool, paths map[string]string) map[string]bool {
+	if len(paths) == 0 {
+		return added
+	}
+	renamed := map[string]bool{}
+	for f := range added {
+		if p, ok := paths[f]; ok {
+			f = p
+		}
+		renamed[f] = true
+	}
+	return renamed
+}
+
+// untrackedFiles returns the note's files that are not in the project's head commit,
+// all of the files are untracked without a head commit
+func untrackedFiles(projPath string, n note.CommitNote) (map[string]bool, error) {
+	head, err := scm.HeadCommit(projPath)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	if head.ID != "" {
+		if files, err = scm.TreeFiles(head.ID, projPath); err != nil {
+			return nil, err
+		}
+	}
+	tracked := map[string]bool{}
+	for _, f := range files {
+		tracked[f] = true
+	}
+	untracked := map[string]bool{}
+	for _, f := range n.Files {
+		if p := filepath.ToSlash(f.SourceFile); !tracked[p] {
+			untracked[p] = true
+		}
+	}
+	return untracked, nil
+}
+
+// readNote returns the commit's note from the cache, if it's not cached the note is read from git and cached
+func readNote(cache *noteCache, commitID, nameSpace string, calcStats bool, projPath string) (cachedNote, error) {
+	if cache != nil {
+		noteID, err := scm.NoteID(commitID, nameSpace, projPath)
+		if err != nil {
+			return cachedNote{}, err
+		}
+		if n, ok := cache.get(nameSpace, commitID, noteID, calcStats); ok {
+			return n, nil
+		}
+	}
+
+	n, err := scm.ReadNote(commitID, nameSpace, calcStats, projPath)
+	if err != nil {
+		return cachedNote{}, err
+	}
+
+	commitNote, err := note.UnMarshal(n.Note)
+	if err != nil {
+		commitNote = note.CommitNote{}
+	}
+
+	cn := cachedNote{
+		ID:      n.ID,
+		Summary: n.Summary,
+		Message: n.Message,
+		Author:  n.Author,
+		Email:   n.Email,
+		When:    n.When,
+		Note:    commitNote,
+		NoteID:  n.NoteID,
+	}
+	if calcStats {
+		stats := n.Stats
+		cn.Stats = &stats
+	}
+	cache.put(nameSpace, cn)
+	return cn, nil
 }
 
 type commitNoteDetails []commitNoteDetail
@@ -96,7 +340,9 @@ func (c commitNoteDetails) Total() int {
 }
 
 type commitNoteDetail struct {
+	ID         string
 	Author     string
+	Email      string
 	Date       string
 	When       time.Time
 	Hash       string
@@ -108,23 +354,55 @@ type commitNoteDetail struct {
 	LineDel    string
 	LineDiff   string
 	ChangeRate string
+	Cumulative int
+	// Added are the files added by the commit, or the files that are not in the head commit for pending time,
+	// it's only set when time is split between new and existing files, see NewCodeSummary
+	Added map[string]bool
+	// Pending is true for the uncommitted time of a project
+	Pending bool
+	// language returns the programming language of a file with the project's config, see project.Config.Language
+	language func(sourcePath string) string
+	// issueKeys returns the issue keys in a commit message with the project's config, see project.Config.IssueKeys
+	issueKeys func(message string) []string
+}
+
+// accumulate sets each commit's cumulative time, the time spent up to and including the commit
+// in commit time order, each commit's time is rounded by the options. Notes are sorted newest first so
+// they are totaled from the end.
+func (c commitNoteDetails) accumulate(options OutputOptions) {
+	total := 0
+	for i := len(c) - 1; i >= 0; i-- {
+		total += options.round(c[i].Note.Total())
+		c[i].Cumulative = total
+	}
 }
 
 func (c commitNoteDetails) files() fileEntries {
-	filesMap := map[string]fileEntry{}
+	t := fileTotals{}
 	for _, n := range c {
-		for _, f := range n.Note.Files {
-			if entry, ok := filesMap[f.SourceFile]; !ok {
-				filesMap[f.SourceFile] = fileEntry{Filename: f.SourceFile, Seconds: f.TimeSpent}
-			} else {
-				entry.add(f.TimeSpent)
-				filesMap[f.SourceFile] = entry
-			}
+		t.add(n)
+	}
+	return t.entries()
+}
+
+// fileTotals totals the time by file one commit note at a time
+type fileTotals map[string]fileEntry
+
+func (t fileTotals) add(n commitNoteDetail) {
+	for _, f := range n.Note.Files {
+		if entry, ok := t[f.SourceFile]; !ok {
+			t[f.SourceFile] = fileEntry{Filename: f.SourceFile, Seconds: f.TimeSpent}
+		} else {
+			entry.add(f.TimeSpent)
+			t[f.SourceFile] = entry
 		}
 	}
+}
 
-	files := make(fileEntries, 0, len(filesMap))
-	for _, entry := range filesMap {
+// entries returns the files' totals, most time first
+func (t fileTotals) entries() fileEntries {
+	files := make(fileEntries, 0, len(t))
+	for _, entry := range t {
 		files = append(files, entry)
 	}
 	sort.Sort(sort.Reverse(files))
@@ -137,10 +415,6 @@ func (f fileEntries) Len() int           { return len(f) }
 func (f fileEntries) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
 func (f fileEntries) Less(i, j int) bool { return f[i].Seconds < f[j].Seconds }
 
-func (f fileEntries) Duration() string {
-	return util.FormatDuration(f.Total())
-}
-
 func (f fileEntries) Total() int {
 	total := 0
 	for _, entry := range f {
@@ -152,16 +426,14 @@ func (f fileEntries) Total() int {
 type fileEntry struct {
 	Filename string
 	Seconds  int
+	// Deleted is true if the file is not in the head commit, it's only set by FileHistory
+	Deleted bool
 }
 
 func (f *fileEntry) add(s int) {
 	f.Seconds += s
 }
 
-func (f *fileEntry) Duration() string {
-	return util.FormatDuration(f.Seconds)
-}
-
 func (f *fileEntry) IsTerminal() bool {
 	return f.Filename == ".gtm/terminal.app"
 }
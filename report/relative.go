@@ -0,0 +1,64 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/git-time-metric/gtm/util"
+)
+
+const (
+	// RelativeDates shows commit dates relative to now, i.e. 3 days ago
+	RelativeDates = "relative"
+	// RelativeAndAbsoluteDates shows commit dates followed by the relative date, i.e. Sun Mar 20 2016 (3 days ago)
+	RelativeAndAbsoluteDates = "both"
+)
+
+// relativeUnits are the units relative dates are humanized in, a date is shown in the largest unit it's at least one of
+var relativeUnits = []struct {
+	name string
+	d    time.Duration
+}{
+	{"year", 365 * 24 * time.Hour},
+	{"month", 30 * 24 * time.Hour},
+	{"week", 7 * 24 * time.Hour},
+	{"day", 24 * time.Hour},
+	{"hour", time.Hour},
+	{"minute", time.Minute},
+}
+
+// formatDate returns the commit date t formatted with layout, relative to now or both for the options' RelativeDates
+func (o OutputOptions) formatDate(t time.Time, layout string) string {
+	switch o.RelativeDates {
+	case RelativeDates:
+		return relativeDate(t, util.Now())
+	case RelativeAndAbsoluteDates:
+		return fmt.Sprintf("%s (%s)", t.Format(layout), relativeDate(t, util.Now()))
+	default:
+		return t.Format(layout)
+	}
+}
+
+// relativeDate returns how long before now t is, i.e. 3 days ago or 1 hour from now for a time after now.
+// It's in English for all locales and months and years are 30 and 365 days so it does not depend on the calendar.
+func relativeDate(t, now time.Time) string {
+	d := now.Sub(t)
+	suffix := "ago"
+	if d < 0 {
+		d = -d
+		suffix = "from now"
+	}
+	for _, u := range relativeUnits {
+		if n := int(d / u.d); n >= 1 {
+			if n > 1 {
+				return fmt.Sprintf("%d %ss %s", n, u.name, suffix)
+			}
+			return fmt.Sprintf("1 %s %s", u.name, suffix)
+		}
+	}
+	return "just now"
+}
@@ -6,15 +6,22 @@ package report
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/git-time-metric/gtm/note"
 	"github.com/git-time-metric/gtm/project"
+	"github.com/git-time-metric/gtm/scm"
 	"github.com/git-time-metric/gtm/util"
 	isatty "github.com/mattn/go-isatty"
 )
@@ -25,53 +32,410 @@ var funcMap = template.FuncMap{
 	"LeftPad2Len":    util.LeftPad2Len,
 	"Percent":        util.Percent,
 	"Blocks":         BlockForVal,
+	"Shades":         ShadeForVal,
+	"Bar":            BarForVal,
 }
 
-// ProjectCommits contains a project's directory path and commit ids
+// ProjectCommits contains a project's directory path and commit ids,
+// if Pending is set the project's uncommitted time is reported as a pending entry
 type ProjectCommits struct {
 	Path    string
 	Commits []string
+	Pending *PendingTime
+}
+
+// PendingTime is a project's uncommitted time and the git user it's for
+type PendingTime struct {
+	Note   note.CommitNote
+	Author string
+	Email  string
 }
 
 // OutputOptions contains cli options for reporting
 type OutputOptions struct {
-	TotalOnly    bool
-	LongDuration bool
-	FullMessage  bool
-	TerminalOff  bool
-	AppOff       bool
-	Color        bool
-	Limit        int
+	TotalOnly      bool
+	LongDuration   bool
+	DurationFormat string
+	DurationPlaces int
+	Seconds        bool
+	ShowPercent    bool
+	ShowApps       bool
+	ShowBranch     bool
+	ShowInsights   bool
+	ShowDetail     bool
+	FullMessage    bool
+	ShowMessage    bool // adds the commit subject to the csv format
+	MessageWidth   int  // truncates commit subjects to the number of characters, 0 is no limit
+	TerminalOff    bool
+	AppOff         bool
+	Weights        *Weights
+	Color          bool
+	NoColor        bool // disables color even if Color is set or a terminal is detected
+	Theme          *Theme
+	Limit          int
+	Mailmap        Mailmap
+	Period         string
+	WeekStart      time.Weekday
+	MinDuration    time.Duration
+	Top            int
+	DirDepth       int
+	Width          int // status lines are fit to the width by eliding the middle of file paths, 0 is no limit
+	Cumulative     bool
+	Title          string
+	Location       *time.Location
+	NotesRef       string
+	Paths          []string
+	Sort           string
+	NoCache        bool
+	Version        string
+	Context        context.Context // cancels reading notes, nil is context.Background()
+	RelativeDates  string          // RelativeDates or RelativeAndAbsoluteDates, dates are absolute if blank
+	Round          time.Duration   // rounds the time of each commit, project, author or period, not rounded if zero
+	RoundMode      string          // RoundUp, RoundNearest or RoundDown, RoundUp if blank
+	FollowRenames  bool            // moves time for files renamed in later commits to the path at the head commit
+	Hours          string          // HoursWorking or HoursOutside of each project's working hours, all time is reported if blank
+	CoAuthors      string          // CoAuthorsEqual splits time between a commit's author and co-authors, CoAuthorsPrimary if blank
+	ExistingOnly   bool            // leaves out files that are not in the head commit from FileHistory
+	Anonymizer     *Anonymizer     // replaces the authors of commits with pseudonyms, authors are not changed if nil
+	splitNewCode   bool            // reads the files added by each commit, see NewCodeSummary
+}
+
+// context returns the options' Context or context.Background() if it's nil
+func (o OutputOptions) context() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
+}
+
+// Weights contains the multipliers applied to terminal and app time before totals are computed,
+// i.e. a Terminal weight of 0.5 counts an hour in the terminal as 30 minutes
+type Weights struct {
+	Terminal float64
+	App      float64
+}
+
+// DefaultWeights returns the weights used when no weights are set, time is counted as is
+func DefaultWeights() Weights {
+	return Weights{Terminal: 1, App: 1}
+}
+
+// Weigh returns the commit note with the Weights applied, TerminalOff and AppOff are a weight of 0.
+// The terminal is an app so AppOff also excludes terminal time.
+func (o OutputOptions) Weigh(n note.CommitNote) note.CommitNote {
+	w := DefaultWeights()
+	if o.Weights != nil {
+		w = *o.Weights
+	}
+	if o.TerminalOff || o.AppOff {
+		w.Terminal = 0
+	}
+	if o.AppOff {
+		w.App = 0
+	}
+	return n.Weigh(w.Terminal, w.App)
+}
+
+// othersEntry sums the files left out when only showing the Top files
+type othersEntry struct {
+	Files   int
+	Seconds int
+}
+
+// topFiles returns the Top files with the most time and the sum of the remaining files
+func (o OutputOptions) topFiles(n note.CommitNote) (note.CommitNote, othersEntry) {
+	if o.Top <= 0 || len(n.Files) <= o.Top {
+		return n, othersEntry{}
+	}
+	fds := make([]note.FileDetail, len(n.Files))
+	copy(fds, n.Files)
+	sort.Stable(sort.Reverse(note.FileByTime(fds)))
+
+	others := othersEntry{}
+	for _, f := range fds[o.Top:] {
+		others.Files++
+		others.Seconds += f.TimeSpent
+	}
+	return note.CommitNote{Files: fds[:o.Top]}, others
+}
+
+// topFileEntries is like topFiles for the files report
+func (o OutputOptions) topFileEntries(files fileEntries) (fileEntries, othersEntry) {
+	if o.Top <= 0 || len(files) <= o.Top {
+		return files, othersEntry{}
+	}
+	others := othersEntry{}
+	for _, f := range files[o.Top:] {
+		others.Files++
+		others.Seconds += f.Seconds
+	}
+	return files[:o.Top], others
+}
+
+// durationFormat returns the format for durations, short, long or decimal,
+// LongDuration is kept for backwards compatibility and selects long if a format is not set
+func (o OutputOptions) durationFormat() string {
+	if o.DurationFormat == "" && o.LongDuration {
+		return "long"
+	}
+	return o.DurationFormat
+}
+
+// FormatDuration formats seconds as a duration in the DurationFormat
+func (o OutputOptions) FormatDuration(secs int) string {
+	switch o.durationFormat() {
+	case "long":
+		return util.DurationStrLong(secs)
+	case "decimal":
+		return util.DurationStrDecimal(secs, o.DurationPlaces)
+	default:
+		return util.FormatDuration(secs)
+	}
+}
+
+// funcMap returns the template functions with durations formatted in the DurationFormat
+func (o OutputOptions) funcMap() template.FuncMap {
+	fm := template.FuncMap{}
+	for k, v := range funcMap {
+		fm[k] = v
+	}
+	fm["FormatDuration"] = o.FormatDuration
+	fm["FormatEpoch"] = o.formatEpoch
+	fm["Subject"] = o.subject
+	fm["ShortenPath"] = o.shortenPath
+	return fm
+}
+
+// statusPathColumn is where file paths start in status lines, after the time and file status columns
+const statusPathColumn = 19
+
+// shortenPath returns the file path shortened to fit the status line in the Width, the middle of the path
+// is elided so the file name stays visible
+func (o OutputOptions) shortenPath(p string) string {
+	if o.Width <= 0 {
+		return p
+	}
+	width := o.Width - statusPathColumn
+	if o.ShowPercent {
+		width -= 5
+	}
+	// always show some of the path on very narrow terminals
+	if width < 10 {
+		width = 10
+	}
+	return util.ElideMiddle(p, width)
+}
+
+// subject returns the commit subject truncated to the MessageWidth
+func (o OutputOptions) subject(s string) string {
+	return util.Truncate(s, o.MessageWidth)
+}
+
+// formatEpoch formats a Unix epoch as the weekday and clock time in the Location
+func (o OutputOptions) formatEpoch(epoch int64) string {
+	loc := o.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return time.Unix(epoch, 0).In(loc).Format("Mon 15:04")
+}
+
+// hideShortFiles removes files with less time than MinDuration from the commit note,
+// callers should total the commit note before hiding files
+func (o OutputOptions) hideShortFiles(n note.CommitNote) note.CommitNote {
+	if o.MinDuration <= 0 {
+		return n
+	}
+	fds := []note.FileDetail{}
+	for _, f := range n.Files {
+		if time.Duration(f.TimeSpent)*time.Second >= o.MinDuration {
+			fds = append(fds, f)
+		}
+	}
+	return note.CommitNote{Files: fds}
 }
 
+// limitNotes returns at most Limit notes, if Paths are set only the commits with time for matching files are returned
 func (o OutputOptions) limitNotes(notes commitNoteDetails) commitNoteDetails {
 	ns := notes
-	if o.Limit > 0 && len(ns) > o.Limit {
-		ns = ns[0:o.Limit]
+	if len(o.Paths) > 0 {
+		// keep the commits with time for files matching the paths
+		ns = commitNoteDetails{}
+		for _, n := range notes {
+			n.Note = n.Note.FilterPaths(o.Paths)
+			if len(n.Note.Files) > 0 {
+				ns = append(ns, n)
+			}
+		}
+	}
+	if o.Limit > 0 {
+		// pending time is not a commit and is not counted towards the limit
+		limited := commitNoteDetails{}
+		cnt := 0
+		for _, n := range ns {
+			if n.Pending || cnt < o.Limit {
+				limited = append(limited, n)
+			}
+			if !n.Pending {
+				cnt++
+			}
+		}
+		ns = limited
 	}
 	return ns
 }
 
+// eachNote calls fn with each of the projects' commit notes that limitNotes would return, in no particular order.
+// The notes are streamed one at a time so totals can be accumulated without holding all of the notes in memory,
+// unless the notes must be read first to limit or anonymize them. Walking stops at the first error fn returns.
+func (o OutputOptions) eachNote(projects []ProjectCommits, fn func(n commitNoteDetail) error) error {
+	commits := 0
+	for _, p := range projects {
+		commits += len(p.Commits)
+	}
+	if (o.Limit > 0 && o.Limit < commits) || o.Anonymizer != nil {
+		notes, err := retrieveNotes(projects, o, false, "")
+		if err != nil {
+			return err
+		}
+		for _, n := range o.limitNotes(notes) {
+			if err := fn(n); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return walkNotes(projects, o, false, "", func(n commitNoteDetail) error {
+		if len(o.Paths) > 0 {
+			// keep the commits with time for files matching the paths
+			n.Note = n.Note.FilterPaths(o.Paths)
+			if len(n.Note.Files) == 0 {
+				return nil
+			}
+		}
+		return fn(n)
+	})
+}
+
+// sortNotes orders the notes by Sort, date is newest first, time is most time first and name is by subject.
+// Ties are broken by commit SHA1. If grouped the notes are only reordered within runs of notes
+// with the same Date. Notes are already in date order so date does not reorder them.
+func (o OutputOptions) sortNotes(notes commitNoteDetails, grouped bool) {
+	var less func(a, b commitNoteDetail) bool
+	switch o.Sort {
+	case "time":
+		less = func(a, b commitNoteDetail) bool {
+			if a.Note.Total() != b.Note.Total() {
+				return a.Note.Total() > b.Note.Total()
+			}
+			return a.ID < b.ID
+		}
+	case "name":
+		less = func(a, b commitNoteDetail) bool {
+			if a.Subject != b.Subject {
+				return a.Subject < b.Subject
+			}
+			return a.ID < b.ID
+		}
+		// files within each commit are also sorted by name
+		for i := range notes {
+			fds := make([]note.FileDetail, len(notes[i].Note.Files))
+			copy(fds, notes[i].Note.Files)
+			sort.Slice(fds, func(x, y int) bool { return fds[x].SourceFile < fds[y].SourceFile })
+			notes[i].Note = note.CommitNote{Files: fds}
+		}
+	default:
+		return
+	}
+
+	start := 0
+	for i := 1; i <= len(notes); i++ {
+		if i == len(notes) || (grouped && notes[i].Date != notes[start].Date) {
+			run := notes[start:i]
+			sort.SliceStable(run, func(x, y int) bool { return less(run[x], run[y]) })
+			start = i
+		}
+	}
+}
+
+// sortFiles orders the files by Sort, name is by path and otherwise files are ordered by most time first.
+// Ties are broken by path.
+func (o OutputOptions) sortFiles(files fileEntries) {
+	sort.SliceStable(files, func(i, j int) bool {
+		if o.Sort != "name" && files[i].Seconds != files[j].Seconds {
+			return files[i].Seconds > files[j].Seconds
+		}
+		return files[i].Filename < files[j].Filename
+	})
+}
+
+// totalOnly returns the total seconds for the total-only status
+func (o OutputOptions) totalOnly(seconds int) string {
+	if o.Seconds {
+		return fmt.Sprintf("%d\n", seconds)
+	}
+	switch o.durationFormat() {
+	case "long":
+		return util.DurationStrLong(seconds)
+	case "decimal":
+		return util.DurationStrDecimal(seconds, o.DurationPlaces)
+	default:
+		return util.DurationStr(seconds)
+	}
+}
+
+// GrandTotal returns the total pending time for all projects as a status line aligned with the project totals,
+// if TotalOnly only the total is returned
+func GrandTotal(seconds int, options OutputOptions) string {
+	if options.TotalOnly {
+		return options.totalOnly(seconds)
+	}
+	return Subtotal("Total", seconds, options)
+}
+
+// Subtotal returns the pending time for a group of projects as a status line aligned with the project totals
+func Subtotal(label string, seconds int, options OutputOptions) string {
+	theme := DefaultTheme()
+	if options.Theme != nil {
+		theme = *options.Theme
+	}
+	cf := colorFormater{color: options.Color, noColor: options.NoColor}
+	percentPad := ""
+	if options.ShowPercent {
+		percentPad = "     "
+	}
+	return fmt.Sprintf("%14s%s     %s%s%s\n",
+		options.FormatDuration(seconds), percentPad, cf.start(theme.Total), label, cf.end(theme.Total))
+}
+
+// Heading returns a heading for a group of projects aligned with the project totals
+func Heading(label string, options OutputOptions) string {
+	theme := DefaultTheme()
+	if options.Theme != nil {
+		theme = *options.Theme
+	}
+	cf := colorFormater{color: options.Color, noColor: options.NoColor}
+	percentPad := ""
+	if options.ShowPercent {
+		percentPad = "     "
+	}
+	return fmt.Sprintf("%14s%s     %s%s%s\n", "", percentPad, cf.start(theme.Heading), label, cf.end(theme.Heading))
+}
+
 // Status returns the status report
 func Status(n note.CommitNote, options OutputOptions, projPath ...string) (string, error) {
 	defer util.Profile()()
 
-	if options.TerminalOff {
-		n = n.FilterOutTerminal()
-	}
-	if options.AppOff {
-		n = n.FilterOutApp()
-	}
+	n = options.Weigh(n)
 
 	if options.TotalOnly {
-		if options.LongDuration {
-			return util.DurationStrLong(n.Total()), nil
-		}
-		return util.DurationStr(n.Total()), nil
+		return options.totalOnly(n.Total()), nil
 	}
 
 	projName := ""
 	tags := ""
+	branch := ""
 	if len(projPath) > 0 {
 		projName = filepath.Base(projPath[0])
 		tagList, err := project.LoadTags(filepath.Join(projPath[0], ".gtm"))
@@ -79,25 +443,104 @@ func Status(n note.CommitNote, options OutputOptions, projPath ...string) (strin
 			return "", err
 		}
 		tags = strings.Join(tagList, ",")
+		if options.ShowBranch {
+			if branch, err = scm.Branch(projPath[0]); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return options.statusText(n, projName, tags, branch, projPath)
+}
+
+// StatusCommits returns the status report of the time committed for each of the project's commit ids
+// with time and the total for the commits, the label is the range of the commits for the heading and total
+func StatusCommits(commitIDs []string, label string, options OutputOptions, projPath string) (string, error) {
+	defer util.Profile()()
+
+	notes, err := retrieveNotes([]ProjectCommits{{Path: projPath, Commits: commitIDs}}, options, false, "")
+	if err != nil {
+		return "", err
+	}
+
+	out := ""
+	total := 0
+	for _, n := range notes {
+		// commits without time or with a note that can't be read are not shown
+		if n.Note.Total() == 0 {
+			continue
+		}
+		s, err := options.statusText(n.Note, n.Hash+" "+n.Subject, "", "", []string{projPath})
+		if err != nil {
+			return "", err
+		}
+		out += s
+		total += n.Note.Total()
+	}
+	out = "\n" + Heading("Committed "+label, options) + strings.TrimPrefix(out, "\n")
+	return out + "\n" + Subtotal(label+" total", total, options), nil
+}
+
+// statusText returns the status report for the weighted commit note with a total line for the name
+func (o OutputOptions) statusText(n note.CommitNote, name, tags, branch string, projPath []string) (string, error) {
+	// list apps in their own section, grouped by app name
+	files := o.hideShortFiles(n)
+	if o.DirDepth > 0 {
+		files = dirTotals(files, o.DirDepth)
+	}
+	apps := []appEntry{}
+	if o.ShowApps {
+		apps = appTotals(files)
+		files = files.FilterOutApp()
+	}
+	files, others := o.topFiles(files)
+
+	var insightsFor *insightsEntry
+	if o.ShowInsights {
+		insightsFor = insights(n, o.Location)
 	}
 
 	b := new(bytes.Buffer)
-	t := template.Must(template.New("Status").Funcs(funcMap).Parse(statusTpl))
-	cf := colorFormater{color: options.Color}
+	t := template.Must(template.New("Status").Funcs(o.funcMap()).Parse(statusTpl))
+	cf := colorFormater{color: o.Color, noColor: o.NoColor}
+	theme := DefaultTheme()
+	if o.Theme != nil {
+		theme = *o.Theme
+	}
 	err := t.Execute(
 		b,
 		struct {
 			ProjPath    []string
 			ProjectName string
 			commitNoteDetail
-			BoldFormat string
-			Tags       string
+			Others        othersEntry
+			Apps          []appEntry
+			Total         int
+			TotalFormat   string
+			HeadingFormat string
+			FileStart     string
+			FileEnd       string
+			Tags          string
+			Branch        string
+			Insights      *insightsEntry
+			ShowPercent   bool
+			ShowDetail    bool
 		}{
 			projPath,
-			projName,
-			commitNoteDetail{Note: n},
-			cf.white(true),
+			name,
+			commitNoteDetail{Note: files},
+			others,
+			apps,
+			n.Total(),
+			cf.start(theme.Total) + "%s" + cf.end(theme.Total),
+			cf.start(theme.Heading) + "%s" + cf.end(theme.Heading),
+			cf.start(theme.File),
+			cf.end(theme.File),
 			tags,
+			branch,
+			insightsFor,
+			o.ShowPercent,
+			o.ShowDetail,
 		})
 
 	if err != nil {
@@ -107,51 +550,177 @@ func Status(n note.CommitNote, options OutputOptions, projPath ...string) (strin
 	return b.String(), nil
 }
 
-// CommitSummary returns the commit summary report
+type statusFile struct {
+	SourceFile string        `json:"source_file"`
+	TimeSpent  int           `json:"time_spent"`
+	Status     string        `json:"status,omitempty"`
+	App        string        `json:"app,omitempty"`
+	Timeline   map[int64]int `json:"timeline"`
+	Sessions   int           `json:"sessions,omitempty"`
+	LastActive int64         `json:"last_active,omitempty"`
+}
+
+type statusProject struct {
+	Project string       `json:"project"`
+	Path    string       `json:"path"`
+	Branch  string       `json:"branch,omitempty"`
+	Tags    []string     `json:"tags"`
+	Total   int          `json:"total"`
+	Files   []statusFile `json:"files,omitempty"`
+	Others  int          `json:"others,omitempty"`
+}
+
+// StatusJSON returns the status report as JSON
+func StatusJSON(n note.CommitNote, options OutputOptions, projPath ...string) (string, error) {
+	defer util.Profile()()
+
+	s, err := statusData(n, options, projPath...)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// StatusTSV returns the status report as tab separated lines of project, file and seconds, one line per file.
+// Tabs, newlines and backslashes in the project and file are escaped as \t, \n and \\ so each line has three fields.
+func StatusTSV(n note.CommitNote, options OutputOptions, projPath ...string) (string, error) {
+	defer util.Profile()()
+
+	s, err := statusData(n, options, projPath...)
+	if err != nil {
+		return "", err
+	}
+	b := strings.Builder{}
+	for _, f := range s.Files {
+		fmt.Fprintf(&b, "%s\t%s\t%d\n", tsvEscaper.Replace(s.Project), tsvEscaper.Replace(f.SourceFile), f.TimeSpent)
+	}
+	return b.String(), nil
+}
+
+var tsvEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r")
+
+// statusData returns the project's status for the json and tsv formats
+func statusData(n note.CommitNote, options OutputOptions, projPath ...string) (statusProject, error) {
+	n = options.Weigh(n)
+
+	s := statusProject{Tags: []string{}, Total: n.Total()}
+	if len(projPath) > 0 {
+		s.Project = filepath.Base(projPath[0])
+		s.Path = projPath[0]
+		tagList, err := project.LoadTags(filepath.Join(projPath[0], ".gtm"))
+		if err != nil {
+			return statusProject{}, err
+		}
+		s.Tags = tagList
+		if options.ShowBranch {
+			if s.Branch, err = scm.Branch(projPath[0]); err != nil {
+				return statusProject{}, err
+			}
+		}
+	}
+
+	if !options.TotalOnly {
+		s.Files = []statusFile{}
+		files := options.hideShortFiles(n)
+		if options.DirDepth > 0 {
+			files = dirTotals(files, options.DirDepth)
+		}
+		files, others := options.topFiles(files)
+		s.Others = others.Seconds
+		for _, f := range files.Files {
+			sf := statusFile{
+				SourceFile: f.SourceFile,
+				TimeSpent:  f.TimeSpent,
+				Status:     f.Status,
+				Timeline:   f.Timeline}
+			if f.IsApp() {
+				sf.App = f.GetAppName()
+			}
+			if options.ShowDetail {
+				sf.Sessions = f.Sessions
+				sf.LastActive = f.LastActive
+			}
+			s.Files = append(s.Files, sf)
+		}
+		// order by time spent and then file name so the output is stable
+		sort.SliceStable(s.Files, func(i, j int) bool {
+			if s.Files[i].TimeSpent != s.Files[j].TimeSpent {
+				return s.Files[i].TimeSpent > s.Files[j].TimeSpent
+			}
+			return s.Files[i].SourceFile < s.Files[j].SourceFile
+		})
+	}
+	return s, nil
+}
+
+// CommitSummary returns the commit summary report, the notes are read before they are listed
+// since each commit is listed in date order
 func CommitSummary(projects []ProjectCommits, options OutputOptions) (string, error) {
-	notes := options.limitNotes(retrieveNotes(projects, options.TerminalOff, options.AppOff, false, "Mon Jan 02"))
+	notes, err := retrieveNotes(projects, options, false, "Mon Jan 02")
+	if err != nil {
+		return "", err
+	}
+	notes = options.limitNotes(notes)
 	if len(notes) == 0 {
 		return "", nil
 	}
 
-	lines := commitSummaryBuilder{}.Build(notes)
+	notes.accumulate(options)
+	options.sortNotes(notes, true)
+	lines := commitSummaryBuilder{round: options.round}.Build(notes)
 
 	b := new(bytes.Buffer)
-	t := template.Must(template.New("Commits").Funcs(funcMap).Parse(commitSummaryTpl))
-	cf := colorFormater{color: options.Color}
-	err := t.Execute(
+	t := template.Must(template.New("Commits").Funcs(options.funcMap()).Parse(commitSummaryTpl))
+	cf := colorFormater{color: options.Color, noColor: options.NoColor}
+	err = t.Execute(
 		b,
 		struct {
 			Lines       []commitSummaryLine
+			Cumulative  bool
 			BoldFormat  string
 			GreenFormat string
 		}{
 			lines,
+			options.Cumulative,
 			cf.white(true),
 			cf.green(false),
 		})
 	if err != nil {
 		return "", err
 	}
+	b.WriteString(options.roundingNote("commit", notes.Total()))
 	return b.String(), nil
 }
 
 // ProjectSummary returns the project summary report
 func ProjectSummary(projects []ProjectCommits, options OutputOptions) (string, error) {
-	notes := options.limitNotes(retrieveNotes(projects, options.TerminalOff, options.AppOff, false, "Mon Jan 02"))
-	if len(notes) == 0 {
+	projectTotals := map[string]int{}
+	count, unrounded := 0, 0
+	err := options.eachNote(projects, func(n commitNoteDetail) error {
+		projectTotals[n.Project] += n.Note.Total()
+		count++
+		unrounded += n.Note.Total()
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
 		return "", nil
 	}
 
-	projectTotals := map[string]int{}
-	for _, n := range notes {
-		projectTotals[n.Project] += n.Note.Total()
+	for project, total := range projectTotals {
+		projectTotals[project] = options.round(total)
 	}
 
 	b := new(bytes.Buffer)
-	t := template.Must(template.New("ProjectSummary").Funcs(funcMap).Parse(projectTotalsTpl))
-	cf := colorFormater{color: options.Color}
-	err := t.Execute(
+	t := template.Must(template.New("ProjectSummary").Funcs(options.funcMap()).Parse(projectTotalsTpl))
+	cf := colorFormater{color: options.Color, noColor: options.NoColor}
+	err = t.Execute(
 		b,
 		struct {
 			Projects    map[string]int
@@ -165,28 +734,271 @@ func ProjectSummary(projects []ProjectCommits, options OutputOptions) (string, e
 	if err != nil {
 		return "", err
 	}
+	if note := options.roundingNote("project", unrounded); note != "" {
+		b.WriteString("\n" + note)
+	}
+	return b.String(), nil
+}
+
+// Lifetime returns the total time of all of the projects' commits, each project's total is listed
+// before the total when there is more than one project
+func Lifetime(projects []ProjectCommits, options OutputOptions) (string, error) {
+	total, unrounded := 0, 0
+	projectTotals := map[string]int{}
+	err := options.eachNote(projects, func(n commitNoteDetail) error {
+		secs := options.round(n.Note.Total())
+		projectTotals[n.Project] += secs
+		total += secs
+		unrounded += n.Note.Total()
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	b := new(bytes.Buffer)
+	if len(projectTotals) > 1 {
+		names := make([]string, 0, len(projectTotals))
+		for name := range projectTotals {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			b.WriteString(Subtotal(name, projectTotals[name], options))
+		}
+	}
+	b.WriteString(Subtotal("Lifetime", total, options))
+	b.WriteString(options.roundingNote("commit", unrounded))
+	return b.String(), nil
+}
+
+// AuthorSummary returns the total time by author report
+func AuthorSummary(projects []ProjectCommits, options OutputOptions) (string, error) {
+	authors := newAuthorTotals(options.Mailmap, options.CoAuthors)
+	count, unrounded := 0, 0
+	err := options.eachNote(projects, func(n commitNoteDetail) error {
+		authors.add(n)
+		count++
+		unrounded += n.Note.Total()
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", nil
+	}
+
+	b := new(bytes.Buffer)
+	t := template.Must(template.New("AuthorSummary").Funcs(options.funcMap()).Parse(authorTotalsTpl))
+	cf := colorFormater{color: options.Color, noColor: options.NoColor}
+	err = t.Execute(
+		b,
+		struct {
+			Authors     authorEntries
+			BoldFormat  string
+			GreenFormat string
+		}{
+			authors.entries().round(options),
+			cf.white(true),
+			cf.green(false),
+		})
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(options.roundingNote("author", unrounded))
+	return b.String(), nil
+}
+
+// LanguageSummary returns the total time by programming language report, see project.Config.Language
+func LanguageSummary(projects []ProjectCommits, options OutputOptions) (string, error) {
+	languages := newLanguageTotals()
+	count, unrounded := 0, 0
+	err := options.eachNote(projects, func(n commitNoteDetail) error {
+		languages.add(n)
+		count++
+		unrounded += n.Note.Total()
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", nil
+	}
+
+	b := new(bytes.Buffer)
+	t := template.Must(template.New("LanguageSummary").Funcs(options.funcMap()).Parse(languageTotalsTpl))
+	cf := colorFormater{color: options.Color, noColor: options.NoColor}
+	err = t.Execute(
+		b,
+		struct {
+			Languages   languageEntries
+			ASCII       bool
+			BoldFormat  string
+			GreenFormat string
+		}{
+			languages.entries().round(options),
+			!cf.hasColor(),
+			cf.white(true),
+			cf.green(false),
+		})
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(options.roundingNote("language", unrounded))
+	return b.String(), nil
+}
+
+// WeekdaySummary returns the total time by day of the week report
+func WeekdaySummary(projects []ProjectCommits, options OutputOptions) (string, error) {
+	weekdays := newWeekdayEntries(options.WeekStart)
+	count, unrounded := 0, 0
+	err := options.eachNote(projects, func(n commitNoteDetail) error {
+		weekdays.add(n, options.Location, options.WeekStart)
+		count++
+		unrounded += n.Note.Total()
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", nil
+	}
+
+	b := new(bytes.Buffer)
+	t := template.Must(template.New("WeekdaySummary").Funcs(options.funcMap()).Parse(weekdayTotalsTpl))
+	cf := colorFormater{color: options.Color, noColor: options.NoColor}
+	err = t.Execute(
+		b,
+		struct {
+			Weekdays    weekdayEntries
+			ASCII       bool
+			BoldFormat  string
+			GreenFormat string
+		}{
+			weekdays.round(options),
+			!cf.hasColor(),
+			cf.white(true),
+			cf.green(false),
+		})
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(options.roundingNote("weekday", unrounded))
+	return b.String(), nil
+}
+
+// NewCodeSummary returns the report of the time split between files added by each commit, new code,
+// and files that existed before the commit, maintenance. Pending time for files that are not in the
+// head commit is new code. Terminal and app time is shown separately and is not part of the split.
+func NewCodeSummary(projects []ProjectCommits, options OutputOptions) (string, error) {
+	options.splitNewCode = true
+	split := newCodeSplit{}
+	count := 0
+	err := options.eachNote(projects, func(n commitNoteDetail) error {
+		split.add(n)
+		count++
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", nil
+	}
+
+	b := new(bytes.Buffer)
+	t := template.Must(template.New("NewCodeSummary").Funcs(options.funcMap()).Parse(newCodeTpl))
+	cf := colorFormater{color: options.Color, noColor: options.NoColor}
+	err = t.Execute(
+		b,
+		struct {
+			Split       newCodeSplit
+			ASCII       bool
+			BoldFormat  string
+			GreenFormat string
+		}{
+			split,
+			!cf.hasColor(),
+			cf.white(true),
+			cf.green(false),
+		})
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// PeriodSummary returns the total time by day, week or month report
+func PeriodSummary(projects []ProjectCommits, options OutputOptions) (string, error) {
+	periods := newPeriodTotals(options.Period, options.WeekStart, options.Location)
+	count, unrounded := 0, 0
+	err := options.eachNote(projects, func(n commitNoteDetail) error {
+		periods.add(n)
+		count++
+		unrounded += n.Note.Total()
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", nil
+	}
+
+	b := new(bytes.Buffer)
+	t := template.Must(template.New("PeriodSummary").Funcs(options.funcMap()).Parse(periodTotalsTpl))
+	cf := colorFormater{color: options.Color, noColor: options.NoColor}
+	err = t.Execute(
+		b,
+		struct {
+			Periods     periodEntries
+			Cumulative  bool
+			BoldFormat  string
+			GreenFormat string
+		}{
+			periods.entries().round(options),
+			options.Cumulative,
+			cf.white(true),
+			cf.green(false),
+		})
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(options.roundingNote(options.Period, unrounded))
 	return b.String(), nil
 }
 
-// Commits returns the commits report
+// Commits returns the commits report, the notes are read before they are listed since each commit is sorted
 func Commits(projects []ProjectCommits, options OutputOptions) (string, error) {
-	notes := options.limitNotes(retrieveNotes(projects, options.TerminalOff, options.AppOff, true, ""))
+	notes, err := retrieveNotes(projects, options, true, "")
+	if err != nil {
+		return "", err
+	}
+	notes = options.limitNotes(notes)
 	if len(notes) == 0 {
 		return "", nil
 	}
 
+	notes.accumulate(options)
+	options.sortNotes(notes, false)
+
 	b := new(bytes.Buffer)
-	t := template.Must(template.New("CommitSummary").Funcs(funcMap).Parse(commitsTpl))
-	cf := colorFormater{color: options.Color}
-	err := t.Execute(
+	t := template.Must(template.New("CommitSummary").Funcs(options.funcMap()).Parse(commitsTpl))
+	cf := colorFormater{color: options.Color, noColor: options.NoColor}
+	err = t.Execute(
 		b,
 		struct {
 			FullMessage bool
+			Cumulative  bool
 			Notes       commitNoteDetails
 			BoldFormat  string
 			GreenFormat string
 		}{
 			options.FullMessage,
+			options.Cumulative,
 			notes,
 			cf.white(true),
 			cf.green(false),
@@ -197,22 +1009,63 @@ func Commits(projects []ProjectCommits, options OutputOptions) (string, error) {
 	return b.String(), nil
 }
 
-// Timeline returns the time spent by hour
-func Timeline(projects []ProjectCommits, options OutputOptions) (string, error) {
-	notes := options.limitNotes(retrieveNotes(projects, options.TerminalOff, options.AppOff, false, ""))
-	if len(notes) == 0 {
+// Heatmap returns the time spent by day of the week and hour of the day
+func Heatmap(projects []ProjectCommits, options OutputOptions) (string, error) {
+	heatmap := newHeatmapEntries()
+	count, unrounded := 0, 0
+	err := options.eachNote(projects, func(n commitNoteDetail) error {
+		heatmap.add(n, options.Location)
+		count++
+		unrounded += n.Note.Total()
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
 		return "", nil
 	}
 
-	timeline, err := notes.timeline()
+	b := new(bytes.Buffer)
+	t := template.Must(template.New("Heatmap").Funcs(options.funcMap()).Parse(heatmapTpl))
+	cf := colorFormater{color: options.Color, noColor: options.NoColor}
+	err = t.Execute(
+		b,
+		struct {
+			Heatmap     heatmapEntries
+			ASCII       bool
+			BoldFormat  string
+			GreenFormat string
+		}{
+			heatmap,
+			!cf.hasColor(),
+			cf.white(true),
+			cf.green(false),
+		})
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
 
+// Timeline returns the time spent by hour
+func Timeline(projects []ProjectCommits, options OutputOptions) (string, error) {
+	totals := timelineTotals{}
+	count := 0
+	err := options.eachNote(projects, func(n commitNoteDetail) error {
+		count++
+		return totals.add(n, options.Location)
+	})
 	if err != nil {
 		return "", err
 	}
+	if count == 0 {
+		return "", nil
+	}
 
 	b := new(bytes.Buffer)
-	t := template.Must(template.New("Timeline").Funcs(funcMap).Parse(timelineTpl))
-	cf := colorFormater{color: options.Color}
+	t := template.Must(template.New("Timeline").Funcs(options.funcMap()).Parse(timelineTpl))
+	cf := colorFormater{color: options.Color, noColor: options.NoColor}
 	err = t.Execute(
 		b,
 		struct {
@@ -220,7 +1073,7 @@ func Timeline(projects []ProjectCommits, options OutputOptions) (string, error)
 			BoldFormat  string
 			GreenFormat string
 		}{
-			timeline,
+			totals.entries(),
 			cf.white(true),
 			cf.green(false),
 		})
@@ -232,20 +1085,22 @@ func Timeline(projects []ProjectCommits, options OutputOptions) (string, error)
 
 // TimelineCommits returns the number commits by hour
 func TimelineCommits(projects []ProjectCommits, options OutputOptions) (string, error) {
-	notes := options.limitNotes(retrieveNotes(projects, options.TerminalOff, options.AppOff, false, ""))
-	if len(notes) == 0 {
-		return "", nil
-	}
-
-	timeline, err := notes.timelineCommits()
-
+	totals := timelineCommitTotals{}
+	count := 0
+	err := options.eachNote(projects, func(n commitNoteDetail) error {
+		count++
+		return totals.add(n)
+	})
 	if err != nil {
 		return "", err
 	}
+	if count == 0 {
+		return "", nil
+	}
 
 	b := new(bytes.Buffer)
-	t := template.Must(template.New("Timeline").Funcs(funcMap).Parse(timelineCommitTpl))
-	cf := colorFormater{color: options.Color}
+	t := template.Must(template.New("Timeline").Funcs(options.funcMap()).Parse(timelineCommitTpl))
+	cf := colorFormater{color: options.Color, noColor: options.NoColor}
 	err = t.Execute(
 		b,
 		struct {
@@ -253,7 +1108,7 @@ func TimelineCommits(projects []ProjectCommits, options OutputOptions) (string,
 			BoldFormat  string
 			GreenFormat string
 		}{
-			timeline,
+			totals.entries(),
 			cf.white(true),
 			cf.green(false),
 		})
@@ -265,36 +1120,194 @@ func TimelineCommits(projects []ProjectCommits, options OutputOptions) (string,
 
 // Files returns the files report
 func Files(projects []ProjectCommits, options OutputOptions) (string, error) {
-	notes := options.limitNotes(retrieveNotes(projects, options.TerminalOff, options.AppOff, false, ""))
-	if len(notes) == 0 {
+	totals := fileTotals{}
+	count, unrounded := 0, 0
+	err := options.eachNote(projects, func(n commitNoteDetail) error {
+		totals.add(n)
+		count++
+		unrounded += n.Note.Total()
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
 		return "", nil
 	}
 
+	files := totals.entries()
+	options.sortFiles(files)
+	top, others := options.topFileEntries(files)
+
 	b := new(bytes.Buffer)
-	t := template.Must(template.New("Files").Funcs(funcMap).Parse(filesTpl))
+	t := template.Must(template.New("Files").Funcs(options.funcMap()).Parse(filesTpl))
 
-	err := t.Execute(
+	err = t.Execute(
+		b,
+		struct {
+			Files  fileEntries
+			Others othersEntry
+			Total  int
+		}{
+			top,
+			others,
+			files.Total(),
+		})
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+
+}
+
+// FileHistory returns the total time of every file with time in the commits, most time first, for auditing
+// the files that took the most time. Files that are not in a project's head commit are marked deleted,
+// they are left out if ExistingOnly is set. Terminal and app time is never deleted.
+func FileHistory(projects []ProjectCommits, options OutputOptions) (string, error) {
+	// the files in each project's head commit by project name, like commitNoteDetail.Project
+	headFiles := map[string]map[string]bool{}
+	for _, p := range projects {
+		head, err := scm.HeadCommit(p.Path)
+		if err != nil {
+			return "", err
+		}
+		files := []string{}
+		if head.ID != "" {
+			if files, err = scm.TreeFiles(head.ID, p.Path); err != nil {
+				return "", err
+			}
+		}
+		name := filepath.Base(p.Path)
+		if headFiles[name] == nil {
+			headFiles[name] = map[string]bool{}
+		}
+		for _, f := range files {
+			headFiles[name][f] = true
+		}
+	}
+
+	// a file is deleted if it's not in the head commit of any of the projects it has time in
+	existing := map[string]bool{}
+	totals := fileTotals{}
+	count := 0
+	err := options.eachNote(projects, func(n commitNoteDetail) error {
+		for _, f := range n.Note.Files {
+			if headFiles[n.Project][filepath.ToSlash(f.SourceFile)] {
+				existing[f.SourceFile] = true
+			}
+		}
+		totals.add(n)
+		count++
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", nil
+	}
+
+	files := fileEntries{}
+	for _, f := range totals.entries() {
+		f.Deleted = !existing[f.Filename] && !f.IsTerminal() && !f.IsApp()
+		if f.Deleted && options.ExistingOnly {
+			continue
+		}
+		files = append(files, f)
+	}
+	options.sortFiles(files)
+	top, others := options.topFileEntries(files)
+
+	b := new(bytes.Buffer)
+	t := template.Must(template.New("FileHistory").Funcs(options.funcMap()).Parse(filesTpl))
+	err = t.Execute(
 		b,
 		struct {
-			Files fileEntries
+			Files  fileEntries
+			Others othersEntry
+			Total  int
 		}{
-			notes.files(),
+			top,
+			others,
+			files.Total(),
 		})
 	if err != nil {
 		return "", err
 	}
 	return b.String(), nil
+}
+
+// CSV returns the time spent per file per commit as RFC 4180 comma separated values,
+// the notes are read before they are written since each commit is sorted
+func CSV(projects []ProjectCommits, options OutputOptions) (string, error) {
+	notes, err := retrieveNotes(projects, options, false, "")
+	if err != nil {
+		return "", err
+	}
+	notes = options.limitNotes(notes)
+	options.sortNotes(notes, false)
 
+	b := new(bytes.Buffer)
+	w := csv.NewWriter(b)
+	header := []string{"commit", "author", "date", "project", "file", "seconds"}
+	if options.ShowMessage {
+		header = append(header, "subject")
+	}
+	if err := w.Write(header); err != nil {
+		return "", err
+	}
+	for _, n := range notes {
+		for _, f := range n.Note.Files {
+			record := []string{
+				n.ID,
+				n.Author,
+				n.When.Format(time.RFC3339),
+				n.Project,
+				f.SourceFile,
+				strconv.Itoa(f.TimeSpent)}
+			if options.ShowMessage {
+				record = append(record, options.subject(n.Subject))
+			}
+			if err := w.Write(record); err != nil {
+				return "", err
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
 }
 
 type colorFormater struct {
-	color bool
+	color   bool
+	noColor bool
 }
 
 func (c colorFormater) hasColor() bool {
+	if c.noColor {
+		return false
+	}
 	return (c.color || isatty.IsTerminal(os.Stdout.Fd())) && runtime.GOOS != "windows"
 }
 
+// start returns the escape sequence to start the ANSI SGR code, if there is no color or code it returns a blank string
+func (c colorFormater) start(code string) string {
+	if code == "" || !c.hasColor() {
+		return ""
+	}
+	return fmt.Sprintf("\033[%sm", code)
+}
+
+// end returns the escape sequence to reset the ANSI SGR code, if there is no color or code it returns a blank string
+func (c colorFormater) end(code string) string {
+	if code == "" || !c.hasColor() {
+		return ""
+	}
+	return "\033[0m"
+}
+
 func (c colorFormater) white(bold bool) string {
 	var attrBold int
 	if bold {
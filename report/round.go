@@ -0,0 +1,69 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// RoundUp rounds durations up to the next multiple of the rounding interval
+	RoundUp = "up"
+	// RoundNearest rounds durations to the nearest multiple of the rounding interval, halves are rounded up
+	RoundNearest = "nearest"
+	// RoundDown rounds durations down to the previous multiple of the rounding interval
+	RoundDown = "down"
+)
+
+// RoundModes are the valid values of the OutputOptions' RoundMode
+var RoundModes = []string{RoundUp, RoundNearest, RoundDown}
+
+// round returns secs rounded to a multiple of the options' Round in the RoundMode, secs is returned as is
+// if Round is less than a second. The RoundMode defaults to RoundUp.
+func (o OutputOptions) round(secs int) int {
+	interval := int(o.Round / time.Second)
+	if interval <= 0 {
+		return secs
+	}
+	switch o.RoundMode {
+	case RoundDown:
+		return secs / interval * interval
+	case RoundNearest:
+		return (secs + interval/2) / interval * interval
+	default:
+		return (secs + interval - 1) / interval * interval
+	}
+}
+
+// roundingNote returns the line shown after a rounded report with the unrounded total, rounded totals don't
+// reconcile exactly with other reports. It's blank if the report is not rounded.
+func (o OutputOptions) roundingNote(per string, unrounded int) string {
+	if o.Round < time.Second {
+		return ""
+	}
+	how := "up to"
+	switch o.RoundMode {
+	case RoundNearest:
+		how = "to the nearest"
+	case RoundDown:
+		how = "down to"
+	}
+	return fmt.Sprintf("\nRounded %s %s per %s, unrounded total %s\n",
+		how, roundInterval(o.Round), per, o.FormatDuration(unrounded))
+}
+
+// roundInterval returns d without trailing zero units, i.e. 15m instead of 15m0s
+func roundInterval(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
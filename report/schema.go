@@ -0,0 +1,96 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// SchemaVersion is the version of the status JSON schema, it's incremented when the JSON output changes
+const SchemaVersion = 1
+
+// StatusJSONSchema returns the JSON Schema of the gtm status -format=json output, an array with an object
+// for each project. It's generated from the structs the status is marshaled from so it can't fall out of date.
+func StatusJSONSchema() (string, error) {
+	definitions := map[string]interface{}{}
+	schema := map[string]interface{}{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"title":       "gtm status -format=json",
+		"version":     SchemaVersion,
+		"type":        "array",
+		"items":       schemaType(reflect.TypeOf(statusProject{}), definitions),
+		"definitions": definitions,
+	}
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// schemaType returns the JSON Schema for values of type t, structs are added to the definitions and referenced
+// by their name without the status prefix, i.e. statusFile is #/definitions/file
+func schemaType(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Struct:
+		name := strings.ToLower(strings.TrimPrefix(t.Name(), "status"))
+		if _, ok := definitions[name]; !ok {
+			definitions[name] = schemaObject(t, definitions)
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + name}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaType(t.Elem(), definitions)}
+	case reflect.Map:
+		// JSON object keys are strings, integer keys such as the timeline's epochs are written as numbers
+		return map[string]interface{}{
+			"type":                 "object",
+			"propertyNames":        map[string]interface{}{"pattern": "^-?[0-9]+$"},
+			"additionalProperties": schemaType(t.Elem(), definitions),
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
+
+// schemaObject returns the JSON Schema for the struct type t from its json tags, fields without omitempty are required
+func schemaObject(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = schemaType(f.Type, definitions)
+		omitEmpty := false
+		for _, p := range parts[1:] {
+			omitEmpty = omitEmpty || p == "omitempty"
+		}
+		if !omitEmpty {
+			required = append(required, name)
+		}
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
@@ -12,9 +12,12 @@ type commitSummaryLine struct {
 	Subject    string
 	Project    string
 	Total      int
+	Cumulative int
 }
 
 type commitSummaryBuilder struct {
+	// round rounds each commit's total, the day totals are the sum of the rounded commits
+	round func(int) int
 }
 
 func (c commitSummaryBuilder) Build(notes commitNoteDetails) []commitSummaryLine {
@@ -28,8 +31,12 @@ func (c commitSummaryBuilder) Build(notes commitNoteDetails) []commitSummaryLine
 			total = 0
 			lines = append(lines, commitSummaryLine{StartGroup: true, Date: n.Date})
 		}
-		lines = append(lines, commitSummaryLine{CommitLine: true, Subject: n.Subject, Project: n.Project, Total: n.Note.Total()})
-		total += n.Note.Total()
+		secs := n.Note.Total()
+		if c.round != nil {
+			secs = c.round(secs)
+		}
+		lines = append(lines, commitSummaryLine{CommitLine: true, Subject: n.Subject, Project: n.Project, Total: secs, Cumulative: n.Cumulative})
+		total += secs
 	}
 	lines = append(lines, commitSummaryLine{EndGroup: true, Total: total})
 	return lines
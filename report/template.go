@@ -0,0 +1,137 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"text/template"
+	"time"
+)
+
+// TemplateData is the data a custom report template is executed with.
+//
+//	{{ range .Commits }}{{ .Date.Format "2006-01-02" }} {{ .Hash }} {{ dur .Seconds }} {{ .Subject }}
+//	{{ range .Files }}  {{ .Path }} {{ dur .Seconds }}
+//	{{ end }}{{ end }}Total {{ dur .Seconds }}
+//
+// Besides the template functions for the built-in reports, dur formats seconds in the -duration-format
+// and hours converts seconds to hours.
+type TemplateData struct {
+	// Commits are ordered by the -sort option, newest first by default
+	Commits []TemplateCommit
+	// Projects are the projects' total time, ordered by name
+	Projects []TemplateProject
+	// Seconds is the total time of the commits
+	Seconds int
+}
+
+// TemplateCommit is a commit and the time saved for it
+type TemplateCommit struct {
+	ID      string
+	Hash    string
+	Author  string
+	Email   string
+	Date    time.Time
+	Subject string
+	Message string
+	Project string
+	Seconds int
+	Files   []TemplateFile
+}
+
+// TemplateFile is the time spent on a file, App is set for time spent in an app
+type TemplateFile struct {
+	Path    string
+	Status  string
+	App     string
+	Seconds int
+}
+
+// TemplateProject is a project's total time
+type TemplateProject struct {
+	Name    string
+	Seconds int
+}
+
+// templateFuncMap returns the template functions for custom report templates
+func (o OutputOptions) templateFuncMap() template.FuncMap {
+	fm := o.funcMap()
+	fm["dur"] = o.FormatDuration
+	fm["hours"] = func(secs int) float64 { return float64(secs) / 3600 }
+	return fm
+}
+
+// ParseTemplate parses a custom report template, name is used in error messages.
+// The options only change how the template functions format values, they do not affect parsing.
+func ParseTemplate(name, text string, options OutputOptions) (*template.Template, error) {
+	t, err := template.New(name).Funcs(options.templateFuncMap()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid template, %s", err)
+	}
+	return t, nil
+}
+
+// Template returns the report rendered with the custom template text, see TemplateData for the data
+// the template is executed with
+func Template(projects []ProjectCommits, options OutputOptions, name, text string) (string, error) {
+	t, err := ParseTemplate(name, text, options)
+	if err != nil {
+		return "", err
+	}
+
+	notes, err := retrieveNotes(projects, options, false, "")
+	if err != nil {
+		return "", err
+	}
+	notes = options.limitNotes(notes)
+	options.sortNotes(notes, false)
+
+	data := TemplateData{Commits: []TemplateCommit{}, Projects: []TemplateProject{}}
+	projectIdx := map[string]int{}
+	for _, n := range notes {
+		if n.ID == "" {
+			// the commit's note could not be read
+			continue
+		}
+		c := TemplateCommit{
+			ID:      n.ID,
+			Hash:    n.Hash,
+			Author:  n.Author,
+			Email:   n.Email,
+			Date:    n.When,
+			Subject: n.Subject,
+			Message: n.Message,
+			Project: n.Project,
+			Seconds: n.Note.Total(),
+			Files:   []TemplateFile{},
+		}
+		for _, f := range n.Note.Files {
+			tf := TemplateFile{Path: f.SourceFile, Status: f.Status, Seconds: f.TimeSpent}
+			if f.IsApp() {
+				tf.App = f.GetAppName()
+			}
+			c.Files = append(c.Files, tf)
+		}
+		data.Commits = append(data.Commits, c)
+		data.Seconds += c.Seconds
+
+		i, ok := projectIdx[n.Project]
+		if !ok {
+			i = len(data.Projects)
+			projectIdx[n.Project] = i
+			data.Projects = append(data.Projects, TemplateProject{Name: n.Project})
+		}
+		data.Projects[i].Seconds += c.Seconds
+	}
+	sort.Slice(data.Projects, func(i, j int) bool { return data.Projects[i].Name < data.Projects[j].Name })
+
+	b := new(bytes.Buffer)
+	if err := t.Execute(b, data); err != nil {
+		return "", fmt.Errorf("Unable to execute template, %s", err)
+	}
+	return b.String(), nil
+}
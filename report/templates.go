@@ -8,6 +8,7 @@ const (
 	commitSummaryTpl string = `
 {{- $boldFormat := .BoldFormat }}
 {{- $greenFormat := .GreenFormat }}
+{{- $cumulative := .Cumulative }}
 {{- range $line := .Lines }}
 	{{- if $line.StartGroup }}
 		{{- printf "\n" }}
@@ -18,7 +19,8 @@ const (
 		{{- printf "\n" }}
 	{{- end }}
 	{{- if $line.CommitLine }}
-		{{- FormatDuration $line.Total | printf "\n%14s" }} {{ printf $greenFormat $line.Subject }} [{{ $line.Project }}]
+		{{- FormatDuration $line.Total | printf "\n%14s" }}
+		{{- if $cumulative }} {{ FormatDuration $line.Cumulative | printf "%14s" }}{{ end }} {{ printf $greenFormat (Subject $line.Subject) }} [{{ $line.Project }}]
 	{{- end }}
 {{- end -}}`
 	projectTotalsTpl string = `
@@ -26,13 +28,79 @@ const (
 {{- range $project, $total := .Projects }}
 	{{- FormatDuration $total | printf "\n%14s" }} {{ printf $boldFormat $project }}
 {{- end -}}`
+	authorTotalsTpl string = `
+{{- $boldFormat := .BoldFormat }}
+{{- $total := .Authors.Total }}
+{{- range $author := .Authors }}
+	{{- FormatDuration $author.Seconds | printf "\n%14s" }} {{ Percent $author.Seconds $total | printf "%3.0f"}}% {{ printf "%4d" $author.Commits }} {{ printf $boldFormat $author.Name }} <{{ $author.Email }}>
+{{- end }}
+{{- if len .Authors }}
+	{{- FormatDuration $total | printf "\n%14s" }}
+{{ end }}`
+	weekdayTotalsTpl string = `
+{{- $boldFormat := .BoldFormat }}
+{{- $greenFormat := .GreenFormat }}
+{{- $ascii := .ASCII }}
+{{- $total := .Weekdays.Total }}
+{{- $max := .Weekdays.MaxSeconds }}
+{{- range $day := .Weekdays }}
+	{{- FormatDuration $day.Seconds | printf "\n%14s" }} {{ Percent $day.Seconds $total | printf "%3.0f"}}% {{ printf "%-9s" $day.Name | printf $boldFormat }} {{ Bar $day.Seconds $max 40 $ascii | printf $greenFormat }}
+{{- end }}
+{{- FormatDuration $total | printf "\n%14s" }}
+`
+	newCodeTpl string = `
+{{- $boldFormat := .BoldFormat }}
+{{- $greenFormat := .GreenFormat }}
+{{- $ascii := .ASCII }}
+{{- $code := .Split.Code }}
+{{- $max := .Split.MaxSeconds }}
+{{- FormatDuration .Split.New | printf "\n%14s" }} {{ Percent .Split.New $code | printf "%3.0f"}}% {{ printf "%-11s" "New" | printf $boldFormat }} {{ Bar .Split.New $max 40 $ascii | printf $greenFormat }}
+{{- FormatDuration .Split.Maintenance | printf "\n%14s" }} {{ Percent .Split.Maintenance $code | printf "%3.0f"}}% {{ printf "%-11s" "Maintenance" | printf $boldFormat }} {{ Bar .Split.Maintenance $max 40 $ascii | printf $greenFormat }}
+{{- FormatDuration $code | printf "\n%14s" }}
+{{- if .Split.Other }}
+{{- FormatDuration .Split.Other | printf "\n%14s" }}      {{ printf "Terminal and apps" | printf $boldFormat }}
+{{- end }}
+{{- if .Split.Maintenance }}
+	{{- printf "%.1f:1" .Split.Ratio | printf "\n%14s" }}      New to maintenance ratio
+{{- end }}
+`
+	languageTotalsTpl string = `
+{{- $boldFormat := .BoldFormat }}
+{{- $greenFormat := .GreenFormat }}
+{{- $ascii := .ASCII }}
+{{- $total := .Languages.Total }}
+{{- $max := .Languages.MaxSeconds }}
+{{- range $language := .Languages }}
+	{{- FormatDuration $language.Seconds | printf "\n%14s" }} {{ Percent $language.Seconds $total | printf "%3.0f"}}% {{ printf "%4d" $language.Files }} {{ printf "%-12s" $language.Name | printf $boldFormat }} {{ Bar $language.Seconds $max 40 $ascii | printf $greenFormat }}
+{{- end }}
+{{- if len .Languages }}
+	{{- FormatDuration $total | printf "\n%14s" }}
+{{ end }}`
+	periodTotalsTpl string = `
+{{- $boldFormat := .BoldFormat }}
+{{- $cumulative := .Cumulative }}
+{{- range $period := .Periods }}
+	{{- FormatDuration $period.Seconds | printf "\n%14s" }}
+	{{- if $cumulative }} {{ FormatDuration $period.Cumulative | printf "%14s" }}{{ end }} {{ printf $boldFormat $period.Label }}
+{{- end }}
+{{- if len .Periods }}
+	{{- FormatDuration .Periods.Total | printf "\n%14s" }}
+{{ end }}`
+	compareTpl string = `
+{{- $boldFormat := .BoldFormat }}
+{{- range $row := .Rows }}
+	{{- FormatDuration $row.Seconds | printf "\n%14s" }} {{ printf "%-10s" $row.Name | printf $boldFormat }} {{ $row.Label }}
+{{- end }}
+{{ .Delta | printf "%14s" }} {{ printf "%-10s" "Change" | printf $boldFormat }} {{ .Change }}
+`
 	commitsTpl string = `
 {{ $boldFormat := .BoldFormat }}
 {{ $greenFormat := .GreenFormat }}
 {{- $fullMessage := .FullMessage }}
+{{- $cumulative := .Cumulative }}
 {{- range $note := .Notes }}
 	{{- $total := .Note.Total }}
-	{{- printf $boldFormat $note.Hash }} {{ printf $greenFormat $note.Subject }}{{- printf "\n" }}
+	{{- printf $boldFormat $note.Hash }} {{ printf $greenFormat (Subject $note.Subject) }}{{- printf "\n" }}
 	{{- $note.Date }} {{ printf $boldFormat $note.Project }} {{ $note.Author }}{{- printf "\n" }}
 	{{- if $fullMessage}}{{- if $note.Message }}{{- printf "\n"}}{{- $note.Message }}{{- printf "\n"}}{{end}}{{end}}
 	{{- range $i, $f := .Note.Files }}
@@ -43,25 +111,52 @@ const (
 		{{- end }}
 	{{- end }}
 	{{- if len .Note.Files }}
-	{{- FormatDuration $total | printf "\n%14s" }}          {{ printf $boldFormat $note.Project }} [{{$note.LineAdd}} {{$note.LineDel}} = {{$note.LineDiff}}] [{{$note.ChangeRate}}/hr]{{ printf "\n\n" }}
+	{{- FormatDuration $total | printf "\n%14s" }}
+	{{- if $cumulative }} {{ FormatDuration $note.Cumulative | printf "%14s" }}{{ end }}          {{ printf $boldFormat $note.Project }} [{{$note.LineAdd}} {{$note.LineDel}} = {{$note.LineDiff}}] [{{$note.ChangeRate}}/hr]{{ printf "\n\n" }}
 	{{- else }}
 		{{- printf "\n" }}
 	{{- end }}
 {{- end -}}`
 
 	statusTpl string = `
-{{- $boldFormat := .BoldFormat }}
-{{- if .Note.Files }}{{ printf "\n"}}{{end}}
-{{- $total := .Note.Total }}
+{{- $fileStart := .FileStart }}
+{{- $fileEnd := .FileEnd }}
+{{- $showPercent := .ShowPercent }}
+{{- $showDetail := .ShowDetail }}
+{{- if .Total }}{{ printf "\n"}}{{end}}
+{{- $total := .Total }}
 {{- range $i, $f := .Note.Files }}
-	{{- if $f.IsApp }}
-		{{- FormatDuration $f.TimeSpent | printf "%14s" }} {{ Percent $f.TimeSpent $total | printf "%3.0f"}}% [{{ $f.Status }}] [app] {{$f.GetAppName }}
-	{{- else }}
-		{{- FormatDuration $f.TimeSpent | printf "%14s" }} {{ Percent $f.TimeSpent $total | printf "%3.0f"}}% [{{ $f.Status }}] {{$f.ShortenSourceFile 100}}
+	{{- $fileStart }}
+	{{- FormatDuration $f.TimeSpent | printf "%14s" }}
+	{{- if $showPercent }} {{ Percent $f.TimeSpent $total | printf "%3.0f"}}%{{ end }}
+	{{- if $f.IsApp }} [{{ $f.Status }}] [app] {{$f.GetAppName }}
+	{{- else if not $f.Status }}     {{ ShortenPath $f.SourceFile }}
+	{{- else }} [{{ $f.Status }}] {{ ShortenPath $f.SourceFile }}
 	{{- end }}
+	{{- if and $showDetail $f.Sessions }} (sessions: {{ $f.Sessions }}, last active: {{ FormatEpoch $f.LastActive }}){{ end }}
+	{{- $fileEnd }}
+{{ end }}
+{{- if .Others.Files }}
+	{{- $fileStart }}
+	{{- FormatDuration .Others.Seconds | printf "%14s" }}
+	{{- if $showPercent }} {{ Percent .Others.Seconds $total | printf "%3.0f"}}%{{ end }}     {{ .Others.Files }} others
+	{{- $fileEnd }}
+{{ end }}
+{{- if .Apps }}
+	{{- if .Note.Files }}{{ printf "\n" }}{{ end }}
+	{{- printf "%14s" "" }}{{ if $showPercent }}     {{ end }} {{ printf .HeadingFormat "Apps" }}
+{{ range $a := .Apps }}
+	{{- $fileStart }}
+	{{- FormatDuration $a.Seconds | printf "%14s" }}
+	{{- if $showPercent }} {{ Percent $a.Seconds $total | printf "%3.0f"}}%{{ end }} {{ $a.Name }}
+	{{- $fileEnd }}
 {{ end }}
-{{- if len .Note.Files }}
-	{{- FormatDuration .Note.Total | printf "%14s" }}          {{ printf $boldFormat .ProjectName }} {{ if .Tags }}[{{ .Tags }}]{{ end }}
+{{- end }}
+{{- if .Total }}
+	{{- FormatDuration .Total | printf "%14s" }}{{ if $showPercent }}     {{ end }}     {{ printf .TotalFormat .ProjectName }} {{ if .Branch }}({{ .Branch }}) {{ end }}{{ if .Tags }}[{{ .Tags }}]{{ end }}
+{{ end }}
+{{- if .Insights }}
+	{{- printf "%14s" "" }}{{ if $showPercent }}     {{ end }}     Most active file: {{ .Insights.File }}, longest session: {{ FormatDuration .Insights.Session }} {{ .Insights.SessionStart.Format "Mon 15:04" }}-{{ .Insights.SessionEnd.Format "15:04" }}
 {{ end }}`
 
 	// TODO: determine left padding based on size of total duration
@@ -72,13 +167,27 @@ const (
 {{printf $boldFormat "             00.01.02.03.04.05.06.07.08.09.10.11.12.01.02.03.04.05.06.07.08.09.10.11." }}
 {{printf $boldFormat "             ------------------------------------------------------------------------"}}
 {{ range $_, $entry := .Timeline }}
-{{- printf $boldFormat $entry.Day }} | {{ range $_, $h := .Hours }}{{ Blocks $h $maxSecondsInHour | printf $greenFormat }}{{ end }} | {{ LeftPad2Len $entry.Duration " " 13 | printf $boldFormat }}
+{{- printf $boldFormat $entry.Day }} | {{ range $_, $h := .Hours }}{{ Blocks $h $maxSecondsInHour | printf $greenFormat }}{{ end }} | {{ LeftPad2Len (FormatDuration $entry.Seconds) " " 13 | printf $boldFormat }}
 {{printf $boldFormat "             ------------------------------------------------------------------------"}}
 {{ end }}
 {{- if len .Timeline }}
-	{{- LeftPad2Len .Timeline.Duration " " 101 | printf $boldFormat }}
+	{{- LeftPad2Len (FormatDuration .Timeline.Seconds) " " 101 | printf $boldFormat }}
 {{ end }}`
 
+	heatmapTpl string = `
+{{- $boldFormat := .BoldFormat }}
+{{- $greenFormat := .GreenFormat }}
+{{- $ascii := .ASCII }}
+{{- $maxSecondsInHour := .Heatmap.HourMaxSeconds }}
+{{printf $boldFormat "      00.01.02.03.04.05.06.07.08.09.10.11.12.01.02.03.04.05.06.07.08.09.10.11." }}
+{{printf $boldFormat "      ------------------------------------------------------------------------"}}
+{{ range $_, $entry := .Heatmap }}
+{{- printf $boldFormat $entry.Day }} | {{ range $_, $h := .Hours }}{{ Shades $h $maxSecondsInHour $ascii | printf $greenFormat }}{{ end }} | {{ LeftPad2Len (FormatDuration $entry.Seconds) " " 13 | printf $boldFormat }}
+{{ end }}
+{{- printf $boldFormat "      ------------------------------------------------------------------------"}}
+{{ LeftPad2Len (FormatDuration .Heatmap.Seconds) " " 94 | printf $boldFormat }}
+`
+
 	timelineCommitTpl string = `
 {{- $boldFormat := .BoldFormat }}
 {{- $greenFormat := .GreenFormat }}
@@ -95,15 +204,66 @@ const (
 
 	// TODO: determine left padding based on total hours
 	filesTpl string = `
-{{- $total := .Files.Total }}
+{{- $total := .Total }}
 {{ range $i, $f := .Files }}
 	{{- if $f.IsApp }}
-		{{- $f.Duration | printf "%14s" }} {{ Percent $f.Seconds $total | printf "%3.0f"}}%  [app] {{ $f.GetAppName }}
+		{{- FormatDuration $f.Seconds | printf "%14s" }} {{ Percent $f.Seconds $total | printf "%3.0f"}}%  [app] {{ $f.GetAppName }}
 	{{- else }}
-		{{- $f.Duration | printf "%14s" }} {{ Percent $f.Seconds $total | printf "%3.0f"}}%  {{ $f.Filename }}
+		{{- FormatDuration $f.Seconds | printf "%14s" }} {{ Percent $f.Seconds $total | printf "%3.0f"}}%  {{ $f.Filename }}{{ if $f.Deleted }} [deleted]{{ end }}
 	{{- end }}
 {{ end }}
+{{- if .Others.Files }}
+	{{- FormatDuration .Others.Seconds | printf "%14s" }} {{ Percent .Others.Seconds $total | printf "%3.0f"}}%  {{ .Others.Files }} others
+{{ end }}
 {{- if len .Files }}
-	{{- .Files.Duration | printf "%14s" }}
+	{{- FormatDuration $total | printf "%14s" }}
 {{ end }}`
+
+	htmlTpl string = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #24292e; margin: 2em; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.2em; margin-top: 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 0.3em 0.8em; border-bottom: 1px solid #e1e4e8; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+td.time { text-align: right; white-space: nowrap; }
+tr.total td { font-weight: bold; }
+.bar { background: #2cbe4e; height: 1em; min-width: 1px; }
+.bars td { border-bottom: none; }
+.bars td.chart { width: 30em; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<h2>Commits</h2>
+<table>
+<tr><th>Commit</th><th>Date</th><th>Author</th><th>Project</th><th>Subject</th><th>Time</th></tr>
+{{- range $note := .Notes }}
+<tr><td><code>{{ $note.Hash }}</code></td><td>{{ $note.Date }}</td><td>{{ $note.Author }}</td><td>{{ $note.Project }}</td><td>{{ Subject $note.Subject }}</td><td class="time">{{ FormatDuration $note.Note.Total }}</td></tr>
+{{- end }}
+<tr class="total"><td colspan="5">Total</td><td class="time">{{ FormatDuration .Notes.Total }}</td></tr>
+</table>
+<h2>Time per Day</h2>
+<table class="bars">
+{{- $maxSeconds := .MaxSeconds }}
+{{- range $day := .Days }}
+<tr><td>{{ $day.Label }}</td><td class="chart"><div class="bar" style="width: {{ Percent $day.Seconds $maxSeconds | printf "%.1f" }}%"></div></td><td class="time">{{ FormatDuration $day.Seconds }}</td></tr>
+{{- end }}
+</table>
+<h2>Files</h2>
+<table>
+<tr><th>File</th><th>Time</th><th>Percent</th></tr>
+{{- $total := .Files.Total }}
+{{- range $f := .Files }}
+<tr><td>{{ if $f.IsApp }}[app] {{ $f.GetAppName }}{{ else }}{{ $f.Filename }}{{ end }}</td><td class="time">{{ FormatDuration $f.Seconds }}</td><td class="time">{{ Percent $f.Seconds $total | printf "%.0f" }}%</td></tr>
+{{- end }}
+</table>
+</body>
+</html>
+`
 )
@@ -0,0 +1,56 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Theme contains the ANSI SGR codes used to color the status output, i.e. "1;97" for bold bright white.
+// An empty code displays the element without color.
+type Theme struct {
+	// Total colors the project name on the total line
+	Total string
+	// File colors the file lines
+	File string
+	// Heading colors section headings, i.e. Apps
+	Heading string
+}
+
+// DefaultTheme returns the theme used when no colors are configured
+func DefaultTheme() Theme {
+	return Theme{Total: "1;97", File: "", Heading: "1;97"}
+}
+
+var colorCodeRegex = regexp.MustCompile(`^[0-9]{1,3}(;[0-9]{1,3})*$`)
+
+// LoadTheme returns the default theme with the colors set in the GTM_COLOR_TOTAL, GTM_COLOR_FILE
+// and GTM_COLOR_HEADING environment variables, i.e. GTM_COLOR_FILE=32 for green file lines.
+// A value of none displays the element without color.
+func LoadTheme() (Theme, error) {
+	t := DefaultTheme()
+	for _, e := range []struct {
+		name string
+		code *string
+	}{
+		{"GTM_COLOR_TOTAL", &t.Total},
+		{"GTM_COLOR_FILE", &t.File},
+		{"GTM_COLOR_HEADING", &t.Heading},
+	} {
+		v, ok := os.LookupEnv(e.name)
+		switch {
+		case !ok:
+		case v == "none":
+			*e.code = ""
+		case colorCodeRegex.MatchString(v):
+			*e.code = v
+		default:
+			return DefaultTheme(), fmt.Errorf("Invalid %s=%s, use ANSI color codes separated by semicolons, i.e. 1;32, or none", e.name, v)
+		}
+	}
+	return t, nil
+}
@@ -8,8 +8,6 @@ import (
 	"sort"
 	"strconv"
 	"time"
-
-	"github.com/git-time-metric/gtm/util"
 )
 
 type timelineCommitEntries []timelineCommitEntry
@@ -45,82 +43,87 @@ func (t timelineCommitEntries) Total() int {
 	return total
 }
 
-func (c commitNoteDetails) timelineCommits() (timelineCommitEntries, error) {
-	timelineMap := map[string]timelineCommitEntry{}
-	timeline := []timelineCommitEntry{}
-	for _, n := range c {
-		t := n.When
-		day := t.Format("2006-01-02")
-		hour, err := strconv.Atoi(t.Format("15"))
-		if err != nil {
-			return timelineCommitEntries{}, err
-		}
-		if entry, ok := timelineMap[day]; !ok {
-			var commits [24]int
-			commits[hour] = 1
-			timelineMap[day] = timelineCommitEntry{Day: t.Format("Mon Jan 02"), Commits: commits, Total: 1}
-		} else {
-			entry.inc(hour)
-			timelineMap[day] = entry
-		}
+// timelineCommitTotals counts the commits by day and hour one commit note at a time
+type timelineCommitTotals map[string]timelineCommitEntry
+
+func (t timelineCommitTotals) add(n commitNoteDetail) error {
+	day := n.When.Format("2006-01-02")
+	hour, err := strconv.Atoi(n.When.Format("15"))
+	if err != nil {
+		return err
 	}
+	if entry, ok := t[day]; !ok {
+		var commits [24]int
+		commits[hour] = 1
+		t[day] = timelineCommitEntry{Day: n.When.Format("Mon Jan 02"), Commits: commits, Total: 1}
+	} else {
+		entry.inc(hour)
+		t[day] = entry
+	}
+	return nil
+}
 
-	keys := make([]string, 0, len(timelineMap))
-	for key := range timelineMap {
+// entries returns the days' counts, oldest first
+func (t timelineCommitTotals) entries() timelineCommitEntries {
+	keys := make([]string, 0, len(t))
+	for key := range t {
 		keys = append(keys, key)
 	}
 	sort.Sort(sort.StringSlice(keys))
+	timeline := timelineCommitEntries{}
 	for _, k := range keys {
-		timeline = append(timeline, timelineMap[k])
+		timeline = append(timeline, t[k])
 	}
-
-	return timeline, nil
+	return timeline
 }
 
-func (c commitNoteDetails) timeline() (timelineEntries, error) {
-	timelineMap := map[string]timelineEntry{}
-	timeline := []timelineEntry{}
-	for _, n := range c {
-		for _, f := range n.Note.Files {
-			for epoch, secs := range f.Timeline {
-				t := time.Unix(epoch, 0)
-				day := t.Format("2006-01-02")
-				hour, err := strconv.Atoi(t.Format("15"))
-				if err != nil {
-					return timelineEntries{}, err
-				}
-				if entry, ok := timelineMap[day]; !ok {
-					var hours [24]int
-					hours[hour] = secs
-					timelineMap[day] = timelineEntry{Day: t.Format("Mon Jan 02"), Hours: hours, Seconds: secs}
-				} else {
-					entry.add(secs, hour)
-					timelineMap[day] = entry
-				}
+// timelineTotals totals the time by day and hour one commit note at a time
+type timelineTotals map[string]timelineEntry
+
+func (t timelineTotals) add(n commitNoteDetail, loc *time.Location) error {
+	for _, f := range n.Note.Files {
+		for epoch, secs := range f.Timeline {
+			when := epochTime(epoch, loc)
+			day := when.Format("2006-01-02")
+			hour, err := strconv.Atoi(when.Format("15"))
+			if err != nil {
+				return err
+			}
+			if entry, ok := t[day]; !ok {
+				var hours [24]int
+				hours[hour] = secs
+				t[day] = timelineEntry{Day: when.Format("Mon Jan 02"), Hours: hours, Seconds: secs}
+			} else {
+				entry.add(secs, hour)
+				t[day] = entry
 			}
 		}
 	}
+	return nil
+}
 
-	keys := make([]string, 0, len(timelineMap))
-	for key := range timelineMap {
+// entries returns the days' totals, oldest first
+func (t timelineTotals) entries() timelineEntries {
+	keys := make([]string, 0, len(t))
+	for key := range t {
 		keys = append(keys, key)
 	}
 	sort.Sort(sort.StringSlice(keys))
+	timeline := timelineEntries{}
 	for _, k := range keys {
-		timeline = append(timeline, timelineMap[k])
+		timeline = append(timeline, t[k])
 	}
-
-	return timeline, nil
+	return timeline
 }
 
 type timelineEntries []timelineEntry
 
-func (t timelineEntries) Duration() string {
+func (t timelineEntries) Seconds() int {
 	total := 0
 	for _, entry := range t {
 		total += entry.Seconds
 	}
-	return util.FormatDuration(total)
+	return total
 }
 
 func (t timelineEntries) HourMaxSeconds() int {
@@ -148,7 +151,3 @@ func (t *timelineEntry) add(s int, hour int) {
 	t.Seconds += s
 	t.Hours[hour] += s
 }
-
-func (t *timelineEntry) Duration() string {
-	return util.FormatDuration(t.Seconds)
-}
@@ -0,0 +1,79 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"strings"
+	"time"
+)
+
+type weekdayEntry struct {
+	Name    string
+	Seconds int
+}
+
+type weekdayEntries []weekdayEntry
+
+func (w weekdayEntries) Total() int {
+	total := 0
+	for _, e := range w {
+		total += e.Seconds
+	}
+	return total
+}
+
+func (w weekdayEntries) MaxSeconds() int {
+	max := 0
+	for _, e := range w {
+		if e.Seconds > max {
+			max = e.Seconds
+		}
+	}
+	return max
+}
+
+// round returns the weekdays with each weekday's time rounded
+func (w weekdayEntries) round(options OutputOptions) weekdayEntries {
+	for i := range w {
+		w[i].Seconds = options.round(w[i].Seconds)
+	}
+	return w
+}
+
+// newWeekdayEntries returns the days of the week without time, starting with weekStart
+func newWeekdayEntries(weekStart time.Weekday) weekdayEntries {
+	w := make(weekdayEntries, 7)
+	for i := range w {
+		w[i].Name = time.Weekday((i + int(weekStart)) % 7).String()
+	}
+	return w
+}
+
+// add totals the commit note's seconds spent by day of the week in loc, the entries must start with weekStart
+func (w weekdayEntries) add(n commitNoteDetail, loc *time.Location, weekStart time.Weekday) {
+	for _, f := range n.Note.Files {
+		for epoch, secs := range f.Timeline {
+			day := epochTime(epoch, loc).Weekday()
+			w[(int(day)-int(weekStart)+7)%7].Seconds += secs
+		}
+	}
+}
+
+// BarForVal returns a bar of up to width characters for val scaled to max, plain ASCII characters are used if ascii is true
+func BarForVal(val, max, width int, ascii bool) string {
+	if val <= 0 || max <= 0 {
+		return ""
+	}
+	bar := "█"
+	if ascii {
+		bar = "#"
+	}
+	// any time spent gets at least one character
+	n := (val*width + max - 1) / max
+	if n > width {
+		n = width
+	}
+	return strings.Repeat(bar, n)
+}
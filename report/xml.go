@@ -0,0 +1,103 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package report
+
+import (
+	"encoding/xml"
+	"path/filepath"
+	"time"
+)
+
+// XMLSchemaVersion is the version of the XML report's elements and attributes,
+// it is incremented when an element or attribute is renamed or removed
+const XMLSchemaVersion = "1"
+
+type xmlReport struct {
+	XMLName  xml.Name     `xml:"report"`
+	Version  string       `xml:"version,attr"`
+	Seconds  int          `xml:"seconds,attr"`
+	Projects []xmlProject `xml:"project"`
+}
+
+type xmlProject struct {
+	Name    string      `xml:"name,attr"`
+	Seconds int         `xml:"seconds,attr"`
+	Commits []xmlCommit `xml:"commit"`
+}
+
+type xmlCommit struct {
+	ID      string    `xml:"id,attr"`
+	Author  string    `xml:"author,attr"`
+	Email   string    `xml:"email,attr"`
+	Date    string    `xml:"date,attr"`
+	Seconds int       `xml:"seconds,attr"`
+	Subject string    `xml:"subject"`
+	Files   []xmlFile `xml:"file"`
+}
+
+type xmlFile struct {
+	Path    string `xml:"path,attr"`
+	Status  string `xml:"status,attr"`
+	Seconds int    `xml:"seconds,attr"`
+}
+
+// XML returns the time spent per file per commit grouped by project as an XML document.
+//
+//	<report version="1" seconds="60">
+//	  <project name="gtm" seconds="60">
+//	    <commit id="..." author="..." email="..." date="2016-03-20T18:00:00Z" seconds="60">
+//	      <subject>...</subject>
+//	      <file path="event/event.go" status="m" seconds="60"></file>
+//	    </commit>
+//	  </project>
+//	</report>
+//
+// Commits without time are not included, dates are RFC3339 timestamps.
+func XML(projects []ProjectCommits, options OutputOptions) (string, error) {
+	notes, err := retrieveNotes(projects, options, false, "")
+	if err != nil {
+		return "", err
+	}
+	notes = options.limitNotes(notes)
+	options.sortNotes(notes, false)
+
+	r := xmlReport{Version: XMLSchemaVersion, Projects: []xmlProject{}}
+	idx := map[string]int{}
+	for _, p := range projects {
+		name := filepath.Base(p.Path)
+		if _, ok := idx[name]; !ok {
+			idx[name] = len(r.Projects)
+			r.Projects = append(r.Projects, xmlProject{Name: name, Commits: []xmlCommit{}})
+		}
+	}
+
+	for _, n := range notes {
+		i, ok := idx[n.Project]
+		if !ok || len(n.Note.Files) == 0 {
+			continue
+		}
+		c := xmlCommit{
+			ID:      n.ID,
+			Author:  n.Author,
+			Email:   n.Email,
+			Date:    n.When.Format(time.RFC3339),
+			Seconds: n.Note.Total(),
+			Subject: n.Subject,
+			Files:   []xmlFile{},
+		}
+		for _, f := range n.Note.Files {
+			c.Files = append(c.Files, xmlFile{Path: f.SourceFile, Status: f.Status, Seconds: f.TimeSpent})
+		}
+		r.Projects[i].Commits = append(r.Projects[i].Commits, c)
+		r.Projects[i].Seconds += c.Seconds
+		r.Seconds += c.Seconds
+	}
+
+	b, err := xml.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(b), nil
+}
@@ -20,7 +20,7 @@ import (
 	"github.com/libgit2/git2go"
 )
 
-// Workdir returns the working directory for a repo
+// Workdir returns the working directory for a repo, ErrBareRepo is returned for bare repos
 func Workdir(gitRepoPath string) (string, error) {
 	defer util.Profile()()
 	util.Debug.Print("gitRepoPath:", gitRepoPath)
@@ -31,6 +31,10 @@ func Workdir(gitRepoPath string) (string, error) {
 	}
 	defer repo.Free()
 
+	if repo.IsBare() {
+		return "", ErrBareRepo
+	}
+
 	workDir := filepath.Clean(repo.Workdir())
 	util.Debug.Print("workDir:", workDir)
 
@@ -63,6 +67,25 @@ func GitRepoPath(path ...string) (string, error) {
 	return filepath.Clean(gitRepoPath), nil
 }
 
+// GitCommonDir returns the .git directory shared by a repo's worktrees, like git rev-parse --git-common-dir.
+// A linked worktree's git directory, i.e. .git/worktrees/feature, has its own HEAD and index but
+// shares hooks, config and refs, including notes, with the main worktree. For the main worktree
+// the git directory is returned.
+func GitCommonDir(gitRepoPath string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(gitRepoPath, "commondir"))
+	if os.IsNotExist(err) {
+		return filepath.Clean(gitRepoPath), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	commonDir := strings.TrimSpace(string(b))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitRepoPath, commonDir)
+	}
+	return filepath.Clean(commonDir), nil
+}
+
 // CommitLimiter struct filter commits by criteria
 type CommitLimiter struct {
 	Max        int
@@ -76,6 +99,20 @@ type CommitLimiter struct {
 	HasAfter   bool
 	HasAuthor  bool
 	HasMessage bool
+	// NoMerges skips commits with more than one parent
+	NoMerges bool
+	// Exclude skips commits matching these full or abbreviated SHA1 commit ids
+	Exclude []string
+}
+
+// IsExcluded returns true if the SHA1 commit id matches one of the excluded commit ids
+func (m CommitLimiter) IsExcluded(commitID string) bool {
+	for _, e := range m.Exclude {
+		if strings.HasPrefix(commitID, e) {
+			return true
+		}
+	}
+	return false
 }
 
 // NewCommitLimiter returns a new initialize CommitLimiter struct
@@ -170,16 +207,33 @@ func NewCommitLimiter(
 	}, nil
 }
 
+// matchAuthor returns true if the Author is the signature's email, when Author contains an @,
+// otherwise if the signature's name contains Author
+func (m CommitLimiter) matchAuthor(sig *git.Signature) bool {
+	if strings.Contains(m.Author, "@") {
+		return strings.EqualFold(sig.Email, m.Author)
+	}
+	return strings.Contains(sig.Name, m.Author)
+}
+
 func (m CommitLimiter) filter(c *git.Commit, cnt int) (bool, bool, error) {
 	if m.HasMax && m.Max == cnt {
 		return false, true, nil
 	}
 
+	if m.NoMerges && c.ParentCount() > 1 {
+		return false, false, nil
+	}
+
+	if m.IsExcluded(c.Object.Id().String()) {
+		return false, false, nil
+	}
+
 	if m.DateRange.IsSet() && !m.DateRange.Within(c.Author().When) {
 		return false, false, nil
 	}
 
-	if m.HasAuthor && !strings.Contains(c.Author().Name, m.Author) {
+	if m.HasAuthor && !m.matchAuthor(c.Author()) {
 		return false, false, nil
 	}
 
@@ -251,6 +305,365 @@ func CommitIDs(limiter CommitLimiter, wd ...string) ([]string, error) {
 	return commits, nil
 }
 
+// WalkCommits calls fn with the SHA1 ID of each commit reachable from the head, newest first, the IDs are not
+// held in memory so it can walk a large history. Walking stops at the first error fn returns and it's returned.
+func WalkCommits(fn func(commitID string) error, wd ...string) error {
+	var (
+		repo *git.Repository
+		w    *git.RevWalk
+		err  error
+	)
+
+	if len(wd) > 0 {
+		repo, err = openRepository(wd[0])
+	} else {
+		repo, err = openRepository()
+	}
+	if err != nil {
+		return err
+	}
+	defer repo.Free()
+
+	w, err = repo.Walk()
+	if err != nil {
+		return err
+	}
+	defer w.Free()
+
+	if err = w.PushHead(); err != nil {
+		return err
+	}
+
+	var fnErr error
+	err = w.Iterate(
+		func(commit *git.Commit) bool {
+			fnErr = fn(commit.Object.Id().String())
+			return fnErr == nil
+		})
+	if fnErr != nil {
+		return fnErr
+	}
+	return err
+}
+
+// RevList returns the SHA1 commit ids in the revision range, i.e. HEAD~5..HEAD, newest first
+func RevList(revRange string, wd ...string) ([]string, error) {
+	defer util.Profile()()
+
+	var (
+		repo *git.Repository
+		err  error
+	)
+	commits := []string{}
+
+	if len(wd) > 0 {
+		repo, err = openRepository(wd[0])
+	} else {
+		repo, err = openRepository()
+	}
+	if err != nil {
+		return commits, err
+	}
+	defer repo.Free()
+
+	w, err := repo.Walk()
+	if err != nil {
+		return commits, err
+	}
+	defer w.Free()
+
+	if err := w.PushRange(revRange); err != nil {
+		return commits, fmt.Errorf("Invalid range %s, %s", revRange, err)
+	}
+
+	err = w.Iterate(
+		func(commit *git.Commit) bool {
+			commits = append(commits, commit.Object.Id().String())
+			return true
+		})
+
+	return commits, err
+}
+
+// TreeFiles returns the paths of the files in the tree of the SHA1 commit id
+func TreeFiles(commitID string, wd ...string) ([]string, error) {
+	var (
+		repo *git.Repository
+		err  error
+	)
+	files := []string{}
+
+	if len(wd) > 0 {
+		repo, err = openRepository(wd[0])
+	} else {
+		repo, err = openRepository()
+	}
+	if err != nil {
+		return files, err
+	}
+	defer repo.Free()
+
+	id, err := git.NewOid(commitID)
+	if err != nil {
+		return files, err
+	}
+
+	commit, err := repo.LookupCommit(id)
+	if err != nil {
+		return files, err
+	}
+	defer commit.Free()
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return files, err
+	}
+	defer tree.Free()
+
+	err = tree.Walk(
+		func(root string, entry *git.TreeEntry) int {
+			if entry.Filemode != git.FilemodeTree {
+				files = append(files, filepath.ToSlash(root+entry.Name))
+			}
+			return 0
+		})
+
+	return files, err
+}
+
+// Renames returns, for each of the SHA1 commit ids, the files renamed by the commits after it up to the head commit,
+// keyed by the file's path in the commit with its path at the head commit. A file renamed more than once maps to its
+// last path. Git's rename detection compares the contents of the files added and deleted by each commit from the head
+// commit back to the oldest of the commit ids, this is slow for long histories and commits that change many files.
+// Commits that are not reachable from the head commit are not returned.
+func Renames(commitIDs []string, wd ...string) (map[string]map[string]string, error) {
+	defer util.Profile()()
+
+	var (
+		repo *git.Repository
+		err  error
+	)
+	result := map[string]map[string]string{}
+
+	if len(wd) > 0 {
+		repo, err = openRepository(wd[0])
+	} else {
+		repo, err = openRepository()
+	}
+	if err != nil {
+		return result, err
+	}
+	defer repo.Free()
+
+	remaining := map[string]bool{}
+	for _, id := range commitIDs {
+		remaining[id] = true
+	}
+	if len(remaining) == 0 {
+		return result, nil
+	}
+
+	w, err := repo.Walk()
+	if err != nil {
+		return result, err
+	}
+	defer w.Free()
+
+	if err := w.PushHead(); err != nil {
+		return result, err
+	}
+
+	// renames are collected from the newest commit back so a path maps to its path at the head commit
+	renames := map[string]string{}
+	var renameErr error
+	err = w.Iterate(
+		func(commit *git.Commit) bool {
+			id := commit.Object.Id().String()
+			if remaining[id] {
+				paths := make(map[string]string, len(renames))
+				for k, v := range renames {
+					paths[k] = v
+				}
+				result[id] = paths
+				delete(remaining, id)
+			}
+			if len(remaining) == 0 {
+				return false
+			}
+
+			found, err := commitRenames(commit)
+			if err != nil {
+				renameErr = err
+				return false
+			}
+			for oldPath, newPath := range found {
+				if p, ok := renames[newPath]; ok {
+					newPath = p
+				}
+				if oldPath == newPath {
+					delete(renames, oldPath)
+					continue
+				}
+				renames[oldPath] = newPath
+			}
+			return true
+		})
+
+	if renameErr != nil {
+		return result, renameErr
+	}
+	return result, err
+}
+
+// commitRenames returns the files renamed by the commit, keyed by the old path with the new path
+func commitRenames(commit *git.Commit) (map[string]string, error) {
+	renames := map[string]string{}
+	if commit.ParentCount() == 0 {
+		return renames, nil
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return renames, err
+	}
+	defer tree.Free()
+
+	parent := commit.Parent(0)
+	defer parent.Free()
+
+	parentTree, err := parent.Tree()
+	if err != nil {
+		return renames, err
+	}
+	defer parentTree.Free()
+
+	options, err := git.DefaultDiffOptions()
+	if err != nil {
+		return renames, err
+	}
+
+	diff, err := commit.Owner().DiffTreeToTree(parentTree, tree, &options)
+	if err != nil {
+		return renames, err
+	}
+	defer func() {
+		if err := diff.Free(); err != nil {
+			fmt.Printf("Unable to free diff, %s\n", err)
+		}
+	}()
+
+	findOptions, err := git.DefaultDiffFindOptions()
+	if err != nil {
+		return renames, err
+	}
+	findOptions.Flags |= git.DiffFindRenames
+	if err := diff.FindSimilar(&findOptions); err != nil {
+		return renames, err
+	}
+
+	err = diff.ForEach(
+		func(delta git.DiffDelta, progress float64) (git.DiffForEachHunkCallback, error) {
+			if delta.Status == git.DeltaRenamed {
+				renames[filepath.ToSlash(delta.OldFile.Path)] = filepath.ToSlash(delta.NewFile.Path)
+			}
+			return nil, nil
+		}, git.DiffDetailFiles)
+
+	return renames, err
+}
+
+// AddedFiles returns, for each of the SHA1 commit ids, the paths of the files added by the commit compared with its
+// first parent. All of the files in the tree of a commit without parents are added by the commit.
+func AddedFiles(commitIDs []string, wd ...string) (map[string]map[string]bool, error) {
+	defer util.Profile()()
+
+	var (
+		repo *git.Repository
+		err  error
+	)
+	result := map[string]map[string]bool{}
+
+	if len(wd) > 0 {
+		repo, err = openRepository(wd[0])
+	} else {
+		repo, err = openRepository()
+	}
+	if err != nil {
+		return result, err
+	}
+	defer repo.Free()
+
+	for _, commitID := range commitIDs {
+		if _, ok := result[commitID]; ok {
+			continue
+		}
+		id, err := git.NewOid(commitID)
+		if err != nil {
+			return result, err
+		}
+		commit, err := repo.LookupCommit(id)
+		if err != nil {
+			return result, err
+		}
+		added, err := commitAddedFiles(commit)
+		commit.Free()
+		if err != nil {
+			return result, err
+		}
+		result[commitID] = added
+	}
+
+	return result, nil
+}
+
+// commitAddedFiles returns the paths of the files added by the commit
+func commitAddedFiles(commit *git.Commit) (map[string]bool, error) {
+	added := map[string]bool{}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return added, err
+	}
+	defer tree.Free()
+
+	// a nil tree diffs the commit's tree with an empty tree
+	var parentTree *git.Tree
+	if commit.ParentCount() > 0 {
+		parent := commit.Parent(0)
+		defer parent.Free()
+
+		if parentTree, err = parent.Tree(); err != nil {
+			return added, err
+		}
+		defer parentTree.Free()
+	}
+
+	options, err := git.DefaultDiffOptions()
+	if err != nil {
+		return added, err
+	}
+
+	diff, err := commit.Owner().DiffTreeToTree(parentTree, tree, &options)
+	if err != nil {
+		return added, err
+	}
+	defer func() {
+		if err := diff.Free(); err != nil {
+			fmt.Printf("Unable to free diff, %s\n", err)
+		}
+	}()
+
+	err = diff.ForEach(
+		func(delta git.DiffDelta, progress float64) (git.DiffForEachHunkCallback, error) {
+			if delta.Status == git.DeltaAdded {
+				added[filepath.ToSlash(delta.NewFile.Path)] = true
+			}
+			return nil, nil
+		}, git.DiffDetailFiles)
+
+	return added, err
+}
+
 // Commit contains commit details
 type Commit struct {
 	ID      string
@@ -358,34 +771,180 @@ func DiffParentCommit(childCommit *git.Commit) (CommitStats, error) {
 		}, git.DiffDetailFiles)
 
 	if err != nil {
-		return CommitStats{}, err
+		return CommitStats{}, err
+	}
+
+	stats, err := diff.Stats()
+	if err != nil {
+		return CommitStats{}, err
+	}
+	defer func() {
+		if err := stats.Free(); err != nil {
+			fmt.Printf("Unable to free stats, %s\n", err)
+		}
+	}()
+
+	return CommitStats{
+		Insertions:   stats.Insertions(),
+		Deletions:    stats.Deletions(),
+		Files:        files,
+		FilesChanged: stats.FilesChanged(),
+	}, err
+}
+
+// HeadCommit returns the latest commit
+func HeadCommit(wd ...string) (Commit, error) {
+	var (
+		repo *git.Repository
+		err  error
+	)
+	commit := Commit{}
+
+	if len(wd) > 0 {
+		repo, err = openRepository(wd[0])
+	} else {
+		repo, err = openRepository()
+	}
+	if err != nil {
+		return commit, err
+	}
+	defer repo.Free()
+
+	headCommit, err := lookupHeadCommit(repo)
+	if err != nil {
+		if err == ErrHeadUnborn {
+			return commit, nil
+		}
+		return commit, err
+	}
+	defer headCommit.Free()
+
+	commitStats, err := DiffParentCommit(headCommit)
+	if err != nil {
+		return commit, err
+	}
+
+	return Commit{
+		ID:      headCommit.Object.Id().String(),
+		OID:     headCommit.Object.Id(),
+		Summary: headCommit.Summary(),
+		Message: headCommit.Message(),
+		Author:  headCommit.Author().Name,
+		Email:   headCommit.Author().Email,
+		When:    headCommit.Author().When,
+		Stats:   commitStats,
+	}, nil
+}
+
+// StashCommit returns the SHA1 commit id of the latest stash, refs/stash, or ErrNoStash if there are no stashes
+func StashCommit(wd ...string) (string, error) {
+	var (
+		repo *git.Repository
+		err  error
+	)
+
+	if len(wd) > 0 {
+		repo, err = openRepository(wd[0])
+	} else {
+		repo, err = openRepository()
+	}
+	if err != nil {
+		return "", err
+	}
+	defer repo.Free()
+
+	stashRef, err := repo.References.Lookup("refs/stash")
+	if err != nil {
+		return "", ErrNoStash
+	}
+	defer stashRef.Free()
+
+	return stashRef.Target().String(), nil
+}
+
+// Branch returns the name of the current branch, i.e. master, or the short SHA1 of the head commit if HEAD is detached
+func Branch(wd ...string) (string, error) {
+	var (
+		repo *git.Repository
+		err  error
+	)
+
+	if len(wd) > 0 {
+		repo, err = openRepository(wd[0])
+	} else {
+		repo, err = openRepository()
+	}
+	if err != nil {
+		return "", err
+	}
+	defer repo.Free()
+
+	detached, err := repo.IsHeadDetached()
+	if err != nil {
+		return "", err
+	}
+	if detached {
+		headCommit, err := lookupHeadCommit(repo)
+		if err != nil {
+			return "", err
+		}
+		defer headCommit.Free()
+		return headCommit.Object.Id().String()[:7], nil
+	}
+
+	// HEAD is a symbolic reference to the branch, the branch may not have any commits yet
+	headRef, err := repo.References.Lookup("HEAD")
+	if err != nil {
+		return "", err
+	}
+	defer headRef.Free()
+
+	return strings.TrimPrefix(headRef.SymbolicTarget(), "refs/heads/"), nil
+}
+
+// CreateNote creates a git note associated with the head commit
+func CreateNote(noteTxt string, nameSpace string, wd ...string) error {
+	defer util.Profile()()
+
+	var (
+		repo *git.Repository
+		err  error
+	)
+
+	if len(wd) > 0 {
+		repo, err = openRepository(wd[0])
+	} else {
+		repo, err = openRepository()
+	}
+	if err != nil {
+		return err
 	}
+	defer repo.Free()
 
-	stats, err := diff.Stats()
+	headCommit, err := lookupHeadCommit(repo)
 	if err != nil {
-		return CommitStats{}, err
+		return err
 	}
-	defer func() {
-		if err := stats.Free(); err != nil {
-			fmt.Printf("Unable to free stats, %s\n", err)
-		}
-	}()
 
-	return CommitStats{
-		Insertions:   stats.Insertions(),
-		Deletions:    stats.Deletions(),
-		Files:        files,
-		FilesChanged: stats.FilesChanged(),
-	}, err
+	sig := &git.Signature{
+		Name:  headCommit.Author().Name,
+		Email: headCommit.Author().Email,
+		When:  headCommit.Author().When,
+	}
+
+	_, err = repo.Notes.Create("refs/notes/"+nameSpace, sig, sig, headCommit.Id(), noteTxt, false)
+
+	return err
 }
 
-// HeadCommit returns the latest commit
-func HeadCommit(wd ...string) (Commit, error) {
+// CreateNoteForCommit creates a git note associated with the SHA1 commit id, replacing any existing note
+func CreateNoteForCommit(commitID string, noteTxt string, nameSpace string, wd ...string) error {
+	defer util.Profile()()
+
 	var (
 		repo *git.Repository
 		err  error
 	)
-	commit := Commit{}
 
 	if len(wd) > 0 {
 		repo, err = openRepository(wd[0])
@@ -393,38 +952,35 @@ func HeadCommit(wd ...string) (Commit, error) {
 		repo, err = openRepository()
 	}
 	if err != nil {
-		return commit, err
+		return err
 	}
 	defer repo.Free()
 
-	headCommit, err := lookupHeadCommit(repo)
+	id, err := git.NewOid(commitID)
 	if err != nil {
-		if err == ErrHeadUnborn {
-			return commit, nil
-		}
-		return commit, err
+		return err
 	}
-	defer headCommit.Free()
 
-	commitStats, err := DiffParentCommit(headCommit)
+	commit, err := repo.LookupCommit(id)
 	if err != nil {
-		return commit, err
+		return err
 	}
+	defer commit.Free()
 
-	return Commit{
-		ID:      headCommit.Object.Id().String(),
-		OID:     headCommit.Object.Id(),
-		Summary: headCommit.Summary(),
-		Message: headCommit.Message(),
-		Author:  headCommit.Author().Name,
-		Email:   headCommit.Author().Email,
-		When:    headCommit.Author().When,
-		Stats:   commitStats,
-	}, nil
+	sig := &git.Signature{
+		Name:  commit.Author().Name,
+		Email: commit.Author().Email,
+		When:  commit.Author().When,
+	}
+
+	_, err = repo.Notes.Create("refs/notes/"+nameSpace, sig, sig, commit.Id(), noteTxt, true)
+
+	return err
 }
 
-// CreateNote creates a git note associated with the head commit
-func CreateNote(noteTxt string, nameSpace string, wd ...string) error {
+// RemoveNoteForCommit removes the git note for the SHA1 commit id in the notes namespace, i.e. gtm-data,
+// a commit without a note is not an error
+func RemoveNoteForCommit(commitID string, nameSpace string, wd ...string) error {
 	defer util.Profile()()
 
 	var (
@@ -442,20 +998,64 @@ func CreateNote(noteTxt string, nameSpace string, wd ...string) error {
 	}
 	defer repo.Free()
 
-	headCommit, err := lookupHeadCommit(repo)
+	id, err := git.NewOid(commitID)
+	if err != nil {
+		return err
+	}
+
+	commit, err := repo.LookupCommit(id)
+	if err != nil {
+		return err
+	}
+	defer commit.Free()
+
+	n, err := repo.Notes.Read("refs/notes/"+nameSpace, id)
 	if err != nil {
+		return nil
+	}
+	if err := n.Free(); err != nil {
 		return err
 	}
 
 	sig := &git.Signature{
-		Name:  headCommit.Author().Name,
-		Email: headCommit.Author().Email,
-		When:  headCommit.Author().When,
+		Name:  commit.Author().Name,
+		Email: commit.Author().Email,
+		When:  commit.Author().When,
 	}
 
-	_, err = repo.Notes.Create("refs/notes/"+nameSpace, sig, sig, headCommit.Id(), noteTxt, false)
+	return repo.Notes.Remove("refs/notes/"+nameSpace, sig, sig, id)
+}
 
-	return err
+// ResolveCommit returns the SHA1 commit id of the revision, i.e. an abbreviated SHA1, a branch or stash@{1}
+func ResolveCommit(rev string, wd ...string) (string, error) {
+	var (
+		repo *git.Repository
+		err  error
+	)
+
+	if len(wd) > 0 {
+		repo, err = openRepository(wd[0])
+	} else {
+		repo, err = openRepository()
+	}
+	if err != nil {
+		return "", err
+	}
+	defer repo.Free()
+
+	obj, err := repo.RevparseSingle(rev)
+	if err != nil {
+		return "", err
+	}
+	defer obj.Free()
+
+	commit, err := repo.LookupCommit(obj.Id())
+	if err != nil {
+		return "", err
+	}
+	defer commit.Free()
+
+	return commit.Id().String(), nil
 }
 
 // CommitNote contains a git note's details
@@ -468,7 +1068,9 @@ type CommitNote struct {
 	Email   string
 	When    time.Time
 	Note    string
-	Stats   CommitStats
+	// NoteID is the SHA1 of the note's blob, it changes when the note is changed and is blank without a note
+	NoteID string
+	Stats  CommitStats
 }
 
 // ReadNote returns a commit note for the SHA1 commit id
@@ -512,12 +1114,11 @@ func ReadNote(commitID string, nameSpace string, calcStats bool, wd ...string) (
 		return CommitNote{}, err
 	}
 
-	var noteTxt string
+	var noteTxt, noteID string
 	n, err = repo.Notes.Read("refs/notes/"+nameSpace, id)
-	if err != nil {
-		noteTxt = ""
-	} else {
+	if err == nil {
 		noteTxt = n.Message()
+		noteID = n.Id().String()
 	}
 
 	stats := CommitStats{}
@@ -537,10 +1138,46 @@ func ReadNote(commitID string, nameSpace string, calcStats bool, wd ...string) (
 		Email:   commit.Author().Email,
 		When:    commit.Author().When,
 		Note:    noteTxt,
+		NoteID:  noteID,
 		Stats:   stats,
 	}, nil
 }
 
+// NoteID returns the SHA1 of the blob of the SHA1 commit id's git note in the notes namespace, i.e. gtm-data,
+// it's blank if the commit does not have a note. A note's blob changes when the note is changed.
+func NoteID(commitID string, nameSpace string, wd ...string) (string, error) {
+	var (
+		err  error
+		repo *git.Repository
+	)
+
+	if len(wd) > 0 {
+		repo, err = openRepository(wd[0])
+	} else {
+		repo, err = openRepository()
+	}
+	if err != nil {
+		return "", err
+	}
+	defer repo.Free()
+
+	id, err := git.NewOid(commitID)
+	if err != nil {
+		return "", err
+	}
+
+	n, err := repo.Notes.Read("refs/notes/"+nameSpace, id)
+	if err != nil {
+		return "", nil
+	}
+	defer func() {
+		if err := n.Free(); err != nil {
+			fmt.Printf("Unable to free note, %s\n", err)
+		}
+	}()
+	return n.Id().String(), nil
+}
+
 // ConfigSet persists git configuration settings
 func ConfigSet(settings map[string]string, wd ...string) error {
 	var (
@@ -573,6 +1210,37 @@ func ConfigSet(settings map[string]string, wd ...string) error {
 	return nil
 }
 
+// ConfigGet returns the value of a git configuration setting, a blank string is returned if it's not set
+func ConfigGet(name string, wd ...string) (string, error) {
+	var (
+		err  error
+		repo *git.Repository
+		cfg  *git.Config
+	)
+
+	if len(wd) > 0 {
+		repo, err = openRepository(wd[0])
+	} else {
+		repo, err = openRepository()
+	}
+	if err != nil {
+		return "", err
+	}
+	defer repo.Free()
+
+	cfg, err = repo.Config()
+	if err != nil {
+		return "", err
+	}
+	defer cfg.Free()
+
+	v, err := cfg.LookupString(name)
+	if err != nil {
+		return "", nil
+	}
+	return v, nil
+}
+
 // ConfigRemove removes git configuration settings
 func ConfigRemove(settings map[string]string, wd ...string) error {
 	var (
@@ -648,26 +1316,33 @@ func (g GitHook) getExeForOS() string {
 	return g.Exe
 }
 
+// hookShebang is added to hook files that do not have one
+const hookShebang = "#!/bin/sh"
+
 // SetHooks creates git hooks
 func SetHooks(hooks map[string]GitHook, wd ...string) error {
-	const shebang = "#!/bin/sh"
-	for ghfile, hook := range hooks {
-		var (
-			p   string
-			err error
-		)
-
-		if len(wd) > 0 {
-			p = wd[0]
-		} else {
+	var (
+		p   string
+		err error
+	)
 
-			p, err = os.Getwd()
-			if err != nil {
-				return err
-			}
+	if len(wd) > 0 {
+		p = wd[0]
+	} else {
+		p, err = os.Getwd()
+		if err != nil {
+			return err
 		}
-		fp := filepath.Join(p, "hooks", ghfile)
-		hooksDir := filepath.Join(p, "hooks")
+	}
+
+	return InstallHooks(hooks, filepath.Join(p, "hooks"))
+}
+
+// InstallHooks adds the hooks' commands to the hook files in hooksDir, commands are appended
+// to existing hook files so other commands in the hook continue to run
+func InstallHooks(hooks map[string]GitHook, hooksDir string) error {
+	for ghfile, hook := range hooks {
+		fp := filepath.Join(hooksDir, ghfile)
 
 		var output string
 
@@ -685,8 +1360,8 @@ func SetHooks(hooks map[string]GitHook, wd ...string) error {
 			output = string(b)
 		}
 
-		if !strings.Contains(output, shebang) {
-			output = fmt.Sprintf("%s\n%s", shebang, output)
+		if !strings.Contains(output, hookShebang) {
+			output = fmt.Sprintf("%s\n%s", hookShebang, output)
 		}
 
 		if hook.RE.MatchString(output) {
@@ -695,7 +1370,7 @@ func SetHooks(hooks map[string]GitHook, wd ...string) error {
 			output = fmt.Sprintf("%s\n%s", output, hook.getCommandPath())
 		}
 
-		if err = ioutil.WriteFile(fp, []byte(output), 0755); err != nil {
+		if err := ioutil.WriteFile(fp, []byte(output), 0755); err != nil {
 			return err
 		}
 
@@ -707,6 +1382,113 @@ func SetHooks(hooks map[string]GitHook, wd ...string) error {
 	return nil
 }
 
+// VerifyHooks returns the problems with the hook files in hooksDir by hook name,
+// hooks that are installed correctly are not included
+func VerifyHooks(hooks map[string]GitHook, hooksDir string) (map[string]string, error) {
+	problems := map[string]string{}
+	for ghfile, hook := range hooks {
+		fp := filepath.Join(hooksDir, ghfile)
+
+		info, err := os.Stat(fp)
+		if os.IsNotExist(err) {
+			problems[ghfile] = "missing"
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := ioutil.ReadFile(fp)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !hook.RE.Match(b):
+			problems[ghfile] = fmt.Sprintf("modified, does not run %s", hook.Command)
+		case runtime.GOOS != "windows" && info.Mode()&0111 == 0:
+			problems[ghfile] = "not executable"
+		}
+	}
+	return problems, nil
+}
+
+// HooksDir returns the hooks directory for the git repo at gitRepoPath,
+// it's core.hooksPath if it's set otherwise the hooks directory in the repo's common git directory.
+// A relative core.hooksPath is relative to the working directory like it is for git.
+func HooksDir(gitRepoPath string) (string, error) {
+	hooksPath, err := ConfigGet("core.hooksPath", gitRepoPath)
+	if err != nil {
+		return "", err
+	}
+	if hooksPath == "" {
+		gitCommonDir, err := GitCommonDir(gitRepoPath)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(gitCommonDir, "hooks"), nil
+	}
+	if filepath.IsAbs(hooksPath) {
+		return filepath.Clean(hooksPath), nil
+	}
+	workDir, err := Workdir(gitRepoPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(workDir, hooksPath), nil
+}
+
+// gitModulesPathRegex matches the path of a submodule in a .gitmodules file
+var gitModulesPathRegex = regexp.MustCompile(`(?m)^\s*path\s*=\s*(.+?)\s*$`)
+
+// Submodules returns the paths of the submodules in the .gitmodules file of the working tree at workDir,
+// paths are relative to workDir. There are no submodules if the .gitmodules file does not exist.
+func Submodules(workDir string) ([]string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(workDir, ".gitmodules"))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return []string{}, err
+	}
+	paths := []string{}
+	for _, m := range gitModulesPathRegex.FindAllStringSubmatch(string(b), -1) {
+		paths = append(paths, filepath.Clean(filepath.FromSlash(strings.Trim(m[1], `"`))))
+	}
+	return paths, nil
+}
+
+// Superproject returns the working tree of the repository that has the working tree at workDir
+// as a submodule, it's blank if workDir is not a submodule's working tree
+func Superproject(workDir string) (string, error) {
+	workDir = filepath.Clean(workDir)
+	parent := filepath.Dir(workDir)
+	if parent == workDir {
+		return "", nil
+	}
+	gitRepoPath, err := GitRepoPath(parent)
+	if err != nil {
+		// not within a repository
+		return "", nil
+	}
+	superWorkDir, err := Workdir(gitRepoPath)
+	if err == ErrBareRepo {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	subs, err := Submodules(superWorkDir)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range subs {
+		if filepath.Join(superWorkDir, s) == workDir {
+			return superWorkDir, nil
+		}
+	}
+	return "", nil
+}
+
 // RemoveHooks remove matching git hook commands
 func RemoveHooks(hooks map[string]GitHook, p string) error {
 
@@ -840,6 +1622,10 @@ func openRepository(wd ...string) (*git.Repository, error) {
 var (
 	// ErrHeadUnborn is raised when there are no commits yet in the git repo
 	ErrHeadUnborn = errors.New("Head commit not found")
+	// ErrBareRepo is raised when a working tree is required but the git repo is bare
+	ErrBareRepo = errors.New("Git working tree not found, bare repositories are only supported by gtm report -repo")
+	// ErrNoStash is raised when there are no stashes in the git repo
+	ErrNoStash = errors.New("Stash not found")
 )
 
 func lookupHeadCommit(repo *git.Repository) (*git.Commit, error) {
@@ -965,6 +1751,17 @@ func (s *Status) IsTracked(path string) bool {
 	return false
 }
 
+// IsDirty returns true if the file is modified or staged or it's untracked and not ignored, like git status
+func (s *Status) IsDirty(path string) bool {
+	path = filepath.ToSlash(path)
+	for _, f := range s.Files {
+		if path == f.Path && f.Status != git.StatusCurrent && f.Status&git.StatusIgnored == 0 {
+			return true
+		}
+	}
+	return false
+}
+
 type fileStatus struct {
 	Status git.Status
 	Path   string
@@ -997,3 +1794,35 @@ func (f fileStatus) IsTracked() bool {
 func (f fileStatus) IsModified() bool {
 	return f.InStaging() || f.InWorking()
 }
+
+// GitEnv is the environment variable for the git binary gtm runs, i.e. /nix/store/.../bin/git, when git
+// is not in the PATH. The repository itself is read with libgit2.
+const GitEnv = "GTM_GIT"
+
+// GitExe returns the path of the git binary, GTM_GIT if it's set or git in the PATH.
+// An error is returned if it's not an executable file.
+func GitExe() (string, error) {
+	exe := os.Getenv(GitEnv)
+	if exe == "" {
+		p, err := exec.LookPath("git")
+		if err != nil {
+			return "", fmt.Errorf("git is not in the PATH, set %s to the git binary", GitEnv)
+		}
+		return p, nil
+	}
+	p, err := exec.LookPath(exe)
+	if err != nil {
+		return "", fmt.Errorf("%s %s is not an executable file", GitEnv, exe)
+	}
+	return p, nil
+}
+
+// GitCommand returns the command to run git with args, all of gtm's git commands are run with it
+// so they use the same git binary
+func GitCommand(args ...string) (*exec.Cmd, error) {
+	exe, err := GitExe()
+	if err != nil {
+		return nil, err
+	}
+	return exec.Command(exe, args...), nil
+}
@@ -7,8 +7,10 @@ package scm
 import (
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strings"
 	"testing"
@@ -77,6 +79,33 @@ func TestGitRepoPath(t *testing.T) {
 	}
 }
 
+func TestGitCommonDir(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+	repo.Seed()
+
+	worktree, err := ioutil.TempDir("", "gtm")
+	util.CheckFatal(t, err)
+	defer os.RemoveAll(worktree)
+	worktree = filepath.Join(worktree, "feature")
+
+	cmd := exec.Command("git", "worktree", "add", "-q", "-b", "feature", worktree)
+	cmd.Dir = repo.Workdir()
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git worktree add, want error nil got %s, %s", err, out)
+	}
+
+	want := filepath.Clean(repo.Path())
+	for _, wd := range []string{repo.Workdir(), worktree} {
+		gitRepoPath, err := GitRepoPath(wd)
+		util.CheckFatal(t, err)
+		got, err := GitCommonDir(gitRepoPath)
+		if err != nil || got != want {
+			t.Errorf("GitCommonDir(%s), want %s, nil got %s, %v", gitRepoPath, want, got, err)
+		}
+	}
+}
+
 func TestCommitIDs(t *testing.T) {
 	repo := util.NewTestRepo(t, false)
 	defer repo.Remove()
@@ -140,6 +169,42 @@ func TestHeadCommit(t *testing.T) {
 	}
 }
 
+func TestBranch(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+
+	workdir := repo.Workdir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = workdir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s, want error nil got %s, %s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	// a branch without commits
+	git("symbolic-ref", "HEAD", "refs/heads/unborn")
+	branch, err := Branch(workdir)
+	if err != nil || branch != "unborn" {
+		t.Errorf("Branch(%s), want unborn, nil got %s, %v", workdir, branch, err)
+	}
+
+	repo.Seed()
+	git("checkout", "-q", "-b", "feature/branch")
+	branch, err = Branch(workdir)
+	if err != nil || branch != "feature/branch" {
+		t.Errorf("Branch(%s), want feature/branch, nil got %s, %v", workdir, branch, err)
+	}
+
+	git("checkout", "-q", "--detach")
+	commit, err := HeadCommit(workdir)
+	util.CheckFatal(t, err)
+	branch, err = Branch(workdir)
+	if err != nil || branch != commit.ID[:7] {
+		t.Errorf("Branch(%s), want %s, nil got %s, %v", workdir, commit.ID[:7], branch, err)
+	}
+}
+
 func TestNote(t *testing.T) {
 	repo := util.NewTestRepo(t, false)
 	defer repo.Remove()
@@ -475,3 +540,38 @@ func TestPushFetchRemote(t *testing.T) {
 		t.Errorf("ReadNote want message \"%s\", got \"%s\"", noteTxt, note.Note)
 	}
 }
+
+func TestSubmodules(t *testing.T) {
+	repo := util.NewTestRepo(t, false)
+	defer repo.Remove()
+
+	subs, err := Submodules(repo.Workdir())
+	util.CheckFatal(t, err)
+	if len(subs) != 0 {
+		t.Errorf("Submodules() want no submodules, got %v", subs)
+	}
+
+	repo.AddSubmodule("http://example.org/submodule", "submodule")
+	repo.AddSubmodule("http://example.org/nested", filepath.Join("lib", "nested"))
+
+	subs, err = Submodules(repo.Workdir())
+	util.CheckFatal(t, err)
+	want := []string{"submodule", filepath.Join("lib", "nested")}
+	if !reflect.DeepEqual(want, subs) {
+		t.Errorf("Submodules() want %v, got %v", want, subs)
+	}
+
+	for _, s := range subs {
+		super, err := Superproject(filepath.Join(repo.Workdir(), s))
+		util.CheckFatal(t, err)
+		if super != repo.Workdir() {
+			t.Errorf("Superproject(%s) want %s, got %s", s, repo.Workdir(), super)
+		}
+	}
+
+	super, err := Superproject(repo.Workdir())
+	util.CheckFatal(t, err)
+	if super != "" {
+		t.Errorf("Superproject(%s) want blank, got %s", repo.Workdir(), super)
+	}
+}
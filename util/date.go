@@ -6,6 +6,7 @@ package util
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jinzhu/now"
@@ -46,6 +47,25 @@ func (d DateRange) Within(t time.Time) bool {
 
 }
 
+// ParseTime parses either a clock time for today, i.e. 15:04, or a RFC3339 timestamp
+func ParseTime(s string) (time.Time, error) {
+	return ParseTimeInLocation(s, time.Local)
+}
+
+// ParseTimeInLocation is like ParseTime but a clock time is for today in loc
+func ParseTimeInLocation(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation("15:04", s, loc); err == nil {
+		n := Now().In(loc)
+		return time.Date(n.Year(), n.Month(), n.Day(), t.Hour(), t.Minute(), 0, 0, n.Location()), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Unable to parse %s, must be a clock time (15:04) or RFC3339 timestamp", s)
+	}
+	return t, nil
+}
+
 // AfterNow returns a date range ending n days in the past
 func AfterNow(n int) DateRange {
 	end := now.New(Now()).EndOfDay().AddDate(0, 0, -n)
@@ -223,3 +223,34 @@ func TestEndOnlyRange(t *testing.T) {
 		t.Errorf("dr.Within(%s) within %+v", testDate, dr)
 	}
 }
+
+func TestParseTime(t *testing.T) {
+	tm, err := time.Parse("2006-Jan-02", "2015-Jul-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saveNow := Now
+	defer func() { Now = saveNow }()
+	Now = func() time.Time { return tm }
+
+	got, err := ParseTime("09:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := parseUnixDate("Wed Jul  1 09:30:00 UTC 2015", t); !got.Equal(want) {
+		t.Errorf("ParseTime(09:30) want %s got %s", want, got)
+	}
+
+	got, err = ParseTime("2015-06-30T17:00:00-05:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := parseUnixDate("Tue Jun 30 22:00:00 UTC 2015", t); !got.Equal(want) {
+		t.Errorf("ParseTime(2015-06-30T17:00:00-05:00) want %s got %s", want, got)
+	}
+
+	if _, err := ParseTime("5pm"); err == nil {
+		t.Errorf("ParseTime(5pm) want error got nil")
+	}
+}
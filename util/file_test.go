@@ -0,0 +1,67 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gtm")
+	if err != nil {
+		t.Fatalf("Unable to create tempory directory %s, %s", dir, err)
+	}
+	defer os.RemoveAll(dir)
+
+	fp := filepath.Join(dir, "status.txt")
+	for _, want := range []string{"first", "second"} {
+		if err := WriteFileAtomic(fp, []byte(want), 0644); err != nil {
+			t.Fatalf("WriteFileAtomic(%s), want error nil got %s", want, err)
+		}
+		b, err := ioutil.ReadFile(fp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("WriteFileAtomic(%s), want %s got %s", want, want, string(b))
+		}
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("WriteFileAtomic(), want only %s got %d files", fp, len(files))
+	}
+
+	if err := WriteFileAtomic(filepath.Join(dir, "missing", "status.txt"), []byte(""), 0644); err == nil {
+		t.Errorf("WriteFileAtomic() to missing directory, want error got nil")
+	}
+}
+
+func TestGzip(t *testing.T) {
+	want := "commit,file,seconds\n"
+	b, err := Gzip([]byte(want))
+	if err != nil {
+		t.Fatalf("Gzip(), want error nil got %s", err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("Gzip(), want gzip data got %s", err)
+	}
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Gzip(), want gzip data got %s", err)
+	}
+	if string(got) != want {
+		t.Errorf("Gzip(), want %s got %s", want, string(got))
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// MatchGlob reports whether the relative file path matches the glob pattern.
+// Patterns always use forward slashes regardless of OS, "**" matches any number
+// of directories and a pattern without a slash is matched against the file name only,
+// i.e. "*.lock" matches "a/b/c.lock" and "vendor/**" matches all files below vendor.
+func MatchGlob(pattern, file string) bool {
+	file = filepath.ToSlash(file)
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(file))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(file, "/"))
+}
+
+// MatchAnyGlob reports whether the relative file path matches any of the glob patterns
+func MatchAnyGlob(patterns []string, file string) bool {
+	for _, p := range patterns {
+		if MatchGlob(p, file) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pattern, file []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(file); i++ {
+				if matchSegments(pattern[1:], file[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(file) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], file[0]); !ok {
+			return false
+		}
+		pattern, file = pattern[1:], file[1:]
+	}
+	return len(file) == 0
+}
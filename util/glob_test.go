@@ -0,0 +1,38 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"*.lock", "Gemfile.lock", true},
+		{"*.lock", filepath.Join("a", "b", "yarn.lock"), true},
+		{"*.min.js", filepath.Join("static", "app.min.js"), true},
+		{"*.min.js", filepath.Join("static", "app.js"), false},
+		{"vendor/**", filepath.Join("vendor", "github.com", "x.go"), true},
+		{"vendor/**", "vendor.go", false},
+		{"vendor/*.go", filepath.Join("vendor", "x.go"), true},
+		{"vendor/*.go", filepath.Join("vendor", "a", "x.go"), false},
+		{"**/testdata/**", filepath.Join("a", "testdata", "b", "c.txt"), true},
+		{"**/testdata/**", filepath.Join("testdata", "c.txt"), true},
+		{"cmd/**/*.go", filepath.Join("cmd", "main.go"), true},
+		{"cmd/**/*.go", filepath.Join("cmd", "gtm", "main.go"), true},
+		{"cmd/**/*.go", filepath.Join("pkg", "gtm", "main.go"), false},
+	}
+
+	for _, tc := range tests {
+		if got := MatchGlob(tc.pattern, tc.file); got != tc.want {
+			t.Errorf("MatchGlob(%s, %s), want %t got %t", tc.pattern, tc.file, tc.want, got)
+		}
+	}
+}
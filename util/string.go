@@ -14,6 +14,13 @@ import (
 	"github.com/hako/durafmt"
 )
 
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// StripANSI removes ANSI escape sequences such as colors from s
+func StripANSI(s string) string {
+	return ansiRegex.ReplaceAllString(s, "")
+}
+
 // Percent returns a values percent of the total
 func Percent(val, total int) float64 {
 	if total == 0 {
@@ -52,6 +59,14 @@ func DurationStrLong(secs int) string {
 	return d.String()
 }
 
+// DurationStrDecimal returns seconds as decimal hours rounded to places, i.e. 1.50h
+func DurationStrDecimal(secs int, places int) string {
+	if places < 0 {
+		places = 0
+	}
+	return fmt.Sprintf("%.*fh", places, float64(secs)/3600)
+}
+
 // https://github.com/DaddyOh/golang-samples/blob/master/pad.go
 
 // RightPad2Len https://github.com/DaddyOh/golang-samples/blob/master/pad.go
@@ -68,6 +83,41 @@ func LeftPad2Len(s string, padStr string, overallLen int) string {
 	return retStr[(len(retStr) - overallLen):]
 }
 
+// Truncate shortens s to at most width characters, not bytes, ending it with ... if it's shortened.
+// s is returned as is if width is less than 1.
+func Truncate(s string, width int) string {
+	runes := []rune(s)
+	if width < 1 || len(runes) <= width {
+		return s
+	}
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+}
+
+// ElideMiddle shortens the path p to at most width characters by replacing the middle of it with ...
+// so the file name stays visible, i.e. cmd/gtm/.../main.go. If the file name alone is too long the
+// end of it is kept. p is returned as is if width is less than 1.
+func ElideMiddle(p string, width int) string {
+	runes := []rune(p)
+	if width < 1 || len(runes) <= width {
+		return p
+	}
+	if width <= 3 {
+		return string(runes[len(runes)-width:])
+	}
+	suffix := runes
+	if i := strings.LastIndexAny(p, `/\`); i >= 0 {
+		suffix = []rune(p[i:])
+	}
+	head := width - 3 - len(suffix)
+	if head <= 0 {
+		return "..." + string(runes[len(runes)-width+3:])
+	}
+	return string(runes[:head]) + "..." + string(suffix)
+}
+
 // StringInSlice https://github.com/DaddyOh/golang-samples/blob/master/pad.go
 func StringInSlice(list []string, a string) bool {
 	for _, b := range list {
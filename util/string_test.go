@@ -0,0 +1,48 @@
+// Copyright 2016 Michael Schenk. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"Fix the parser", 0, "Fix the parser"},
+		{"Fix the parser", 14, "Fix the parser"},
+		{"Fix the parser", 10, "Fix the..."},
+		{"Fix the parser", 2, "Fi"},
+		{"Füge Übersetzungen hinzu", 8, "Füge ..."},
+		{"日本語のコミット", 6, "日本語..."},
+	}
+	for _, tc := range tests {
+		if got := Truncate(tc.s, tc.width); got != tc.want {
+			t.Errorf("Truncate(%q, %d), want %q got %q", tc.s, tc.width, tc.want, got)
+		}
+	}
+}
+
+func TestElideMiddle(t *testing.T) {
+	tests := []struct {
+		p     string
+		width int
+		want  string
+	}{
+		{"cmd/gtm/main.go", 0, "cmd/gtm/main.go"},
+		{"cmd/gtm/main.go", 15, "cmd/gtm/main.go"},
+		{"cmd/very/long/path/file.go", 20, "cmd/very/.../file.go"},
+		{"cmd/very/long/path/file.go", 12, "c.../file.go"},
+		{"cmd/a_very_long_file_name.go", 12, "...e_name.go"},
+		{"doc/日本語/ドキュメント/説明.md", 14, "doc/日.../説明.md"},
+		{"main.go", 2, "go"},
+	}
+	for _, tc := range tests {
+		if got := ElideMiddle(tc.p, tc.width); got != tc.want {
+			t.Errorf("ElideMiddle(%q, %d), want %q got %q", tc.p, tc.width, tc.want, got)
+		}
+	}
+}